@@ -0,0 +1,118 @@
+// pony-worker consumes broker events into the database on its own, so the
+// projections stay current while no TUI is running. Run as many as you like;
+// only one consumes at a time.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/config"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/metrics"
+	"github.com/revrost/pony/pkg/migrate"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/tracing"
+	"github.com/revrost/pony/pkg/worker"
+)
+
+func main() {
+	if err := run(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run() error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	conn, err := store.Open(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.QueryHooks = []store.QueryHook{metrics.QueryHook}
+	if cfg.TraceExporter != tracing.ExporterNone {
+		conn.QueryHooks = append(conn.QueryHooks, tracing.QueryHook(string(conn.Dialect)))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Service:  "pony-worker",
+		Exporter: cfg.TraceExporter,
+		File:     cfg.TraceFile,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("failed to flush traces: %v", err)
+		}
+	}()
+
+	// The worker may well start before any TUI has migrated the schema
+	migrator, err := migrate.New(conn)
+	if err != nil {
+		return err
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		return fmt.Errorf("failed to run migrations: %w", err)
+	}
+
+	// The worker only reads from the broker, so it skips the audit log and outbox
+	alpacaClient := broker.NewAlpacaClient(
+		cfg.AlpacaAPIKey,
+		cfg.AlpacaAPISecret,
+		cfg.AlpacaBaseURL,
+	)
+	alpacaClient.WrapTransport(metrics.Transport)
+	if cfg.TraceExporter != tracing.ExporterNone {
+		alpacaClient.WrapTransport(tracing.Transport)
+	}
+	brokerClient := tracing.NewClient(metrics.NewClient(alpacaClient))
+	eventLog := events.NewStore(conn)
+	eventLog.LotMethod = cfg.TaxLotMethod
+
+	w := worker.New(brokerClient, conn, eventLog)
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /healthz", w)
+	mux.Handle("GET /metrics", metrics.Handler())
+	server := &http.Server{Addr: cfg.WorkerHealthAddr, Handler: mux}
+	serverErr := make(chan error, 1)
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- fmt.Errorf("failed to serve health and metrics endpoints: %w", err)
+			stop()
+		}
+	}()
+
+	log.Printf("pony-worker: health and metrics endpoints on %s, waiting for the worker lock", cfg.WorkerHealthAddr)
+	runErr := w.Run(ctx)
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+
+	select {
+	case err := <-serverErr:
+		return err
+	default:
+		return runErr
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/store"
+)
+
+var accountColumns = []column[*account.Account]{
+	{name: "id", value: func(a *account.Account) string { return a.ID }},
+	{name: "alpaca_account_id", value: func(a *account.Account) string { return a.AlpacaAccountID }, detail: true},
+	{name: "status", value: func(a *account.Account) string { return a.Status }},
+	{name: "currency", value: func(a *account.Account) string { return a.Currency }},
+	{name: "cash", value: func(a *account.Account) string { return decimalValue(a.Cash) },
+		shown: func(a *account.Account) string { return format.Money(a.Cash) }},
+	{name: "buying_power", value: func(a *account.Account) string { return decimalValue(a.BuyingPower) },
+		shown: func(a *account.Account) string { return format.Money(a.BuyingPower) }},
+	{name: "portfolio_value", value: func(a *account.Account) string { return decimalValue(a.PortfolioValue) },
+		shown: func(a *account.Account) string { return format.Money(a.PortfolioValue) }},
+	{name: "created_at", value: func(a *account.Account) string { return timeValue(a.CreatedAt) },
+		shown: func(a *account.Account) string { return shownTime(a.CreatedAt) }, detail: true},
+}
+
+var positionColumns = []column[*position.Position]{
+	{name: "account_id", value: func(p *position.Position) string { return p.AccountID }},
+	{name: "symbol", value: func(p *position.Position) string { return p.Symbol }},
+	{name: "qty", value: func(p *position.Position) string { return decimalValue(p.Qty) },
+		shown: func(p *position.Position) string { return format.Qty(p.Qty) }},
+	{name: "avg_entry_price", value: func(p *position.Position) string { return decimalValue(p.AvgEntryPrice) },
+		shown: func(p *position.Position) string { return format.Price(p.AvgEntryPrice) }},
+	{name: "current_price", value: func(p *position.Position) string { return decimalValue(p.CurrentPrice) },
+		shown: func(p *position.Position) string { return format.Price(p.CurrentPrice) }},
+	{name: "market_value", value: func(p *position.Position) string { return decimalValue(p.MarketValue) },
+		shown: func(p *position.Position) string { return format.Money(p.MarketValue) }},
+	{name: "cost_basis", value: func(p *position.Position) string { return decimalValue(p.CostBasis) },
+		shown: func(p *position.Position) string { return format.Money(p.CostBasis) }},
+	{name: "unrealized_pl", value: func(p *position.Position) string { return decimalValue(p.UnrealizedPL) },
+		shown: func(p *position.Position) string { return format.SignedMoney(p.UnrealizedPL) }},
+	{name: "unrealized_plpc", value: func(p *position.Position) string { return decimalValue(p.UnrealizedPLPC) },
+		shown: func(p *position.Position) string { return format.Percent(p.UnrealizedPLPC) }},
+	{name: "updated_at", value: func(p *position.Position) string { return timeValue(p.UpdatedAt) },
+		shown: func(p *position.Position) string { return shownTime(p.UpdatedAt) }, detail: true},
+}
+
+// runAccounts lists the accounts in the database
+func runAccounts(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("accounts", flag.ContinueOnError)
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony accounts [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.Queries().ListAccounts(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list accounts: %w", err)
+	}
+	return printList(p, accountColumns, db.ToAccounts(rows))
+}
+
+// runAccount shows one account, by its ID or Alpaca account ID
+func runAccount(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("account", flag.ContinueOnError)
+	out := outputFlag(flags)
+	id, err := parseWithID(flags, args, "usage: pony account ID [--output table|json|csv]")
+	if err != nil {
+		return err
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	acc, err := findAccount(context.Background(), conn.Queries(), id)
+	if err != nil {
+		return err
+	}
+	return printOne(p, accountColumns, acc)
+}
+
+// runPositions lists the positions in the database, of every account
+// unless --account is given
+func runPositions(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("positions", flag.ContinueOnError)
+	accountID := flags.String("account", "", "only show positions of this account ID")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony positions [--account ID] [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	queries := conn.Queries()
+
+	accountIDs := []string{*accountID}
+	if *accountID == "" {
+		rows, err := queries.ListAccounts(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list accounts: %w", err)
+		}
+		accountIDs = accountIDs[:0]
+		for _, row := range rows {
+			accountIDs = append(accountIDs, row.ID)
+		}
+	} else if _, err := findAccount(ctx, queries, *accountID); err != nil {
+		return err
+	}
+
+	var positions []*position.Position
+	for _, id := range accountIDs {
+		rows, err := queries.ListPositions(ctx, id)
+		if err != nil {
+			return fmt.Errorf("failed to list positions: %w", err)
+		}
+		positions = append(positions, db.ToPositions(rows)...)
+	}
+	return printList(p, positionColumns, positions)
+}
+
+// findAccount looks an account up by its ID or Alpaca account ID
+func findAccount(ctx context.Context, queries db.Querier, id string) (*account.Account, error) {
+	row, err := queries.GetAccount(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		row, err = queries.GetAccountByAlpacaID(ctx, id)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("account %q %w", id, errNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+	return db.ToAccount(row), nil
+}
+
+// defaultAccountID returns id, or the first account's ID when id is empty
+func defaultAccountID(ctx context.Context, queries db.Querier, id string) (string, error) {
+	if id != "" {
+		return id, nil
+	}
+
+	accounts, err := queries.ListAccounts(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to list accounts: %w", err)
+	}
+	if len(accounts) == 0 {
+		return "", errors.New("no accounts found; run pony reconcile first")
+	}
+	return accounts[0].ID, nil
+}
+
+// parseWithID parses a command line of one ID and flags, in either order,
+// and returns the ID
+func parseWithID(flags *flag.FlagSet, args []string, usage string) (string, error) {
+	var id string
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		id, args = args[0], args[1:]
+	}
+	if err := parseFlags(flags, args); err != nil {
+		return "", err
+	}
+	if id == "" && flags.NArg() == 1 {
+		id = flags.Arg(0)
+	} else if flags.NArg() != 0 {
+		return "", usageError(usage)
+	}
+	if id == "" {
+		return "", usageError(usage)
+	}
+	return id, nil
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/alerter"
+	"github.com/revrost/pony/pkg/audit"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/config"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/store"
+)
+
+const alertsUsage = "usage: pony alerts list|add|enable|disable|delete|watch"
+
+var alertRuleColumns = []column[*alert.Rule]{
+	{name: "id", value: func(r *alert.Rule) string { return strconv.FormatInt(r.ID, 10) }},
+	{name: "account_id", value: func(r *alert.Rule) string { return r.AccountID }, detail: true},
+	{name: "rule", value: func(r *alert.Rule) string { return r.Expr }},
+	{name: "notify", value: func(r *alert.Rule) string { return alert.JoinChannels(r.Notify) }},
+	{name: "enabled", value: func(r *alert.Rule) string { return strconv.FormatBool(r.Enabled) }},
+	{name: "trigger_count", value: func(r *alert.Rule) string { return strconv.FormatInt(r.TriggerCount, 10) }},
+	{name: "last_triggered_at", value: func(r *alert.Rule) string { return timePtrValue(r.LastTriggeredAt) },
+		shown: func(r *alert.Rule) string { return shownTimePtr(r.LastTriggeredAt) }},
+	{name: "last_message", value: func(r *alert.Rule) string { return r.LastMessage }},
+}
+
+var alertColumns = []column[*alert.Alert]{
+	{name: "triggered_at", value: func(a *alert.Alert) string { return timeValue(a.TriggeredAt) },
+		shown: func(a *alert.Alert) string { return shownTime(a.TriggeredAt) }},
+	{name: "alert_id", value: func(a *alert.Alert) string { return strconv.FormatInt(a.RuleID, 10) }},
+	{name: "rule", value: func(a *alert.Alert) string { return a.Rule }},
+	{name: "account_id", value: func(a *alert.Alert) string { return a.AccountID }, detail: true},
+	{name: "symbol", value: func(a *alert.Alert) string { return a.Symbol }},
+	{name: "message", value: func(a *alert.Alert) string { return a.Message }},
+}
+
+// runAlerts manages alert rules. The TUI checks them while it runs, and so
+// does pony alerts watch.
+func runAlerts(cfg *config.Config, brokerClient broker.Client, conn *store.DB, auditLog *audit.Log, args []string) error {
+	if len(args) == 0 {
+		return usageError(alertsUsage)
+	}
+	// Changes to alert rules are recorded in the audit log
+	queries := audit.NewQuerier(conn.Queries(), auditLog)
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		return listAlerts(conn, args)
+	case "add":
+		return addAlert(cfg, queries, args)
+	case "enable":
+		return setAlertEnabled(queries, args, true)
+	case "disable":
+		return setAlertEnabled(queries, args, false)
+	case "delete":
+		return deleteAlert(queries, args)
+	case "watch":
+		return watchAlerts(cfg, brokerClient, conn, args)
+	default:
+		return usageError(alertsUsage)
+	}
+}
+
+func listAlerts(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("alerts list", flag.ContinueOnError)
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony alerts list [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.Queries().ListAlerts(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list alerts: %w", err)
+	}
+	return printList(p, alertRuleColumns, db.ToAlertRules(rows))
+}
+
+func addAlert(cfg *config.Config, queries db.Querier, args []string) error {
+	const usage = `usage: pony alerts add [--account ID] [--notify toast,bell,webhook,command] [--output table|json|csv] RULE, such as "AAPL last > 200"`
+
+	flags := flag.NewFlagSet("alerts add", flag.ContinueOnError)
+	accountID := flags.String("account", "", "account ID (default: every account)")
+	notify := flags.String("notify", alert.JoinChannels(alert.DefaultChannels), "where to deliver alerts: toast, bell, webhook or command, comma-separated")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usageError(usage)
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	channels, err := alert.ParseChannels(*notify)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	ctx := context.Background()
+	if *accountID != "" {
+		acct, err := findAccount(ctx, queries, *accountID)
+		if err != nil {
+			return err
+		}
+		*accountID = acct.ID
+	}
+	r, err := alert.New(*accountID, strings.Join(flags.Args(), " "), channels)
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	row, err := queries.CreateAlert(ctx, db.NewCreateAlertParams(r))
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+	if r.Notifies(alert.ChannelWebhook) && cfg.AlertWebhookURL == "" {
+		fmt.Fprintln(os.Stderr, "ALERT_WEBHOOK_URL is not set, so webhook alerts are not delivered")
+	}
+	if r.Notifies(alert.ChannelCommand) && cfg.AlertCommand == "" {
+		fmt.Fprintln(os.Stderr, "ALERT_COMMAND is not set, so command alerts are not delivered")
+	}
+	return printOne(p, alertRuleColumns, db.ToAlertRule(row))
+}
+
+func setAlertEnabled(queries db.Querier, args []string, enabled bool) error {
+	name := "disable"
+	if enabled {
+		name = "enable"
+	}
+	flags := flag.NewFlagSet("alerts "+name, flag.ContinueOnError)
+	id, err := parseAlertID(flags, args, "usage: pony alerts "+name+" ID")
+	if err != nil {
+		return err
+	}
+
+	_, err = queries.SetAlertEnabled(context.Background(), db.SetAlertEnabledParams{ID: id, Enabled: enabled})
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("alert %d %w", id, errNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to %s alert: %w", name, err)
+	}
+	fmt.Printf("Alert %d %sd\n", id, name)
+	return nil
+}
+
+func deleteAlert(queries db.Querier, args []string) error {
+	flags := flag.NewFlagSet("alerts delete", flag.ContinueOnError)
+	id, err := parseAlertID(flags, args, "usage: pony alerts delete ID")
+	if err != nil {
+		return err
+	}
+
+	n, err := queries.DeleteAlert(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete alert: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("alert %d %w", id, errNotFound)
+	}
+	fmt.Printf("Deleted alert %d\n", id)
+	return nil
+}
+
+// watchAlerts checks the alert rules and prints the alerts they fire until
+// interrupted, for when the TUI is not running. Broker events are recorded
+// in the event log first, as the TUI does.
+func watchAlerts(cfg *config.Config, brokerClient broker.Client, conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("alerts watch", flag.ContinueOnError)
+	once := flags.Bool("once", false, "check the stored accounts and positions once and exit")
+	interval := flags.Duration("interval", alerter.DefaultInterval, "how often to reload the rules and check the stored accounts and positions")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *interval <= 0 {
+		return usageError("usage: pony alerts watch [--once] [--interval 30s] [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	monitor := newAlertMonitor(cfg, conn.Queries())
+	monitor.Interval = *interval
+
+	if *once {
+		alerts, err := monitor.Check(context.Background())
+		err = errors.Join(err, monitor.Wait())
+		if len(alerts) > 0 {
+			if printErr := printList(p, alertColumns, alerts); printErr != nil {
+				return printErr
+			}
+		}
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Order and account status rules need the broker's events
+	if !cfg.UseEventWorker {
+		eventLog := events.NewStore(conn)
+		eventLog.LotMethod = cfg.TaxLotMethod
+		subscription := events.NewSubscription(brokerClient, eventLog)
+		go subscription.Run(ctx, func(event broker.Event) {
+			monitor.HandleEvent(ctx, event)
+		}, func(state events.State, err error) {
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "event stream %s: %v\n", state, err)
+			}
+		})
+	}
+
+	// Alerts come from the market data stream and the event stream as well
+	// as the monitor's own checks
+	var mu sync.Mutex
+	var printErr error
+	monitor.Run(ctx, func(alerts []*alert.Alert, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, a := range alerts {
+			if printErr == nil {
+				printErr = printStream(p, alertColumns, a)
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if printErr != nil {
+			stop()
+		}
+	})
+	monitor.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	return printErr
+}
+
+// newAlertMonitor checks the stored alert rules against Alpaca's market
+// data, ringing the bell and calling the configured webhook and command.
+// Toasts are left to the TUI.
+func newAlertMonitor(cfg *config.Config, store alerter.Store) *alerter.Monitor {
+	feed := marketdata.NewAlpacaFeed(cfg.AlpacaAPIKey, cfg.AlpacaAPISecret, cfg.MarketDataFeed)
+	feed.BaseURL = cfg.MarketDataURL
+
+	monitor := alerter.NewMonitor(feed, store)
+	monitor.Notifiers[alert.ChannelBell] = alerter.Bell{Out: os.Stderr}
+	if cfg.AlertWebhookURL != "" {
+		monitor.Notifiers[alert.ChannelWebhook] = alerter.NewWebhook(cfg.AlertWebhookURL)
+	}
+	if cfg.AlertCommand != "" {
+		monitor.Notifiers[alert.ChannelCommand] = alerter.Command{Command: cfg.AlertCommand}
+	}
+	return monitor
+}
+
+// parseAlertID parses a command line of one alert ID and flags
+func parseAlertID(flags *flag.FlagSet, args []string, usage string) (int64, error) {
+	arg, err := parseWithID(flags, args, usage)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, usageError(fmt.Sprintf("invalid alert ID %q", arg))
+	}
+	return id, nil
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/revrost/pony/pkg/audit"
+	"github.com/revrost/pony/pkg/store"
+)
+
+func runAudit(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("audit", flag.ContinueOnError)
+	accountID := flags.String("account", "", "only show entries for this account ID")
+	action := flags.String("action", "", "only show this action, e.g. order_submit or order_cancel")
+	since := flags.String("since", "", "only show entries newer than a duration (24h) or date (2006-01-02)")
+	limit := flags.Int("limit", audit.DefaultLimit, "maximum number of entries to show")
+	full := flags.Bool("full", false, "print the full request and broker response of each entry")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	filter := audit.Filter{
+		AccountID: *accountID,
+		Action:    audit.Action(*action),
+		Limit:     *limit,
+	}
+	if *since != "" {
+		t, err := parseSince(*since)
+		if err != nil {
+			return err
+		}
+		filter.Since = t
+	}
+
+	entries, err := audit.ListEntries(context.Background(), conn.Queries(), filter)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("No audit entries found")
+		return nil
+	}
+
+	if *full {
+		for _, e := range entries {
+			fmt.Printf("#%d %s %s@%s %s account=%s\n",
+				e.ID, e.CreatedAt.Local().Format(time.RFC3339), e.Operator, e.Host, e.Action, e.AccountID)
+			fmt.Printf("  request:  %s\n", e.Request)
+			fmt.Printf("  response: %s\n", e.Response)
+			if e.Failed() {
+				fmt.Printf("  error:    %s\n", e.Error)
+			}
+		}
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tTIME\tOPERATOR\tHOST\tACCOUNT\tACTION\tRESULT")
+	for _, e := range entries {
+		result := "ok"
+		if e.Failed() {
+			result = "error: " + e.Error
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			e.ID, e.CreatedAt.Local().Format("2006-01-02 15:04:05"), e.Operator, e.Host, e.AccountID, e.Action, result)
+	}
+	return w.Flush()
+}
+
+// parseSince accepts either a duration back from now or a calendar date.
+func parseSince(s string) (time.Time, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a duration like 24h or a date like 2006-01-02", s)
+}
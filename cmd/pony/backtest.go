@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/backtest"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/sim"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/strategy"
+)
+
+const backtestUsage = "usage: pony backtest --strategy KIND:SYMBOL [--csv FILE,...] [--timeframe 1Day] [--start DATE] [--end DATE]"
+
+var reportColumns = []column[*backtest.Report]{
+	{name: "strategy", value: func(r *backtest.Report) string { return r.Strategy }},
+	{name: "start", value: func(r *backtest.Report) string { return timeValue(r.Start) },
+		shown: func(r *backtest.Report) string { return shownTime(r.Start) }},
+	{name: "end", value: func(r *backtest.Report) string { return timeValue(r.End) },
+		shown: func(r *backtest.Report) string { return shownTime(r.End) }},
+	{name: "bars", value: func(r *backtest.Report) string { return strconv.Itoa(r.Bars) }},
+	{name: "starting_cash", value: func(r *backtest.Report) string { return decimalValue(r.StartingCash) },
+		shown: func(r *backtest.Report) string { return format.Money(r.StartingCash) }},
+	{name: "final_equity", value: func(r *backtest.Report) string { return decimalValue(r.FinalEquity) },
+		shown: func(r *backtest.Report) string { return format.Money(r.FinalEquity) }},
+	{name: "total_return", value: func(r *backtest.Report) string { return floatValue(r.Stats.TotalReturn) },
+		shown: func(r *backtest.Report) string { return shownPercent(r.Stats.TotalReturn) }},
+	{name: "cagr", value: func(r *backtest.Report) string { return floatValue(r.Stats.CAGR) },
+		shown: func(r *backtest.Report) string { return shownPercent(r.Stats.CAGR) }},
+	{name: "max_drawdown", value: func(r *backtest.Report) string { return floatValue(r.Stats.MaxDrawdown) },
+		shown: func(r *backtest.Report) string { return shownPercent(-r.Stats.MaxDrawdown) }},
+	{name: "sharpe", value: func(r *backtest.Report) string { return floatValue(r.Stats.Sharpe) },
+		shown: func(r *backtest.Report) string { return strconv.FormatFloat(r.Stats.Sharpe, 'f', 2, 64) }},
+	{name: "trades", value: func(r *backtest.Report) string { return strconv.Itoa(r.Stats.Trades) }},
+	{name: "win_rate", value: func(r *backtest.Report) string { return floatValue(r.Stats.WinRate) },
+		shown: func(r *backtest.Report) string {
+			return strconv.FormatFloat(r.Stats.WinRate*100, 'f', 2, 64) + "%"
+		}},
+	{name: "commission", value: func(r *backtest.Report) string { return decimalValue(r.Stats.Commission) },
+		shown: func(r *backtest.Report) string { return format.Money(r.Stats.Commission) }},
+}
+
+var tradeColumns = []column[backtest.Trade]{
+	{name: "symbol", value: func(t backtest.Trade) string { return t.Symbol }},
+	{name: "direction", value: func(t backtest.Trade) string { return string(t.Direction) }},
+	{name: "qty", value: func(t backtest.Trade) string { return decimalValue(t.Qty) },
+		shown: func(t backtest.Trade) string { return format.Qty(t.Qty) }},
+	{name: "opened_at", value: func(t backtest.Trade) string { return timeValue(t.OpenedAt) },
+		shown: func(t backtest.Trade) string { return shownTime(t.OpenedAt) }},
+	{name: "closed_at", value: func(t backtest.Trade) string { return timePtrValue(t.ClosedAt) },
+		shown: func(t backtest.Trade) string {
+			if t.ClosedAt == nil {
+				return "open"
+			}
+			return shownTime(*t.ClosedAt)
+		}},
+	{name: "entry_price", value: func(t backtest.Trade) string { return decimalValue(t.EntryPrice) },
+		shown: func(t backtest.Trade) string { return format.Price(t.EntryPrice) }},
+	{name: "exit_price", value: func(t backtest.Trade) string { return decimalValue(t.ExitPrice) },
+		shown: func(t backtest.Trade) string { return format.Price(t.ExitPrice) }},
+	{name: "commission", value: func(t backtest.Trade) string { return decimalValue(t.Commission) },
+		shown: func(t backtest.Trade) string { return format.Money(t.Commission) }},
+	{name: "pl", value: func(t backtest.Trade) string { return decimalValue(t.PL) },
+		shown: func(t backtest.Trade) string { return format.SignedMoney(t.PL) }},
+	{name: "return", value: func(t backtest.Trade) string { return floatValue(t.Return()) },
+		shown: func(t backtest.Trade) string { return shownPercent(t.Return()) }},
+}
+
+var equityColumns = []column[backtest.EquityPoint]{
+	{name: "time", value: func(p backtest.EquityPoint) string { return timeValue(p.Time) }},
+	{name: "equity", value: func(p backtest.EquityPoint) string { return decimalValue(p.Equity) }},
+	{name: "cash", value: func(p backtest.EquityPoint) string { return decimalValue(p.Cash) }},
+	{name: "drawdown", value: func(p backtest.EquityPoint) string { return floatValue(p.Drawdown) }},
+}
+
+// runBacktest replays bars from CSV files or the database through one of
+// the built-in strategies and prints its report
+func runBacktest(conn *store.DB, logger *slog.Logger, args []string) error {
+	flags := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	spec := flags.String("strategy", "", "strategy to test, as kind:SYMBOL, such as sma_cross:AAPL")
+	csvFiles := flags.String("csv", "", "comma-separated CSV files of bars to replay (default: bars stored with pony bars)")
+	timeframe := flags.String("timeframe", string(marketdata.OneDay), "period of the stored bars: 1Min, 1Hour or 1Day")
+	start := flags.String("start", "", "first day to replay, like 2006-01-02 (default: the first bar)")
+	end := flags.String("end", "", "last day to replay (default: the last bar)")
+	interval := flags.Duration("interval", 0, "how often the strategy's timer fires, in replayed time (default: never)")
+	cash := flags.String("cash", sim.DefaultCash.String(), "cash the account starts with")
+	slippage := flags.String("slippage-bps", "0", "slippage of market and stop fills, in basis points")
+	perShare := flags.String("commission-per-share", "0", "commission per share filled")
+	perOrder := flags.String("commission-per-order", "0", "commission per order filled")
+	tradesFile := flags.String("trades", "", "also write the trades as CSV to this file")
+	equityFile := flags.String("equity", "", "also write the equity curve as CSV to this file")
+	outputName := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *spec == "" || flags.NArg() != 0 {
+		return usageError(backtestUsage)
+	}
+	p, err := newPrinter(*outputName)
+	if err != nil {
+		return err
+	}
+
+	specs, err := strategy.ParseSpecs(*spec)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	if len(specs) != 1 {
+		return usageError("--strategy takes one kind:SYMBOL")
+	}
+	s := specs[0]
+
+	var opts backtest.Options
+	for _, f := range []struct {
+		name  string
+		value string
+		dest  *decimal.Decimal
+	}{
+		{"cash", *cash, &opts.Cash},
+		{"slippage-bps", *slippage, &opts.FillModel.SlippageBps},
+		{"commission-per-share", *perShare, &opts.FillModel.CommissionPerShare},
+		{"commission-per-order", *perOrder, &opts.FillModel.CommissionPerOrder},
+	} {
+		d, err := decimalFlag(f.name, f.value)
+		if err != nil {
+			return err
+		}
+		if d != nil {
+			if d.IsNegative() {
+				return usageError(fmt.Sprintf("--%s must not be negative", f.name))
+			}
+			*f.dest = *d
+		}
+	}
+
+	from, until := time.Time{}, time.Date(9999, time.January, 1, 0, 0, 0, 0, time.UTC)
+	if *start != "" || *end != "" {
+		first := *start
+		if first == "" {
+			first = "0001-01-01"
+		}
+		if from, until, err = parseDateRange(first, *end); err != nil {
+			return err
+		}
+	}
+
+	// What the strategy logs goes to the log file, not between the report.
+	// Errors returned are printed to the terminal as usual.
+	slog.SetDefault(logger)
+	defer log.SetFlags(log.LstdFlags)
+	defer log.SetOutput(os.Stderr)
+
+	ctx := context.Background()
+	bars, err := loadBars(ctx, conn, s.Symbol, *csvFiles, *timeframe, from, until)
+	if err != nil {
+		return err
+	}
+	if len(bars) == 0 {
+		return fmt.Errorf("no bars for %s to replay: %w", s.Symbol, errNotFound)
+	}
+
+	report, err := backtest.Run(ctx, s.New(), strategy.Config{
+		Name:     s.Name(),
+		Symbols:  []string{s.Symbol},
+		Interval: *interval,
+	}, bars, opts)
+	if err != nil {
+		return err
+	}
+
+	if *tradesFile != "" {
+		if err := writeCSVFile(*tradesFile, tradeColumns, report.Trades); err != nil {
+			return err
+		}
+	}
+	if *equityFile != "" {
+		if err := writeCSVFile(*equityFile, equityColumns, report.Equity); err != nil {
+			return err
+		}
+	}
+
+	if err := printOne(p, reportColumns, report); err != nil {
+		return err
+	}
+	// The table has room for the trades too; JSON and CSV are one record
+	// each, with the trades in --trades
+	if p.output == outputTable && len(report.Trades) > 0 {
+		fmt.Println()
+		return printList(p, tradeColumns, report.Trades)
+	}
+	return nil
+}
+
+// loadBars reads symbol's bars that started in [from, until) from the CSV
+// files, or from the database if there are none
+func loadBars(ctx context.Context, conn *store.DB, symbol, csvFiles, timeframe string, from, until time.Time) ([]marketdata.Bar, error) {
+	if csvFiles == "" {
+		tf, err := marketdata.ParseTimeframe(timeframe)
+		if err != nil {
+			return nil, usageError(err.Error())
+		}
+		rows, err := conn.Queries().ListBars(ctx, db.ListBarsParams{
+			Symbol:       symbol,
+			Timeframe:    string(tf),
+			StartedFrom:  from,
+			StartedUntil: until,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list bars: %w", err)
+		}
+		return db.ToBars(rows), nil
+	}
+
+	var bars []marketdata.Bar
+	for path := range strings.SplitSeq(csvFiles, ",") {
+		read, err := readBarsFile(strings.TrimSpace(path), symbol)
+		if err != nil {
+			return nil, err
+		}
+		for _, bar := range read {
+			if bar.Symbol == symbol && !bar.Time.Before(from) && bar.Time.Before(until) {
+				bars = append(bars, bar)
+			}
+		}
+	}
+	return bars, nil
+}
+
+func writeCSVFile[T any](path string, columns []column[T], items []T) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := printList(&printer{output: outputCSV, w: f}, columns, items); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return f.Close()
+}
+
+// floatValue is a ratio as JSON and CSV get it
+func floatValue(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+// shownPercent is a fraction as tables show it, such as +12.50%
+func shownPercent(f float64) string {
+	return format.Percent(decimal.NewFromFloat(f))
+}
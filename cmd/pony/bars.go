@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/revrost/pony/pkg/config"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/store"
+)
+
+const barsUsage = "usage: pony bars [import|fetch]"
+
+// runBars stores historical bars for backtests to replay
+func runBars(cfg *config.Config, conn *store.DB, args []string) error {
+	if len(args) == 0 {
+		return usageError(barsUsage)
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "import":
+		return importBars(conn, args)
+	case "fetch":
+		return fetchBars(cfg, conn, args)
+	default:
+		return usageError(barsUsage)
+	}
+}
+
+func importBars(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("bars import", flag.ContinueOnError)
+	symbol := flags.String("symbol", "", "symbol of the bars, for files without a symbol column")
+	timeframe := flags.String("timeframe", string(marketdata.OneDay), "period each bar covers: 1Min, 1Hour or 1Day")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		return usageError("usage: pony bars import [--symbol SYMBOL] [--timeframe 1Day] FILE.csv...")
+	}
+	tf, err := marketdata.ParseTimeframe(*timeframe)
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	for _, path := range flags.Args() {
+		bars, err := readBarsFile(path, strings.ToUpper(*symbol))
+		if err != nil {
+			return err
+		}
+		if err := storeBars(context.Background(), conn, bars, tf); err != nil {
+			return err
+		}
+		fmt.Printf("Imported %d %s bars from %s\n", len(bars), tf, path)
+	}
+	return nil
+}
+
+func fetchBars(cfg *config.Config, conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("bars fetch", flag.ContinueOnError)
+	timeframe := flags.String("timeframe", string(marketdata.OneDay), "period each bar covers: 1Min, 1Hour or 1Day")
+	start := flags.String("start", "", "first day to fetch, like 2006-01-02")
+	end := flags.String("end", "", "last day to fetch (default: today)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 || *start == "" {
+		return usageError("usage: pony bars fetch --start DATE [--end DATE] [--timeframe 1Day] SYMBOL...")
+	}
+	tf, err := marketdata.ParseTimeframe(*timeframe)
+	if err != nil {
+		return usageError(err.Error())
+	}
+	from, until, err := parseDateRange(*start, *end)
+	if err != nil {
+		return err
+	}
+
+	// The API's end is inclusive and must not be in the future
+	last := until.Add(-time.Nanosecond)
+	if now := time.Now(); last.After(now) {
+		last = now
+	}
+
+	ctx := context.Background()
+	history := marketdata.NewAlpacaHistory(cfg.AlpacaAPIKey, cfg.AlpacaAPISecret, cfg.MarketDataFeed)
+	for _, symbol := range flags.Args() {
+		symbol = strings.ToUpper(symbol)
+		bars, err := history.Bars(ctx, symbol, tf, from, last)
+		if err != nil {
+			return err
+		}
+		if err := storeBars(ctx, conn, bars, tf); err != nil {
+			return err
+		}
+		fmt.Printf("Fetched %d %s bars for %s\n", len(bars), tf, symbol)
+	}
+	return nil
+}
+
+func readBarsFile(path, symbol string) ([]marketdata.Bar, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	bars, err := marketdata.ReadBarsCSV(f, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return bars, nil
+}
+
+func storeBars(ctx context.Context, conn *store.DB, bars []marketdata.Bar, tf marketdata.Timeframe) error {
+	return conn.InTx(ctx, func(q db.Querier) error {
+		for _, bar := range bars {
+			if err := q.UpsertBar(ctx, db.NewUpsertBarParams(bar, string(tf))); err != nil {
+				return fmt.Errorf("failed to store %s bar at %s: %w", bar.Symbol, bar.Time.Format(time.RFC3339), err)
+			}
+		}
+		return nil
+	})
+}
+
+// parseDateRange parses the first and last days of a range, in UTC, and
+// returns the start of the first and the end of the last. An empty end is
+// today.
+func parseDateRange(start, end string) (time.Time, time.Time, error) {
+	from, err := time.Parse("2006-01-02", start)
+	if err != nil {
+		return time.Time{}, time.Time{}, usageError(fmt.Sprintf("invalid --start %q: use a date like 2006-01-02", start))
+	}
+	last := time.Now().UTC().Truncate(24 * time.Hour)
+	if end != "" {
+		if last, err = time.Parse("2006-01-02", end); err != nil {
+			return time.Time{}, time.Time{}, usageError(fmt.Sprintf("invalid --end %q: use a date like 2006-01-02", end))
+		}
+	}
+	if last.Before(from) {
+		return time.Time{}, time.Time{}, usageError("--end is before --start")
+	}
+	return from, last.AddDate(0, 0, 1), nil
+}
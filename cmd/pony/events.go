@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/taxlot"
+)
+
+const eventsUsage = "usage: pony events apply|rebuild"
+
+func runEvents(conn *store.DB, lotMethod taxlot.Method, args []string) error {
+	if len(args) != 1 {
+		return usageError(eventsUsage)
+	}
+
+	ctx := context.Background()
+	eventLog := events.NewStore(conn)
+	eventLog.LotMethod = lotMethod
+
+	switch args[0] {
+	case "apply":
+		applied, err := eventLog.ApplyPending(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Applied %d pending events\n", applied)
+		return nil
+
+	case "rebuild":
+		replayed, err := eventLog.Rebuild(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rebuilt projections from %d events\n", replayed)
+		return nil
+
+	default:
+		return usageError(eventsUsage)
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+
+	"github.com/revrost/pony/pkg/broker"
+)
+
+// Exit codes, so scripts can tell failures apart
+const (
+	exitOK       = 0
+	exitError    = 1 // anything else, including network errors
+	exitUsage    = 2 // the command line was wrong
+	exitNotFound = 3 // no such account, order or position
+	exitRejected = 4 // the broker refused the request
+)
+
+// errNotFound is for lookups in the database that found nothing
+var errNotFound = errors.New("not found")
+
+// errBadFlags means flag parsing failed. The flag package has already
+// printed the error and the usage, so it is not printed again.
+var errBadFlags = errors.New("invalid flags")
+
+// usageError is a command line that does not make sense, such as an
+// unknown subcommand or a missing argument
+type usageError string
+
+func (e usageError) Error() string {
+	return string(e)
+}
+
+// parseFlags parses args, turning flag errors into errBadFlags
+func parseFlags(flags *flag.FlagSet, args []string) error {
+	err := flags.Parse(args)
+	if err == nil || errors.Is(err, flag.ErrHelp) {
+		return err
+	}
+	return errBadFlags
+}
+
+func exitCode(err error) int {
+	var usage usageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case errors.Is(err, errBadFlags), errors.As(err, &usage):
+		return exitUsage
+	case errors.Is(err, errNotFound), errors.Is(err, broker.ErrNotFound):
+		return exitNotFound
+	case errors.Is(err, broker.ErrRejected):
+		return exitRejected
+	default:
+		return exitError
+	}
+}
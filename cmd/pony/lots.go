@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/taxlot"
+)
+
+const lotsUsage = "usage: pony lots [list|select|rebuild]"
+
+func runLots(conn *store.DB, method taxlot.Method, args []string) error {
+	ledger := taxlot.NewLedger(conn, method)
+
+	cmd := "list"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "list":
+		return listLots(ledger, args)
+	case "select":
+		return selectLot(ledger, args)
+	case "rebuild":
+		if len(args) != 0 {
+			return usageError(lotsUsage)
+		}
+		rebuilt, err := ledger.Rebuild(context.Background())
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Rebuilt tax lots for %d symbols using %s\n", rebuilt, ledger.Method())
+		return nil
+	default:
+		return usageError(lotsUsage)
+	}
+}
+
+func listLots(ledger *taxlot.Ledger, args []string) error {
+	flags := flag.NewFlagSet("lots list", flag.ContinueOnError)
+	accountID := flags.String("account", "", "only show lots for this account ID")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	lots, err := ledger.OpenLots(context.Background(), *accountID)
+	if err != nil {
+		return err
+	}
+	if len(lots) == 0 {
+		fmt.Println("No open lots")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "LOT\tACCOUNT\tSYMBOL\tSIDE\tOPENED\tQTY\tREMAINING\tPRICE\tCOST BASIS")
+	for _, l := range lots {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			l.ExecutionID, l.AccountID, l.Symbol, l.Direction, l.OpenedAt.Local().Format("2006-01-02"),
+			format.Qty(l.Qty), format.Qty(l.Remaining), format.Price(l.Price), format.Money(l.CostBasis()))
+	}
+	return w.Flush()
+}
+
+func selectLot(ledger *taxlot.Ledger, args []string) error {
+	flags := flag.NewFlagSet("lots select", flag.ContinueOnError)
+	orderID := flags.String("order", "", "the closing order, by ID or Alpaca order ID")
+	lotID := flags.String("lot", "", "the lot to close, by the execution ID that opened it")
+	qtyFlag := flags.String("qty", "", "how much of the lot the order closes")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if *orderID == "" || *lotID == "" || *qtyFlag == "" {
+		return usageError("usage: pony lots select --order ID --lot EXECUTION_ID --qty N")
+	}
+
+	qty, err := decimal.NewFromString(*qtyFlag)
+	if err != nil {
+		return fmt.Errorf("invalid --qty %q: %w", *qtyFlag, err)
+	}
+
+	if err := ledger.Select(context.Background(), *orderID, *lotID, qty); err != nil {
+		return err
+	}
+
+	fmt.Printf("Order %s closes %s of lot %s\n", *orderID, format.Qty(qty), *lotID)
+	if ledger.Method() != taxlot.SpecificID {
+		fmt.Printf("Note: TAX_LOT_METHOD is %s; selections only apply with %s\n", ledger.Method(), taxlot.SpecificID)
+	}
+	return nil
+}
+
+// runGains prints realized gains for a tax year, or writes them as CSV
+func runGains(conn *store.DB, method taxlot.Method, args []string) error {
+	flags := flag.NewFlagSet("gains", flag.ContinueOnError)
+	year := flags.Int("year", time.Now().Year(), "tax year to report")
+	accountID := flags.String("account", "", "only report this account ID")
+	asCSV := flags.Bool("csv", false, "write CSV to stdout instead of a table")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	// Tax years follow the calendar in local time
+	from := time.Date(*year, time.January, 1, 0, 0, 0, 0, time.Local)
+	until := from.AddDate(1, 0, 0)
+
+	disposals, err := taxlot.NewLedger(conn, method).Disposals(context.Background(), *accountID, from, until)
+	if err != nil {
+		return err
+	}
+
+	if *asCSV {
+		return writeGainsCSV(disposals)
+	}
+
+	if len(disposals) == 0 {
+		fmt.Printf("No realized gains in %d\n", *year)
+		return nil
+	}
+
+	totals := map[taxlot.Term]decimal.Decimal{}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SYMBOL\tQTY\tOPENED\tCLOSED\tPROCEEDS\tCOST BASIS\tGAIN\tTERM")
+	for _, d := range disposals {
+		totals[d.Term] = totals[d.Term].Add(d.RealizedPL)
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			d.Symbol, format.Qty(d.Qty),
+			d.OpenedAt.Local().Format("2006-01-02"), d.ClosedAt.Local().Format("2006-01-02"),
+			format.Money(d.Proceeds()), format.Money(d.CostBasis()), format.SignedMoney(d.RealizedPL), d.Term)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nShort-term: %s\n", format.SignedMoney(totals[taxlot.ShortTerm]))
+	fmt.Printf("Long-term:  %s\n", format.SignedMoney(totals[taxlot.LongTerm]))
+	fmt.Printf("Total:      %s\n", format.SignedMoney(totals[taxlot.ShortTerm].Add(totals[taxlot.LongTerm])))
+	return nil
+}
+
+// writeGainsCSV writes one row per disposal with the columns of a Form 8949
+// line, plus the lot and execution IDs for tracing each row back.
+func writeGainsCSV(disposals []*taxlot.Disposal) error {
+	w := csv.NewWriter(os.Stdout)
+	if err := w.Write([]string{
+		"account_id", "symbol", "direction", "qty", "date_acquired", "date_sold",
+		"proceeds", "cost_basis", "gain_loss", "term", "lot_execution_id", "closing_execution_id",
+	}); err != nil {
+		return err
+	}
+
+	for _, d := range disposals {
+		if err := w.Write([]string{
+			d.AccountID,
+			d.Symbol,
+			string(d.Direction),
+			d.Qty.String(),
+			d.OpenedAt.Local().Format("2006-01-02"),
+			d.ClosedAt.Local().Format("2006-01-02"),
+			d.Proceeds().StringFixed(2),
+			d.CostBasis().StringFixed(2),
+			d.RealizedPL.StringFixed(2),
+			string(d.Term),
+			d.LotExecutionID,
+			d.ExecutionID,
+		}); err != nil {
+			return err
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
@@ -1,63 +1,344 @@
 package main
 
 import (
-	"database/sql"
+	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	_ "github.com/lib/pq"
 
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/audit"
 	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/changes"
 	"github.com/revrost/pony/pkg/config"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/logging"
+	"github.com/revrost/pony/pkg/metrics"
+	"github.com/revrost/pony/pkg/migrate"
+	"github.com/revrost/pony/pkg/outbox"
+	"github.com/revrost/pony/pkg/reconcile"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/scheduler"
+	"github.com/revrost/pony/pkg/snapshot"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/tracing"
 	"github.com/revrost/pony/pkg/tui"
 )
 
 func main() {
-	if err := run(); err != nil {
-		log.Fatal(err)
+	err := run(os.Args[1:])
+	if err != nil && !errors.Is(err, flag.ErrHelp) && !errors.Is(err, errBadFlags) {
+		log.Print(err)
 	}
+	os.Exit(exitCode(err))
 }
 
-func run() error {
+func run(args []string) error {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	// Initialize database connection
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	// Log to a file, as the TUI's alt screen hides anything written to the terminal
+	logger, logs, logFile, err := logging.Open(logging.Options{Path: cfg.LogFile, Level: cfg.LogLevel})
 	if err != nil {
-		return fmt.Errorf("failed to connect to database: %w", err)
+		return err
 	}
-	defer db.Close()
+	defer logFile.Close()
 
-	if err := db.Ping(); err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+	// Trace TUI commands, broker calls and queries, if an exporter is set
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Options{
+		Service:  "pony",
+		Exporter: cfg.TraceExporter,
+		File:     cfg.TraceFile,
+	})
+	if err != nil {
+		return err
 	}
+	defer flushTraces(shutdownTracing)
 
-	// Initialize sqlc generated queries
-	// After running `sqlc generate`, you'll do:
-	// queries := db.New(db)
-	// For now, we'll pass nil and handle it in the TUI
-	var store tui.Store = nil // TODO: Replace with sqlc generated Queries
+	// Initialize database connection; the DATABASE_URL scheme picks Postgres or SQLite
+	conn, err := store.Open(cfg.DatabaseURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.QueryHooks = []store.QueryHook{metrics.QueryHook}
+	if cfg.TraceExporter != tracing.ExporterNone {
+		conn.QueryHooks = append(conn.QueryHooks, tracing.QueryHook(string(conn.Dialect)))
+	}
 
-	// Initialize Alpaca broker client
-	brokerClient := broker.NewAlpacaClient(
+	// Initialize Alpaca broker client; every trading action goes through the audit log
+	alpacaClient := broker.NewAlpacaClient(
 		cfg.AlpacaAPIKey,
 		cfg.AlpacaAPISecret,
 		cfg.AlpacaBaseURL,
 	)
+	alpacaClient.WrapTransport(metrics.Transport)
+	if cfg.TraceExporter != tracing.ExporterNone {
+		alpacaClient.WrapTransport(tracing.Transport)
+	}
+	alpacaClient.WrapTransport(logging.Transport(logger))
+	var brokerClient broker.Client = metrics.NewClient(alpacaClient)
+	brokerClient = tracing.NewClient(brokerClient)
+	auditLog := audit.NewLog(conn.Queries(), cfg.Operator)
+	brokerClient = audit.NewClient(brokerClient, auditLog)
+	// Orders are written to the outbox before they are sent, so none is lost to a crash
+	brokerClient = outbox.NewClient(brokerClient, conn)
+
+	// Bring the schema up to date before anything touches it. pony migrate
+	// manages it by hand, so down and status see it as it is.
+	if len(args) == 0 || args[0] != "migrate" {
+		migrator, err := migrate.New(conn)
+		if err != nil {
+			return err
+		}
+		if _, err := migrator.Up(context.Background()); err != nil {
+			return fmt.Errorf("failed to run migrations: %w", err)
+		}
+	}
+
+	// Without a command, or with only flags, pony runs the TUI
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return runTUI(cfg, brokerClient, conn, auditLog, logger, logs, args)
+	}
+
+	switch args[0] {
+	case "migrate":
+		return runMigrate(conn, args[1:])
+	case "reconcile":
+		return runReconcile(brokerClient, conn, cfg.TaxLotMethod, args[1:])
+	case "snapshot":
+		return runSnapshot(brokerClient, conn, args[1:])
+	case "outbox":
+		return runOutbox(brokerClient, conn, args[1:])
+	case "events":
+		return runEvents(conn, cfg.TaxLotMethod, args[1:])
+	case "accounts":
+		return runAccounts(conn, args[1:])
+	case "account":
+		return runAccount(conn, args[1:])
+	case "positions":
+		return runPositions(conn, args[1:])
+	case "orders":
+		return runOrders(brokerClient, conn, args[1:])
+	case "serve":
+		return runServe(cfg, brokerClient, conn, args[1:])
+	case "stream":
+		return runStream(brokerClient, conn, cfg.TaxLotMethod, args[1:])
+	case "audit":
+		return runAudit(conn, args[1:])
+	case "lots":
+		return runLots(conn, cfg.TaxLotMethod, args[1:])
+	case "gains":
+		return runGains(conn, cfg.TaxLotMethod, args[1:])
+	case "bars":
+		return runBars(cfg, conn, args[1:])
+	case "backtest":
+		return runBacktest(conn, logger, args[1:])
+	case "schedules":
+		return runSchedules(brokerClient, conn, auditLog, args[1:])
+	case "alerts":
+		return runAlerts(cfg, brokerClient, conn, auditLog, args[1:])
+	default:
+		return usageError(fmt.Sprintf("unknown command %q", args[0]))
+	}
+}
+
+func runTUI(cfg *config.Config, brokerClient broker.Client, conn *store.DB, auditLog *audit.Log, logger *slog.Logger, logs *logging.Ring, args []string) error {
+	flags := flag.NewFlagSet("pony", flag.ContinueOnError)
+	metricsAddr := flags.String("metrics-addr", cfg.MetricsAddr, "serve Prometheus metrics on this address (default: off)")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony [--metrics-addr HOST:PORT]")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Everything logged while the TUI runs, the standard log package
+	// included, goes to the log file and the log pane. Errors returned
+	// after it quits are printed to the terminal as usual.
+	slog.SetDefault(logger)
+	defer log.SetFlags(log.LstdFlags)
+	defer log.SetOutput(os.Stderr)
+	slog.Info("starting TUI", "database", string(conn.Dialect), "broker", cfg.AlpacaBaseURL)
+
+	// Serve metrics on the side; the TUI owns the terminal, so only a
+	// failure to listen is reported
+	if *metricsAddr != "" {
+		listener, err := net.Listen("tcp", *metricsAddr)
+		if err != nil {
+			return fmt.Errorf("failed to serve metrics: %w", err)
+		}
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", metrics.Handler())
+		metricsServer := &http.Server{Handler: mux}
+		go metricsServer.Serve(listener)
+		defer metricsServer.Close()
+	}
+
+	// Initialize sqlc generated queries for the database's dialect
+	queries := conn.Queries()
+
+	// Catch up with the broker before the first view loads. A failure,
+	// such as the broker being unreachable, is shown in the TUI rather than
+	// keeping it from starting, so the stored data can still be browsed;
+	// the background passes below try again.
+	var startupErrs []error
+	engine := reconcile.NewEngine(brokerClient, queries)
+	engine.Interval = cfg.ReconcileInterval
+	engine.LotMethod = cfg.TaxLotMethod
+	if _, err := engine.Reconcile(ctx, false); err != nil {
+		startupErrs = append(startupErrs, fmt.Errorf("failed to reconcile with broker: %w", err))
+	}
+
+	// Apply any events that were logged but not applied last time
+	eventLog := events.NewStore(conn)
+	eventLog.LotMethod = cfg.TaxLotMethod
+	if _, err := eventLog.ApplyPending(ctx); err != nil {
+		startupErrs = append(startupErrs, fmt.Errorf("failed to apply pending events: %w", err))
+	}
 
-	// Initialize TUI model
-	model := tui.NewModel(brokerClient, store)
+	// Settle orders whose broker response was never recorded
+	if _, err := outbox.Resolve(ctx, brokerClient, conn); err != nil {
+		startupErrs = append(startupErrs, fmt.Errorf("failed to resolve pending orders: %w", err))
+	}
+	for _, err := range startupErrs {
+		slog.Error("startup sync failed", "error", err)
+	}
+
+	if cfg.UseEventWorker && conn.Dialect != store.Postgres {
+		return fmt.Errorf("USE_EVENT_WORKER needs Postgres; SQLite has no change feed")
+	}
+
+	// Strategies start out stopped; the Strategies view starts them
+	var strategies tui.Strategies
+	if len(cfg.Strategies) > 0 {
+		strategyEngine, err := newStrategyEngine(ctx, cfg, brokerClient, queries, auditLog)
+		if err != nil {
+			return fmt.Errorf("failed to set up strategies: %w", err)
+		}
+		strategies = strategyEngine
+
+		strategiesDone := make(chan struct{})
+		go func() {
+			defer close(strategiesDone)
+			strategyEngine.Run(ctx)
+		}()
+		defer func() {
+			cancel()
+			select {
+			case <-strategiesDone:
+			case <-time.After(5 * time.Second):
+			}
+		}()
+	}
+
+	// Initialize TUI model; changes to schedules and alerts made in it go
+	// through the audit log
+	model := tui.NewModel(brokerClient, tuiStore{Querier: audit.NewQuerier(queries, auditLog), conn: conn}, logs, strategies)
 
 	// Start the TUI
 	p := tea.NewProgram(
 		model,
 		tea.WithAltScreen(),
 	)
+	if len(startupErrs) > 0 {
+		go p.Send(tui.ReconciledMsg{Err: errors.Join(startupErrs...)})
+	}
+
+	// Check alert rules against market data, broker events and the stored
+	// accounts and positions, showing their alerts as toasts
+	alertMonitor := newAlertMonitor(cfg, queries)
+	go alertMonitor.Run(ctx, func(alerts []*alert.Alert, err error) {
+		p.Send(tui.AlertMsg{Alerts: alerts, Err: err})
+	})
+
+	// Stream broker events into the database and on to the TUI for as long
+	// as it runs. With an event worker running, the TUI leaves streaming
+	// events to it and picks its writes up from the change feed.
+	if !cfg.UseEventWorker {
+		subscription := events.NewSubscription(brokerClient, eventLog)
+		streamDone := make(chan struct{})
+		go func() {
+			defer close(streamDone)
+			subscription.Run(ctx, func(event broker.Event) {
+				alertMonitor.HandleEvent(ctx, event)
+				p.Send(tui.EventMsg{Event: event})
+			}, func(state events.State, err error) {
+				p.Send(tui.StreamStateMsg{State: state, Err: err})
+			})
+		}()
+
+		// On quit, close the stream before the database is closed, without
+		// waiting forever on a broker that does not answer
+		defer func() {
+			cancel()
+			select {
+			case <-streamDone:
+			case <-time.After(5 * time.Second):
+			}
+		}()
+	}
+
+	// Keep reconciling in the background; the first pass already ran above
+	go func() {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(engine.Interval):
+		}
+		engine.Run(ctx, func(report *reconcile.Report, err error) {
+			p.Send(tui.ReconciledMsg{Err: err})
+		})
+	}()
+
+	// Refresh the views when another process, such as a second TUI, writes
+	// to the database. Postgres only; SQLite has no change feed.
+	go func() {
+		err := changes.Watch(ctx, conn, func(changed []changes.Change) {
+			p.Send(tui.ChangedMsg{Changes: changed})
+		})
+		if err != nil {
+			p.Send(tui.ChangedMsg{Err: err})
+		}
+	}()
+
+	// Store today's account snapshots as the day goes on, after filling in
+	// any trading days missed while pony was not running
+	recorder := snapshot.NewRecorder(brokerClient, queries)
+	recorder.Interval = cfg.SnapshotInterval
+	go func() {
+		if _, err := recorder.Backfill(ctx, snapshot.DefaultBackfillPeriod); err != nil && ctx.Err() == nil {
+			p.Send(tui.SnapshotMsg{Err: err})
+		}
+		recorder.Run(ctx, func(snapshots []*account.Snapshot, err error) {
+			p.Send(tui.SnapshotMsg{Err: err})
+		})
+	}()
+
+	// Place scheduled orders as they come due. Another pony running the
+	// scheduler cannot place the same run twice.
+	orderScheduler := scheduler.NewScheduler(brokerClient, queries)
+	orderScheduler.ResolveIntents = resolveIntents(brokerClient, conn)
+	go orderScheduler.Run(ctx, func(runs []*schedule.Run, err error) {
+		p.Send(tui.ScheduledMsg{Runs: runs, Err: err})
+	})
 
 	if _, err := p.Run(); err != nil {
 		return fmt.Errorf("error running program: %w", err)
@@ -65,3 +346,24 @@ func run() error {
 
 	return nil
 }
+
+// tuiStore is the TUI's store: queries that record changes in the audit
+// log, and transactions on the connection for writes that go together
+type tuiStore struct {
+	*audit.Querier
+	conn *store.DB
+}
+
+func (s tuiStore) InTx(ctx context.Context, fn func(q db.Querier) error) error {
+	return s.conn.InTx(ctx, fn)
+}
+
+// flushTraces exports the spans still buffered, giving up after a few
+// seconds so an unreachable collector cannot hold up exiting
+func flushTraces(shutdown func(context.Context) error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdown(ctx); err != nil {
+		log.Printf("failed to flush traces: %v", err)
+	}
+}
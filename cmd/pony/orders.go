@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/history"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/store"
+)
+
+const ordersUsage = "usage: pony orders list|get|place|cancel|replace"
+
+var orderColumns = []column[*order.Order]{
+	{name: "id", value: func(o *order.Order) string { return o.ID }},
+	{name: "alpaca_order_id", value: func(o *order.Order) string { return o.AlpacaOrderID }, detail: true},
+	{name: "account_id", value: func(o *order.Order) string { return o.AccountID }, detail: true},
+	{name: "symbol", value: func(o *order.Order) string { return o.Symbol }},
+	{name: "side", value: func(o *order.Order) string { return string(o.Side) }},
+	{name: "type", value: func(o *order.Order) string { return string(o.OrderType) }},
+	{name: "qty", value: func(o *order.Order) string { return decimalPtrValue(o.Qty) },
+		shown: func(o *order.Order) string { return format.QtyOrDash(o.Qty) }},
+	{name: "filled_qty", value: func(o *order.Order) string { return decimalValue(o.FilledQty) },
+		shown: func(o *order.Order) string { return format.Qty(o.FilledQty) }},
+	{name: "limit_price", value: func(o *order.Order) string { return decimalPtrValue(o.LimitPrice) },
+		shown: func(o *order.Order) string { return format.PriceOrDash(o.LimitPrice) }},
+	{name: "stop_price", value: func(o *order.Order) string { return decimalPtrValue(o.StopPrice) },
+		shown: func(o *order.Order) string { return format.PriceOrDash(o.StopPrice) }},
+	{name: "time_in_force", value: func(o *order.Order) string { return string(o.TimeInForce) }, detail: true},
+	{name: "status", value: func(o *order.Order) string { return string(o.Status) }},
+	{name: "filled_avg_price", value: func(o *order.Order) string { return decimalPtrValue(o.FilledAvgPrice) },
+		shown: func(o *order.Order) string { return format.PriceOrDash(o.FilledAvgPrice) }},
+	{name: "submitted_at", value: func(o *order.Order) string { return timeValue(o.SubmittedAt) },
+		shown: func(o *order.Order) string { return shownTime(o.SubmittedAt) }},
+	{name: "filled_at", value: func(o *order.Order) string { return timePtrValue(o.FilledAt) }, detail: true},
+	{name: "canceled_at", value: func(o *order.Order) string { return timePtrValue(o.CanceledAt) }, detail: true},
+}
+
+func runOrders(brokerClient broker.Client, conn *store.DB, args []string) error {
+	if len(args) == 0 {
+		return usageError(ordersUsage)
+	}
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		return listOrders(conn, args)
+	case "get":
+		return getOrder(conn, args)
+	case "place":
+		return placeOrder(brokerClient, conn, args)
+	case "cancel":
+		return cancelOrder(brokerClient, conn, args)
+	case "replace":
+		return replaceOrder(brokerClient, conn, args)
+	default:
+		return usageError(ordersUsage)
+	}
+}
+
+func listOrders(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("orders list", flag.ContinueOnError)
+	accountID := flags.String("account", "", "account ID (default: the first account)")
+	symbol := flags.String("symbol", "", "only list orders for this symbol")
+	side := flags.String("side", "", "only list buy or sell orders")
+	status := flags.String("status", "", "only list orders with these comma-separated statuses; open means any status of a working order")
+	limit := flags.Int("limit", history.DefaultPageSize, "maximum number of orders to list")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony orders list [--account ID] [--symbol SYM] [--side buy|sell] [--status S,...] [--limit N] [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	id, err := defaultAccountID(ctx, conn.Queries(), *accountID)
+	if err != nil {
+		return err
+	}
+
+	filter := history.Filter{
+		AccountID: id,
+		Symbol:    strings.ToUpper(*symbol),
+		Side:      order.OrderSide(*side),
+	}
+	filter.Statuses = order.ParseStatuses(*status)
+
+	page, err := history.Search(ctx, conn.Queries(), filter, history.Cursor{}, *limit)
+	if err != nil {
+		return err
+	}
+	return printList(p, orderColumns, page.Orders)
+}
+
+func getOrder(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("orders get", flag.ContinueOnError)
+	out := outputFlag(flags)
+	id, err := parseWithID(flags, args, "usage: pony orders get ID [--output table|json|csv]")
+	if err != nil {
+		return err
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	o, err := findOrder(context.Background(), conn.Queries(), id)
+	if err != nil {
+		return err
+	}
+	return printOne(p, orderColumns, o)
+}
+
+func placeOrder(brokerClient broker.Client, conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("orders place", flag.ContinueOnError)
+	accountID := flags.String("account", "", "account ID (default: the first account)")
+	symbol := flags.String("symbol", "", "symbol to trade")
+	side := flags.String("side", string(order.OrderSideBuy), "buy or sell")
+	orderType := flags.String("type", string(order.OrderTypeMarket), "market, limit, stop or stop_limit")
+	qty := flags.String("qty", "", "quantity")
+	notional := flags.String("notional", "", "dollar amount to buy or sell instead of a quantity, for market day orders")
+	limitPrice := flags.String("limit-price", "", "limit price, for limit and stop_limit orders")
+	stopPrice := flags.String("stop-price", "", "stop price, for stop and stop_limit orders")
+	tif := flags.String("tif", string(order.TimeInForceDay), "time in force: day, gtc, ioc or fok")
+	clientOrderID := flags.String("client-order-id", "", "client order ID, so a retried script cannot place the order twice (default: a new one)")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony orders place --symbol SYM --qty N|--notional USD [--side buy|sell] [--type T] [--limit-price P] [--stop-price P] [--tif day|gtc|ioc|fok] [--account ID] [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	id, err := defaultAccountID(ctx, conn.Queries(), *accountID)
+	if err != nil {
+		return err
+	}
+
+	req := &order.CreateOrderRequest{
+		AccountID:     id,
+		Symbol:        strings.ToUpper(*symbol),
+		Side:          order.OrderSide(*side),
+		OrderType:     order.OrderType(*orderType),
+		TimeInForce:   order.TimeInForce(*tif),
+		ClientOrderID: *clientOrderID,
+	}
+	if req.Qty, err = decimalFlag("qty", *qty); err != nil {
+		return err
+	}
+	if req.Notional, err = decimalFlag("notional", *notional); err != nil {
+		return err
+	}
+	if req.LimitPrice, err = decimalFlag("limit-price", *limitPrice); err != nil {
+		return err
+	}
+	if req.StopPrice, err = decimalFlag("stop-price", *stopPrice); err != nil {
+		return err
+	}
+	if err := req.Validate(); err != nil {
+		return usageError(err.Error())
+	}
+
+	o, err := brokerClient.CreateOrder(ctx, req)
+	if err != nil {
+		return err
+	}
+	return printOne(p, orderColumns, o)
+}
+
+func cancelOrder(brokerClient broker.Client, conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("orders cancel", flag.ContinueOnError)
+	id, err := parseWithID(flags, args, "usage: pony orders cancel ID")
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	alpacaOrderID, err := brokerOrderID(ctx, conn.Queries(), id)
+	if err != nil {
+		return err
+	}
+	if err := brokerClient.CancelOrder(ctx, alpacaOrderID); err != nil {
+		return err
+	}
+
+	fmt.Printf("Cancel requested for order %s\n", alpacaOrderID)
+	return nil
+}
+
+func replaceOrder(brokerClient broker.Client, conn *store.DB, args []string) error {
+	const usage = "usage: pony orders replace ID [--qty N] [--limit-price P] [--stop-price P] [--tif day|gtc|ioc|fok] [--output table|json|csv]"
+
+	flags := flag.NewFlagSet("orders replace", flag.ContinueOnError)
+	qty := flags.String("qty", "", "new quantity")
+	limitPrice := flags.String("limit-price", "", "new limit price")
+	stopPrice := flags.String("stop-price", "", "new stop price")
+	tif := flags.String("tif", "", "new time in force: day, gtc, ioc or fok")
+	out := outputFlag(flags)
+	id, err := parseWithID(flags, args, usage)
+	if err != nil {
+		return err
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	req := &order.ReplaceOrderRequest{TimeInForce: order.TimeInForce(*tif)}
+	if req.Qty, err = decimalFlag("qty", *qty); err != nil {
+		return err
+	}
+	if req.LimitPrice, err = decimalFlag("limit-price", *limitPrice); err != nil {
+		return err
+	}
+	if req.StopPrice, err = decimalFlag("stop-price", *stopPrice); err != nil {
+		return err
+	}
+	if req.Qty == nil && req.LimitPrice == nil && req.StopPrice == nil && req.TimeInForce == "" {
+		return usageError(usage)
+	}
+
+	ctx := context.Background()
+	alpacaOrderID, err := brokerOrderID(ctx, conn.Queries(), id)
+	if err != nil {
+		return err
+	}
+	o, err := brokerClient.ReplaceOrder(ctx, alpacaOrderID, req)
+	if err != nil {
+		return err
+	}
+	return printOne(p, orderColumns, o)
+}
+
+// findOrder looks an order up by its ID or Alpaca order ID
+func findOrder(ctx context.Context, queries db.Querier, id string) (*order.Order, error) {
+	row, err := queries.GetOrder(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		row, err = queries.GetOrderByAlpacaID(ctx, id)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("order %q %w", id, errNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return db.ToOrder(row), nil
+}
+
+// brokerOrderID is the Alpaca order ID for an order given by either ID. An
+// order not in the database yet is passed to the broker as it is.
+func brokerOrderID(ctx context.Context, queries db.Querier, id string) (string, error) {
+	o, err := findOrder(ctx, queries, id)
+	if errors.Is(err, errNotFound) {
+		return id, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return o.AlpacaOrderID, nil
+}
+
+// decimalFlag parses an optional number flag; empty means not given
+func decimalFlag(name, value string) (*decimal.Decimal, error) {
+	if value == "" {
+		return nil, nil
+	}
+	d, err := decimal.NewFromString(value)
+	if err != nil {
+		return nil, usageError(fmt.Sprintf("invalid --%s %q", name, value))
+	}
+	return &d, nil
+}
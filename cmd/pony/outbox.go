@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/outbox"
+	"github.com/revrost/pony/pkg/store"
+)
+
+const outboxUsage = "usage: pony outbox [list|resolve]"
+
+func runOutbox(brokerClient broker.Client, conn *store.DB, args []string) error {
+	cmd := "list"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "list":
+		return listIntents(conn, args)
+	case "resolve":
+		if len(args) != 0 {
+			return usageError(outboxUsage)
+		}
+		resolved, err := outbox.Resolve(context.Background(), brokerClient, conn)
+		for _, i := range resolved {
+			fmt.Printf("%s %s %s %s: %s\n", i.ClientOrderID, i.Side, format.QtyOrDash(i.Qty), i.Symbol, i.Status)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Resolved %d pending order intents\n", len(resolved))
+		return nil
+	default:
+		return usageError(outboxUsage)
+	}
+}
+
+func listIntents(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("outbox list", flag.ContinueOnError)
+	accountID := flags.String("account", "", "only show intents for this account ID")
+	status := flags.String("status", "", "only show intents with this status: pending, sent or failed")
+	limit := flags.Int("limit", outbox.DefaultLimit, "maximum number of intents to show")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	intents, err := outbox.ListIntents(context.Background(), conn.Queries(), outbox.Filter{
+		AccountID: *accountID,
+		Status:    outbox.Status(*status),
+		Limit:     *limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(intents) == 0 {
+		fmt.Println("No order intents found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT ORDER ID\tTIME\tSYMBOL\tSIDE\tTYPE\tQTY\tSTATUS\tORDER / ERROR")
+	for _, i := range intents {
+		result := i.AlpacaOrderID
+		if i.Status == outbox.StatusFailed {
+			result = i.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			i.ClientOrderID, i.CreatedAt.Local().Format("2006-01-02 15:04:05"), i.Symbol, i.Side, i.OrderType,
+			format.QtyOrDash(i.Qty), i.Status, result)
+	}
+	return w.Flush()
+}
+
+// resolveIntents asks the broker about the order intents it never answered
+// for, such as for the scheduler before it settles pending runs
+func resolveIntents(brokerClient broker.Client, conn *store.DB) func(context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := outbox.Resolve(ctx, brokerClient, conn)
+		return err
+	}
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// output is how a command prints its results, chosen with --output
+type output string
+
+const (
+	outputTable output = "table"
+	outputJSON  output = "json"
+	outputCSV   output = "csv"
+)
+
+// column is one field of the records a command prints. Value is what JSON
+// and CSV get, raw and unformatted; an empty value is JSON null. Shown is
+// what the table gets and defaults to the value.
+type column[T any] struct {
+	name  string
+	value func(T) string
+	shown func(T) string
+
+	// detail columns are left out of the table to keep it narrow
+	detail bool
+}
+
+// printer writes records in the chosen output to stdout
+type printer struct {
+	output output
+	w      io.Writer
+
+	// streaming is set once printStream has written its header
+	streaming bool
+}
+
+// outputFlag adds --output to a command's flags
+func outputFlag(flags *flag.FlagSet) *string {
+	return flags.String("output", string(outputTable), "output format: table, json or csv")
+}
+
+func newPrinter(name string) (*printer, error) {
+	switch o := output(name); o {
+	case outputTable, outputJSON, outputCSV:
+		return &printer{output: o, w: os.Stdout}, nil
+	default:
+		return nil, usageError(fmt.Sprintf("--output must be table, json or csv, not %q", name))
+	}
+}
+
+// printList writes items as a table, a JSON array or CSV with a header
+func printList[T any](p *printer, columns []column[T], items []T) error {
+	switch p.output {
+	case outputJSON:
+		records := make([]record, 0, len(items))
+		for _, item := range items {
+			records = append(records, newRecord(columns, item))
+		}
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(records)
+
+	case outputCSV:
+		w := csv.NewWriter(p.w)
+		w.Write(names(columns))
+		for _, item := range items {
+			w.Write(values(columns, item))
+		}
+		w.Flush()
+		return w.Error()
+
+	default:
+		w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, strings.Join(headers(columns), "\t"))
+		for _, item := range items {
+			fmt.Fprintln(w, strings.Join(shown(columns, item), "\t"))
+		}
+		return w.Flush()
+	}
+}
+
+// printOne writes a single item: a JSON object, CSV with a header, or one
+// field per line
+func printOne[T any](p *printer, columns []column[T], item T) error {
+	switch p.output {
+	case outputJSON:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(newRecord(columns, item))
+
+	case outputCSV:
+		return printList(p, columns, []T{item})
+
+	default:
+		// Every field, detail ones included
+		w := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+		for _, c := range columns {
+			fmt.Fprintf(w, "%s:\t%s\n", header(c.name), c.show(item))
+		}
+		return w.Flush()
+	}
+}
+
+// printStream writes one item of a stream as soon as it arrives: a table
+// row, a line of JSON or a CSV row. The header comes before the first one.
+func printStream[T any](p *printer, columns []column[T], item T) error {
+	first := !p.streaming
+	p.streaming = true
+
+	switch p.output {
+	case outputJSON:
+		return json.NewEncoder(p.w).Encode(newRecord(columns, item))
+
+	case outputCSV:
+		w := csv.NewWriter(p.w)
+		if first {
+			w.Write(names(columns))
+		}
+		w.Write(values(columns, item))
+		w.Flush()
+		return w.Error()
+
+	default:
+		// Rows cannot be aligned with ones not seen yet, so tabs will do
+		if first {
+			fmt.Fprintln(p.w, strings.Join(headers(columns), "\t"))
+		}
+		_, err := fmt.Fprintln(p.w, strings.Join(shown(columns, item), "\t"))
+		return err
+	}
+}
+
+func (c column[T]) show(item T) string {
+	if c.shown != nil {
+		return c.shown(item)
+	}
+	if v := c.value(item); v != "" {
+		return v
+	}
+	return "-"
+}
+
+func names[T any](columns []column[T]) []string {
+	out := make([]string, 0, len(columns))
+	for _, c := range columns {
+		out = append(out, c.name)
+	}
+	return out
+}
+
+func values[T any](columns []column[T], item T) []string {
+	out := make([]string, 0, len(columns))
+	for _, c := range columns {
+		out = append(out, c.value(item))
+	}
+	return out
+}
+
+func headers[T any](columns []column[T]) []string {
+	out := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !c.detail {
+			out = append(out, header(c.name))
+		}
+	}
+	return out
+}
+
+func shown[T any](columns []column[T], item T) []string {
+	out := make([]string, 0, len(columns))
+	for _, c := range columns {
+		if !c.detail {
+			out = append(out, c.show(item))
+		}
+	}
+	return out
+}
+
+// header turns a field name such as buying_power into BUYING POWER
+func header(name string) string {
+	return strings.ToUpper(strings.ReplaceAll(name, "_", " "))
+}
+
+// record is one item as a JSON object, keeping the column order
+type record struct {
+	names  []string
+	values []string
+}
+
+func newRecord[T any](columns []column[T], item T) record {
+	return record{names: names(columns), values: values(columns, item)}
+}
+
+func (r record) MarshalJSON() ([]byte, error) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, name := range r.names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		b.Write(key)
+		b.WriteByte(':')
+		if r.values[i] == "" {
+			b.WriteString("null")
+			continue
+		}
+		value, _ := json.Marshal(r.values[i])
+		b.Write(value)
+	}
+	b.WriteByte('}')
+	return b.Bytes(), nil
+}
+
+// Raw values for JSON and CSV. Decimals keep their exact digits and times
+// are RFC 3339 in UTC.
+
+func decimalValue(d decimal.Decimal) string {
+	return d.String()
+}
+
+func decimalPtrValue(d *decimal.Decimal) string {
+	if d == nil {
+		return ""
+	}
+	return d.String()
+}
+
+func timeValue(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func timePtrValue(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return timeValue(*t)
+}
+
+// shownTime is a time as tables show it, in local time
+func shownTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04:05")
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/reconcile"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/taxlot"
+)
+
+func runReconcile(brokerClient broker.Client, conn *store.DB, lotMethod taxlot.Method, args []string) error {
+	flags := flag.NewFlagSet("reconcile", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "print the differences without writing to the database")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	engine := reconcile.NewEngine(brokerClient, conn.Queries())
+	engine.LotMethod = lotMethod
+	report, err := engine.Reconcile(context.Background(), *dryRun)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Checked %d accounts, %d orders, %d positions\n",
+		report.Accounts, report.Orders, report.Positions)
+
+	if len(report.Drifts) == 0 {
+		fmt.Println("No drift found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ENTITY\tID\tFIELD\tLOCAL\tBROKER")
+	for _, d := range report.Drifts {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Entity, d.EntityID, d.Field, d.LocalValue, d.BrokerValue)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if *dryRun {
+		fmt.Printf("%d differences found (dry run, nothing written)\n", len(report.Drifts))
+	} else {
+		fmt.Printf("%d differences corrected\n", len(report.Drifts))
+	}
+	return nil
+}
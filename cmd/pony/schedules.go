@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/revrost/pony/pkg/audit"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/scheduler"
+	"github.com/revrost/pony/pkg/store"
+)
+
+const schedulesUsage = "usage: pony schedules list|add|enable|disable|delete|runs|run"
+
+var scheduleColumns = []column[*schedule.ScheduledOrder]{
+	{name: "id", value: func(s *schedule.ScheduledOrder) string { return strconv.FormatInt(s.ID, 10) }},
+	{name: "account_id", value: func(s *schedule.ScheduledOrder) string { return s.Request.AccountID }, detail: true},
+	{name: "schedule", value: func(s *schedule.ScheduledOrder) string { return s.Schedule }},
+	{name: "time_zone", value: func(s *schedule.ScheduledOrder) string { return s.TimeZone }, detail: true},
+	{name: "symbol", value: func(s *schedule.ScheduledOrder) string { return s.Request.Symbol }},
+	{name: "side", value: func(s *schedule.ScheduledOrder) string { return string(s.Request.Side) }},
+	{name: "type", value: func(s *schedule.ScheduledOrder) string { return string(s.Request.OrderType) }},
+	{name: "qty", value: func(s *schedule.ScheduledOrder) string { return decimalPtrValue(s.Request.Qty) },
+		shown: func(s *schedule.ScheduledOrder) string { return format.QtyOrDash(s.Request.Qty) }},
+	{name: "notional", value: func(s *schedule.ScheduledOrder) string { return decimalPtrValue(s.Request.Notional) },
+		shown: func(s *schedule.ScheduledOrder) string { return format.MoneyOrDash(s.Request.Notional) }},
+	{name: "limit_price", value: func(s *schedule.ScheduledOrder) string { return decimalPtrValue(s.Request.LimitPrice) }, detail: true},
+	{name: "stop_price", value: func(s *schedule.ScheduledOrder) string { return decimalPtrValue(s.Request.StopPrice) }, detail: true},
+	{name: "time_in_force", value: func(s *schedule.ScheduledOrder) string { return string(s.Request.TimeInForce) }, detail: true},
+	{name: "enabled", value: func(s *schedule.ScheduledOrder) string { return strconv.FormatBool(s.Enabled) }},
+	{name: "next_run_at", value: func(s *schedule.ScheduledOrder) string { return timePtrValue(s.NextRunAt) },
+		shown: func(s *schedule.ScheduledOrder) string { return shownTimePtr(s.NextRunAt) }},
+	{name: "last_run_at", value: func(s *schedule.ScheduledOrder) string { return timePtrValue(s.LastRunAt) },
+		shown: func(s *schedule.ScheduledOrder) string { return shownTimePtr(s.LastRunAt) }},
+	{name: "last_result", value: func(s *schedule.ScheduledOrder) string { return string(s.LastResult) }},
+}
+
+var runColumns = []column[*schedule.Run]{
+	{name: "id", value: func(r *schedule.Run) string { return strconv.FormatInt(r.ID, 10) }, detail: true},
+	{name: "schedule_id", value: func(r *schedule.Run) string { return strconv.FormatInt(r.ScheduleID, 10) }},
+	{name: "scheduled_for", value: func(r *schedule.Run) string { return timeValue(r.ScheduledFor) },
+		shown: func(r *schedule.Run) string { return shownTime(r.ScheduledFor) }},
+	{name: "result", value: func(r *schedule.Run) string { return string(r.Result) }},
+	{name: "client_order_id", value: func(r *schedule.Run) string { return r.ClientOrderID }, detail: true},
+	{name: "order_id", value: func(r *schedule.Run) string { return r.OrderID }},
+	{name: "order_status", value: func(r *schedule.Run) string { return string(r.OrderStatus) }},
+	{name: "message", value: func(r *schedule.Run) string { return r.Message }},
+}
+
+// runSchedules manages the orders placed on a schedule. The TUI places them
+// while it runs, and so does pony schedules run.
+func runSchedules(brokerClient broker.Client, conn *store.DB, auditLog *audit.Log, args []string) error {
+	if len(args) == 0 {
+		return usageError(schedulesUsage)
+	}
+	// Changes to schedules are recorded in the audit log
+	queries := audit.NewQuerier(conn.Queries(), auditLog)
+
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "list":
+		return listSchedules(conn, args)
+	case "add":
+		return addSchedule(queries, args)
+	case "enable":
+		return setScheduleEnabled(queries, args, true)
+	case "disable":
+		return setScheduleEnabled(queries, args, false)
+	case "delete":
+		return deleteSchedule(queries, args)
+	case "runs":
+		return listScheduleRuns(conn, args)
+	case "run":
+		return runScheduler(brokerClient, conn, args)
+	default:
+		return usageError(schedulesUsage)
+	}
+}
+
+func listSchedules(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("schedules list", flag.ContinueOnError)
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony schedules list [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	rows, err := conn.Queries().ListScheduledOrders(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to list schedules: %w", err)
+	}
+	return printList(p, scheduleColumns, db.ToScheduledOrders(rows))
+}
+
+func addSchedule(queries db.Querier, args []string) error {
+	const usage = "usage: pony schedules add --cron EXPR --symbol SYM --qty N|--notional USD [--side buy|sell] [--type T] [--limit-price P] [--stop-price P] [--tif day|gtc|ioc|fok] [--tz ZONE] [--account ID] [--output table|json|csv]"
+
+	flags := flag.NewFlagSet("schedules add", flag.ContinueOnError)
+	spec := flags.String("cron", "", `when to place the order, as a cron expression such as "0 10 * * mon"`)
+	tz := flags.String("tz", schedule.DefaultTimeZone, "time zone the cron expression is read in")
+	accountID := flags.String("account", "", "account ID (default: the first account)")
+	symbol := flags.String("symbol", "", "symbol to trade")
+	side := flags.String("side", string(order.OrderSideBuy), "buy or sell")
+	orderType := flags.String("type", string(order.OrderTypeMarket), "market, limit, stop or stop_limit")
+	qty := flags.String("qty", "", "quantity")
+	notional := flags.String("notional", "", "dollar amount to buy or sell instead of a quantity, for market day orders")
+	limitPrice := flags.String("limit-price", "", "limit price, for limit and stop_limit orders")
+	stopPrice := flags.String("stop-price", "", "stop price, for stop and stop_limit orders")
+	tif := flags.String("tif", string(order.TimeInForceDay), "time in force: day, gtc, ioc or fok")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *spec == "" {
+		return usageError(usage)
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	id, err := defaultAccountID(ctx, queries, *accountID)
+	if err != nil {
+		return err
+	}
+
+	req := order.CreateOrderRequest{
+		AccountID:   id,
+		Symbol:      strings.ToUpper(*symbol),
+		Side:        order.OrderSide(*side),
+		OrderType:   order.OrderType(*orderType),
+		TimeInForce: order.TimeInForce(*tif),
+	}
+	if req.Qty, err = decimalFlag("qty", *qty); err != nil {
+		return err
+	}
+	if req.Notional, err = decimalFlag("notional", *notional); err != nil {
+		return err
+	}
+	if req.LimitPrice, err = decimalFlag("limit-price", *limitPrice); err != nil {
+		return err
+	}
+	if req.StopPrice, err = decimalFlag("stop-price", *stopPrice); err != nil {
+		return err
+	}
+	s, err := schedule.New(req, *spec, *tz, time.Now())
+	if err != nil {
+		return usageError(err.Error())
+	}
+
+	row, err := queries.CreateScheduledOrder(ctx, db.NewCreateScheduledOrderParams(s))
+	if err != nil {
+		return fmt.Errorf("failed to create schedule: %w", err)
+	}
+	return printOne(p, scheduleColumns, db.ToScheduledOrder(row))
+}
+
+func setScheduleEnabled(queries db.Querier, args []string, enabled bool) error {
+	name := "disable"
+	if enabled {
+		name = "enable"
+	}
+	flags := flag.NewFlagSet("schedules "+name, flag.ContinueOnError)
+	id, err := parseScheduleID(flags, args, "usage: pony schedules "+name+" ID")
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	s, err := findSchedule(ctx, queries, id)
+	if err != nil {
+		return err
+	}
+	arg := db.SetScheduledOrderEnabledParams{ID: id, Enabled: enabled}
+	if enabled {
+		// Runs due while it was disabled are not made up
+		next, err := s.Next(time.Now())
+		if err != nil {
+			return err
+		}
+		arg.NextRunAt = sql.NullTime{Time: next.UTC(), Valid: true}
+	}
+	row, err := queries.SetScheduledOrderEnabled(ctx, arg)
+	if err != nil {
+		return fmt.Errorf("failed to %s schedule: %w", name, err)
+	}
+
+	if s = db.ToScheduledOrder(row); s.NextRunAt != nil {
+		fmt.Printf("Schedule %d enabled; next run %s\n", id, shownTime(*s.NextRunAt))
+		return nil
+	}
+	fmt.Printf("Schedule %d disabled\n", id)
+	return nil
+}
+
+func deleteSchedule(queries db.Querier, args []string) error {
+	flags := flag.NewFlagSet("schedules delete", flag.ContinueOnError)
+	id, err := parseScheduleID(flags, args, "usage: pony schedules delete ID")
+	if err != nil {
+		return err
+	}
+
+	n, err := queries.DeleteScheduledOrder(context.Background(), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete schedule: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("schedule %d %w", id, errNotFound)
+	}
+	fmt.Printf("Deleted schedule %d and its runs\n", id)
+	return nil
+}
+
+func listScheduleRuns(conn *store.DB, args []string) error {
+	const usage = "usage: pony schedules runs ID [--limit N] [--output table|json|csv]"
+
+	flags := flag.NewFlagSet("schedules runs", flag.ContinueOnError)
+	limit := flags.Int("limit", 50, "maximum number of runs to list")
+	out := outputFlag(flags)
+	id, err := parseScheduleID(flags, args, usage)
+	if err != nil {
+		return err
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	if _, err := findSchedule(ctx, conn.Queries(), id); err != nil {
+		return err
+	}
+	rows, err := conn.Queries().ListScheduledOrderRuns(ctx, db.ListScheduledOrderRunsParams{
+		ScheduledOrderID: id,
+		RowLimit:         int32(*limit),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list schedule runs: %w", err)
+	}
+	return printList(p, runColumns, db.ToScheduledOrderRuns(rows))
+}
+
+// runScheduler places scheduled orders as they come due until interrupted,
+// for when the TUI is not running
+func runScheduler(brokerClient broker.Client, conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("schedules run", flag.ContinueOnError)
+	once := flags.Bool("once", false, "place the runs due now and exit")
+	interval := flags.Duration("interval", scheduler.DefaultInterval, "how often to look for due runs")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 || *interval <= 0 {
+		return usageError("usage: pony schedules run [--once] [--interval 30s] [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	s := scheduler.NewScheduler(brokerClient, conn.Queries())
+	s.Interval = *interval
+	s.ResolveIntents = resolveIntents(brokerClient, conn)
+
+	if *once {
+		runs, err := s.RunDue(context.Background(), time.Now())
+		if len(runs) > 0 {
+			if printErr := printList(p, runColumns, runs); printErr != nil {
+				return printErr
+			}
+		}
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var printErr error
+	s.Run(ctx, func(runs []*schedule.Run, err error) {
+		for _, run := range runs {
+			if printErr == nil {
+				printErr = printStream(p, runColumns, run)
+			}
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		if printErr != nil {
+			stop()
+		}
+	})
+	return printErr
+}
+
+// parseScheduleID parses a command line of one schedule ID and flags
+func parseScheduleID(flags *flag.FlagSet, args []string, usage string) (int64, error) {
+	arg, err := parseWithID(flags, args, usage)
+	if err != nil {
+		return 0, err
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		return 0, usageError(fmt.Sprintf("invalid schedule ID %q", arg))
+	}
+	return id, nil
+}
+
+func findSchedule(ctx context.Context, queries db.Querier, id int64) (*schedule.ScheduledOrder, error) {
+	row, err := queries.GetScheduledOrder(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("schedule %d %w", id, errNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule: %w", err)
+	}
+	return db.ToScheduledOrder(row), nil
+}
+
+func shownTimePtr(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return shownTime(*t)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/revrost/pony/pkg/api"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/config"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/metrics"
+	"github.com/revrost/pony/pkg/store"
+)
+
+// runServe serves the local REST API until interrupted
+func runServe(cfg *config.Config, brokerClient broker.Client, conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := flags.String("addr", cfg.APIAddr, "address to listen on")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony serve [--addr HOST:PORT]")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	token := cfg.APIToken
+	if token == "" {
+		token = rand.Text()
+		log.Printf("PONY_API_TOKEN is not set; clients must send: Authorization: Bearer %s", token)
+	}
+
+	// With an event worker running the events are already being recorded,
+	// so the server only passes them on
+	var eventLog api.EventLog
+	if !cfg.UseEventWorker {
+		store := events.NewStore(conn)
+		store.LotMethod = cfg.TaxLotMethod
+		eventLog = store
+	}
+
+	server := api.NewServer(brokerClient, conn.Queries(), eventLog, token)
+	go server.Stream(ctx, func(err error) {
+		log.Printf("event stream: %v", err)
+	})
+
+	// Metrics are scraped without the API token
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", metrics.Handler())
+	mux.Handle("/", server)
+
+	httpServer := &http.Server{
+		Addr:    *addr,
+		Handler: mux,
+		// Requests end with the server, so open event streams let go
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- httpServer.ListenAndServe()
+	}()
+	log.Printf("Serving the pony API on http://%s", *addr)
+
+	select {
+	case err := <-serveErr:
+		return fmt.Errorf("failed to serve API: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	return httpServer.Shutdown(shutdownCtx)
+}
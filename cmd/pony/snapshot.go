@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/snapshot"
+	"github.com/revrost/pony/pkg/store"
+)
+
+const snapshotUsage = "usage: pony snapshot [take|backfill|list]"
+
+func runSnapshot(brokerClient broker.Client, conn *store.DB, args []string) error {
+	recorder := snapshot.NewRecorder(brokerClient, conn.Queries())
+
+	cmd := "take"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+
+	switch cmd {
+	case "take":
+		if len(args) != 0 {
+			return usageError(snapshotUsage)
+		}
+		snapshots, err := recorder.Take(context.Background())
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			fmt.Println("The market is closed today; no snapshots taken")
+			return nil
+		}
+		fmt.Printf("Saved %d snapshots for %s\n", len(snapshots), snapshots[0].Date.Format("2006-01-02"))
+		return nil
+	case "backfill":
+		return backfillSnapshots(recorder, args)
+	case "list":
+		return listSnapshots(conn, args)
+	default:
+		return usageError(snapshotUsage)
+	}
+}
+
+func backfillSnapshots(recorder *snapshot.Recorder, args []string) error {
+	flags := flag.NewFlagSet("snapshot backfill", flag.ContinueOnError)
+	period := flags.String("period", snapshot.DefaultBackfillPeriod, "how much portfolio history to read, such as 1M, 3M or 1A")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	stored, err := recorder.Backfill(context.Background(), *period)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Backfilled %d snapshots\n", stored)
+	return nil
+}
+
+func listSnapshots(conn *store.DB, args []string) error {
+	flags := flag.NewFlagSet("snapshot list", flag.ContinueOnError)
+	accountID := flags.String("account", "", "account ID (default: the first account)")
+	days := flags.Int("days", 30, "how many calendar days back to list")
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+	queries := conn.Queries()
+
+	id, err := defaultAccountID(ctx, queries, *accountID)
+	if err != nil {
+		return err
+	}
+
+	until := snapshot.TradingDate(time.Now())
+	rows, err := queries.ListAccountSnapshots(ctx, db.ListAccountSnapshotsParams{
+		AccountID: id,
+		DateFrom:  until.AddDate(0, 0, -*days),
+		DateUntil: until,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list account snapshots: %w", err)
+	}
+	if len(rows) == 0 {
+		fmt.Println("No snapshots found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "DATE\tEQUITY\tCASH\tBUYING POWER\tPOSITIONS\tDAY P&L\tSOURCE")
+	for _, s := range db.ToAccountSnapshots(rows) {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Date.Format("2006-01-02"),
+			format.Money(s.Equity),
+			format.MoneyOrDash(s.Cash),
+			format.MoneyOrDash(s.BuyingPower),
+			format.MoneyOrDash(s.PositionsValue),
+			format.SignedMoney(s.DayPL),
+			s.Source)
+	}
+	return w.Flush()
+}
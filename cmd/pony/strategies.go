@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/revrost/pony/pkg/audit"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/config"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/sim"
+	"github.com/revrost/pony/pkg/strategy"
+)
+
+// newStrategyEngine adds the configured strategies to an engine fed by the
+// broker's market data. They trade in the simulated broker unless
+// STRATEGY_BROKER=alpaca, in which case they trade in the first account.
+// Starting, pausing and stopping them is recorded in the audit log.
+func newStrategyEngine(ctx context.Context, cfg *config.Config, brokerClient broker.Client, queries db.Querier, auditLog *audit.Log) (*audit.Strategies, error) {
+	alpacaFeed := marketdata.NewAlpacaFeed(cfg.AlpacaAPIKey, cfg.AlpacaAPISecret, cfg.MarketDataFeed)
+	alpacaFeed.BaseURL = cfg.MarketDataURL
+
+	var client broker.Client
+	var feed marketdata.Feed
+	var accountID string
+	switch cfg.StrategyVenue {
+	case strategy.VenueAlpaca:
+		accounts, err := queries.ListAccounts(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list accounts: %w", err)
+		}
+		if len(accounts) == 0 {
+			return nil, errors.New("strategies need an account to trade in")
+		}
+		client, feed, accountID = brokerClient, alpacaFeed, accounts[0].ID
+	default:
+		simBroker := sim.NewBroker(sim.DefaultCash)
+		client, feed, accountID = simBroker, simBroker.Feed(alpacaFeed), sim.AccountID
+	}
+
+	engine := strategy.NewEngine(client, feed)
+	for _, spec := range cfg.Strategies {
+		cfg := strategy.Config{
+			Name:      spec.Name(),
+			AccountID: accountID,
+			Symbols:   []string{spec.Symbol},
+		}
+		if err := engine.Add(cfg, spec.New()); err != nil {
+			return nil, err
+		}
+	}
+	return audit.NewStrategies(engine, auditLog, accountID), nil
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/taxlot"
+)
+
+var eventColumns = []column[broker.Event]{
+	{name: "received_at", value: func(e broker.Event) string { return timeValue(e.Metadata().ReceivedAt) },
+		shown: func(e broker.Event) string { return shownTime(e.Metadata().ReceivedAt) }},
+	{name: "event_id", value: func(e broker.Event) string { return e.Metadata().ID }, detail: true},
+	{name: "type", value: func(e broker.Event) string { return string(e.Type()) }},
+	{name: "account_id", value: func(e broker.Event) string { return e.Metadata().AccountID }},
+	{name: "event", value: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return string(tu.Event)
+		}
+		return ""
+	}},
+	{name: "order_id", value: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return tu.Order.AlpacaOrderID
+		}
+		return ""
+	}, detail: true},
+	{name: "symbol", value: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return tu.Order.Symbol
+		}
+		return ""
+	}},
+	{name: "side", value: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return string(tu.Order.Side)
+		}
+		return ""
+	}},
+	{name: "qty", value: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return decimalPtrValue(tu.Qty)
+		}
+		return ""
+	}, shown: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return format.QtyOrDash(tu.Qty)
+		}
+		return "-"
+	}},
+	{name: "price", value: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return decimalPtrValue(tu.Price)
+		}
+		return ""
+	}, shown: func(e broker.Event) string {
+		if tu, ok := e.(broker.TradeUpdateEvent); ok {
+			return format.PriceOrDash(tu.Price)
+		}
+		return "-"
+	}},
+	{name: "status", value: func(e broker.Event) string {
+		switch e := e.(type) {
+		case broker.TradeUpdateEvent:
+			return string(e.Order.Status)
+		case broker.AccountUpdateEvent:
+			return e.Account.Status
+		}
+		return ""
+	}},
+}
+
+// runStream prints broker events as they arrive until interrupted. Each
+// event is recorded in the event log first, as the TUI does, so the
+// database stays current while it runs.
+func runStream(brokerClient broker.Client, conn *store.DB, lotMethod taxlot.Method, args []string) error {
+	flags := flag.NewFlagSet("stream", flag.ContinueOnError)
+	accountID := flags.String("account", "", "account ID to stream (default: the account the API key belongs to)")
+	out := outputFlag(flags)
+	if err := parseFlags(flags, args); err != nil {
+		return err
+	}
+	if flags.NArg() != 0 {
+		return usageError("usage: pony stream [--account ID] [--output table|json|csv]")
+	}
+	p, err := newPrinter(*out)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	eventLog := events.NewStore(conn)
+	eventLog.LotMethod = lotMethod
+
+	eventCh, errCh := brokerClient.StreamEvents(ctx, *accountID)
+	for {
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return errors.New("event stream closed")
+			}
+			if err := eventLog.Record(ctx, event); err != nil {
+				return err
+			}
+			if err := printStream(p, eventColumns, event); err != nil {
+				return err
+			}
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			if ctx.Err() != nil {
+				// Interrupted, which is how a stream normally ends
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
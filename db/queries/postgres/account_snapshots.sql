@@ -0,0 +1,35 @@
+-- name: UpsertAccountSnapshot :one
+-- Live snapshots replace whatever was stored for the day.
+INSERT INTO account_snapshots (
+    account_id, trading_date, equity, cash, buying_power, positions_value,
+    day_pl, source
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (account_id, trading_date) DO UPDATE SET
+    equity = EXCLUDED.equity,
+    cash = EXCLUDED.cash,
+    buying_power = EXCLUDED.buying_power,
+    positions_value = EXCLUDED.positions_value,
+    day_pl = EXCLUDED.day_pl,
+    source = EXCLUDED.source,
+    updated_at = NOW()
+RETURNING *;
+
+-- name: CreateMissingAccountSnapshot :execrows
+-- Backfilled snapshots never replace a stored day.
+INSERT INTO account_snapshots (
+    account_id, trading_date, equity, cash, buying_power, positions_value,
+    day_pl, source
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (account_id, trading_date) DO NOTHING;
+
+-- name: ListAccountSnapshots :many
+-- Snapshots for trading days in [date_from, date_until], oldest first.
+SELECT * FROM account_snapshots
+WHERE account_id = sqlc.arg(account_id)
+  AND trading_date >= sqlc.arg(date_from)::date
+  AND trading_date <= sqlc.arg(date_until)::date
+ORDER BY trading_date;
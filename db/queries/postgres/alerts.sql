@@ -0,0 +1,30 @@
+-- name: CreateAlert :one
+INSERT INTO alerts (account_id, rule, notify, enabled)
+VALUES ($1, $2, $3, $4)
+RETURNING *;
+
+-- name: GetAlert :one
+SELECT * FROM alerts WHERE id = $1;
+
+-- name: ListAlerts :many
+SELECT * FROM alerts ORDER BY id;
+
+-- name: ListEnabledAlerts :many
+SELECT * FROM alerts WHERE enabled ORDER BY id;
+
+-- name: SetAlertEnabled :one
+UPDATE alerts SET
+    enabled = $2,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: RecordAlertTrigger :exec
+UPDATE alerts SET
+    trigger_count = trigger_count + 1,
+    last_triggered_at = $2,
+    last_message = $3
+WHERE id = $1;
+
+-- name: DeleteAlert :execrows
+DELETE FROM alerts WHERE id = $1;
@@ -0,0 +1,15 @@
+-- name: CreateAuditEntry :one
+INSERT INTO audit_log (
+    operator, host, account_id, action, request, response, error
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING *;
+
+-- name: ListAuditEntries :many
+-- Empty account_id or action match every entry.
+SELECT * FROM audit_log
+WHERE (sqlc.arg(account_id)::text = '' OR account_id = sqlc.arg(account_id))
+  AND (sqlc.arg(action)::text = '' OR action = sqlc.arg(action))
+  AND created_at >= sqlc.arg(since)
+ORDER BY id DESC
+LIMIT sqlc.arg(row_limit);
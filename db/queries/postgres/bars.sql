@@ -0,0 +1,23 @@
+-- name: UpsertBar :exec
+-- Imported and fetched bars replace whatever was stored for the period.
+INSERT INTO bars (
+    symbol, timeframe, started_at, open_price, high_price, low_price,
+    close_price, volume
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (symbol, timeframe, started_at) DO UPDATE SET
+    open_price = EXCLUDED.open_price,
+    high_price = EXCLUDED.high_price,
+    low_price = EXCLUDED.low_price,
+    close_price = EXCLUDED.close_price,
+    volume = EXCLUDED.volume;
+
+-- name: ListBars :many
+-- Bars that started in [started_from, started_until), oldest first.
+SELECT * FROM bars
+WHERE symbol = sqlc.arg(symbol)
+  AND timeframe = sqlc.arg(timeframe)
+  AND started_at >= sqlc.arg(started_from)
+  AND started_at < sqlc.arg(started_until)
+ORDER BY started_at;
@@ -0,0 +1,29 @@
+-- name: AppendEvent :one
+INSERT INTO events (
+    event_id, event_type, account_id, payload, received_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING *;
+
+-- name: GetEventForUpdate :one
+SELECT * FROM events WHERE id = $1 FOR UPDATE;
+
+-- name: MarkEventApplied :exec
+UPDATE events SET applied_at = NOW() WHERE id = $1;
+
+-- name: ResetEventsApplied :exec
+UPDATE events SET applied_at = NULL WHERE applied_at IS NOT NULL;
+
+-- name: ListUnappliedEvents :many
+SELECT * FROM events
+WHERE applied_at IS NULL
+ORDER BY id
+LIMIT $1;
+
+-- name: ListEventsAfter :many
+SELECT * FROM events
+WHERE id > $1
+ORDER BY id
+LIMIT $2;
@@ -0,0 +1,89 @@
+-- name: CreateOrder :one
+INSERT INTO orders (
+    id, alpaca_order_id, account_id, symbol, side, order_type, qty,
+    limit_price, stop_price, time_in_force, status, submitted_at, created_at,
+    notional
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+) RETURNING *;
+
+-- name: GetOrder :one
+SELECT * FROM orders WHERE id = $1;
+
+-- name: GetOrderByAlpacaID :one
+SELECT * FROM orders WHERE alpaca_order_id = $1;
+
+-- name: ListOrders :many
+SELECT * FROM orders
+WHERE account_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3;
+
+-- name: ListOrdersByStatus :many
+SELECT * FROM orders
+WHERE account_id = $1 AND status = $2
+ORDER BY created_at DESC;
+
+-- name: UpdateOrder :one
+-- Keeps a notional order's qty at the quantity filled so far.
+UPDATE orders SET
+    status = $2,
+    qty = CASE WHEN notional IS NULL THEN qty ELSE $3 END,
+    filled_qty = $3,
+    filled_avg_price = $4,
+    filled_at = $5,
+    canceled_at = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteAllOrders :exec
+DELETE FROM orders;
+
+-- name: SearchOrders :many
+-- Empty text filters and an empty statuses array match every order; NULL
+-- times leave that end of the range open. Pages are keyed on
+-- (created_at, id): pass the last row of the previous page as
+-- before_created_at and before_id, or NULL for the first page.
+SELECT * FROM orders
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.arg(symbol)::text = '' OR symbol = sqlc.arg(symbol))
+  AND (sqlc.arg(side)::text = '' OR side = sqlc.arg(side))
+  AND (sqlc.arg(order_type)::text = '' OR order_type = sqlc.arg(order_type))
+  AND (cardinality(sqlc.arg(statuses)::text[]) = 0 OR status = ANY(sqlc.arg(statuses)::text[]))
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_until)::timestamp IS NULL OR created_at < sqlc.narg(created_until))
+  AND (sqlc.narg(filled_from)::timestamp IS NULL OR filled_at >= sqlc.narg(filled_from))
+  AND (sqlc.narg(filled_until)::timestamp IS NULL OR filled_at < sqlc.narg(filled_until))
+  AND (sqlc.narg(before_created_at)::timestamp IS NULL
+       OR (created_at, id) < (sqlc.narg(before_created_at), sqlc.arg(before_id)::text))
+ORDER BY created_at DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: OrderStatsByDay :many
+-- Days are New York trading dates, as for account snapshots. Notional is
+-- filled quantity times average fill price.
+SELECT
+    to_char(created_at AT TIME ZONE 'UTC' AT TIME ZONE 'America/New_York', 'YYYY-MM-DD')::text AS day,
+    COUNT(*) AS order_count,
+    COUNT(filled_at) AS filled_count,
+    COALESCE(SUM(filled_qty * filled_avg_price), 0)::numeric AS notional
+FROM orders
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_until)::timestamp IS NULL OR created_at < sqlc.narg(created_until))
+GROUP BY 1
+ORDER BY 1 DESC;
+
+-- name: OrderStatsBySymbol :many
+SELECT
+    symbol,
+    COUNT(*) AS order_count,
+    COUNT(filled_at) AS filled_count,
+    COALESCE(SUM(filled_qty * filled_avg_price), 0)::numeric AS notional
+FROM orders
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(created_from)::timestamp IS NULL OR created_at >= sqlc.narg(created_from))
+  AND (sqlc.narg(created_until)::timestamp IS NULL OR created_at < sqlc.narg(created_until))
+GROUP BY symbol
+ORDER BY notional DESC, symbol;
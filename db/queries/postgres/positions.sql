@@ -31,3 +31,6 @@ RETURNING *;
 -- name: DeletePosition :exec
 DELETE FROM positions
 WHERE account_id = $1 AND symbol = $2;
+
+-- name: DeleteAllPositions :exec
+DELETE FROM positions;
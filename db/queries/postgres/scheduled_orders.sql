@@ -0,0 +1,100 @@
+-- name: CreateScheduledOrder :one
+INSERT INTO scheduled_orders (
+    account_id, schedule, time_zone, symbol, side, order_type, qty, notional,
+    limit_price, stop_price, time_in_force, enabled, next_run_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING *;
+
+-- name: GetScheduledOrder :one
+SELECT * FROM scheduled_orders WHERE id = $1;
+
+-- name: ListScheduledOrders :many
+SELECT * FROM scheduled_orders ORDER BY id;
+
+-- name: ListDueScheduledOrders :many
+-- Enabled schedules whose next run is at or before now, earliest first.
+SELECT * FROM scheduled_orders
+WHERE enabled AND next_run_at <= sqlc.arg(now)::timestamp
+ORDER BY next_run_at, id;
+
+-- name: ClaimScheduledOrderRun :execrows
+-- Moves a schedule on to its next run, unless another process already did,
+-- so each run is placed once.
+UPDATE scheduled_orders SET
+    next_run_at = sqlc.arg(next_run_at),
+    last_run_at = sqlc.arg(scheduled_for)::timestamp,
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND enabled
+  AND next_run_at = sqlc.arg(scheduled_for)::timestamp;
+
+-- name: SetScheduledOrderResult :exec
+UPDATE scheduled_orders SET
+    last_result = $2,
+    updated_at = NOW()
+WHERE id = $1;
+
+-- name: SetScheduledOrderEnabled :one
+UPDATE scheduled_orders SET
+    enabled = $2,
+    next_run_at = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING *;
+
+-- name: DeleteScheduledOrder :execrows
+DELETE FROM scheduled_orders WHERE id = $1;
+
+-- name: CreateScheduledOrderRun :one
+INSERT INTO scheduled_order_runs (
+    scheduled_order_id, scheduled_for, result, client_order_id, order_id, message
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING *;
+
+-- name: ListScheduledOrderRuns :many
+-- A schedule's runs, newest first, with the status of the order each placed.
+SELECT
+    scheduled_order_runs.id, scheduled_order_runs.scheduled_order_id,
+    scheduled_order_runs.scheduled_for, scheduled_order_runs.result,
+    scheduled_order_runs.client_order_id, scheduled_order_runs.order_id,
+    scheduled_order_runs.message, scheduled_order_runs.created_at,
+    orders.status AS order_status
+FROM scheduled_order_runs
+LEFT JOIN orders ON orders.id = scheduled_order_runs.order_id
+WHERE scheduled_order_runs.scheduled_order_id = sqlc.arg(scheduled_order_id)
+ORDER BY scheduled_order_runs.scheduled_for DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListPendingScheduledOrderRuns :many
+-- Runs whose order was sent without an answer, with what the outbox has
+-- since made of it: the intent's status, and the order if it was sent.
+SELECT
+    scheduled_order_runs.id, scheduled_order_runs.scheduled_order_id,
+    scheduled_order_runs.scheduled_for, scheduled_order_runs.client_order_id,
+    order_intents.status AS intent_status,
+    order_intents.error AS intent_error,
+    orders.id AS order_id,
+    orders.status AS order_status
+FROM scheduled_order_runs
+LEFT JOIN order_intents ON order_intents.client_order_id = scheduled_order_runs.client_order_id
+LEFT JOIN orders ON orders.alpaca_order_id = order_intents.alpaca_order_id
+WHERE scheduled_order_runs.result = 'pending'
+ORDER BY scheduled_order_runs.id;
+
+-- name: SettleScheduledOrderRun :execrows
+-- Records what became of a pending run, unless another process already did.
+UPDATE scheduled_order_runs SET
+    result = sqlc.arg(result),
+    order_id = sqlc.narg(order_id),
+    message = sqlc.narg(message)
+WHERE id = sqlc.arg(id) AND result = 'pending';
+
+-- name: SettleScheduledOrderResult :exec
+-- Gives the schedule a settled run's result, if that run was its last.
+UPDATE scheduled_orders SET
+    last_result = sqlc.arg(last_result),
+    updated_at = NOW()
+WHERE id = sqlc.arg(id)
+  AND last_run_at = sqlc.arg(scheduled_for)::timestamp;
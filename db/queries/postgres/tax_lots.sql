@@ -0,0 +1,56 @@
+-- name: CreateTaxLot :one
+INSERT INTO tax_lots (
+    account_id, symbol, execution_id, direction, qty, remaining_qty, price,
+    opened_at, closed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING *;
+
+-- name: GetTaxLotByExecution :one
+SELECT * FROM tax_lots WHERE execution_id = $1;
+
+-- name: ListOpenTaxLots :many
+-- An empty account_id lists the open lots of every account.
+SELECT * FROM tax_lots
+WHERE (sqlc.arg(account_id)::text = '' OR account_id = sqlc.arg(account_id))
+  AND closed_at IS NULL
+ORDER BY account_id, symbol, opened_at, id;
+
+-- name: DeleteTaxLots :exec
+DELETE FROM tax_lots WHERE account_id = $1 AND symbol = $2;
+
+-- name: DeleteAllTaxLots :exec
+DELETE FROM tax_lots;
+
+-- name: CreateLotDisposal :one
+INSERT INTO lot_disposals (
+    lot_id, account_id, symbol, execution_id, qty, open_price, close_price,
+    realized_pl, opened_at, closed_at, term
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING *;
+
+-- name: ListLotDisposals :many
+-- Disposals closed in [closed_from, closed_until). An empty account_id
+-- matches every account.
+SELECT lot_disposals.*, tax_lots.execution_id AS lot_execution_id, tax_lots.direction
+FROM lot_disposals
+JOIN tax_lots ON tax_lots.id = lot_disposals.lot_id
+WHERE (sqlc.arg(account_id)::text = '' OR lot_disposals.account_id = sqlc.arg(account_id))
+  AND lot_disposals.closed_at >= sqlc.arg(closed_from)
+  AND lot_disposals.closed_at < sqlc.arg(closed_until)
+ORDER BY lot_disposals.closed_at, lot_disposals.id;
+
+-- name: UpsertLotSelection :one
+INSERT INTO lot_selections (
+    order_id, lot_execution_id, account_id, qty
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (order_id, lot_execution_id) DO UPDATE SET qty = EXCLUDED.qty
+RETURNING *;
+
+-- name: ListLotSelections :many
+SELECT * FROM lot_selections
+WHERE account_id = $1
+ORDER BY created_at, order_id, lot_execution_id;
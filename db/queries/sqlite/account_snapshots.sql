@@ -0,0 +1,35 @@
+-- name: UpsertAccountSnapshot :one
+-- Live snapshots replace whatever was stored for the day.
+INSERT INTO account_snapshots (
+    account_id, trading_date, equity, cash, buying_power, positions_value,
+    day_pl, source
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8
+)
+ON CONFLICT (account_id, trading_date) DO UPDATE SET
+    equity = excluded.equity,
+    cash = excluded.cash,
+    buying_power = excluded.buying_power,
+    positions_value = excluded.positions_value,
+    day_pl = excluded.day_pl,
+    source = excluded.source,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: CreateMissingAccountSnapshot :execrows
+-- Backfilled snapshots never replace a stored day.
+INSERT INTO account_snapshots (
+    account_id, trading_date, equity, cash, buying_power, positions_value,
+    day_pl, source
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8
+)
+ON CONFLICT (account_id, trading_date) DO NOTHING;
+
+-- name: ListAccountSnapshots :many
+-- Snapshots for trading days in [date_from, date_until], oldest first.
+SELECT * FROM account_snapshots
+WHERE account_id = sqlc.arg(account_id)
+  AND julianday(trading_date) >= julianday(sqlc.arg(date_from))
+  AND julianday(trading_date) <= julianday(sqlc.arg(date_until))
+ORDER BY trading_date;
@@ -0,0 +1,23 @@
+-- name: UpsertBar :exec
+-- Imported and fetched bars replace whatever was stored for the period.
+INSERT INTO bars (
+    symbol, timeframe, started_at, open_price, high_price, low_price,
+    close_price, volume
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8
+)
+ON CONFLICT (symbol, timeframe, started_at) DO UPDATE SET
+    open_price = excluded.open_price,
+    high_price = excluded.high_price,
+    low_price = excluded.low_price,
+    close_price = excluded.close_price,
+    volume = excluded.volume;
+
+-- name: ListBars :many
+-- Bars that started in [started_from, started_until), oldest first.
+SELECT * FROM bars
+WHERE symbol = sqlc.arg(symbol)
+  AND timeframe = sqlc.arg(timeframe)
+  AND julianday(started_at) >= julianday(sqlc.arg(started_from))
+  AND julianday(started_at) < julianday(sqlc.arg(started_until))
+ORDER BY julianday(started_at);
@@ -0,0 +1,29 @@
+-- name: CreateExecution :one
+INSERT INTO executions (
+    id, order_id, account_id, event_id, symbol, side, qty, price,
+    position_qty, executed_at
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10
+)
+ON CONFLICT (id) DO NOTHING
+RETURNING *;
+
+-- name: ListExecutionsByOrder :many
+SELECT * FROM executions
+WHERE order_id = ?1
+ORDER BY executed_at, id;
+
+-- name: ListExecutions :many
+SELECT * FROM executions
+WHERE account_id = ?1
+ORDER BY executed_at DESC, id
+LIMIT ?2;
+
+-- name: ListExecutionsBySymbol :many
+SELECT * FROM executions
+WHERE account_id = ?1 AND symbol = ?2
+ORDER BY executed_at, id;
+
+-- name: ListExecutedSymbols :many
+SELECT DISTINCT account_id, symbol FROM executions
+ORDER BY account_id, symbol;
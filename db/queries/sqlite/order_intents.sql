@@ -0,0 +1,38 @@
+-- name: CreateOrderIntent :one
+-- created_at comes from the caller in UTC, as Resolve measures its age with
+-- the Go clock.
+INSERT INTO order_intents (
+    client_order_id, account_id, symbol, side, order_type, qty, limit_price,
+    stop_price, time_in_force, notional, created_at
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11
+) RETURNING *;
+
+-- name: MarkOrderIntentSent :one
+UPDATE order_intents SET
+    status = 'sent',
+    alpaca_order_id = ?2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE client_order_id = ?1
+RETURNING *;
+
+-- name: MarkOrderIntentFailed :one
+UPDATE order_intents SET
+    status = 'failed',
+    error = ?2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE client_order_id = ?1
+RETURNING *;
+
+-- name: ListPendingOrderIntents :many
+SELECT * FROM order_intents
+WHERE status = 'pending'
+ORDER BY created_at;
+
+-- name: ListOrderIntents :many
+-- Newest first. Empty account_id or status match every intent.
+SELECT * FROM order_intents
+WHERE (CAST(sqlc.arg(account_id) AS TEXT) = '' OR account_id = sqlc.arg(account_id))
+  AND (CAST(sqlc.arg(status) AS TEXT) = '' OR status = sqlc.arg(status))
+ORDER BY created_at DESC
+LIMIT sqlc.arg(row_limit);
@@ -0,0 +1,77 @@
+-- name: CreateOrder :one
+INSERT INTO orders (
+    id, alpaca_order_id, account_id, symbol, side, order_type, qty,
+    limit_price, stop_price, time_in_force, status, submitted_at, created_at,
+    notional
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14
+) RETURNING *;
+
+-- name: GetOrder :one
+SELECT * FROM orders WHERE id = ?1;
+
+-- name: GetOrderByAlpacaID :one
+SELECT * FROM orders WHERE alpaca_order_id = ?1;
+
+-- name: ListOrders :many
+SELECT * FROM orders
+WHERE account_id = ?1
+ORDER BY created_at DESC
+LIMIT ?2 OFFSET ?3;
+
+-- name: ListOrdersByStatus :many
+SELECT * FROM orders
+WHERE account_id = ?1 AND status = ?2
+ORDER BY created_at DESC;
+
+-- name: UpdateOrder :one
+-- Keeps a notional order's qty at the quantity filled so far.
+UPDATE orders SET
+    status = ?2,
+    qty = CASE WHEN notional IS NULL THEN qty ELSE ?3 END,
+    filled_qty = ?3,
+    filled_avg_price = ?4,
+    filled_at = ?5,
+    canceled_at = ?6,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?1
+RETURNING *;
+
+-- name: DeleteAllOrders :exec
+DELETE FROM orders;
+
+-- name: SearchOrders :many
+-- Same filters as the Postgres query, with statuses passed as a JSON array.
+-- Timestamps are compared through julianday() because rows written with
+-- CURRENT_TIMESTAMP and times bound from Go are formatted differently.
+SELECT * FROM orders
+WHERE account_id = sqlc.arg(account_id)
+  AND (CAST(sqlc.arg(symbol) AS TEXT) = '' OR symbol = sqlc.arg(symbol))
+  AND (CAST(sqlc.arg(side) AS TEXT) = '' OR side = sqlc.arg(side))
+  AND (CAST(sqlc.arg(order_type) AS TEXT) = '' OR order_type = sqlc.arg(order_type))
+  AND (json_array_length(CAST(sqlc.arg(statuses) AS TEXT)) = 0
+       OR status IN (SELECT value FROM json_each(CAST(sqlc.arg(statuses) AS TEXT))))
+  AND (sqlc.narg(created_from) IS NULL OR julianday(created_at) >= julianday(sqlc.narg(created_from)))
+  AND (sqlc.narg(created_until) IS NULL OR julianday(created_at) < julianday(sqlc.narg(created_until)))
+  AND (sqlc.narg(filled_from) IS NULL OR julianday(filled_at) >= julianday(sqlc.narg(filled_from)))
+  AND (sqlc.narg(filled_until) IS NULL OR julianday(filled_at) < julianday(sqlc.narg(filled_until)))
+  AND (sqlc.narg(before_created_at) IS NULL
+       OR julianday(created_at) < julianday(sqlc.narg(before_created_at))
+       OR (julianday(created_at) = julianday(sqlc.narg(before_created_at)) AND id < CAST(sqlc.arg(before_id) AS TEXT)))
+ORDER BY julianday(created_at) DESC, id DESC
+LIMIT sqlc.arg(row_limit);
+
+-- name: ListOrderStatsRows :many
+-- The rows behind OrderStatsByDay and OrderStatsBySymbol, which sum them in
+-- Go: SQLite has no exact decimal arithmetic, nor time zones to find the
+-- trading date in.
+SELECT
+    created_at,
+    symbol,
+    filled_at,
+    filled_qty,
+    filled_avg_price
+FROM orders
+WHERE account_id = sqlc.arg(account_id)
+  AND (sqlc.narg(created_from) IS NULL OR julianday(created_at) >= julianday(sqlc.narg(created_from)))
+  AND (sqlc.narg(created_until) IS NULL OR julianday(created_at) < julianday(sqlc.narg(created_until)));
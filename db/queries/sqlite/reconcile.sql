@@ -0,0 +1,12 @@
+-- name: CreateReconcileDrift :one
+INSERT INTO reconcile_drifts (
+    account_id, entity, entity_id, field, local_value, broker_value
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6
+) RETURNING *;
+
+-- name: ListReconcileDrifts :many
+SELECT * FROM reconcile_drifts
+WHERE account_id = ?1
+ORDER BY corrected_at DESC
+LIMIT ?2;
@@ -0,0 +1,36 @@
+-- name: UpsertWatchlist :one
+INSERT INTO watchlists (
+    id, account_id, name
+) VALUES (
+    ?1, ?2, ?3
+)
+ON CONFLICT (id) DO UPDATE SET
+    name = EXCLUDED.name,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: GetWatchlist :one
+SELECT * FROM watchlists WHERE id = ?1;
+
+-- name: ListWatchlists :many
+SELECT * FROM watchlists
+WHERE account_id = ?1
+ORDER BY created_at;
+
+-- name: DeleteWatchlist :exec
+DELETE FROM watchlists WHERE id = ?1;
+
+-- name: ListWatchlistItems :many
+SELECT * FROM watchlist_items
+WHERE watchlist_id = ?1
+ORDER BY position;
+
+-- name: AddWatchlistItem :one
+INSERT INTO watchlist_items (
+    watchlist_id, symbol, position
+) VALUES (
+    ?1, ?2, ?3
+) RETURNING *;
+
+-- name: DeleteWatchlistItems :exec
+DELETE FROM watchlist_items WHERE watchlist_id = ?1;
@@ -15,4 +15,40 @@ type Account struct {
 	PortfolioValue  decimal.Decimal
 	BuyingPower     decimal.Decimal
 	CreatedAt       time.Time
+
+	// Only filled in from the broker; the accounts table does not keep them
+	Equity         decimal.Decimal
+	LastEquity     decimal.Decimal // equity at the previous trading day's close
+	PositionsValue decimal.Decimal
+}
+
+// EquityPoint is the account's equity at the close of one trading day.
+// ProfitLoss is the cumulative P&L since the start of the history, which
+// leaves out deposits and withdrawals.
+type EquityPoint struct {
+	Time       time.Time
+	Equity     decimal.Decimal
+	ProfitLoss decimal.Decimal
+}
+
+// Snapshot is an account at the end of one trading day. Date is midnight
+// UTC on the trading date. Snapshots backfilled from portfolio history only
+// know equity and P&L, so the balances are nil for them.
+type Snapshot struct {
+	AccountID      string
+	Date           time.Time
+	Equity         decimal.Decimal
+	Cash           *decimal.Decimal
+	BuyingPower    *decimal.Decimal
+	PositionsValue *decimal.Decimal
+	DayPL          decimal.Decimal
+	Source         SnapshotSource
 }
+
+// SnapshotSource is where a snapshot's figures came from
+type SnapshotSource string
+
+const (
+	SnapshotLive    SnapshotSource = "live"
+	SnapshotHistory SnapshotSource = "history"
+)
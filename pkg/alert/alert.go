@@ -0,0 +1,110 @@
+// Package alert describes rules such as "AAPL last > 200" or "order
+// rejected", and the alerts they fire.
+package alert
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Channel is where an alert is delivered
+type Channel string
+
+const (
+	// ChannelToast shows the alert in the TUI
+	ChannelToast Channel = "toast"
+	// ChannelBell rings the terminal bell
+	ChannelBell Channel = "bell"
+	// ChannelWebhook posts the alert to ALERT_WEBHOOK_URL
+	ChannelWebhook Channel = "webhook"
+	// ChannelCommand runs ALERT_COMMAND
+	ChannelCommand Channel = "command"
+)
+
+// DefaultChannels is where alerts go unless their rule says otherwise
+var DefaultChannels = []Channel{ChannelToast}
+
+// ParseChannels reads a comma-separated list of channels, such as
+// "toast,bell"
+func ParseChannels(s string) ([]Channel, error) {
+	var channels []Channel
+	for _, name := range strings.Split(s, ",") {
+		ch := Channel(strings.ToLower(strings.TrimSpace(name)))
+		switch ch {
+		case ChannelToast, ChannelBell, ChannelWebhook, ChannelCommand:
+			channels = append(channels, ch)
+		case "":
+		default:
+			return nil, fmt.Errorf("unknown channel %q: use toast, bell, webhook or command", name)
+		}
+	}
+	if len(channels) == 0 {
+		return nil, fmt.Errorf("no channels given")
+	}
+	return channels, nil
+}
+
+// JoinChannels is the inverse of ParseChannels
+func JoinChannels(channels []Channel) string {
+	names := make([]string, len(channels))
+	for i, ch := range channels {
+		names[i] = string(ch)
+	}
+	return strings.Join(names, ",")
+}
+
+// Rule fires an alert when its condition is met
+type Rule struct {
+	ID int64
+	// AccountID limits position, balance, order and account status rules to
+	// one account; empty means every account. Price rules ignore it.
+	AccountID string
+	// Expr is the rule as written, such as "AAPL last > 200"
+	Expr    string
+	Notify  []Channel
+	Enabled bool
+
+	TriggerCount    int64
+	LastTriggeredAt *time.Time
+	LastMessage     string
+	CreatedAt       time.Time
+	UpdatedAt       time.Time
+}
+
+// New returns an enabled rule for expr, delivered to notify (default
+// DefaultChannels)
+func New(accountID, expr string, notify []Channel) (*Rule, error) {
+	expr = strings.Join(strings.Fields(expr), " ")
+	if _, err := Parse(expr); err != nil {
+		return nil, err
+	}
+	if len(notify) == 0 {
+		notify = DefaultChannels
+	}
+	return &Rule{AccountID: accountID, Expr: expr, Notify: notify, Enabled: true}, nil
+}
+
+// Notifies reports whether the rule's alerts go to ch
+func (r *Rule) Notifies(ch Channel) bool {
+	return slices.Contains(r.Notify, ch)
+}
+
+// Alert is one time a rule fired
+type Alert struct {
+	RuleID int64
+	Rule   string
+	// AccountID is the account the alert is about, if any
+	AccountID string
+	Symbol    string
+	// Message says what met the rule, such as "AAPL last is 201.37"
+	Message     string
+	Notify      []Channel
+	TriggeredAt time.Time
+}
+
+// Notifies reports whether the alert goes to ch
+func (a *Alert) Notifies(ch Channel) bool {
+	return slices.Contains(a.Notify, ch)
+}
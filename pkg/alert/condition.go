@@ -0,0 +1,257 @@
+package alert
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/broker"
+)
+
+// Kind is what a condition watches
+type Kind string
+
+const (
+	// KindPrice compares a symbol's price from market data
+	KindPrice Kind = "price"
+	// KindPosition compares a position's unrealized P/L
+	KindPosition Kind = "position"
+	// KindBalance compares an account balance
+	KindBalance Kind = "balance"
+	// KindOrder matches trade updates of orders
+	KindOrder Kind = "order"
+	// KindAccountStatus matches an account's status changing
+	KindAccountStatus Kind = "account_status"
+)
+
+// Field is the price or balance a condition compares
+type Field string
+
+const (
+	// FieldLast is the close of the latest minute bar
+	FieldLast Field = "last"
+	FieldBid  Field = "bid"
+	FieldAsk  Field = "ask"
+	FieldMid  Field = "mid"
+
+	FieldBuyingPower    Field = "buying power"
+	FieldCash           Field = "cash"
+	FieldPortfolioValue Field = "portfolio value"
+)
+
+// Op compares a value with a condition's threshold
+type Op string
+
+const (
+	OpAbove     Op = ">"
+	OpAtOrAbove Op = ">="
+	OpBelow     Op = "<"
+	OpAtOrBelow Op = "<="
+)
+
+// Condition is a parsed rule. Price, position and balance conditions are
+// thresholds, which fire when they start to hold; order and account status
+// conditions fire on every matching event.
+type Condition struct {
+	Kind Kind
+	// Symbol is the symbol of price conditions. Position and order
+	// conditions watch every symbol when it is empty.
+	Symbol string
+	Field  Field
+	Op     Op
+	Value  decimal.Decimal
+	// Percent means Value is a percentage of the position's cost basis
+	// rather than dollars
+	Percent bool
+	// Event is the trade update order conditions match
+	Event broker.TradeEventKind
+}
+
+var (
+	opPattern     = regexp.MustCompile(`>=|<=|>|<`)
+	symbolPattern = regexp.MustCompile(`^[a-z][a-z0-9./]*$`)
+	valuePattern  = regexp.MustCompile(`^([+-]?)(\$?)([0-9]*\.?[0-9]+)([km]?)(%?)$`)
+)
+
+// orderEvents are the words order conditions take for each trade update
+var orderEvents = map[string]broker.TradeEventKind{
+	"new":                    broker.TradeEventNew,
+	"filled":                 broker.TradeEventFill,
+	"fill":                   broker.TradeEventFill,
+	"partially_filled":       broker.TradeEventPartialFill,
+	"partial_fill":           broker.TradeEventPartialFill,
+	"canceled":               broker.TradeEventCanceled,
+	"cancelled":              broker.TradeEventCanceled,
+	"expired":                broker.TradeEventExpired,
+	"replaced":               broker.TradeEventReplaced,
+	"rejected":               broker.TradeEventRejected,
+	"done_for_day":           broker.TradeEventDoneForDay,
+	"order_cancel_rejected":  broker.TradeEventOrderCancelRejected,
+	"order_replace_rejected": broker.TradeEventOrderReplaceRejected,
+}
+
+// Parse reads a rule, case insensitively:
+//
+//	AAPL last > 200            price: last (the default), bid, ask or mid
+//	position P/L < -5%         any position; "position TSLA P/L < -$500" for one
+//	buying power < $1k         or cash, or portfolio value
+//	order rejected             any order; "order AAPL filled" for one symbol
+//	account status changed
+//
+// Thresholds compare with >, >=, < or <=, and amounts may end in k or m.
+func Parse(rule string) (Condition, error) {
+	words := strings.Fields(opPattern.ReplaceAllStringFunc(strings.ToLower(rule), func(op string) string {
+		return " " + op + " "
+	}))
+	if len(words) == 0 {
+		return Condition{}, fmt.Errorf("rule is empty")
+	}
+
+	opAt := -1
+	for i, w := range words {
+		if opPattern.FindString(w) == w {
+			opAt = i
+			break
+		}
+	}
+	if opAt < 0 {
+		return parseEvent(rule, words)
+	}
+	if opAt == 0 || opAt != len(words)-2 {
+		return Condition{}, fmt.Errorf("rule %q must compare one value, such as AAPL last > 200", rule)
+	}
+
+	subject, op, value := words[:opAt], Op(words[opAt]), words[opAt+1]
+	switch {
+	case subject[0] == "position":
+		return parsePosition(rule, subject[1:], op, value)
+	case isBalance(strings.Join(subject, " ")):
+		c := Condition{Kind: KindBalance, Field: Field(strings.Join(subject, " ")), Op: op}
+		if err := c.parseValue(rule, value, false); err != nil {
+			return Condition{}, err
+		}
+		return c, nil
+	default:
+		return parsePrice(rule, subject, op, value)
+	}
+}
+
+func parseEvent(rule string, words []string) (Condition, error) {
+	switch {
+	case strings.Join(words, " ") == "account status changed":
+		return Condition{Kind: KindAccountStatus}, nil
+
+	case words[0] == "order" && (len(words) == 2 || len(words) == 3):
+		c := Condition{Kind: KindOrder}
+		if len(words) == 3 {
+			if !symbolPattern.MatchString(words[1]) {
+				return Condition{}, fmt.Errorf("rule %q has an invalid symbol %q", rule, words[1])
+			}
+			c.Symbol = strings.ToUpper(words[1])
+		}
+		event, ok := orderEvents[words[len(words)-1]]
+		if !ok {
+			return Condition{}, fmt.Errorf("rule %q: order conditions take an event such as filled, canceled or rejected", rule)
+		}
+		c.Event = event
+		return c, nil
+
+	default:
+		return Condition{}, fmt.Errorf("rule %q is not a comparison, an order event or account status changed", rule)
+	}
+}
+
+func parsePosition(rule string, words []string, op Op, value string) (Condition, error) {
+	c := Condition{Kind: KindPosition, Op: op}
+	if len(words) == 2 {
+		if !symbolPattern.MatchString(words[0]) {
+			return Condition{}, fmt.Errorf("rule %q has an invalid symbol %q", rule, words[0])
+		}
+		c.Symbol = strings.ToUpper(words[0])
+		words = words[1:]
+	}
+	if len(words) != 1 || (words[0] != "p/l" && words[0] != "pl" && words[0] != "p&l") {
+		return Condition{}, fmt.Errorf("rule %q: position conditions compare P/L, such as position P/L < -5%%", rule)
+	}
+	if err := c.parseValue(rule, value, true); err != nil {
+		return Condition{}, err
+	}
+	return c, nil
+}
+
+func parsePrice(rule string, words []string, op Op, value string) (Condition, error) {
+	c := Condition{Kind: KindPrice, Field: FieldLast, Op: op}
+	if len(words) > 2 || !symbolPattern.MatchString(words[0]) {
+		return Condition{}, fmt.Errorf("rule %q must start with a symbol, position, buying power, cash or portfolio value", rule)
+	}
+	c.Symbol = strings.ToUpper(words[0])
+	if len(words) == 2 {
+		switch f := Field(words[1]); f {
+		case FieldLast, FieldBid, FieldAsk, FieldMid:
+			c.Field = f
+		default:
+			return Condition{}, fmt.Errorf("rule %q: prices are last, bid, ask or mid", rule)
+		}
+	}
+	if err := c.parseValue(rule, value, false); err != nil {
+		return Condition{}, err
+	}
+	return c, nil
+}
+
+func isBalance(s string) bool {
+	switch Field(s) {
+	case FieldBuyingPower, FieldCash, FieldPortfolioValue:
+		return true
+	}
+	return false
+}
+
+// parseValue reads an amount such as 200, -$500, $1k or -5%, the last only
+// where percent is allowed
+func (c *Condition) parseValue(rule, value string, percent bool) error {
+	m := valuePattern.FindStringSubmatch(value)
+	if m == nil || (m[5] == "%" && (!percent || m[2] == "$")) {
+		return fmt.Errorf("rule %q has an invalid amount %q", rule, value)
+	}
+
+	d, err := decimal.NewFromString(m[3])
+	if err != nil {
+		return fmt.Errorf("rule %q has an invalid amount %q", rule, value)
+	}
+	switch m[4] {
+	case "k":
+		d = d.Shift(3)
+	case "m":
+		d = d.Shift(6)
+	}
+	if m[1] == "-" {
+		d = d.Neg()
+	}
+	c.Value = d
+	c.Percent = m[5] == "%"
+	return nil
+}
+
+// Threshold reports whether the condition compares a value, rather than
+// matching events
+func (c Condition) Threshold() bool {
+	return c.Kind == KindPrice || c.Kind == KindPosition || c.Kind == KindBalance
+}
+
+// Holds reports whether v meets the threshold
+func (c Condition) Holds(v decimal.Decimal) bool {
+	switch c.Op {
+	case OpAbove:
+		return v.GreaterThan(c.Value)
+	case OpAtOrAbove:
+		return v.GreaterThanOrEqual(c.Value)
+	case OpBelow:
+		return v.LessThan(c.Value)
+	case OpAtOrBelow:
+		return v.LessThanOrEqual(c.Value)
+	}
+	return false
+}
@@ -0,0 +1,508 @@
+// Package alerter checks alert rules against market data, broker events and
+// the stored accounts and positions, and delivers the alerts they fire.
+package alerter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/position"
+)
+
+const (
+	// DefaultInterval is how often Run reloads the rules and checks the
+	// stored accounts and positions
+	DefaultInterval = 30 * time.Second
+
+	// DefaultRetryInterval is how long Run waits before reopening a market
+	// data stream that broke
+	DefaultRetryInterval = 5 * time.Second
+
+	// notifyTimeout bounds each delivery to a webhook or command
+	notifyTimeout = 10 * time.Second
+)
+
+// Store is the subset of the sqlc generated Querier the Monitor needs.
+// *db.Queries implements it.
+type Store interface {
+	ListEnabledAlerts(ctx context.Context) ([]db.Alert, error)
+	RecordAlertTrigger(ctx context.Context, arg db.RecordAlertTriggerParams) error
+	ListAccounts(ctx context.Context) ([]db.Account, error)
+	ListPositions(ctx context.Context, accountID string) ([]db.Position, error)
+}
+
+// Notifier delivers alerts to one channel
+type Notifier interface {
+	Notify(ctx context.Context, a *alert.Alert) error
+}
+
+// rule is an enabled rule with its parsed condition
+type rule struct {
+	*alert.Rule
+	cond alert.Condition
+}
+
+// covers reports whether the rule applies to the account
+func (r rule) covers(accountID string) bool {
+	return r.AccountID == "" || r.AccountID == accountID
+}
+
+// key is what a threshold rule was last checked against: a symbol for price
+// rules, an account for balance and account status rules, and both for
+// position rules
+type key struct {
+	rule      int64
+	accountID string
+	symbol    string
+}
+
+// Monitor fires the alerts of the stored rules. Threshold rules fire when
+// they start to hold, including when first checked, and again only after
+// they stopped holding; order and account status rules fire on every
+// matching event.
+type Monitor struct {
+	feed  marketdata.Feed
+	store Store
+
+	Interval      time.Duration
+	RetryInterval time.Duration
+
+	// Notifiers deliver alerts to their channels. Toasts are left to the
+	// handler passed to Run, and channels with no notifier are skipped.
+	Notifiers map[alert.Channel]Notifier
+	notifying sync.WaitGroup
+
+	mu       sync.Mutex
+	rules    []rule
+	bars     map[string]marketdata.Bar
+	quotes   map[string]marketdata.Quote
+	holding  map[key]bool
+	statuses map[key]string
+	handle   func([]*alert.Alert, error)
+	// failed holds the delivery errors there was no handler to report to
+	failed []error
+}
+
+func NewMonitor(feed marketdata.Feed, store Store) *Monitor {
+	return &Monitor{
+		feed:          feed,
+		store:         store,
+		Interval:      DefaultInterval,
+		RetryInterval: DefaultRetryInterval,
+		Notifiers:     map[alert.Channel]Notifier{},
+		bars:          map[string]marketdata.Bar{},
+		quotes:        map[string]marketdata.Quote{},
+		holding:       map[key]bool{},
+		statuses:      map[key]string{},
+	}
+}
+
+// Run checks the rules immediately and then every Interval until ctx is
+// done, streaming market data for the symbols of the price rules meanwhile.
+// Every alert fired, whether by Run, market data or HandleEvent, and every
+// error is passed to handle, which may be nil.
+func (m *Monitor) Run(ctx context.Context, handle func([]*alert.Alert, error)) {
+	m.mu.Lock()
+	m.handle = handle
+	m.mu.Unlock()
+
+	ticker := time.NewTicker(m.Interval)
+	defer ticker.Stop()
+
+	var symbols []string
+	stopStream := func() {}
+	defer func() { stopStream() }()
+
+	for {
+		alerts, err := m.Check(ctx)
+		if ctx.Err() == nil {
+			m.report(alerts, err)
+		}
+
+		// Resubscribe when the price rules change
+		if want := m.symbols(); !slices.Equal(want, symbols) {
+			stopStream()
+			stopStream = func() {}
+			symbols = want
+			if len(symbols) > 0 {
+				streamCtx, cancel := context.WithCancel(ctx)
+				stopStream = cancel
+				go m.stream(streamCtx, symbols)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Check reloads the enabled rules, checks the stored accounts and positions
+// against them and delivers the alerts fired, which it returns
+func (m *Monitor) Check(ctx context.Context) ([]*alert.Alert, error) {
+	// A rule that fails to load leaves the others to be checked
+	var errs []error
+	if err := m.load(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	rows, err := m.store.ListAccounts(ctx)
+	if err != nil {
+		return nil, errors.Join(append(errs, fmt.Errorf("failed to list accounts: %w", err))...)
+	}
+
+	var alerts []*alert.Alert
+	for _, row := range rows {
+		acct := db.ToAccount(row)
+		alerts = append(alerts, m.checkAccount(acct)...)
+
+		if !m.watches(alert.KindPosition, acct.ID) {
+			continue
+		}
+		positions, err := m.store.ListPositions(ctx, acct.ID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to list positions: %w", err))
+			continue
+		}
+		alerts = append(alerts, m.checkPositions(acct.ID, db.ToPositions(positions))...)
+	}
+
+	if err := m.deliver(ctx, alerts); err != nil {
+		errs = append(errs, err)
+	}
+	return alerts, errors.Join(errs...)
+}
+
+// HandleEvent checks a broker event against the order and account rules
+func (m *Monitor) HandleEvent(ctx context.Context, event broker.Event) {
+	var alerts []*alert.Alert
+	switch e := event.(type) {
+	case broker.TradeUpdateEvent:
+		alerts = m.checkOrder(e)
+	case broker.AccountUpdateEvent:
+		alerts = m.checkAccount(e.Account)
+	}
+	m.report(alerts, m.deliver(ctx, alerts))
+}
+
+// Wait waits for the alerts already fired to be delivered to the notifiers.
+// It returns the delivery errors that were not reported to Run's handler.
+func (m *Monitor) Wait() error {
+	m.notifying.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	err := errors.Join(m.failed...)
+	m.failed = nil
+	return err
+}
+
+// load replaces the rules with the enabled ones in the store, forgetting
+// what was checked for rules that are gone. Rules that no longer parse are
+// reported and left out; if the store cannot be read, the rules are kept.
+func (m *Monitor) load(ctx context.Context) error {
+	rows, err := m.store.ListEnabledAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list alerts: %w", err)
+	}
+
+	rules := make([]rule, 0, len(rows))
+	ids := map[int64]bool{}
+	var errs []error
+	for _, row := range rows {
+		r := db.ToAlertRule(row)
+		cond, err := alert.Parse(r.Expr)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("alert %d: %w", r.ID, err))
+			continue
+		}
+		rules = append(rules, rule{Rule: r, cond: cond})
+		ids[r.ID] = true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules = rules
+	for k := range m.holding {
+		if !ids[k.rule] {
+			delete(m.holding, k)
+		}
+	}
+	for k := range m.statuses {
+		if !ids[k.rule] {
+			delete(m.statuses, k)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// symbols returns the symbols of the price rules, sorted
+func (m *Monitor) symbols() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var symbols []string
+	for _, r := range m.rules {
+		if r.cond.Kind == alert.KindPrice && !slices.Contains(symbols, r.cond.Symbol) {
+			symbols = append(symbols, r.cond.Symbol)
+		}
+	}
+	slices.Sort(symbols)
+	return symbols
+}
+
+// watches reports whether any rule of the kind applies to the account
+func (m *Monitor) watches(kind alert.Kind, accountID string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.ContainsFunc(m.rules, func(r rule) bool {
+		return r.cond.Kind == kind && r.covers(accountID)
+	})
+}
+
+// stream feeds market data to the price rules until ctx is done, reopening
+// the stream whenever it breaks
+func (m *Monitor) stream(ctx context.Context, symbols []string) {
+	for {
+		err := m.feed.Stream(ctx, symbols, func(b marketdata.Bar) {
+			m.mu.Lock()
+			m.bars[b.Symbol] = b
+			alerts := m.checkPrices(b.Symbol)
+			m.mu.Unlock()
+			m.report(alerts, m.deliver(ctx, alerts))
+		}, func(q marketdata.Quote) {
+			m.mu.Lock()
+			m.quotes[q.Symbol] = q
+			alerts := m.checkPrices(q.Symbol)
+			m.mu.Unlock()
+			m.report(alerts, m.deliver(ctx, alerts))
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("alert market data stream broke", "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.RetryInterval):
+		}
+	}
+}
+
+// checkPrices checks the price rules of a symbol against its latest bar and
+// quote. m.mu must be held.
+func (m *Monitor) checkPrices(symbol string) []*alert.Alert {
+	var alerts []*alert.Alert
+	for _, r := range m.rules {
+		if r.cond.Kind != alert.KindPrice || r.cond.Symbol != symbol {
+			continue
+		}
+
+		var price decimal.Decimal
+		switch quote := m.quotes[symbol]; r.cond.Field {
+		case alert.FieldLast:
+			price = m.bars[symbol].Close
+		case alert.FieldBid:
+			price = quote.BidPrice
+		case alert.FieldAsk:
+			price = quote.AskPrice
+		case alert.FieldMid:
+			price = quote.Mid()
+		}
+		if !price.IsPositive() {
+			// Nothing received for this field yet
+			continue
+		}
+
+		if m.crossed(key{rule: r.ID, symbol: symbol}, r.cond.Holds(price)) {
+			alerts = append(alerts, newAlert(r, "", symbol,
+				fmt.Sprintf("%s %s is %s", symbol, r.cond.Field, format.Price(price))))
+		}
+	}
+	return alerts
+}
+
+// checkAccount checks an account's balances and status
+func (m *Monitor) checkAccount(acct *account.Account) []*alert.Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var alerts []*alert.Alert
+	for _, r := range m.rules {
+		if !r.covers(acct.ID) {
+			continue
+		}
+		k := key{rule: r.ID, accountID: acct.ID}
+
+		switch r.cond.Kind {
+		case alert.KindBalance:
+			var balance decimal.Decimal
+			switch r.cond.Field {
+			case alert.FieldBuyingPower:
+				balance = acct.BuyingPower
+			case alert.FieldCash:
+				balance = acct.Cash
+			case alert.FieldPortfolioValue:
+				balance = acct.PortfolioValue
+			}
+			if m.crossed(k, r.cond.Holds(balance)) {
+				alerts = append(alerts, newAlert(r, acct.ID, "",
+					fmt.Sprintf("%s is %s", r.cond.Field, format.Money(balance))))
+			}
+
+		case alert.KindAccountStatus:
+			// The first status seen is where changes are counted from
+			last, seen := m.statuses[k]
+			m.statuses[k] = acct.Status
+			if seen && last != acct.Status {
+				alerts = append(alerts, newAlert(r, acct.ID, "",
+					fmt.Sprintf("account status changed from %s to %s", last, acct.Status)))
+			}
+		}
+	}
+	return alerts
+}
+
+// checkPositions checks the P/L of an account's positions. Positions that
+// were closed are forgotten, so reopening one checks it afresh.
+func (m *Monitor) checkPositions(accountID string, positions []*position.Position) []*alert.Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	open := map[string]bool{}
+	var alerts []*alert.Alert
+	for _, p := range positions {
+		open[p.Symbol] = true
+		for _, r := range m.rules {
+			if r.cond.Kind != alert.KindPosition || !r.covers(accountID) ||
+				(r.cond.Symbol != "" && r.cond.Symbol != p.Symbol) {
+				continue
+			}
+
+			pl := p.UnrealizedPL
+			if r.cond.Percent {
+				pl = p.UnrealizedPLPC.Shift(2)
+			}
+			if m.crossed(key{rule: r.ID, accountID: accountID, symbol: p.Symbol}, r.cond.Holds(pl)) {
+				alerts = append(alerts, newAlert(r, accountID, p.Symbol,
+					fmt.Sprintf("%s P/L is %s (%s)", p.Symbol, format.Percent(p.UnrealizedPLPC), format.SignedMoney(p.UnrealizedPL))))
+			}
+		}
+	}
+
+	for k := range m.holding {
+		if k.accountID == accountID && k.symbol != "" && !open[k.symbol] {
+			delete(m.holding, k)
+		}
+	}
+	return alerts
+}
+
+// checkOrder matches a trade update against the order rules
+func (m *Monitor) checkOrder(e broker.TradeUpdateEvent) []*alert.Alert {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o := e.Order
+	var alerts []*alert.Alert
+	for _, r := range m.rules {
+		if r.cond.Kind != alert.KindOrder || r.cond.Event != e.Event || !r.covers(o.AccountID) ||
+			(r.cond.Symbol != "" && r.cond.Symbol != o.Symbol) {
+			continue
+		}
+
+		message := fmt.Sprintf("%s %s order of %s %s", o.Symbol, o.Side, format.QtyOrDash(o.Qty), e.Event)
+		if e.IsFill() && e.Qty != nil && e.Price != nil {
+			message += fmt.Sprintf(": %s at %s", format.Qty(*e.Qty), format.Price(*e.Price))
+		}
+		alerts = append(alerts, newAlert(r, o.AccountID, o.Symbol, message))
+	}
+	return alerts
+}
+
+// crossed records whether a threshold holds and reports whether it just
+// started to. m.mu must be held.
+func (m *Monitor) crossed(k key, holds bool) bool {
+	was := m.holding[k]
+	m.holding[k] = holds
+	return holds && !was
+}
+
+func newAlert(r rule, accountID, symbol, message string) *alert.Alert {
+	return &alert.Alert{
+		RuleID:      r.ID,
+		Rule:        r.Expr,
+		AccountID:   accountID,
+		Symbol:      symbol,
+		Message:     message,
+		Notify:      r.Notify,
+		TriggeredAt: time.Now(),
+	}
+}
+
+// deliver records each alert against its rule and sends it to the notifiers
+// of its channels in the background
+func (m *Monitor) deliver(ctx context.Context, alerts []*alert.Alert) error {
+	if len(alerts) == 0 {
+		return nil
+	}
+
+	var errs []error
+	for _, a := range alerts {
+		slog.Info("alert fired", "alert_id", a.RuleID, "rule", a.Rule, "message", a.Message)
+		if err := m.store.RecordAlertTrigger(ctx, db.NewRecordAlertTriggerParams(a)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to record alert %d: %w", a.RuleID, err))
+		}
+		for _, ch := range a.Notify {
+			if n := m.Notifiers[ch]; n != nil {
+				m.notifying.Add(1)
+				go m.notify(ctx, ch, n, a)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Monitor) notify(ctx context.Context, ch alert.Channel, n Notifier, a *alert.Alert) {
+	defer m.notifying.Done()
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), notifyTimeout)
+	defer cancel()
+	if err := n.Notify(ctx, a); err != nil {
+		err = fmt.Errorf("failed to deliver alert %d to %s: %w", a.RuleID, ch, err)
+
+		m.mu.Lock()
+		handle := m.handle
+		if handle == nil {
+			m.failed = append(m.failed, err)
+		}
+		m.mu.Unlock()
+		if handle != nil {
+			handle(nil, err)
+		}
+	}
+}
+
+func (m *Monitor) report(alerts []*alert.Alert, err error) {
+	m.mu.Lock()
+	handle := m.handle
+	m.mu.Unlock()
+	if handle != nil && (len(alerts) > 0 || err != nil) {
+		handle(alerts, err)
+	}
+}
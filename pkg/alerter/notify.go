@@ -0,0 +1,117 @@
+package alerter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/revrost/pony/pkg/alert"
+)
+
+// Bell rings the terminal bell by writing BEL to Out
+type Bell struct {
+	Out io.Writer
+}
+
+func (b Bell) Notify(ctx context.Context, a *alert.Alert) error {
+	_, err := io.WriteString(b.Out, "\a")
+	return err
+}
+
+// Webhook posts each alert to URL as JSON
+type Webhook struct {
+	URL    string
+	Client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{URL: url, Client: &http.Client{Timeout: notifyTimeout}}
+}
+
+func (w *Webhook) Notify(ctx context.Context, a *alert.Alert) error {
+	body, err := json.Marshal(newPayload(a))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := w.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post webhook: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// Command runs a shell command for each alert, with the alert as JSON on
+// its standard input and in PONY_ALERT_* environment variables
+type Command struct {
+	Command string
+}
+
+func (c Command) Notify(ctx context.Context, a *alert.Alert) error {
+	body, err := json.Marshal(newPayload(a))
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", c.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(),
+		"PONY_ALERT_ID="+strconv.FormatInt(a.RuleID, 10),
+		"PONY_ALERT_RULE="+a.Rule,
+		"PONY_ALERT_ACCOUNT_ID="+a.AccountID,
+		"PONY_ALERT_SYMBOL="+a.Symbol,
+		"PONY_ALERT_MESSAGE="+a.Message,
+		"PONY_ALERT_TRIGGERED_AT="+a.TriggeredAt.UTC().Format(time.RFC3339),
+	)
+
+	// The TUI owns the terminal, so the output only goes into the error
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("alert command failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("alert command failed: %w", err)
+	}
+	return nil
+}
+
+// payload is an alert as webhooks and commands get it
+type payload struct {
+	AlertID     int64     `json:"alert_id"`
+	Rule        string    `json:"rule"`
+	AccountID   string    `json:"account_id,omitempty"`
+	Symbol      string    `json:"symbol,omitempty"`
+	Message     string    `json:"message"`
+	TriggeredAt time.Time `json:"triggered_at"`
+}
+
+func newPayload(a *alert.Alert) payload {
+	return payload{
+		AlertID:     a.RuleID,
+		Rule:        a.Rule,
+		AccountID:   a.AccountID,
+		Symbol:      a.Symbol,
+		Message:     a.Message,
+		TriggeredAt: a.TriggeredAt.UTC(),
+	}
+}
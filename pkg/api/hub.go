@@ -0,0 +1,59 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/revrost/pony/pkg/broker"
+)
+
+// subscriberBuffer is how many events a subscriber may fall behind by
+// before it is dropped
+const subscriberBuffer = 256
+
+// hub fans the server's one broker event stream out to any number of
+// subscribers
+type hub struct {
+	mu   sync.Mutex
+	subs map[chan broker.Event]struct{}
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[chan broker.Event]struct{})}
+}
+
+// subscribe returns a channel that receives every event published from now
+// on. It is closed by unsubscribe, or when the subscriber falls too far
+// behind.
+func (h *hub) subscribe() chan broker.Event {
+	ch := make(chan broker.Event, subscriberBuffer)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.subs[ch] = struct{}{}
+	return ch
+}
+
+func (h *hub) unsubscribe(ch chan broker.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[ch]; ok {
+		delete(h.subs, ch)
+		close(ch)
+	}
+}
+
+// publish passes the event to every subscriber without waiting on any of
+// them. A subscriber whose buffer is full is dropped, so one stuck client
+// cannot hold up the rest; it sees its stream end and can reconnect.
+func (h *hub) publish(event broker.Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- event:
+		default:
+			delete(h.subs, ch)
+			close(ch)
+		}
+	}
+}
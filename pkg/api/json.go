@@ -0,0 +1,189 @@
+package api
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/position"
+)
+
+// The API's JSON shapes. Decimals are encoded as strings so they keep
+// their exact digits, and missing values are null.
+
+type accountJSON struct {
+	ID              string          `json:"id"`
+	AlpacaAccountID string          `json:"alpaca_account_id"`
+	Status          string          `json:"status"`
+	Currency        string          `json:"currency"`
+	Cash            decimal.Decimal `json:"cash"`
+	BuyingPower     decimal.Decimal `json:"buying_power"`
+	PortfolioValue  decimal.Decimal `json:"portfolio_value"`
+	CreatedAt       *time.Time      `json:"created_at"`
+
+	// Only set on live account state from the broker
+	Equity         *decimal.Decimal `json:"equity,omitempty"`
+	LastEquity     *decimal.Decimal `json:"last_equity,omitempty"`
+	PositionsValue *decimal.Decimal `json:"positions_value,omitempty"`
+}
+
+func toAccountJSON(a *account.Account) accountJSON {
+	return accountJSON{
+		ID:              a.ID,
+		AlpacaAccountID: a.AlpacaAccountID,
+		Status:          a.Status,
+		Currency:        a.Currency,
+		Cash:            a.Cash,
+		BuyingPower:     a.BuyingPower,
+		PortfolioValue:  a.PortfolioValue,
+		CreatedAt:       timePtr(a.CreatedAt),
+	}
+}
+
+// toAccountStateJSON is an account as the broker has it right now
+func toAccountStateJSON(a *account.Account) accountJSON {
+	j := toAccountJSON(a)
+	j.Equity = &a.Equity
+	j.LastEquity = &a.LastEquity
+	j.PositionsValue = &a.PositionsValue
+	return j
+}
+
+type orderJSON struct {
+	ID             string            `json:"id"`
+	AlpacaOrderID  string            `json:"alpaca_order_id"`
+	AccountID      string            `json:"account_id"`
+	Symbol         string            `json:"symbol"`
+	Side           order.OrderSide   `json:"side"`
+	Type           order.OrderType   `json:"type"`
+	Qty            *decimal.Decimal  `json:"qty"`
+	Notional       *decimal.Decimal  `json:"notional"`
+	FilledQty      decimal.Decimal   `json:"filled_qty"`
+	LimitPrice     *decimal.Decimal  `json:"limit_price"`
+	StopPrice      *decimal.Decimal  `json:"stop_price"`
+	TimeInForce    order.TimeInForce `json:"time_in_force"`
+	Status         order.OrderStatus `json:"status"`
+	FilledAvgPrice *decimal.Decimal  `json:"filled_avg_price"`
+	SubmittedAt    *time.Time        `json:"submitted_at"`
+	FilledAt       *time.Time        `json:"filled_at"`
+	CanceledAt     *time.Time        `json:"canceled_at"`
+}
+
+func toOrderJSON(o *order.Order) orderJSON {
+	return orderJSON{
+		ID:             o.ID,
+		AlpacaOrderID:  o.AlpacaOrderID,
+		AccountID:      o.AccountID,
+		Symbol:         o.Symbol,
+		Side:           o.Side,
+		Type:           o.OrderType,
+		Qty:            o.Qty,
+		Notional:       o.Notional,
+		FilledQty:      o.FilledQty,
+		LimitPrice:     o.LimitPrice,
+		StopPrice:      o.StopPrice,
+		TimeInForce:    o.TimeInForce,
+		Status:         o.Status,
+		FilledAvgPrice: o.FilledAvgPrice,
+		SubmittedAt:    timePtr(o.SubmittedAt),
+		FilledAt:       o.FilledAt,
+		CanceledAt:     o.CanceledAt,
+	}
+}
+
+type positionJSON struct {
+	AccountID      string          `json:"account_id"`
+	Symbol         string          `json:"symbol"`
+	Qty            decimal.Decimal `json:"qty"`
+	AvgEntryPrice  decimal.Decimal `json:"avg_entry_price"`
+	CurrentPrice   decimal.Decimal `json:"current_price"`
+	MarketValue    decimal.Decimal `json:"market_value"`
+	CostBasis      decimal.Decimal `json:"cost_basis"`
+	UnrealizedPL   decimal.Decimal `json:"unrealized_pl"`
+	UnrealizedPLPC decimal.Decimal `json:"unrealized_plpc"`
+	UpdatedAt      *time.Time      `json:"updated_at"`
+}
+
+func toPositionJSON(p *position.Position) positionJSON {
+	return positionJSON{
+		AccountID:      p.AccountID,
+		Symbol:         p.Symbol,
+		Qty:            p.Qty,
+		AvgEntryPrice:  p.AvgEntryPrice,
+		CurrentPrice:   p.CurrentPrice,
+		MarketValue:    p.MarketValue,
+		CostBasis:      p.CostBasis,
+		UnrealizedPL:   p.UnrealizedPL,
+		UnrealizedPLPC: p.UnrealizedPLPC,
+		UpdatedAt:      timePtr(p.UpdatedAt),
+	}
+}
+
+// eventJSON is a broker event as the events stream sends it. Order is set
+// for trade updates and Account for account updates.
+type eventJSON struct {
+	ID         string                `json:"id"`
+	Type       broker.EventType      `json:"type"`
+	AccountID  string                `json:"account_id"`
+	ReceivedAt time.Time             `json:"received_at"`
+	Event      broker.TradeEventKind `json:"event,omitempty"`
+	Order      *orderJSON            `json:"order,omitempty"`
+	Price      *decimal.Decimal      `json:"price,omitempty"`
+	Qty        *decimal.Decimal      `json:"qty,omitempty"`
+	Account    *accountJSON          `json:"account,omitempty"`
+}
+
+func toEventJSON(e broker.Event) eventJSON {
+	meta := e.Metadata()
+	j := eventJSON{
+		ID:         meta.ID,
+		Type:       e.Type(),
+		AccountID:  meta.AccountID,
+		ReceivedAt: meta.ReceivedAt,
+	}
+
+	switch e := e.(type) {
+	case broker.TradeUpdateEvent:
+		o := toOrderJSON(e.Order)
+		j.Event = e.Event
+		j.Order = &o
+		j.Price = e.Price
+		j.Qty = e.Qty
+	case broker.AccountUpdateEvent:
+		a := toAccountJSON(e.Account)
+		j.Account = &a
+	}
+	return j
+}
+
+// createOrderJSON is the body of a new order. Numbers may be given as JSON
+// numbers or strings.
+type createOrderJSON struct {
+	Symbol        string            `json:"symbol"`
+	Side          order.OrderSide   `json:"side"`
+	Type          order.OrderType   `json:"type"`
+	Qty           *decimal.Decimal  `json:"qty"`
+	Notional      *decimal.Decimal  `json:"notional"`
+	LimitPrice    *decimal.Decimal  `json:"limit_price"`
+	StopPrice     *decimal.Decimal  `json:"stop_price"`
+	TimeInForce   order.TimeInForce `json:"time_in_force"`
+	ClientOrderID string            `json:"client_order_id"`
+}
+
+// replaceOrderJSON is the body of an order change; missing fields are kept
+type replaceOrderJSON struct {
+	Qty         *decimal.Decimal  `json:"qty"`
+	LimitPrice  *decimal.Decimal  `json:"limit_price"`
+	StopPrice   *decimal.Decimal  `json:"stop_price"`
+	TimeInForce order.TimeInForce `json:"time_in_force"`
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
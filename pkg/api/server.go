@@ -0,0 +1,511 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/history"
+	"github.com/revrost/pony/pkg/order"
+)
+
+// DefaultAddr is where pony serve listens. It is loopback only; the API is
+// for tools on the same machine.
+const DefaultAddr = "127.0.0.1:8484"
+
+const (
+	// streamRetry is how long the server waits before reconnecting to the
+	// broker's event stream
+	streamRetry = 5 * time.Second
+
+	// heartbeat is how often an idle events stream gets a comment, so
+	// proxies and clients do not time it out
+	heartbeat = 15 * time.Second
+)
+
+// errNotFound is for lookups in the database that found nothing
+var errNotFound = errors.New("not found")
+
+// Store is the subset of the sqlc generated Querier the API reads through.
+// *db.Queries implements it.
+type Store interface {
+	history.Store
+	ListAccounts(ctx context.Context) ([]db.Account, error)
+	GetAccount(ctx context.Context, id string) (db.Account, error)
+	GetAccountByAlpacaID(ctx context.Context, alpacaAccountID string) (db.Account, error)
+	GetOrder(ctx context.Context, id string) (db.Order, error)
+	GetOrderByAlpacaID(ctx context.Context, alpacaOrderID string) (db.Order, error)
+	ListPositions(ctx context.Context, accountID string) ([]db.Position, error)
+}
+
+// EventLog is where streamed events are recorded before they are sent to
+// subscribers. *events.Store implements it.
+type EventLog interface {
+	Record(ctx context.Context, event broker.Event) error
+}
+
+// Server is the local HTTP JSON API. Reads come from the database; trading
+// goes through the broker client, and so through the outbox and audit log
+// when cmd/pony wraps it. Every request needs the bearer token.
+type Server struct {
+	brokerClient broker.Client
+	store        Store
+	eventLog     EventLog
+	token        string
+
+	hub *hub
+	mux *http.ServeMux
+}
+
+var _ http.Handler = (*Server)(nil)
+
+// NewServer returns a server accepting token. A nil eventLog leaves
+// recording events to someone else, such as pony-worker.
+func NewServer(brokerClient broker.Client, store Store, eventLog EventLog, token string) *Server {
+	s := &Server{
+		brokerClient: brokerClient,
+		store:        store,
+		eventLog:     eventLog,
+		token:        token,
+		hub:          newHub(),
+		mux:          http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("GET /v1/accounts", s.listAccounts)
+	s.mux.HandleFunc("GET /v1/accounts/{id}", s.getAccount)
+	s.mux.HandleFunc("GET /v1/accounts/{id}/positions", s.listPositions)
+	s.mux.HandleFunc("GET /v1/accounts/{id}/orders", s.listOrders)
+	s.mux.HandleFunc("POST /v1/accounts/{id}/orders", s.createOrder)
+	s.mux.HandleFunc("GET /v1/orders/{id}", s.getOrder)
+	s.mux.HandleFunc("PATCH /v1/orders/{id}", s.replaceOrder)
+	s.mux.HandleFunc("DELETE /v1/orders/{id}", s.cancelOrder)
+	s.mux.HandleFunc("GET /v1/events", s.streamEvents)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="pony"`)
+		writeError(w, http.StatusUnauthorized, errors.New("missing or wrong bearer token"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// Stream consumes the broker's event stream, records each event and passes
+// it to the subscribers of GET /v1/events, until ctx is done. It reconnects
+// after failures and reports each one to handle, which may be nil.
+func (s *Server) Stream(ctx context.Context, handle func(error)) {
+	for {
+		err := s.stream(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		if handle != nil {
+			handle(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(streamRetry):
+		}
+	}
+}
+
+func (s *Server) stream(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eventCh, errCh := s.brokerClient.StreamEvents(ctx, "")
+	for {
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				return errors.New("event stream closed")
+			}
+			if s.eventLog != nil {
+				if err := s.eventLog.Record(ctx, event); err != nil {
+					return fmt.Errorf("failed to record event: %w", err)
+				}
+			}
+			s.hub.publish(event)
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+func (s *Server) listAccounts(w http.ResponseWriter, r *http.Request) {
+	rows, err := s.store.ListAccounts(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list accounts: %w", err))
+		return
+	}
+
+	accounts := make([]accountJSON, 0, len(rows))
+	for _, a := range db.ToAccounts(rows) {
+		accounts = append(accounts, toAccountJSON(a))
+	}
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+// getAccount returns the account's live state from the broker
+func (s *Server) getAccount(w http.ResponseWriter, r *http.Request) {
+	accountID, err := s.findAccountID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+
+	acc, err := s.brokerClient.GetAccount(r.Context(), accountID)
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toAccountStateJSON(acc))
+}
+
+func (s *Server) listPositions(w http.ResponseWriter, r *http.Request) {
+	accountID, err := s.findAccountID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+
+	rows, err := s.store.ListPositions(r.Context(), accountID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("failed to list positions: %w", err))
+		return
+	}
+
+	positions := make([]positionJSON, 0, len(rows))
+	for _, p := range db.ToPositions(rows) {
+		positions = append(positions, toPositionJSON(p))
+	}
+	writeJSON(w, http.StatusOK, positions)
+}
+
+// listOrders returns a page of the account's orders, newest first. The
+// symbol, side and status query parameters filter them, status taking a
+// comma-separated list where open means any working status, and limit sets the page size. The response's next
+// is passed back as after for the following page.
+func (s *Server) listOrders(w http.ResponseWriter, r *http.Request) {
+	accountID, err := s.findAccountID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := history.Filter{
+		AccountID: accountID,
+		Symbol:    strings.ToUpper(query.Get("symbol")),
+		Side:      order.OrderSide(query.Get("side")),
+	}
+	filter.Statuses = order.ParseStatuses(query.Get("status"))
+
+	limit := history.DefaultPageSize
+	if v := query.Get("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit <= 0 {
+			writeError(w, http.StatusBadRequest, errors.New("limit must be a positive number"))
+			return
+		}
+	}
+
+	after, err := decodeCursor(query.Get("after"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := history.Search(r.Context(), s.store, filter, after, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	orders := make([]orderJSON, 0, len(page.Orders))
+	for _, o := range page.Orders {
+		orders = append(orders, toOrderJSON(o))
+	}
+	resp := struct {
+		Orders []orderJSON `json:"orders"`
+		Next   *string     `json:"next"`
+	}{Orders: orders}
+	if page.HasMore {
+		next := encodeCursor(page.Next)
+		resp.Next = &next
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) getOrder(w http.ResponseWriter, r *http.Request) {
+	o, err := s.findOrder(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOrderJSON(o))
+}
+
+func (s *Server) createOrder(w http.ResponseWriter, r *http.Request) {
+	accountID, err := s.findAccountID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+
+	var body createOrderJSON
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if (body.Qty == nil) == (body.Notional == nil) {
+		writeError(w, http.StatusBadRequest, errors.New("exactly one of qty or notional is required"))
+		return
+	}
+	req := &order.CreateOrderRequest{
+		AccountID:     accountID,
+		Symbol:        strings.ToUpper(body.Symbol),
+		Side:          body.Side,
+		OrderType:     body.Type,
+		Qty:           body.Qty,
+		Notional:      body.Notional,
+		LimitPrice:    body.LimitPrice,
+		StopPrice:     body.StopPrice,
+		TimeInForce:   body.TimeInForce,
+		ClientOrderID: body.ClientOrderID,
+	}
+	if req.OrderType == "" {
+		req.OrderType = order.OrderTypeMarket
+	}
+	if req.TimeInForce == "" {
+		req.TimeInForce = order.TimeInForceDay
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	o, err := s.brokerClient.CreateOrder(r.Context(), req)
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, toOrderJSON(o))
+}
+
+func (s *Server) replaceOrder(w http.ResponseWriter, r *http.Request) {
+	var body replaceOrderJSON
+	if err := decodeBody(r, &body); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if body.Qty == nil && body.LimitPrice == nil && body.StopPrice == nil && body.TimeInForce == "" {
+		writeError(w, http.StatusBadRequest, errors.New("nothing to change"))
+		return
+	}
+
+	alpacaOrderID, err := s.brokerOrderID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+
+	o, err := s.brokerClient.ReplaceOrder(r.Context(), alpacaOrderID, &order.ReplaceOrderRequest{
+		Qty:         body.Qty,
+		LimitPrice:  body.LimitPrice,
+		StopPrice:   body.StopPrice,
+		TimeInForce: body.TimeInForce,
+	})
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toOrderJSON(o))
+}
+
+func (s *Server) cancelOrder(w http.ResponseWriter, r *http.Request) {
+	alpacaOrderID, err := s.brokerOrderID(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+
+	if err := s.brokerClient.CancelOrder(r.Context(), alpacaOrderID); err != nil {
+		writeBrokerError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// streamEvents sends every broker event as a server-sent event named after
+// its type, with the event as JSON data, until the client goes away
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming is not supported"))
+		return
+	}
+
+	events := s.hub.subscribe()
+	defer s.hub.unsubscribe(events)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-ticker.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case event, ok := <-events:
+			if !ok {
+				// Dropped for falling behind
+				return
+			}
+			data, err := json.Marshal(toEventJSON(event))
+			if err != nil {
+				return
+			}
+			if id := event.Metadata().ID; id != "" {
+				fmt.Fprintf(w, "id: %s\n", id)
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type(), data)
+		}
+		flusher.Flush()
+	}
+}
+
+// findAccountID resolves an account ID or Alpaca account ID to the ID
+func (s *Server) findAccountID(ctx context.Context, id string) (string, error) {
+	row, err := s.store.GetAccount(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		row, err = s.store.GetAccountByAlpacaID(ctx, id)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("account %q %w", id, errNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get account: %w", err)
+	}
+	return row.ID, nil
+}
+
+// findOrder looks an order up by its ID or Alpaca order ID
+func (s *Server) findOrder(ctx context.Context, id string) (*order.Order, error) {
+	row, err := s.store.GetOrder(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		row, err = s.store.GetOrderByAlpacaID(ctx, id)
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("order %q %w", id, errNotFound)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order: %w", err)
+	}
+	return db.ToOrder(row), nil
+}
+
+// brokerOrderID is the Alpaca order ID for an order given by either ID. An
+// order not in the database yet is passed to the broker as it is.
+func (s *Server) brokerOrderID(ctx context.Context, id string) (string, error) {
+	o, err := s.findOrder(ctx, id)
+	if errors.Is(err, errNotFound) {
+		return id, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return o.AlpacaOrderID, nil
+}
+
+// encodeCursor turns a page cursor into an opaque string for clients
+func encodeCursor(c history.Cursor) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID))
+}
+
+func decodeCursor(s string) (history.Cursor, error) {
+	if s == "" {
+		return history.Cursor{}, nil
+	}
+
+	invalid := errors.New("after is not a cursor returned as next")
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return history.Cursor{}, invalid
+	}
+	createdAt, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return history.Cursor{}, invalid
+	}
+	t, err := time.Parse(time.RFC3339Nano, createdAt)
+	if err != nil {
+		return history.Cursor{}, invalid
+	}
+	return history.Cursor{CreatedAt: t, ID: id}, nil
+}
+
+func decodeBody(r *http.Request, v any) error {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+	}{Error: err.Error()})
+}
+
+// writeBrokerError picks the status for an error from a lookup or the
+// broker: 404 for anything not found, 422 for a request the broker refused,
+// and 502 otherwise, since the broker may not have answered at all
+func writeBrokerError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, errNotFound), errors.Is(err, broker.ErrNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case errors.Is(err, broker.ErrRejected):
+		writeError(w, http.StatusUnprocessableEntity, err)
+	default:
+		writeError(w, http.StatusBadGateway, err)
+	}
+}
@@ -0,0 +1,165 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+
+	"github.com/revrost/pony/pkg/db"
+)
+
+type Action string
+
+const (
+	ActionOrderSubmit   Action = "order_submit"
+	ActionOrderReplace  Action = "order_replace"
+	ActionOrderCancel   Action = "order_cancel"
+	ActionPositionClose Action = "position_close"
+	ActionConfigChange  Action = "config_change"
+)
+
+// Entry is one recorded action. Request and Response hold what was sent to
+// the broker and what it answered as JSON. Response is JSON null when the
+// broker returned nothing, or when the action failed and Error says why.
+type Entry struct {
+	ID        int64
+	Operator  string
+	Host      string
+	AccountID string
+	Action    Action
+	Request   json.RawMessage
+	Response  json.RawMessage
+	Error     string
+	CreatedAt time.Time
+}
+
+// Failed reports whether the broker rejected the action.
+func (e *Entry) Failed() bool {
+	return e.Error != ""
+}
+
+// Filter narrows ListEntries. Zero fields match everything.
+type Filter struct {
+	AccountID string
+	Action    Action
+	Since     time.Time
+	Limit     int
+}
+
+// DefaultLimit is how many entries ListEntries returns when Filter.Limit is unset
+const DefaultLimit = 100
+
+// Reader is the subset of the sqlc generated Querier ListEntries needs.
+type Reader interface {
+	ListAuditEntries(ctx context.Context, arg db.ListAuditEntriesParams) ([]db.AuditLog, error)
+}
+
+// Store is the subset of the sqlc generated Querier the audit log needs.
+// *db.Queries implements it.
+type Store interface {
+	Reader
+	CreateAuditEntry(ctx context.Context, arg db.CreateAuditEntryParams) (db.AuditLog, error)
+}
+
+// Log writes audit entries stamped with the operator and host of this process.
+// The audit_log table rejects updates and deletes, so entries are immutable.
+type Log struct {
+	store    Store
+	operator string
+	host     string
+}
+
+// NewLog returns a Log for operator, falling back to the OS user name when
+// operator is empty.
+func NewLog(store Store, operator string) *Log {
+	if operator == "" {
+		if u, err := user.Current(); err == nil {
+			operator = u.Username
+		}
+	}
+	if operator == "" {
+		operator = "unknown"
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return &Log{
+		store:    store,
+		operator: operator,
+		host:     host,
+	}
+}
+
+// Record writes one entry. request and response are stored as JSON; actionErr
+// is the broker's error, if any.
+func (l *Log) Record(ctx context.Context, accountID string, action Action, request, response any, actionErr error) error {
+	req, err := json.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit request: %w", err)
+	}
+
+	var errText string
+	if actionErr != nil {
+		errText = actionErr.Error()
+		response = nil
+	}
+	resp, err := json.Marshal(response)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit response: %w", err)
+	}
+
+	if _, err := l.store.CreateAuditEntry(ctx, db.CreateAuditEntryParams{
+		Operator:  l.operator,
+		Host:      l.host,
+		AccountID: accountID,
+		Action:    string(action),
+		Request:   req,
+		Response:  resp,
+		Error:     errText,
+	}); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// ListEntries returns entries matching filter, newest first.
+func ListEntries(ctx context.Context, store Reader, filter Filter) ([]*Entry, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	rows, err := store.ListAuditEntries(ctx, db.ListAuditEntriesParams{
+		AccountID: filter.AccountID,
+		Action:    string(filter.Action),
+		Since:     filter.Since.UTC(),
+		RowLimit:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit log: %w", err)
+	}
+
+	entries := make([]*Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, &Entry{
+			ID:        row.ID,
+			Operator:  row.Operator,
+			Host:      row.Host,
+			AccountID: row.AccountID,
+			Action:    Action(row.Action),
+			Request:   row.Request,
+			Response:  row.Response,
+			Error:     row.Error,
+			CreatedAt: row.CreatedAt,
+		})
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,94 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/order"
+)
+
+// Client wraps a broker.Client and records every trading action it performs
+// in the audit log. Read-only calls pass straight through.
+//
+// The broker call always happens first. If it succeeds but the audit entry
+// cannot be written, the result is returned together with the audit error,
+// so callers see both that the action went through and that it was not
+// recorded.
+type Client struct {
+	broker.Client
+	log *Log
+
+	// The Alpaca trading API works on a single account, which is looked up
+	// for entries whose request does not name one and kept once found
+	accountMu sync.Mutex
+	accountID string
+}
+
+var _ broker.Client = (*Client)(nil)
+
+func NewClient(client broker.Client, log *Log) *Client {
+	return &Client{Client: client, log: log}
+}
+
+type cancelOrderRequest struct {
+	OrderID string
+}
+
+type replaceOrderRequest struct {
+	OrderID string
+	Changes *order.ReplaceOrderRequest
+}
+
+type closePositionRequest struct {
+	AccountID string
+	Symbol    string
+}
+
+func (c *Client) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.Order, error) {
+	o, err := c.Client.CreateOrder(ctx, req)
+	return o, c.record(ctx, req.AccountID, ActionOrderSubmit, req, o, err)
+}
+
+func (c *Client) ReplaceOrder(ctx context.Context, orderID string, req *order.ReplaceOrderRequest) (*order.Order, error) {
+	o, err := c.Client.ReplaceOrder(ctx, orderID, req)
+	return o, c.record(ctx, "", ActionOrderReplace, replaceOrderRequest{OrderID: orderID, Changes: req}, o, err)
+}
+
+func (c *Client) CancelOrder(ctx context.Context, orderID string) error {
+	err := c.Client.CancelOrder(ctx, orderID)
+	return c.record(ctx, "", ActionOrderCancel, cancelOrderRequest{OrderID: orderID}, nil, err)
+}
+
+func (c *Client) ClosePosition(ctx context.Context, accountID, symbol string) (*order.Order, error) {
+	o, err := c.Client.ClosePosition(ctx, accountID, symbol)
+	return o, c.record(ctx, accountID, ActionPositionClose, closePositionRequest{AccountID: accountID, Symbol: symbol}, o, err)
+}
+
+// record writes the entry and returns the broker error joined with any
+// failure to write it.
+func (c *Client) record(ctx context.Context, accountID string, action Action, request, response any, actionErr error) error {
+	if accountID == "" {
+		accountID = c.defaultAccountID(ctx)
+	}
+
+	if err := c.log.Record(ctx, accountID, action, request, response, actionErr); err != nil {
+		return errors.Join(actionErr, err)
+	}
+	return actionErr
+}
+
+// defaultAccountID returns the broker's account, or "" if it cannot be
+// looked up. A failed lookup is tried again on the next entry.
+func (c *Client) defaultAccountID(ctx context.Context) string {
+	c.accountMu.Lock()
+	defer c.accountMu.Unlock()
+
+	if c.accountID == "" {
+		if acc, err := c.Client.GetAccount(ctx, ""); err == nil {
+			c.accountID = acc.ID
+		}
+	}
+	return c.accountID
+}
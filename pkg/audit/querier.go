@@ -0,0 +1,183 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/db"
+)
+
+// Querier wraps a db.Querier and records every change to scheduled orders
+// and alert rules made through it in the audit log, as config_change
+// entries. Everything else passes straight through.
+//
+// As with Client, the change is made first and a failure to record it is
+// returned joined with the change's own error. Enabling, disabling or
+// deleting a row that does not exist changes nothing and is not recorded.
+type Querier struct {
+	db.Querier
+	log *Log
+}
+
+var _ db.Querier = (*Querier)(nil)
+
+func NewQuerier(querier db.Querier, log *Log) *Querier {
+	return &Querier{Querier: querier, log: log}
+}
+
+// createScheduleRequest is db.CreateScheduledOrderParams without the next
+// run, which is worked out from the schedule
+type createScheduleRequest struct {
+	AccountID   string
+	Schedule    string
+	TimeZone    string
+	Symbol      string
+	Side        string
+	OrderType   string
+	Qty         decimal.NullDecimal
+	Notional    decimal.NullDecimal
+	LimitPrice  decimal.NullDecimal
+	StopPrice   decimal.NullDecimal
+	TimeInForce string
+	Enabled     bool
+}
+
+// createAlertRequest is db.CreateAlertParams without the sql.NullString,
+// which would be recorded as an object
+type createAlertRequest struct {
+	AccountID string
+	Rule      string
+	Notify    string
+	Enabled   bool
+}
+
+// ConfigChange is the request of a config_change entry
+type ConfigChange struct {
+	// Target is what changed: schedule, alert or strategy
+	Target string
+	// Change is create, enable, disable or delete for schedules and alerts,
+	// and start, pause or stop for strategies
+	Change string
+	// ID is the schedule's or alert's ID, or the strategy's name
+	ID any `json:",omitempty"`
+	// Request is what a create asked for
+	Request any `json:",omitempty"`
+}
+
+func (q *Querier) CreateScheduledOrder(ctx context.Context, arg db.CreateScheduledOrderParams) (db.ScheduledOrder, error) {
+	row, err := q.Querier.CreateScheduledOrder(ctx, arg)
+	change := ConfigChange{Target: "schedule", Change: "create", ID: row.ID, Request: createScheduleRequest{
+		AccountID:   arg.AccountID,
+		Schedule:    arg.Schedule,
+		TimeZone:    arg.TimeZone,
+		Symbol:      arg.Symbol,
+		Side:        arg.Side,
+		OrderType:   arg.OrderType,
+		Qty:         arg.Qty,
+		Notional:    arg.Notional,
+		LimitPrice:  arg.LimitPrice,
+		StopPrice:   arg.StopPrice,
+		TimeInForce: arg.TimeInForce,
+		Enabled:     arg.Enabled,
+	}}
+	return row, q.record(ctx, arg.AccountID, change, scheduleResponse(row, err), err)
+}
+
+func (q *Querier) SetScheduledOrderEnabled(ctx context.Context, arg db.SetScheduledOrderEnabledParams) (db.ScheduledOrder, error) {
+	row, err := q.Querier.SetScheduledOrderEnabled(ctx, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, err
+	}
+	change := ConfigChange{Target: "schedule", Change: enableChange(arg.Enabled), ID: arg.ID}
+	return row, q.record(ctx, row.AccountID, change, scheduleResponse(row, err), err)
+}
+
+func (q *Querier) DeleteScheduledOrder(ctx context.Context, id int64) (int64, error) {
+	// The account is only known before the row is gone
+	existing, err := q.Querier.GetScheduledOrder(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := q.Querier.DeleteScheduledOrder(ctx, id)
+	if err == nil && n == 0 {
+		return n, nil
+	}
+	change := ConfigChange{Target: "schedule", Change: "delete", ID: id}
+	return n, q.record(ctx, existing.AccountID, change, nil, err)
+}
+
+func (q *Querier) CreateAlert(ctx context.Context, arg db.CreateAlertParams) (db.Alert, error) {
+	row, err := q.Querier.CreateAlert(ctx, arg)
+	change := ConfigChange{Target: "alert", Change: "create", ID: row.ID, Request: createAlertRequest{
+		AccountID: arg.AccountID.String,
+		Rule:      arg.Rule,
+		Notify:    arg.Notify,
+		Enabled:   arg.Enabled,
+	}}
+	return row, q.record(ctx, arg.AccountID.String, change, alertResponse(row, err), err)
+}
+
+func (q *Querier) SetAlertEnabled(ctx context.Context, arg db.SetAlertEnabledParams) (db.Alert, error) {
+	row, err := q.Querier.SetAlertEnabled(ctx, arg)
+	if errors.Is(err, sql.ErrNoRows) {
+		return row, err
+	}
+	change := ConfigChange{Target: "alert", Change: enableChange(arg.Enabled), ID: arg.ID}
+	return row, q.record(ctx, row.AccountID.String, change, alertResponse(row, err), err)
+}
+
+func (q *Querier) DeleteAlert(ctx context.Context, id int64) (int64, error) {
+	existing, err := q.Querier.GetAlert(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	n, err := q.Querier.DeleteAlert(ctx, id)
+	if err == nil && n == 0 {
+		return n, nil
+	}
+	change := ConfigChange{Target: "alert", Change: "delete", ID: id}
+	return n, q.record(ctx, existing.AccountID.String, change, nil, err)
+}
+
+// record writes the entry and returns the change's error joined with any
+// failure to write it
+func (q *Querier) record(ctx context.Context, accountID string, change ConfigChange, response any, changeErr error) error {
+	if err := q.log.Record(ctx, accountID, ActionConfigChange, change, response, changeErr); err != nil {
+		return errors.Join(changeErr, err)
+	}
+	return changeErr
+}
+
+func enableChange(enabled bool) string {
+	if enabled {
+		return "enable"
+	}
+	return "disable"
+}
+
+// scheduleResponse is what a change to a schedule left in the database
+func scheduleResponse(row db.ScheduledOrder, err error) any {
+	if err != nil {
+		return nil
+	}
+	return db.ToScheduledOrder(row)
+}
+
+// alertResponse is what a change to an alert rule left in the database
+func alertResponse(row db.Alert, err error) any {
+	if err != nil {
+		return nil
+	}
+	return db.ToAlertRule(row)
+}
@@ -0,0 +1,43 @@
+package audit
+
+import (
+	"context"
+	"errors"
+
+	"github.com/revrost/pony/pkg/strategy"
+)
+
+// Strategies wraps a strategy engine and records starting, pausing and
+// stopping its strategies in the audit log, as config_change entries
+type Strategies struct {
+	*strategy.Engine
+	log *Log
+	// accountID is the account the engine's strategies trade in
+	accountID string
+}
+
+func NewStrategies(engine *strategy.Engine, log *Log, accountID string) *Strategies {
+	return &Strategies{Engine: engine, log: log, accountID: accountID}
+}
+
+func (s *Strategies) Start(ctx context.Context, name string) error {
+	return s.record(ctx, "start", name, s.Engine.Start(ctx, name))
+}
+
+func (s *Strategies) Pause(name string) error {
+	return s.record(context.Background(), "pause", name, s.Engine.Pause(name))
+}
+
+func (s *Strategies) Stop(ctx context.Context, name string) error {
+	return s.record(ctx, "stop", name, s.Engine.Stop(ctx, name))
+}
+
+// record writes the entry and returns the engine's error joined with any
+// failure to write it
+func (s *Strategies) record(ctx context.Context, change, name string, changeErr error) error {
+	request := ConfigChange{Target: "strategy", Change: change, ID: name}
+	if err := s.log.Record(ctx, s.accountID, ActionConfigChange, request, nil, changeErr); err != nil {
+		return errors.Join(changeErr, err)
+	}
+	return changeErr
+}
@@ -0,0 +1,171 @@
+// Package backtest replays historical bars through a strategy, filling its
+// orders with the simulated broker, and reports how it would have done.
+package backtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/sim"
+	"github.com/revrost/pony/pkg/strategy"
+)
+
+// Options are the account a backtest starts with and what its fills cost
+type Options struct {
+	// Cash defaults to sim.DefaultCash
+	Cash      decimal.Decimal
+	FillModel sim.FillModel
+}
+
+// Report is how a strategy did over the bars replayed
+type Report struct {
+	Strategy string
+	Start    time.Time
+	End      time.Time
+	Bars     int
+
+	StartingCash decimal.Decimal
+	FinalEquity  decimal.Decimal
+
+	// Equity has a point for every time a bar started, after the strategy
+	// acted on it
+	Equity []EquityPoint
+	Trades []Trade
+	Stats  Stats
+}
+
+// EquityPoint is the account's value at a time
+type EquityPoint struct {
+	Time   time.Time
+	Equity decimal.Decimal
+	Cash   decimal.Decimal
+	// Drawdown is how far Equity is below its highest so far, as a
+	// fraction of that high
+	Drawdown float64
+}
+
+// Run replays bars through s, which gets a broker client scoped to cfg as
+// it would live. Orders placed for a bar fill no earlier than the next one,
+// using opts' fill model. Bars may be for several symbols and in any order.
+//
+// Run fails if the strategy does, as a live strategy would be stopped.
+func Run(ctx context.Context, s strategy.Strategy, cfg strategy.Config, bars []marketdata.Bar, opts Options) (*Report, error) {
+	if len(bars) == 0 {
+		return nil, errors.New("no bars to replay")
+	}
+	bars = slices.Clone(bars)
+	marketdata.SortBars(bars)
+
+	cash := opts.Cash
+	if cash.IsZero() {
+		cash = sim.DefaultCash
+	}
+	simBroker := sim.NewBroker(cash)
+	simBroker.FillModel = opts.FillModel
+	simBroker.NextBar = true
+	events := simBroker.Subscribe()
+
+	cfg.AccountID = sim.AccountID
+	engine := strategy.NewEngine(simBroker, nil)
+	if err := engine.Add(cfg, s); err != nil {
+		return nil, err
+	}
+
+	// deliver passes the trade updates on until the strategy stops placing
+	// orders in answer to them
+	deliver := func() {
+		for batch := events.Drain(); len(batch) > 0; batch = events.Drain() {
+			for _, event := range batch {
+				engine.HandleEvent(ctx, event)
+			}
+		}
+	}
+
+	// The strategy starts at the first bar's time rather than today's
+	engine.HandleTime(ctx, bars[0].Time)
+	if err := engine.Start(ctx, cfg.Name); err != nil {
+		return nil, fmt.Errorf("failed to start strategy %s: %w", cfg.Name, err)
+	}
+	deliver()
+
+	report := &Report{
+		Strategy:     cfg.Name,
+		Start:        bars[0].Time,
+		End:          bars[len(bars)-1].Time,
+		Bars:         len(bars),
+		StartingCash: cash,
+	}
+	peak := cash
+
+	for i := 0; i < len(bars); {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		// Bars that start together are one step, so no symbol's bar is
+		// seen before another's fills
+		now := bars[i].Time
+		j := i
+		for j < len(bars) && bars[j].Time.Equal(now) {
+			j++
+		}
+		step := bars[i:j]
+		i = j
+
+		for _, bar := range step {
+			simBroker.SetBar(bar)
+		}
+		deliver()
+		engine.HandleTime(ctx, now)
+		deliver()
+		for _, bar := range step {
+			engine.HandleBar(ctx, bar)
+			deliver()
+		}
+
+		if err := engine.Status()[0].Err; err != nil {
+			return nil, fmt.Errorf("strategy %s failed at %s: %w", cfg.Name, now.Format(time.RFC3339), err)
+		}
+
+		acc, err := simBroker.GetAccount(ctx, sim.AccountID)
+		if err != nil {
+			return nil, err
+		}
+		peak = decimal.Max(peak, acc.Equity)
+		report.Equity = append(report.Equity, EquityPoint{
+			Time:     now,
+			Equity:   acc.Equity,
+			Cash:     acc.Cash,
+			Drawdown: fraction(peak.Sub(acc.Equity), peak),
+		})
+	}
+
+	report.FinalEquity = report.Equity[len(report.Equity)-1].Equity
+	report.Trades = trades(simBroker.Fills(), lastPrices(bars))
+	report.Stats = stats(report)
+	return report, nil
+}
+
+// lastPrices are the closes of each symbol's last bar
+func lastPrices(bars []marketdata.Bar) map[string]decimal.Decimal {
+	prices := map[string]decimal.Decimal{}
+	for _, bar := range bars {
+		prices[bar.Symbol] = bar.Close
+	}
+	return prices
+}
+
+// fraction is a / b as a float, or 0 if b is not positive
+func fraction(a, b decimal.Decimal) float64 {
+	if !b.IsPositive() {
+		return 0
+	}
+	f, _ := a.Div(b).Float64()
+	return f
+}
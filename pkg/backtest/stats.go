@@ -0,0 +1,223 @@
+package backtest
+
+import (
+	"math"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/sim"
+)
+
+// Direction is whether a trade bought first or sold short first
+type Direction string
+
+const (
+	Long  Direction = "long"
+	Short Direction = "short"
+)
+
+// Trade is a round trip in one symbol, from flat back to flat
+type Trade struct {
+	Symbol    string
+	Direction Direction
+	// Qty is the most held at once
+	Qty      decimal.Decimal
+	OpenedAt time.Time
+	// ClosedAt is nil for a trade still open when the bars ran out
+	ClosedAt *time.Time
+	// EntryPrice and ExitPrice are averages over the fills in and out.
+	// An open trade's ExitPrice is the last close.
+	EntryPrice decimal.Decimal
+	ExitPrice  decimal.Decimal
+	Commission decimal.Decimal
+	// PL is after commissions, and unrealized for an open trade
+	PL decimal.Decimal
+}
+
+// Return is PL as a fraction of what entering cost
+func (t Trade) Return() float64 {
+	return fraction(t.PL, t.EntryPrice.Mul(t.Qty))
+}
+
+// Stats sum up a backtest. Returns and drawdowns are fractions, so 0.1 is
+// 10%.
+type Stats struct {
+	TotalReturn float64
+	// CAGR is the yearly return compounded over the backtest's length
+	CAGR float64
+	// MaxDrawdown is the largest fall from a high in equity
+	MaxDrawdown float64
+	// Sharpe is the mean over the standard deviation of the returns
+	// between equity points, annualized, with no risk free rate
+	Sharpe float64
+	// Trades counts the closed trades, and WinRate is the fraction of them
+	// that made money
+	Trades     int
+	WinRate    float64
+	Commission decimal.Decimal
+}
+
+const yearLength = 365.25 * 24 * time.Hour
+
+func stats(r *Report) Stats {
+	s := Stats{TotalReturn: fraction(r.FinalEquity.Sub(r.StartingCash), r.StartingCash)}
+
+	years := r.End.Sub(r.Start).Hours() / yearLength.Hours()
+	if years > 0 && r.FinalEquity.IsPositive() {
+		s.CAGR = math.Pow(1+s.TotalReturn, 1/years) - 1
+	}
+
+	// The first point is at the start, so the returns are between it and
+	// each later one
+	var returns []float64
+	for i, p := range r.Equity {
+		s.MaxDrawdown = max(s.MaxDrawdown, p.Drawdown)
+		if i > 0 {
+			prev := r.Equity[i-1].Equity
+			returns = append(returns, fraction(p.Equity.Sub(prev), prev))
+		}
+	}
+	if len(returns) > 1 && years > 0 {
+		mean, sd := meanStdDev(returns)
+		if sd > 0 {
+			periodsPerYear := float64(len(returns)) / years
+			s.Sharpe = mean / sd * math.Sqrt(periodsPerYear)
+		}
+	}
+
+	wins := 0
+	for _, t := range r.Trades {
+		s.Commission = s.Commission.Add(t.Commission)
+		if t.ClosedAt == nil {
+			continue
+		}
+		s.Trades++
+		if t.PL.IsPositive() {
+			wins++
+		}
+	}
+	if s.Trades > 0 {
+		s.WinRate = float64(wins) / float64(s.Trades)
+	}
+	return s
+}
+
+// meanStdDev returns the mean and sample standard deviation of xs
+func meanStdDev(xs []float64) (float64, float64) {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	mean := sum / float64(len(xs))
+
+	var sq float64
+	for _, x := range xs {
+		sq += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(sq / float64(len(xs)-1))
+}
+
+// openTrade is a trade being built from fills
+type openTrade struct {
+	Trade
+	// held is the signed position and avgPrice its average cost
+	held     decimal.Decimal
+	avgPrice decimal.Decimal
+	// entered and exited are the quantities and values of the fills in
+	// and out, for their average prices
+	enteredQty, enteredValue decimal.Decimal
+	exitedQty, exitedValue   decimal.Decimal
+}
+
+// trades pairs fills into round trips, oldest first. A fill that flips a
+// position closes one trade and opens the next, and its commission is
+// split between them by quantity.
+func trades(fills []sim.Fill, lastPrices map[string]decimal.Decimal) []Trade {
+	var done []Trade
+	open := map[string]*openTrade{}
+	var openOrder []string
+
+	for _, f := range fills {
+		qty := f.Qty
+		if f.Side == order.OrderSideSell {
+			qty = qty.Neg()
+		}
+		perShare := decimal.Zero
+		if f.Qty.IsPositive() {
+			perShare = f.Commission.Div(f.Qty)
+		}
+
+		for !qty.IsZero() {
+			t, ok := open[f.Symbol]
+			if !ok {
+				direction := Long
+				if qty.IsNegative() {
+					direction = Short
+				}
+				t = &openTrade{Trade: Trade{Symbol: f.Symbol, Direction: direction, OpenedAt: f.Time}}
+				open[f.Symbol] = t
+				openOrder = append(openOrder, f.Symbol)
+			}
+
+			if t.held.IsZero() || t.held.Sign() == qty.Sign() {
+				// Entering, or adding to the position
+				t.Commission = t.Commission.Add(perShare.Mul(qty.Abs()))
+				t.enteredQty = t.enteredQty.Add(qty.Abs())
+				t.enteredValue = t.enteredValue.Add(qty.Abs().Mul(f.Price))
+				t.avgPrice = t.avgPrice.Mul(t.held).Add(f.Price.Mul(qty)).Div(t.held.Add(qty))
+				t.held = t.held.Add(qty)
+				t.Qty = decimal.Max(t.Qty, t.held.Abs())
+				break
+			}
+
+			// Exiting, in part or in full
+			closed := decimal.Min(t.held.Abs(), qty.Abs())
+			signedClosed := closed
+			if t.held.IsNegative() {
+				signedClosed = closed.Neg()
+			}
+			t.Commission = t.Commission.Add(perShare.Mul(closed))
+			t.PL = t.PL.Add(f.Price.Sub(t.avgPrice).Mul(signedClosed))
+			t.exitedQty = t.exitedQty.Add(closed)
+			t.exitedValue = t.exitedValue.Add(closed.Mul(f.Price))
+			t.held = t.held.Sub(signedClosed)
+			qty = qty.Add(signedClosed)
+
+			if t.held.IsZero() {
+				closedAt := f.Time
+				t.ClosedAt = &closedAt
+				done = append(done, t.finish())
+				delete(open, f.Symbol)
+			}
+		}
+	}
+
+	// Trades still open are marked to the last close
+	for _, symbol := range openOrder {
+		t, ok := open[symbol]
+		if !ok {
+			continue
+		}
+		price := lastPrices[symbol]
+		t.PL = t.PL.Add(price.Sub(t.avgPrice).Mul(t.held))
+		t.exitedQty = t.exitedQty.Add(t.held.Abs())
+		t.exitedValue = t.exitedValue.Add(t.held.Abs().Mul(price))
+		done = append(done, t.finish())
+		delete(open, symbol)
+	}
+	return done
+}
+
+func (t *openTrade) finish() Trade {
+	trade := t.Trade
+	trade.PL = trade.PL.Sub(trade.Commission)
+	if t.enteredQty.IsPositive() {
+		trade.EntryPrice = t.enteredValue.Div(t.enteredQty)
+	}
+	if t.exitedQty.IsPositive() {
+		trade.ExitPrice = t.exitedValue.Div(t.exitedQty)
+	}
+	return trade
+}
@@ -0,0 +1,108 @@
+package backtest
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var start = time.Date(2024, time.January, 2, 14, 30, 0, 0, time.UTC)
+
+// report runs from start for years with an equity point for each value,
+// evenly spaced, and drawdowns worked out as Run does
+func report(years float64, values ...int64) *Report {
+	end := start.Add(time.Duration(years * float64(yearLength)))
+	r := &Report{
+		Start:        start,
+		End:          end,
+		StartingCash: decimal.NewFromInt(values[0]),
+		FinalEquity:  decimal.NewFromInt(values[len(values)-1]),
+	}
+
+	step := end.Sub(start) / time.Duration(max(len(values)-1, 1))
+	peak := decimal.Zero
+	for i, v := range values {
+		equity := decimal.NewFromInt(v)
+		peak = decimal.Max(peak, equity)
+		r.Equity = append(r.Equity, EquityPoint{
+			Time:     start.Add(time.Duration(i) * step),
+			Equity:   equity,
+			Drawdown: fraction(peak.Sub(equity), peak),
+		})
+	}
+	return r
+}
+
+func TestStats(t *testing.T) {
+	closedAt := start.Add(time.Hour)
+
+	tests := []struct {
+		name   string
+		report *Report
+		want   Stats
+	}{
+		{"compounds over two years", report(2, 100, 110, 99, 121), Stats{
+			TotalReturn: 0.21,
+			CAGR:        0.1,
+			MaxDrawdown: 0.1,
+			// Returns of 10%, -10% and 22.2% over 1.5 periods a year
+			Sharpe: 0.5577113865331604,
+		}},
+		{"half a year", report(0.5, 100, 121), Stats{
+			TotalReturn: 0.21,
+			CAGR:        0.4641,
+		}},
+		{"deepest drawdown of several", report(1, 100, 80, 120, 60, 90), Stats{
+			TotalReturn: -0.1,
+			CAGR:        -0.1,
+			MaxDrawdown: 0.5,
+			// Returns of -20%, 50%, -50% and 50% over 4 periods a year
+			Sharpe: 0.29656008284352153,
+		}},
+		{"flat equity has no Sharpe", report(1, 100, 100, 100), Stats{}},
+		{"no time", report(0, 100, 110), Stats{TotalReturn: 0.1}},
+		{"wiped out", report(1, 100, 50, 0), Stats{
+			TotalReturn: -1,
+			MaxDrawdown: 1,
+			// A mean of -75% over a deviation of 35.4%, twice a year
+			Sharpe: -3,
+		}},
+		{"trades", &Report{
+			StartingCash: decimal.NewFromInt(100),
+			FinalEquity:  decimal.NewFromInt(100),
+			Trades: []Trade{
+				{PL: decimal.NewFromInt(5), ClosedAt: &closedAt, Commission: decimal.RequireFromString("0.5")},
+				{PL: decimal.NewFromInt(-3), ClosedAt: &closedAt, Commission: decimal.RequireFromString("0.5")},
+				{PL: decimal.NewFromInt(1), ClosedAt: &closedAt},
+				// Still open, so not counted
+				{PL: decimal.NewFromInt(9), Commission: decimal.RequireFromString("0.25")},
+			},
+		}, Stats{Trades: 3, WinRate: 2.0 / 3, Commission: decimal.RequireFromString("1.25")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := stats(tt.report)
+
+			floats := []struct {
+				name      string
+				got, want float64
+			}{
+				{"total return", got.TotalReturn, tt.want.TotalReturn},
+				{"CAGR", got.CAGR, tt.want.CAGR},
+				{"max drawdown", got.MaxDrawdown, tt.want.MaxDrawdown},
+				{"Sharpe", got.Sharpe, tt.want.Sharpe},
+				{"win rate", got.WinRate, tt.want.WinRate},
+			}
+			for _, f := range floats {
+				if math.Abs(f.got-f.want) > 1e-4 {
+					t.Errorf("%s = %v, want %v", f.name, f.got, f.want)
+				}
+			}
+			if got.Trades != tt.want.Trades || !got.Commission.Equal(tt.want.Commission) {
+				t.Errorf("trades = %d with commission %s, want %d with %s", got.Trades, got.Commission, tt.want.Trades, tt.want.Commission)
+			}
+		})
+	}
+}
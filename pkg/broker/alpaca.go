@@ -2,6 +2,8 @@ package broker
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,30 +13,82 @@ import (
 	"github.com/revrost/pony/pkg/account"
 	"github.com/revrost/pony/pkg/order"
 	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/watchlist"
+	"github.com/shopspring/decimal"
 )
 
 type AlpacaClient struct {
-	apiKey       string
-	apiSecret    string
-	baseURL      string
-	alpacaClient *alpaca.Client
-	httpClient   *http.Client
+	apiKey     string
+	apiSecret  string
+	baseURL    string
+	httpClient *http.Client
 }
 
 func NewAlpacaClient(apiKey, apiSecret, baseURL string) *AlpacaClient {
+	// One HTTP client for our own requests and the SDK's, so WrapTransport
+	// sees all of them
+	httpClient := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+
 	return &AlpacaClient{
-		apiKey:    apiKey,
-		apiSecret: apiSecret,
-		baseURL:   baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		alpacaClient: alpaca.NewClient(alpaca.ClientOpts{
-			APIKey:    apiKey,
-			APISecret: apiSecret,
-			BaseURL:   baseURL,
-		}),
+		apiKey:     apiKey,
+		apiSecret:  apiSecret,
+		baseURL:    baseURL,
+		httpClient: httpClient,
+	}
+}
+
+// sdk returns an SDK client for one call. The SDK makes its requests
+// without a context, so its requests are given ctx's values, such as the
+// caller's trace span, on their way through the transport.
+func (c *AlpacaClient) sdk(ctx context.Context) *alpaca.Client {
+	httpClient := *c.httpClient
+	httpClient.Transport = contextTransport{values: ctx, next: c.transport()}
+
+	return alpaca.NewClient(alpaca.ClientOpts{
+		APIKey:     c.apiKey,
+		APISecret:  c.apiSecret,
+		BaseURL:    c.baseURL,
+		HTTPClient: &httpClient,
+	})
+}
+
+func (c *AlpacaClient) transport() http.RoundTripper {
+	if c.httpClient.Transport == nil {
+		return http.DefaultTransport
 	}
+	return c.httpClient.Transport
+}
+
+// contextTransport adds the values of a context to each request's own
+type contextTransport struct {
+	values context.Context
+	next   http.RoundTripper
+}
+
+func (t contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.next.RoundTrip(req.WithContext(valuesContext{Context: req.Context(), values: t.values}))
+}
+
+// valuesContext looks values up in values first, but is done when its
+// own context is
+type valuesContext struct {
+	context.Context
+	values context.Context
+}
+
+func (c valuesContext) Value(key any) any {
+	if v := c.values.Value(key); v != nil {
+		return v
+	}
+	return c.Context.Value(key)
+}
+
+// WrapTransport puts wrap around the transport every request to the broker
+// goes through, e.g. to count responses. Call it before using the client.
+func (c *AlpacaClient) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.httpClient.Transport = wrap(c.transport())
 }
 
 func (c *AlpacaClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
@@ -52,65 +106,129 @@ func (c *AlpacaClient) doRequest(ctx context.Context, method, path string, body
 
 // GetAccount retrieves account information from Alpaca Broker API
 func (c *AlpacaClient) GetAccount(ctx context.Context, accountID string) (*account.Account, error) {
-	resp, err := c.alpacaClient.GetAccount()
+	resp, err := c.sdk(ctx).GetAccount()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get account: %w", err)
 	}
 
-	return &account.Account{
-		ID:              resp.ID,
-		AlpacaAccountID: resp.AccountNumber,
-		Status:          resp.Status,
-		Currency:        resp.Currency,
-		Cash:            resp.Cash,
-		PortfolioValue:  resp.PortfolioValue,
-		BuyingPower:     resp.BuyingPower,
-		CreatedAt:       resp.CreatedAt,
-	}, nil
+	return AccountFromAlpaca(resp), nil
 }
 
 // ListAccounts lists all accounts from Alpaca Broker API
 func (c *AlpacaClient) ListAccounts(ctx context.Context) ([]*account.Account, error) {
-	// TODO: implement actual Alpaca Broker API call GetAllAccounts
-	resp := make([]*alpaca.Account, 0)
-
-	var accounts []*account.Account
-	for _, acc := range resp {
-		accounts = append(accounts, &account.Account{
-			ID:              acc.ID,
-			AlpacaAccountID: acc.AccountNumber,
-			Status:          acc.Status,
-			Currency:        acc.Currency,
-			Cash:            acc.Cash,
-			PortfolioValue:  acc.PortfolioValue,
-			BuyingPower:     acc.BuyingPower,
-			CreatedAt:       acc.CreatedAt,
+	// TODO: switch to the Broker API GetAllAccounts call. Until then the
+	// trading API only exposes the account the credentials belong to.
+	resp, err := c.sdk(ctx).GetAccount()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	return []*account.Account{AccountFromAlpaca(resp)}, nil
+}
+
+func AccountFromAlpaca(acc *alpaca.Account) *account.Account {
+	return &account.Account{
+		ID:              acc.ID,
+		AlpacaAccountID: acc.AccountNumber,
+		Status:          acc.Status,
+		Currency:        acc.Currency,
+		Cash:            acc.Cash,
+		PortfolioValue:  acc.PortfolioValue,
+		BuyingPower:     acc.BuyingPower,
+		CreatedAt:       acc.CreatedAt,
+		Equity:          acc.Equity,
+		LastEquity:      acc.LastEquity,
+		PositionsValue:  acc.LongMarketValue.Add(acc.ShortMarketValue),
+	}
+}
+
+// GetPortfolioHistory returns the account's daily equity over period, such
+// as 1M or 1A, oldest first
+func (c *AlpacaClient) GetPortfolioHistory(ctx context.Context, accountID, period string) ([]*account.EquityPoint, error) {
+	resp, err := c.sdk(ctx).GetPortfolioHistory(alpaca.GetPortfolioHistoryRequest{
+		Period:    period,
+		TimeFrame: alpaca.Day1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get portfolio history: %w", err)
+	}
+
+	points := make([]*account.EquityPoint, 0, len(resp.Timestamp))
+	for i, ts := range resp.Timestamp {
+		if i >= len(resp.Equity) || i >= len(resp.ProfitLoss) {
+			break
+		}
+		points = append(points, &account.EquityPoint{
+			Time:       time.Unix(ts, 0),
+			Equity:     resp.Equity[i],
+			ProfitLoss: resp.ProfitLoss[i],
 		})
 	}
 
-	return accounts, nil
+	return points, nil
+}
+
+// ListTradingDays returns the days the market is open between start and end
+// inclusive, as midnight UTC on each date
+func (c *AlpacaClient) ListTradingDays(ctx context.Context, start, end time.Time) ([]time.Time, error) {
+	resp, err := c.sdk(ctx).GetCalendar(alpaca.GetCalendarRequest{Start: start, End: end})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get market calendar: %w", err)
+	}
+
+	days := make([]time.Time, 0, len(resp))
+	for _, d := range resp {
+		day, err := time.Parse("2006-01-02", d.Date)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calendar date %q: %w", d.Date, err)
+		}
+		days = append(days, day)
+	}
+
+	return days, nil
 }
 
 // CreateOrder creates a new order via Alpaca Broker API
 func (c *AlpacaClient) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.Order, error) {
-	resp, err := c.alpacaClient.PlaceOrder(alpaca.PlaceOrderRequest{
-		Symbol:         req.Symbol,
-		Qty:            req.Qty,
-		Side:           alpaca.Side(req.Side),
-		Type:           alpaca.OrderType(req.OrderType),
-		TimeInForce:    alpaca.TimeInForce(req.TimeInForce),
-		LimitPrice:     req.LimitPrice,
-		ExtendedHours:  false,
-		StopPrice:      req.StopPrice,
-		ClientOrderID:  req.AccountID,
-		OrderClass:     alpaca.OrderClass(req.OrderType),
-		PositionIntent: alpaca.PositionIntent(req.OrderType),
+	resp, err := c.sdk(ctx).PlaceOrder(alpaca.PlaceOrderRequest{
+		Symbol:        req.Symbol,
+		Qty:           req.Qty,
+		Notional:      req.Notional,
+		Side:          alpaca.Side(req.Side),
+		Type:          alpaca.OrderType(req.OrderType),
+		TimeInForce:   alpaca.TimeInForce(req.TimeInForce),
+		LimitPrice:    req.LimitPrice,
+		ExtendedHours: false,
+		StopPrice:     req.StopPrice,
+		ClientOrderID: req.ClientOrderID,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create order: %w", err)
+		return nil, fmt.Errorf("failed to create order: %w", classify(err))
 	}
 
-	return OrderFromAlpaca(resp), nil
+	o := OrderFromAlpaca(resp)
+	o.AccountID = req.AccountID
+	return o, nil
+}
+
+// classify marks API errors that mean the request was refused, so callers
+// can tell them apart from network errors, which leave its outcome unknown.
+func classify(err error) error {
+	var apiErr *alpaca.APIError
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch {
+	case apiErr.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %w", ErrNotFound, err)
+	case apiErr.StatusCode == http.StatusTooManyRequests:
+		// Still out of requests after the SDK's retries
+		return fmt.Errorf("%w, %w: %w", ErrRejected, ErrRateLimited, err)
+	case apiErr.StatusCode >= 400 && apiErr.StatusCode < 500:
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	default:
+		return err
+	}
 }
 
 func OrderTypeFromAlpaca(orderType alpaca.OrderType) order.OrderType {
@@ -164,16 +282,42 @@ func OrderStatusFromAlpaca(status string) order.OrderStatus {
 		return order.OrderStatusFilled
 	case "canceled":
 		return order.OrderStatusCanceled
+	case "done_for_day":
+		return order.OrderStatusDoneForDay
+	case "expired":
+		return order.OrderStatusExpired
+	case "replaced":
+		return order.OrderStatusReplaced
+	case "pending_cancel":
+		return order.OrderStatusPendingCancel
+	case "pending_replace":
+		return order.OrderStatusPendingReplace
+	case "pending_new":
+		return order.OrderStatusPendingNew
+	case "accepted":
+		return order.OrderStatusAccepted
+	case "accepted_for_bidding":
+		return order.OrderStatusAcceptedForBidding
+	case "stopped":
+		return order.OrderStatusStopped
 	case "rejected":
 		return order.OrderStatusRejected
+	case "suspended":
+		return order.OrderStatusSuspended
+	case "calculated":
+		return order.OrderStatusCalculated
+	case "held":
+		return order.OrderStatusHeld
 	default:
-		return order.OrderStatusNew
+		// An unknown status is kept as it is, and so is not open
+		return order.OrderStatus(status)
 	}
 }
 
 func OrderFromAlpaca(o *alpaca.Order) *order.Order {
 	return &order.Order{
 		ID:             o.ID,
+		AlpacaOrderID:  o.ID,
 		StakeOrderID:   o.ClientOrderID,
 		CreatedAt:      o.CreatedAt,
 		UpdatedAt:      o.UpdatedAt,
@@ -189,6 +333,7 @@ func OrderFromAlpaca(o *alpaca.Order) *order.Order {
 		TimeInForce:    TimeInForceFromAlpaca(o.TimeInForce),
 		Status:         OrderStatusFromAlpaca(o.Status),
 		Qty:            o.Qty,
+		Notional:       o.Notional,
 		FilledQty:      o.FilledQty,
 		FilledAvgPrice: o.FilledAvgPrice,
 		LimitPrice:     o.LimitPrice,
@@ -207,13 +352,12 @@ func OrderFromAlpaca(o *alpaca.Order) *order.Order {
 		// ExtendedHours:  resp.ExtendedHours,
 		// RatioQty:       resp.RatioQty,
 		// Legs:           resp.Legs,
-		// Notional:       resp.Notional,
 	}
 }
 
 // GetOrder retrieves order information from Alpaca Broker API
 func (c *AlpacaClient) GetOrder(ctx context.Context, orderID string) (*order.Order, error) {
-	resp, err := c.alpacaClient.GetOrder(orderID)
+	resp, err := c.sdk(ctx).GetOrder(orderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order: %w", err)
 	}
@@ -221,19 +365,222 @@ func (c *AlpacaClient) GetOrder(ctx context.Context, orderID string) (*order.Ord
 	return OrderFromAlpaca(resp), nil
 }
 
+// GetOrderByClientOrderID retrieves an order by the client order ID it was
+// submitted with. It returns ErrNotFound when the broker has no such order.
+func (c *AlpacaClient) GetOrderByClientOrderID(ctx context.Context, clientOrderID string) (*order.Order, error) {
+	resp, err := c.sdk(ctx).GetOrderByClientOrderID(clientOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order by client order ID: %w", classify(err))
+	}
+
+	return OrderFromAlpaca(resp), nil
+}
+
+// ListOrders lists orders for an account from Alpaca Broker API
+func (c *AlpacaClient) ListOrders(ctx context.Context, accountID string, req *order.ListOrdersRequest) ([]*order.Order, error) {
+	resp, err := c.sdk(ctx).GetOrders(alpaca.GetOrdersRequest{
+		Status:    string(req.Status),
+		Limit:     req.Limit,
+		After:     req.After,
+		Until:     req.Until,
+		Direction: "desc",
+		Symbols:   req.Symbols,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+
+	orders := make([]*order.Order, 0, len(resp))
+	for i := range resp {
+		o := OrderFromAlpaca(&resp[i])
+		o.AccountID = accountID
+		orders = append(orders, o)
+	}
+
+	return orders, nil
+}
+
+// ReplaceOrder changes the quantity, prices or time in force of an open order
+// via Alpaca Broker API. Alpaca cancels the original and returns the new order.
+func (c *AlpacaClient) ReplaceOrder(ctx context.Context, orderID string, req *order.ReplaceOrderRequest) (*order.Order, error) {
+	resp, err := c.sdk(ctx).ReplaceOrder(orderID, alpaca.ReplaceOrderRequest{
+		Qty:         req.Qty,
+		LimitPrice:  req.LimitPrice,
+		StopPrice:   req.StopPrice,
+		TimeInForce: alpaca.TimeInForce(req.TimeInForce),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to replace order: %w", err)
+	}
+
+	return OrderFromAlpaca(resp), nil
+}
+
 // CancelOrder cancels an order via Alpaca Broker API
 func (c *AlpacaClient) CancelOrder(ctx context.Context, orderID string) error {
-	return c.alpacaClient.CancelOrder(orderID)
+	return c.sdk(ctx).CancelOrder(orderID)
 }
 
 // ListPositions lists all positions for an account from Alpaca Broker API
 func (c *AlpacaClient) ListPositions(ctx context.Context, accountID string) ([]*position.Position, error) {
-	// TODO: Implement actual Alpaca Broker API call
-	// GET /v1/trading/accounts/{account_id}/positions
-	return nil, fmt.Errorf("not implemented")
+	resp, err := c.sdk(ctx).GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list positions: %w", err)
+	}
+
+	positions := make([]*position.Position, 0, len(resp))
+	for i := range resp {
+		p := PositionFromAlpaca(&resp[i])
+		p.AccountID = accountID
+		positions = append(positions, p)
+	}
+
+	return positions, nil
 }
 
-// StreamEvents streams SSE events from Alpaca Broker API
+// ClosePosition liquidates the whole position in symbol at market via Alpaca
+// Broker API and returns the closing order.
+func (c *AlpacaClient) ClosePosition(ctx context.Context, accountID, symbol string) (*order.Order, error) {
+	resp, err := c.sdk(ctx).ClosePosition(symbol, alpaca.ClosePositionRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to close position: %w", err)
+	}
+
+	o := OrderFromAlpaca(resp)
+	o.AccountID = accountID
+	return o, nil
+}
+
+func PositionFromAlpaca(p *alpaca.Position) *position.Position {
+	// Alpaca leaves the price-dependent fields empty when there is no quote
+	optional := func(d *decimal.Decimal) decimal.Decimal {
+		if d == nil {
+			return decimal.Zero
+		}
+		return *d
+	}
+
+	return &position.Position{
+		Symbol:         p.Symbol,
+		Qty:            p.Qty,
+		AvgEntryPrice:  p.AvgEntryPrice,
+		CurrentPrice:   optional(p.CurrentPrice),
+		MarketValue:    optional(p.MarketValue),
+		CostBasis:      p.CostBasis,
+		UnrealizedPL:   optional(p.UnrealizedPL),
+		UnrealizedPLPC: optional(p.UnrealizedPLPC),
+	}
+}
+
+// ListWatchlists lists all watchlists for an account from Alpaca Broker API
+func (c *AlpacaClient) ListWatchlists(ctx context.Context, accountID string) ([]*watchlist.Watchlist, error) {
+	resp, err := c.sdk(ctx).GetWatchlists()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list watchlists: %w", err)
+	}
+
+	watchlists := make([]*watchlist.Watchlist, 0, len(resp))
+	for i := range resp {
+		watchlists = append(watchlists, WatchlistFromAlpaca(&resp[i]))
+	}
+
+	return watchlists, nil
+}
+
+// GetWatchlist retrieves a watchlist and its symbols from Alpaca Broker API
+func (c *AlpacaClient) GetWatchlist(ctx context.Context, watchlistID string) (*watchlist.Watchlist, error) {
+	resp, err := c.sdk(ctx).GetWatchlist(watchlistID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchlist: %w", err)
+	}
+
+	return WatchlistFromAlpaca(resp), nil
+}
+
+// CreateWatchlist creates a new watchlist via Alpaca Broker API
+func (c *AlpacaClient) CreateWatchlist(ctx context.Context, req *watchlist.CreateWatchlistRequest) (*watchlist.Watchlist, error) {
+	resp, err := c.sdk(ctx).CreateWatchlist(alpaca.CreateWatchlistRequest{
+		Name:    req.Name,
+		Symbols: req.Symbols,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watchlist: %w", err)
+	}
+
+	return WatchlistFromAlpaca(resp), nil
+}
+
+// UpdateWatchlist replaces the name and symbols of a watchlist via Alpaca Broker API
+func (c *AlpacaClient) UpdateWatchlist(ctx context.Context, watchlistID string, req *watchlist.UpdateWatchlistRequest) (*watchlist.Watchlist, error) {
+	resp, err := c.sdk(ctx).UpdateWatchlist(watchlistID, alpaca.UpdateWatchlistRequest{
+		Name:    req.Name,
+		Symbols: req.Symbols,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to update watchlist: %w", err)
+	}
+
+	return WatchlistFromAlpaca(resp), nil
+}
+
+// AddWatchlistSymbol appends a symbol to a watchlist via Alpaca Broker API
+func (c *AlpacaClient) AddWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (*watchlist.Watchlist, error) {
+	resp, err := c.sdk(ctx).AddSymbolToWatchlist(watchlistID, alpaca.AddSymbolToWatchlistRequest{
+		Symbol: symbol,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add symbol to watchlist: %w", err)
+	}
+
+	return WatchlistFromAlpaca(resp), nil
+}
+
+// RemoveWatchlistSymbol removes a symbol from a watchlist via Alpaca Broker API
+func (c *AlpacaClient) RemoveWatchlistSymbol(ctx context.Context, watchlistID, symbol string) error {
+	err := c.sdk(ctx).RemoveSymbolFromWatchlist(watchlistID, alpaca.RemoveSymbolFromWatchlistRequest{
+		Symbol: symbol,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove symbol from watchlist: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteWatchlist deletes a watchlist via Alpaca Broker API
+func (c *AlpacaClient) DeleteWatchlist(ctx context.Context, watchlistID string) error {
+	if err := c.sdk(ctx).DeleteWatchlist(watchlistID); err != nil {
+		return fmt.Errorf("failed to delete watchlist: %w", err)
+	}
+
+	return nil
+}
+
+func WatchlistFromAlpaca(w *alpaca.Watchlist) *watchlist.Watchlist {
+	symbols := make([]string, 0, len(w.Assets))
+	for _, asset := range w.Assets {
+		symbols = append(symbols, asset.Symbol)
+	}
+
+	// Alpaca returns watchlist timestamps as strings; a zero time is fine
+	// if they are ever missing or malformed.
+	createdAt, _ := time.Parse(time.RFC3339Nano, w.CreatedAt)
+	updatedAt, _ := time.Parse(time.RFC3339Nano, w.UpdatedAt)
+
+	return &watchlist.Watchlist{
+		ID:        w.ID,
+		AccountID: w.AccountID,
+		Name:      w.Name,
+		Symbols:   symbols,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}
+}
+
+// StreamEvents streams trade updates from the Alpaca API until ctx is done
+// or the stream breaks. Either way the reason is sent on the error channel
+// before both channels are closed, and it is up to the caller to open a new
+// stream.
 func (c *AlpacaClient) StreamEvents(ctx context.Context, accountID string) (<-chan Event, <-chan error) {
 	eventCh := make(chan Event)
 	errCh := make(chan error, 1)
@@ -242,15 +589,98 @@ func (c *AlpacaClient) StreamEvents(ctx context.Context, accountID string) (<-ch
 		defer close(eventCh)
 		defer close(errCh)
 
-		alpaca.StreamTradeUpdatesInBackground(context.Background(), func(tu alpaca.TradeUpdate) {
-			eventCh <- TradeUpdateEvent{
-				Order: OrderFromAlpaca(&tu.Order),
+		// Trade updates are for the account the credentials belong to
+		if accountID == "" {
+			acc, err := c.sdk(ctx).GetAccount()
+			if err != nil {
+				errCh <- fmt.Errorf("failed to resolve streaming account: %w", err)
+				return
 			}
-		})
+			accountID = acc.ID
+		}
+
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
 
-		<-ctx.Done()
-		errCh <- ctx.Err()
+		// The handler runs on this goroutine, so nothing is sent once the
+		// stream has returned
+		var handleErr error
+		err := c.sdk(ctx).StreamTradeUpdates(streamCtx, func(tu alpaca.TradeUpdate) {
+			payload, err := json.Marshal(tu)
+			if err != nil {
+				handleErr = fmt.Errorf("failed to encode trade update: %w", err)
+				cancel()
+				return
+			}
+			select {
+			case eventCh <- TradeUpdateEventFromAlpaca(tu, EventMeta{
+				ID:         tu.EventID,
+				AccountID:  accountID,
+				ReceivedAt: time.Now(),
+				Payload:    payload,
+			}):
+			case <-streamCtx.Done():
+			}
+		}, alpaca.StreamTradeUpdatesRequest{})
+
+		switch {
+		case handleErr != nil:
+			errCh <- handleErr
+		case ctx.Err() != nil:
+			errCh <- ctx.Err()
+		case err != nil:
+			errCh <- fmt.Errorf("trade update stream failed: %w", err)
+		default:
+			errCh <- errors.New("trade update stream ended")
+		}
 	}()
 
 	return eventCh, errCh
 }
+
+func TradeUpdateEventFromAlpaca(tu alpaca.TradeUpdate, meta EventMeta) TradeUpdateEvent {
+	o := OrderFromAlpaca(&tu.Order)
+	o.AccountID = meta.AccountID
+
+	executedAt := tu.Timestamp
+	if executedAt == nil && !tu.At.IsZero() {
+		executedAt = &tu.At
+	}
+
+	return TradeUpdateEvent{
+		EventMeta:   meta,
+		Event:       TradeEventKind(tu.Event),
+		Order:       o,
+		ExecutionID: tu.ExecutionID,
+		Price:       tu.Price,
+		Qty:         tu.Qty,
+		PositionQty: tu.PositionQty,
+		ExecutedAt:  executedAt,
+	}
+}
+
+// DecodeEvent rebuilds an event from the payload it was received with, as
+// stored in the event log.
+func DecodeEvent(eventType EventType, meta EventMeta) (Event, error) {
+	switch eventType {
+	case EventTypeTradeUpdate:
+		var tu alpaca.TradeUpdate
+		if err := json.Unmarshal(meta.Payload, &tu); err != nil {
+			return nil, fmt.Errorf("failed to decode trade update %s: %w", meta.ID, err)
+		}
+		return TradeUpdateEventFromAlpaca(tu, meta), nil
+
+	case EventTypeAccountUpdate:
+		var acc alpaca.Account
+		if err := json.Unmarshal(meta.Payload, &acc); err != nil {
+			return nil, fmt.Errorf("failed to decode account update %s: %w", meta.ID, err)
+		}
+		return AccountUpdateEvent{
+			EventMeta: meta,
+			Account:   AccountFromAlpaca(&acc),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown event type %q", eventType)
+	}
+}
@@ -2,10 +2,28 @@ package broker
 
 import (
 	"context"
+	"errors"
+	"time"
+
+	"github.com/shopspring/decimal"
 
 	"github.com/revrost/pony/pkg/account"
 	"github.com/revrost/pony/pkg/order"
 	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/watchlist"
+)
+
+var (
+	// ErrNotFound means the broker has no such object
+	ErrNotFound = errors.New("not found at broker")
+
+	// ErrRejected means the broker received the request and refused it, so
+	// it had no effect. Any other error may have left the request applied.
+	ErrRejected = errors.New("rejected by broker")
+
+	// ErrRateLimited means the broker refused the request because too many
+	// were made. It always comes with ErrRejected.
+	ErrRateLimited = errors.New("rate limited")
 )
 
 // Client defines the interface for Alpaca Broker API interactions
@@ -13,14 +31,31 @@ type Client interface {
 	// Account operations
 	GetAccount(ctx context.Context, accountID string) (*account.Account, error)
 	ListAccounts(ctx context.Context) ([]*account.Account, error)
+	GetPortfolioHistory(ctx context.Context, accountID, period string) ([]*account.EquityPoint, error)
+
+	// Market calendar
+	ListTradingDays(ctx context.Context, start, end time.Time) ([]time.Time, error)
 
 	// Order operations
 	CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.Order, error)
 	GetOrder(ctx context.Context, orderID string) (*order.Order, error)
+	GetOrderByClientOrderID(ctx context.Context, clientOrderID string) (*order.Order, error)
+	ListOrders(ctx context.Context, accountID string, req *order.ListOrdersRequest) ([]*order.Order, error)
+	ReplaceOrder(ctx context.Context, orderID string, req *order.ReplaceOrderRequest) (*order.Order, error)
 	CancelOrder(ctx context.Context, orderID string) error
 
 	// Position operations
 	ListPositions(ctx context.Context, accountID string) ([]*position.Position, error)
+	ClosePosition(ctx context.Context, accountID, symbol string) (*order.Order, error)
+
+	// Watchlist operations
+	ListWatchlists(ctx context.Context, accountID string) ([]*watchlist.Watchlist, error)
+	GetWatchlist(ctx context.Context, watchlistID string) (*watchlist.Watchlist, error)
+	CreateWatchlist(ctx context.Context, req *watchlist.CreateWatchlistRequest) (*watchlist.Watchlist, error)
+	UpdateWatchlist(ctx context.Context, watchlistID string, req *watchlist.UpdateWatchlistRequest) (*watchlist.Watchlist, error)
+	AddWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (*watchlist.Watchlist, error)
+	RemoveWatchlistSymbol(ctx context.Context, watchlistID, symbol string) error
+	DeleteWatchlist(ctx context.Context, watchlistID string) error
 
 	// Event streaming
 	StreamEvents(ctx context.Context, accountID string) (<-chan Event, <-chan error)
@@ -36,10 +71,88 @@ const (
 
 type Event interface {
 	Type() EventType
+	Metadata() EventMeta
+}
+
+// EventMeta is carried by every event so it can be persisted and replayed
+type EventMeta struct {
+	// ID is the broker's event ID, unique across the event stream
+	ID         string
+	AccountID  string
+	ReceivedAt time.Time
+	// Payload is the raw event as received from the broker
+	Payload []byte
+}
+
+func (m EventMeta) Metadata() EventMeta {
+	return m
 }
 
+// TradeEventKind is what happened to the order in a trade update
+type TradeEventKind string
+
+const (
+	TradeEventNew                  TradeEventKind = "new"
+	TradeEventFill                 TradeEventKind = "fill"
+	TradeEventPartialFill          TradeEventKind = "partial_fill"
+	TradeEventCanceled             TradeEventKind = "canceled"
+	TradeEventExpired              TradeEventKind = "expired"
+	TradeEventReplaced             TradeEventKind = "replaced"
+	TradeEventRejected             TradeEventKind = "rejected"
+	TradeEventDoneForDay           TradeEventKind = "done_for_day"
+	TradeEventPendingNew           TradeEventKind = "pending_new"
+	TradeEventPendingCancel        TradeEventKind = "pending_cancel"
+	TradeEventPendingReplace       TradeEventKind = "pending_replace"
+	TradeEventOrderCancelRejected  TradeEventKind = "order_cancel_rejected"
+	TradeEventOrderReplaceRejected TradeEventKind = "order_replace_rejected"
+)
+
+// TradeUpdateEvent carries the order snapshot after the update. For fill and
+// partial_fill events ExecutionID, Price, Qty and ExecutedAt describe the
+// individual execution.
 type TradeUpdateEvent struct {
-	Order *order.Order
+	EventMeta
+	Event       TradeEventKind
+	Order       *order.Order
+	ExecutionID string
+	Price       *decimal.Decimal
+	Qty         *decimal.Decimal
+	PositionQty *decimal.Decimal
+	ExecutedAt  *time.Time
+}
+
+// IsFill reports whether the update is a fill or partial fill
+func (e TradeUpdateEvent) IsFill() bool {
+	return e.Event == TradeEventFill || e.Event == TradeEventPartialFill
+}
+
+// Execution returns the execution carried by a fill event, or nil if the
+// update is not a fill or is missing execution details.
+func (e TradeUpdateEvent) Execution() *order.Execution {
+	if !e.IsFill() || e.Price == nil || e.Qty == nil {
+		return nil
+	}
+
+	id := e.ExecutionID
+	if id == "" {
+		id = e.ID
+	}
+	executedAt := e.ReceivedAt
+	if e.ExecutedAt != nil {
+		executedAt = *e.ExecutedAt
+	}
+
+	return &order.Execution{
+		ID:          id,
+		OrderID:     e.Order.ID,
+		AccountID:   e.Order.AccountID,
+		Symbol:      e.Order.Symbol,
+		Side:        e.Order.Side,
+		Qty:         *e.Qty,
+		Price:       *e.Price,
+		PositionQty: e.PositionQty,
+		ExecutedAt:  executedAt,
+	}
 }
 
 func (e TradeUpdateEvent) Type() EventType {
@@ -47,6 +160,7 @@ func (e TradeUpdateEvent) Type() EventType {
 }
 
 type AccountUpdateEvent struct {
+	EventMeta
 	Account *account.Account
 }
 
@@ -0,0 +1,75 @@
+package changes
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/revrost/pony/pkg/store"
+)
+
+// Channel is the Postgres notification channel the change triggers use
+const Channel = "pony_changes"
+
+// debounce is how long Watch waits for more notifications before handing
+// over what it has, so a burst of writes causes a single refresh
+const debounce = 250 * time.Millisecond
+
+type Table string
+
+const (
+	TableOrders    Table = "orders"
+	TablePositions Table = "positions"
+	TableAccounts  Table = "accounts"
+)
+
+// Change says rows of one table changed for one account. The zero Change
+// means anything may have changed, for when notifications were missed.
+type Change struct {
+	Table     Table  `json:"table"`
+	AccountID string `json:"account_id"`
+}
+
+// Everything reports whether the change stands for any change at all
+func (c Change) Everything() bool {
+	return c == Change{}
+}
+
+// Watch passes the changes committed to the database, by this or any other
+// process, to handle until ctx is done. Each call gets the distinct changes
+// seen since the previous one. On SQLite there is no change feed, so Watch
+// returns at once.
+func Watch(ctx context.Context, conn *store.DB, handle func([]Change)) error {
+	payloads, err := conn.Listen(ctx, Channel)
+	if err != nil {
+		return err
+	}
+
+	var pending []Change
+	var flush <-chan time.Time
+	for {
+		select {
+		case payload, ok := <-payloads:
+			if !ok {
+				return nil
+			}
+			var c Change
+			if payload != "" {
+				// A payload we cannot read still says something changed
+				if err := json.Unmarshal([]byte(payload), &c); err != nil {
+					c = Change{}
+				}
+			}
+			if !slices.Contains(pending, c) {
+				pending = append(pending, c)
+			}
+			if flush == nil {
+				flush = time.After(debounce)
+			}
+		case <-flush:
+			handle(pending)
+			pending, flush = nil, nil
+		}
+	}
+}
@@ -2,16 +2,83 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
+
+	"github.com/revrost/pony/pkg/api"
+	"github.com/revrost/pony/pkg/logging"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/snapshot"
+	"github.com/revrost/pony/pkg/strategy"
+	"github.com/revrost/pony/pkg/taxlot"
+	"github.com/revrost/pony/pkg/tracing"
+	"github.com/revrost/pony/pkg/worker"
 )
 
 type Config struct {
-	DatabaseURL      string
-	AlpacaAPIKey     string
-	AlpacaAPISecret  string
-	AlpacaBaseURL    string
+	DatabaseURL       string
+	AlpacaAPIKey      string
+	AlpacaAPISecret   string
+	AlpacaBaseURL     string
+	ReconcileInterval time.Duration
+
+	// SnapshotInterval is how often today's account snapshots are refreshed
+	SnapshotInterval time.Duration
+
+	// Operator is recorded in the audit log for every trading action. When
+	// PONY_OPERATOR is unset the OS user name is used.
+	Operator string
+
+	// TaxLotMethod picks which lots a sale disposes of first
+	TaxLotMethod taxlot.Method
+
+	// UseEventWorker leaves consuming broker events to pony-worker, so the
+	// TUI does not stream them itself
+	UseEventWorker bool
+
+	// WorkerHealthAddr is where pony-worker serves its health endpoint
+	WorkerHealthAddr string
+
+	// APIAddr is where pony serve listens, and APIToken the bearer token
+	// its clients must send. pony serve makes up a token when none is set.
+	APIAddr  string
+	APIToken string
+
+	// MetricsAddr is where the TUI serves Prometheus metrics; empty means
+	// it does not. pony-worker and pony serve always serve them.
+	MetricsAddr string
+
+	// LogFile is where the structured log is written, rotating as it
+	// grows, and LogLevel the least severe level written. Broker requests
+	// and responses are logged at debug.
+	LogFile  string
+	LogLevel slog.Level
+
+	// TraceExporter is where trace spans go, if anywhere: over OTLP to the
+	// collector at OTEL_EXPORTER_OTLP_ENDPOINT, or to TraceFile
+	TraceExporter tracing.Exporter
+	TraceFile     string
+
+	// Strategies are the built-in strategies the TUI can run, and
+	// StrategyVenue where they trade: the simulated broker unless set to
+	// alpaca
+	Strategies    []strategy.Spec
+	StrategyVenue strategy.Venue
+
+	// MarketDataFeed is the market data strategies get, iex or sip, and
+	// MarketDataURL overrides where it is streamed from
+	MarketDataFeed string
+	MarketDataURL  string
+
+	// AlertWebhookURL is where alerts for the webhook channel are posted,
+	// and AlertCommand the shell command run for the command channel
+	AlertWebhookURL string
+	AlertCommand    string
 }
 
 func Load() (*Config, error) {
@@ -19,10 +86,25 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		DatabaseURL:     os.Getenv("DATABASE_URL"),
-		AlpacaAPIKey:    os.Getenv("ALPACA_API_KEY"),
-		AlpacaAPISecret: os.Getenv("ALPACA_API_SECRET"),
-		AlpacaBaseURL:   os.Getenv("ALPACA_BASE_URL"),
+		DatabaseURL:       os.Getenv("DATABASE_URL"),
+		AlpacaAPIKey:      os.Getenv("ALPACA_API_KEY"),
+		AlpacaAPISecret:   os.Getenv("ALPACA_API_SECRET"),
+		AlpacaBaseURL:     os.Getenv("ALPACA_BASE_URL"),
+		ReconcileInterval: time.Minute,
+		SnapshotInterval:  snapshot.DefaultInterval,
+		Operator:          os.Getenv("PONY_OPERATOR"),
+		TaxLotMethod:      taxlot.DefaultMethod,
+		WorkerHealthAddr:  worker.DefaultHealthAddr,
+		APIAddr:           api.DefaultAddr,
+		APIToken:          os.Getenv("PONY_API_TOKEN"),
+		MetricsAddr:       os.Getenv("METRICS_ADDR"),
+		LogFile:           logging.DefaultPath(),
+		LogLevel:          slog.LevelInfo,
+		TraceFile:         tracing.DefaultFile(),
+		StrategyVenue:     strategy.VenueSim,
+		MarketDataFeed:    marketdata.DefaultFeed,
+		MarketDataURL:     os.Getenv("MARKET_DATA_URL"),
+		AlertCommand:      os.Getenv("ALERT_COMMAND"),
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -41,5 +123,100 @@ func Load() (*Config, error) {
 		cfg.AlpacaBaseURL = "https://broker-api.sandbox.alpaca.markets"
 	}
 
+	if v := os.Getenv("RECONCILE_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("RECONCILE_INTERVAL must be a positive duration like 30s or 5m")
+		}
+		cfg.ReconcileInterval = interval
+	}
+
+	if v := os.Getenv("SNAPSHOT_INTERVAL"); v != "" {
+		interval, err := time.ParseDuration(v)
+		if err != nil || interval <= 0 {
+			return nil, fmt.Errorf("SNAPSHOT_INTERVAL must be a positive duration like 15m or 1h")
+		}
+		cfg.SnapshotInterval = interval
+	}
+
+	if v := os.Getenv("TAX_LOT_METHOD"); v != "" {
+		method, err := taxlot.ParseMethod(v)
+		if err != nil {
+			return nil, fmt.Errorf("TAX_LOT_METHOD: %w", err)
+		}
+		cfg.TaxLotMethod = method
+	}
+
+	if v := os.Getenv("USE_EVENT_WORKER"); v != "" {
+		use, err := strconv.ParseBool(v)
+		if err != nil {
+			return nil, fmt.Errorf("USE_EVENT_WORKER must be true or false")
+		}
+		cfg.UseEventWorker = use
+	}
+
+	if v := os.Getenv("WORKER_HEALTH_ADDR"); v != "" {
+		cfg.WorkerHealthAddr = v
+	}
+
+	if v := os.Getenv("API_ADDR"); v != "" {
+		cfg.APIAddr = v
+	}
+
+	if v := os.Getenv("LOG_FILE"); v != "" {
+		cfg.LogFile = v
+	}
+
+	if v := os.Getenv("LOG_LEVEL"); v != "" {
+		level, err := logging.ParseLevel(v)
+		if err != nil {
+			return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+		}
+		cfg.LogLevel = level
+	}
+
+	if v := os.Getenv("TRACE_EXPORTER"); v != "" {
+		exporter, err := tracing.ParseExporter(v)
+		if err != nil {
+			return nil, fmt.Errorf("TRACE_EXPORTER: %w", err)
+		}
+		cfg.TraceExporter = exporter
+	}
+
+	if v := os.Getenv("TRACE_FILE"); v != "" {
+		cfg.TraceFile = v
+	}
+
+	if v := os.Getenv("STRATEGIES"); v != "" {
+		specs, err := strategy.ParseSpecs(v)
+		if err != nil {
+			return nil, fmt.Errorf("STRATEGIES: %w", err)
+		}
+		cfg.Strategies = specs
+	}
+
+	if v := os.Getenv("STRATEGY_BROKER"); v != "" {
+		venue, err := strategy.ParseVenue(v)
+		if err != nil {
+			return nil, fmt.Errorf("STRATEGY_BROKER: %w", err)
+		}
+		cfg.StrategyVenue = venue
+	}
+
+	if v := os.Getenv("MARKET_DATA_FEED"); v != "" {
+		if v != "iex" && v != "sip" {
+			return nil, fmt.Errorf("MARKET_DATA_FEED must be iex or sip")
+		}
+		cfg.MarketDataFeed = v
+	}
+
+	if v := os.Getenv("ALERT_WEBHOOK_URL"); v != "" {
+		u, err := url.Parse(v)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("ALERT_WEBHOOK_URL must be an http or https URL")
+		}
+		cfg.AlertWebhookURL = v
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,160 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: account_snapshots.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createMissingAccountSnapshot = `-- name: CreateMissingAccountSnapshot :execrows
+INSERT INTO account_snapshots (
+    account_id, trading_date, equity, cash, buying_power, positions_value,
+    day_pl, source
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (account_id, trading_date) DO NOTHING
+`
+
+type CreateMissingAccountSnapshotParams struct {
+	AccountID      string              `json:"account_id"`
+	TradingDate    time.Time           `json:"trading_date"`
+	Equity         decimal.Decimal     `json:"equity"`
+	Cash           decimal.NullDecimal `json:"cash"`
+	BuyingPower    decimal.NullDecimal `json:"buying_power"`
+	PositionsValue decimal.NullDecimal `json:"positions_value"`
+	DayPl          decimal.Decimal     `json:"day_pl"`
+	Source         string              `json:"source"`
+}
+
+// Backfilled snapshots never replace a stored day.
+func (q *Queries) CreateMissingAccountSnapshot(ctx context.Context, arg CreateMissingAccountSnapshotParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, createMissingAccountSnapshot,
+		arg.AccountID,
+		arg.TradingDate,
+		arg.Equity,
+		arg.Cash,
+		arg.BuyingPower,
+		arg.PositionsValue,
+		arg.DayPl,
+		arg.Source,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const listAccountSnapshots = `-- name: ListAccountSnapshots :many
+SELECT id, account_id, trading_date, equity, cash, buying_power, positions_value, day_pl, source, created_at, updated_at FROM account_snapshots
+WHERE account_id = $1
+  AND trading_date >= $2::date
+  AND trading_date <= $3::date
+ORDER BY trading_date
+`
+
+type ListAccountSnapshotsParams struct {
+	AccountID string    `json:"account_id"`
+	DateFrom  time.Time `json:"date_from"`
+	DateUntil time.Time `json:"date_until"`
+}
+
+// Snapshots for trading days in [date_from, date_until], oldest first.
+func (q *Queries) ListAccountSnapshots(ctx context.Context, arg ListAccountSnapshotsParams) ([]AccountSnapshot, error) {
+	rows, err := q.db.QueryContext(ctx, listAccountSnapshots, arg.AccountID, arg.DateFrom, arg.DateUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AccountSnapshot{}
+	for rows.Next() {
+		var i AccountSnapshot
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.TradingDate,
+			&i.Equity,
+			&i.Cash,
+			&i.BuyingPower,
+			&i.PositionsValue,
+			&i.DayPl,
+			&i.Source,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertAccountSnapshot = `-- name: UpsertAccountSnapshot :one
+INSERT INTO account_snapshots (
+    account_id, trading_date, equity, cash, buying_power, positions_value,
+    day_pl, source
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (account_id, trading_date) DO UPDATE SET
+    equity = EXCLUDED.equity,
+    cash = EXCLUDED.cash,
+    buying_power = EXCLUDED.buying_power,
+    positions_value = EXCLUDED.positions_value,
+    day_pl = EXCLUDED.day_pl,
+    source = EXCLUDED.source,
+    updated_at = NOW()
+RETURNING id, account_id, trading_date, equity, cash, buying_power, positions_value, day_pl, source, created_at, updated_at
+`
+
+type UpsertAccountSnapshotParams struct {
+	AccountID      string              `json:"account_id"`
+	TradingDate    time.Time           `json:"trading_date"`
+	Equity         decimal.Decimal     `json:"equity"`
+	Cash           decimal.NullDecimal `json:"cash"`
+	BuyingPower    decimal.NullDecimal `json:"buying_power"`
+	PositionsValue decimal.NullDecimal `json:"positions_value"`
+	DayPl          decimal.Decimal     `json:"day_pl"`
+	Source         string              `json:"source"`
+}
+
+// Live snapshots replace whatever was stored for the day.
+func (q *Queries) UpsertAccountSnapshot(ctx context.Context, arg UpsertAccountSnapshotParams) (AccountSnapshot, error) {
+	row := q.db.QueryRowContext(ctx, upsertAccountSnapshot,
+		arg.AccountID,
+		arg.TradingDate,
+		arg.Equity,
+		arg.Cash,
+		arg.BuyingPower,
+		arg.PositionsValue,
+		arg.DayPl,
+		arg.Source,
+	)
+	var i AccountSnapshot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.TradingDate,
+		&i.Equity,
+		&i.Cash,
+		&i.BuyingPower,
+		&i.PositionsValue,
+		&i.DayPl,
+		&i.Source,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,110 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: audit.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const createAuditEntry = `-- name: CreateAuditEntry :one
+INSERT INTO audit_log (
+    operator, host, account_id, action, request, response, error
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7
+) RETURNING id, operator, host, account_id, action, request, response, error, created_at
+`
+
+type CreateAuditEntryParams struct {
+	Operator  string          `json:"operator"`
+	Host      string          `json:"host"`
+	AccountID string          `json:"account_id"`
+	Action    string          `json:"action"`
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response"`
+	Error     string          `json:"error"`
+}
+
+func (q *Queries) CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditLog, error) {
+	row := q.db.QueryRowContext(ctx, createAuditEntry,
+		arg.Operator,
+		arg.Host,
+		arg.AccountID,
+		arg.Action,
+		arg.Request,
+		arg.Response,
+		arg.Error,
+	)
+	var i AuditLog
+	err := row.Scan(
+		&i.ID,
+		&i.Operator,
+		&i.Host,
+		&i.AccountID,
+		&i.Action,
+		&i.Request,
+		&i.Response,
+		&i.Error,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAuditEntries = `-- name: ListAuditEntries :many
+SELECT id, operator, host, account_id, action, request, response, error, created_at FROM audit_log
+WHERE ($1::text = '' OR account_id = $1)
+  AND ($2::text = '' OR action = $2)
+  AND created_at >= $3
+ORDER BY id DESC
+LIMIT $4
+`
+
+type ListAuditEntriesParams struct {
+	AccountID string    `json:"account_id"`
+	Action    string    `json:"action"`
+	Since     time.Time `json:"since"`
+	RowLimit  int32     `json:"row_limit"`
+}
+
+// Empty account_id or action match every entry.
+func (q *Queries) ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, listAuditEntries,
+		arg.AccountID,
+		arg.Action,
+		arg.Since,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []AuditLog{}
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Operator,
+			&i.Host,
+			&i.AccountID,
+			&i.Action,
+			&i.Request,
+			&i.Response,
+			&i.Error,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,108 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: bars.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const listBars = `-- name: ListBars :many
+SELECT symbol, timeframe, started_at, open_price, high_price, low_price, close_price, volume FROM bars
+WHERE symbol = $1
+  AND timeframe = $2
+  AND started_at >= $3
+  AND started_at < $4
+ORDER BY started_at
+`
+
+type ListBarsParams struct {
+	Symbol       string    `json:"symbol"`
+	Timeframe    string    `json:"timeframe"`
+	StartedFrom  time.Time `json:"started_from"`
+	StartedUntil time.Time `json:"started_until"`
+}
+
+// Bars that started in [started_from, started_until), oldest first.
+func (q *Queries) ListBars(ctx context.Context, arg ListBarsParams) ([]Bar, error) {
+	rows, err := q.db.QueryContext(ctx, listBars,
+		arg.Symbol,
+		arg.Timeframe,
+		arg.StartedFrom,
+		arg.StartedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Bar{}
+	for rows.Next() {
+		var i Bar
+		if err := rows.Scan(
+			&i.Symbol,
+			&i.Timeframe,
+			&i.StartedAt,
+			&i.OpenPrice,
+			&i.HighPrice,
+			&i.LowPrice,
+			&i.ClosePrice,
+			&i.Volume,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertBar = `-- name: UpsertBar :exec
+INSERT INTO bars (
+    symbol, timeframe, started_at, open_price, high_price, low_price,
+    close_price, volume
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8
+)
+ON CONFLICT (symbol, timeframe, started_at) DO UPDATE SET
+    open_price = EXCLUDED.open_price,
+    high_price = EXCLUDED.high_price,
+    low_price = EXCLUDED.low_price,
+    close_price = EXCLUDED.close_price,
+    volume = EXCLUDED.volume
+`
+
+type UpsertBarParams struct {
+	Symbol     string          `json:"symbol"`
+	Timeframe  string          `json:"timeframe"`
+	StartedAt  time.Time       `json:"started_at"`
+	OpenPrice  decimal.Decimal `json:"open_price"`
+	HighPrice  decimal.Decimal `json:"high_price"`
+	LowPrice   decimal.Decimal `json:"low_price"`
+	ClosePrice decimal.Decimal `json:"close_price"`
+	Volume     int64           `json:"volume"`
+}
+
+// Imported and fetched bars replace whatever was stored for the period.
+func (q *Queries) UpsertBar(ctx context.Context, arg UpsertBarParams) error {
+	_, err := q.db.ExecContext(ctx, upsertBar,
+		arg.Symbol,
+		arg.Timeframe,
+		arg.StartedAt,
+		arg.OpenPrice,
+		arg.HighPrice,
+		arg.LowPrice,
+		arg.ClosePrice,
+		arg.Volume,
+	)
+	return err
+}
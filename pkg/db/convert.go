@@ -0,0 +1,525 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/watchlist"
+)
+
+// Mapping between sqlc row types and the domain structs. This file is not
+// generated, so it survives `make sqlc`.
+
+func ToAccount(a Account) *account.Account {
+	return &account.Account{
+		ID:              a.ID,
+		AlpacaAccountID: a.AlpacaAccountID,
+		Status:          a.Status,
+		Currency:        a.Currency,
+		Cash:            a.Cash,
+		PortfolioValue:  a.PortfolioValue,
+		BuyingPower:     a.BuyingPower,
+		CreatedAt:       a.CreatedAt,
+	}
+}
+
+func ToAccounts(rows []Account) []*account.Account {
+	accounts := make([]*account.Account, 0, len(rows))
+	for _, row := range rows {
+		accounts = append(accounts, ToAccount(row))
+	}
+	return accounts
+}
+
+func NewCreateAccountParams(a *account.Account) CreateAccountParams {
+	return CreateAccountParams{
+		ID:              a.ID,
+		AlpacaAccountID: a.AlpacaAccountID,
+		Status:          a.Status,
+		Currency:        a.Currency,
+		Cash:            a.Cash,
+		PortfolioValue:  a.PortfolioValue,
+		BuyingPower:     a.BuyingPower,
+	}
+}
+
+func NewUpdateAccountParams(a *account.Account) UpdateAccountParams {
+	return UpdateAccountParams{
+		ID:             a.ID,
+		Status:         a.Status,
+		Cash:           a.Cash,
+		PortfolioValue: a.PortfolioValue,
+		BuyingPower:    a.BuyingPower,
+	}
+}
+
+func ToAccountSnapshot(s AccountSnapshot) *account.Snapshot {
+	return &account.Snapshot{
+		AccountID:      s.AccountID,
+		Date:           s.TradingDate,
+		Equity:         s.Equity,
+		Cash:           decimalPtr(s.Cash),
+		BuyingPower:    decimalPtr(s.BuyingPower),
+		PositionsValue: decimalPtr(s.PositionsValue),
+		DayPL:          s.DayPl,
+		Source:         account.SnapshotSource(s.Source),
+	}
+}
+
+func ToAccountSnapshots(rows []AccountSnapshot) []*account.Snapshot {
+	snapshots := make([]*account.Snapshot, 0, len(rows))
+	for _, row := range rows {
+		snapshots = append(snapshots, ToAccountSnapshot(row))
+	}
+	return snapshots
+}
+
+func NewUpsertAccountSnapshotParams(s *account.Snapshot) UpsertAccountSnapshotParams {
+	return UpsertAccountSnapshotParams{
+		AccountID:      s.AccountID,
+		TradingDate:    s.Date,
+		Equity:         s.Equity,
+		Cash:           nullDecimal(s.Cash),
+		BuyingPower:    nullDecimal(s.BuyingPower),
+		PositionsValue: nullDecimal(s.PositionsValue),
+		DayPl:          s.DayPL,
+		Source:         string(s.Source),
+	}
+}
+
+func NewCreateMissingAccountSnapshotParams(s *account.Snapshot) CreateMissingAccountSnapshotParams {
+	return CreateMissingAccountSnapshotParams(NewUpsertAccountSnapshotParams(s))
+}
+
+func ToAlertRule(a Alert) *alert.Rule {
+	// The channels were checked when the rule was made
+	notify, _ := alert.ParseChannels(a.Notify)
+	return &alert.Rule{
+		ID:              a.ID,
+		AccountID:       a.AccountID.String,
+		Expr:            a.Rule,
+		Notify:          notify,
+		Enabled:         a.Enabled,
+		TriggerCount:    a.TriggerCount,
+		LastTriggeredAt: timePtr(a.LastTriggeredAt),
+		LastMessage:     a.LastMessage.String,
+		CreatedAt:       a.CreatedAt,
+		UpdatedAt:       a.UpdatedAt,
+	}
+}
+
+func ToAlertRules(rows []Alert) []*alert.Rule {
+	rules := make([]*alert.Rule, 0, len(rows))
+	for _, row := range rows {
+		rules = append(rules, ToAlertRule(row))
+	}
+	return rules
+}
+
+func NewCreateAlertParams(r *alert.Rule) CreateAlertParams {
+	return CreateAlertParams{
+		AccountID: nullString(r.AccountID),
+		Rule:      r.Expr,
+		Notify:    alert.JoinChannels(r.Notify),
+		Enabled:   r.Enabled,
+	}
+}
+
+func NewRecordAlertTriggerParams(a *alert.Alert) RecordAlertTriggerParams {
+	return RecordAlertTriggerParams{
+		ID:              a.RuleID,
+		LastTriggeredAt: sql.NullTime{Time: a.TriggeredAt.UTC(), Valid: true},
+		LastMessage:     nullString(a.Message),
+	}
+}
+
+func ToBar(b Bar) marketdata.Bar {
+	return marketdata.Bar{
+		Symbol: b.Symbol,
+		Time:   b.StartedAt,
+		Open:   b.OpenPrice,
+		High:   b.HighPrice,
+		Low:    b.LowPrice,
+		Close:  b.ClosePrice,
+		Volume: uint64(b.Volume),
+	}
+}
+
+func ToBars(rows []Bar) []marketdata.Bar {
+	bars := make([]marketdata.Bar, 0, len(rows))
+	for _, row := range rows {
+		bars = append(bars, ToBar(row))
+	}
+	return bars
+}
+
+// NewUpsertBarParams stores the bar's time in UTC, so the same period
+// always has the same key
+func NewUpsertBarParams(b marketdata.Bar, timeframe string) UpsertBarParams {
+	return UpsertBarParams{
+		Symbol:     b.Symbol,
+		Timeframe:  timeframe,
+		StartedAt:  b.Time.UTC(),
+		OpenPrice:  b.Open,
+		HighPrice:  b.High,
+		LowPrice:   b.Low,
+		ClosePrice: b.Close,
+		Volume:     int64(b.Volume),
+	}
+}
+
+func ToOrder(o Order) *order.Order {
+	// A notional order has no quantity until something of it fills
+	qty := &o.Qty
+	if o.Notional.Valid && o.Qty.IsZero() {
+		qty = nil
+	}
+
+	return &order.Order{
+		ID:             o.ID,
+		AlpacaOrderID:  o.AlpacaOrderID,
+		AccountID:      o.AccountID,
+		Symbol:         o.Symbol,
+		Side:           order.OrderSide(o.Side),
+		OrderType:      order.OrderType(o.OrderType),
+		Qty:            qty,
+		Notional:       decimalPtr(o.Notional),
+		FilledQty:      o.FilledQty,
+		LimitPrice:     decimalPtr(o.LimitPrice),
+		StopPrice:      decimalPtr(o.StopPrice),
+		TimeInForce:    order.TimeInForce(o.TimeInForce),
+		Status:         order.OrderStatus(o.Status),
+		FilledAvgPrice: decimalPtr(o.FilledAvgPrice),
+		SubmittedAt:    o.SubmittedAt.Time,
+		FilledAt:       timePtr(o.FilledAt),
+		CanceledAt:     timePtr(o.CanceledAt),
+		CreatedAt:      o.CreatedAt,
+		UpdatedAt:      o.UpdatedAt,
+	}
+}
+
+func ToOrders(rows []Order) []*order.Order {
+	orders := make([]*order.Order, 0, len(rows))
+	for _, row := range rows {
+		orders = append(orders, ToOrder(row))
+	}
+	return orders
+}
+
+// NewCreateOrderParams keeps the broker's creation time, so orders imported
+// later still page and group by when they were placed
+func NewCreateOrderParams(o *order.Order) CreateOrderParams {
+	var qty decimal.Decimal
+	if o.Qty != nil {
+		qty = *o.Qty
+	}
+	createdAt := o.CreatedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	return CreateOrderParams{
+		ID:            o.ID,
+		AlpacaOrderID: o.AlpacaOrderID,
+		AccountID:     o.AccountID,
+		Symbol:        o.Symbol,
+		Side:          string(o.Side),
+		OrderType:     string(o.OrderType),
+		Qty:           qty,
+		LimitPrice:    nullDecimal(o.LimitPrice),
+		StopPrice:     nullDecimal(o.StopPrice),
+		TimeInForce:   string(o.TimeInForce),
+		Status:        string(o.Status),
+		SubmittedAt:   nullTime(&o.SubmittedAt),
+		CreatedAt:     createdAt.UTC(),
+		Notional:      nullDecimal(o.Notional),
+	}
+}
+
+func NewUpdateOrderParams(o *order.Order) UpdateOrderParams {
+	return UpdateOrderParams{
+		ID:             o.ID,
+		Status:         string(o.Status),
+		FilledQty:      o.FilledQty,
+		FilledAvgPrice: nullDecimal(o.FilledAvgPrice),
+		FilledAt:       nullTime(o.FilledAt),
+		CanceledAt:     nullTime(o.CanceledAt),
+	}
+}
+
+// ToCreateOrderRequest rebuilds the request an order intent was written for
+func ToCreateOrderRequest(i OrderIntent) *order.CreateOrderRequest {
+	return &order.CreateOrderRequest{
+		AccountID:     i.AccountID,
+		Symbol:        i.Symbol,
+		Side:          order.OrderSide(i.Side),
+		OrderType:     order.OrderType(i.OrderType),
+		Qty:           decimalPtr(i.Qty),
+		Notional:      decimalPtr(i.Notional),
+		LimitPrice:    decimalPtr(i.LimitPrice),
+		StopPrice:     decimalPtr(i.StopPrice),
+		TimeInForce:   order.TimeInForce(i.TimeInForce),
+		ClientOrderID: i.ClientOrderID,
+	}
+}
+
+// NewCreateOrderIntentParams stamps the intent with the current time in UTC,
+// whatever the database session's time zone
+func NewCreateOrderIntentParams(req *order.CreateOrderRequest) CreateOrderIntentParams {
+	return CreateOrderIntentParams{
+		ClientOrderID: req.ClientOrderID,
+		AccountID:     req.AccountID,
+		Symbol:        req.Symbol,
+		Side:          string(req.Side),
+		OrderType:     string(req.OrderType),
+		Qty:           nullDecimal(req.Qty),
+		Notional:      nullDecimal(req.Notional),
+		LimitPrice:    nullDecimal(req.LimitPrice),
+		StopPrice:     nullDecimal(req.StopPrice),
+		TimeInForce:   string(req.TimeInForce),
+		CreatedAt:     time.Now().UTC(),
+	}
+}
+
+func ToExecution(e Execution) *order.Execution {
+	return &order.Execution{
+		ID:          e.ID,
+		OrderID:     e.OrderID,
+		AccountID:   e.AccountID,
+		Symbol:      e.Symbol,
+		Side:        order.OrderSide(e.Side),
+		Qty:         e.Qty,
+		Price:       e.Price,
+		PositionQty: decimalPtr(e.PositionQty),
+		ExecutedAt:  e.ExecutedAt,
+	}
+}
+
+func ToExecutions(rows []Execution) []*order.Execution {
+	executions := make([]*order.Execution, 0, len(rows))
+	for _, row := range rows {
+		executions = append(executions, ToExecution(row))
+	}
+	return executions
+}
+
+func NewCreateExecutionParams(e *order.Execution, eventID string) CreateExecutionParams {
+	return CreateExecutionParams{
+		ID:          e.ID,
+		OrderID:     e.OrderID,
+		AccountID:   e.AccountID,
+		EventID:     eventID,
+		Symbol:      e.Symbol,
+		Side:        string(e.Side),
+		Qty:         e.Qty,
+		Price:       e.Price,
+		PositionQty: nullDecimal(e.PositionQty),
+		ExecutedAt:  e.ExecutedAt,
+	}
+}
+
+func ToPosition(p Position) *position.Position {
+	return &position.Position{
+		ID:             int64(p.ID),
+		AccountID:      p.AccountID,
+		Symbol:         p.Symbol,
+		Qty:            p.Qty,
+		AvgEntryPrice:  p.AvgEntryPrice,
+		CurrentPrice:   p.CurrentPrice,
+		MarketValue:    p.MarketValue,
+		CostBasis:      p.CostBasis,
+		UnrealizedPL:   p.UnrealizedPl,
+		UnrealizedPLPC: p.UnrealizedPlpc,
+		CreatedAt:      p.CreatedAt,
+		UpdatedAt:      p.UpdatedAt,
+	}
+}
+
+func ToPositions(rows []Position) []*position.Position {
+	positions := make([]*position.Position, 0, len(rows))
+	for _, row := range rows {
+		positions = append(positions, ToPosition(row))
+	}
+	return positions
+}
+
+func NewCreatePositionParams(p *position.Position) CreatePositionParams {
+	return CreatePositionParams{
+		AccountID:      p.AccountID,
+		Symbol:         p.Symbol,
+		Qty:            p.Qty,
+		AvgEntryPrice:  p.AvgEntryPrice,
+		CurrentPrice:   p.CurrentPrice,
+		MarketValue:    p.MarketValue,
+		CostBasis:      p.CostBasis,
+		UnrealizedPl:   p.UnrealizedPL,
+		UnrealizedPlpc: p.UnrealizedPLPC,
+	}
+}
+
+func NewUpdatePositionParams(p *position.Position) UpdatePositionParams {
+	return UpdatePositionParams{
+		AccountID:      p.AccountID,
+		Symbol:         p.Symbol,
+		Qty:            p.Qty,
+		AvgEntryPrice:  p.AvgEntryPrice,
+		CurrentPrice:   p.CurrentPrice,
+		MarketValue:    p.MarketValue,
+		CostBasis:      p.CostBasis,
+		UnrealizedPl:   p.UnrealizedPL,
+		UnrealizedPlpc: p.UnrealizedPLPC,
+	}
+}
+
+func ToScheduledOrder(s ScheduledOrder) *schedule.ScheduledOrder {
+	return &schedule.ScheduledOrder{
+		ID:       s.ID,
+		Schedule: s.Schedule,
+		TimeZone: s.TimeZone,
+		Request: order.CreateOrderRequest{
+			AccountID:   s.AccountID,
+			Symbol:      s.Symbol,
+			Side:        order.OrderSide(s.Side),
+			OrderType:   order.OrderType(s.OrderType),
+			Qty:         decimalPtr(s.Qty),
+			Notional:    decimalPtr(s.Notional),
+			LimitPrice:  decimalPtr(s.LimitPrice),
+			StopPrice:   decimalPtr(s.StopPrice),
+			TimeInForce: order.TimeInForce(s.TimeInForce),
+		},
+		Enabled:    s.Enabled,
+		NextRunAt:  timePtr(s.NextRunAt),
+		LastRunAt:  timePtr(s.LastRunAt),
+		LastResult: schedule.Result(s.LastResult.String),
+		CreatedAt:  s.CreatedAt,
+		UpdatedAt:  s.UpdatedAt,
+	}
+}
+
+func ToScheduledOrders(rows []ScheduledOrder) []*schedule.ScheduledOrder {
+	schedules := make([]*schedule.ScheduledOrder, 0, len(rows))
+	for _, row := range rows {
+		schedules = append(schedules, ToScheduledOrder(row))
+	}
+	return schedules
+}
+
+// NewCreateScheduledOrderParams stores next run times in UTC, as the
+// scheduler compares them with the time in UTC
+func NewCreateScheduledOrderParams(s *schedule.ScheduledOrder) CreateScheduledOrderParams {
+	return CreateScheduledOrderParams{
+		AccountID:   s.Request.AccountID,
+		Schedule:    s.Schedule,
+		TimeZone:    s.TimeZone,
+		Symbol:      s.Request.Symbol,
+		Side:        string(s.Request.Side),
+		OrderType:   string(s.Request.OrderType),
+		Qty:         nullDecimal(s.Request.Qty),
+		Notional:    nullDecimal(s.Request.Notional),
+		LimitPrice:  nullDecimal(s.Request.LimitPrice),
+		StopPrice:   nullDecimal(s.Request.StopPrice),
+		TimeInForce: string(s.Request.TimeInForce),
+		Enabled:     s.Enabled,
+		NextRunAt:   nullUTC(s.NextRunAt),
+	}
+}
+
+func ToScheduledOrderRun(r ListScheduledOrderRunsRow) *schedule.Run {
+	return &schedule.Run{
+		ID:            r.ID,
+		ScheduleID:    r.ScheduledOrderID,
+		ScheduledFor:  r.ScheduledFor,
+		Result:        schedule.Result(r.Result),
+		ClientOrderID: r.ClientOrderID.String,
+		OrderID:       r.OrderID.String,
+		OrderStatus:   order.OrderStatus(r.OrderStatus.String),
+		Message:       r.Message.String,
+		CreatedAt:     r.CreatedAt,
+	}
+}
+
+func ToScheduledOrderRuns(rows []ListScheduledOrderRunsRow) []*schedule.Run {
+	runs := make([]*schedule.Run, 0, len(rows))
+	for _, row := range rows {
+		runs = append(runs, ToScheduledOrderRun(row))
+	}
+	return runs
+}
+
+func NewCreateScheduledOrderRunParams(r *schedule.Run) CreateScheduledOrderRunParams {
+	return CreateScheduledOrderRunParams{
+		ScheduledOrderID: r.ScheduleID,
+		ScheduledFor:     r.ScheduledFor.UTC(),
+		Result:           string(r.Result),
+		ClientOrderID:    nullString(r.ClientOrderID),
+		OrderID:          nullString(r.OrderID),
+		Message:          nullString(r.Message),
+	}
+}
+
+// ToWatchlist combines a watchlist row with its items, which must already be
+// sorted by position (as ListWatchlistItems returns them).
+func ToWatchlist(w Watchlist, items []WatchlistItem) *watchlist.Watchlist {
+	symbols := make([]string, 0, len(items))
+	for _, item := range items {
+		symbols = append(symbols, item.Symbol)
+	}
+
+	return &watchlist.Watchlist{
+		ID:        w.ID,
+		AccountID: w.AccountID,
+		Name:      w.Name,
+		Symbols:   symbols,
+		CreatedAt: w.CreatedAt,
+		UpdatedAt: w.UpdatedAt,
+	}
+}
+
+func decimalPtr(d decimal.NullDecimal) *decimal.Decimal {
+	if !d.Valid {
+		return nil
+	}
+	v := d.Decimal
+	return &v
+}
+
+func nullDecimal(d *decimal.Decimal) decimal.NullDecimal {
+	if d == nil {
+		return decimal.NullDecimal{}
+	}
+	return decimal.NullDecimal{Decimal: *d, Valid: true}
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	v := t.Time
+	return &v
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil || t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func nullUTC(t *time.Time) sql.NullTime {
+	if t == nil || t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
+
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
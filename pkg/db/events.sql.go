@@ -0,0 +1,173 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: events.sql
+
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+const appendEvent = `-- name: AppendEvent :one
+INSERT INTO events (
+    event_id, event_type, account_id, payload, received_at
+) VALUES (
+    $1, $2, $3, $4, $5
+)
+ON CONFLICT (event_id) DO NOTHING
+RETURNING id, event_id, event_type, account_id, payload, received_at, applied_at, created_at
+`
+
+type AppendEventParams struct {
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	AccountID  string          `json:"account_id"`
+	Payload    json.RawMessage `json:"payload"`
+	ReceivedAt time.Time       `json:"received_at"`
+}
+
+func (q *Queries) AppendEvent(ctx context.Context, arg AppendEventParams) (Event, error) {
+	row := q.db.QueryRowContext(ctx, appendEvent,
+		arg.EventID,
+		arg.EventType,
+		arg.AccountID,
+		arg.Payload,
+		arg.ReceivedAt,
+	)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.AccountID,
+		&i.Payload,
+		&i.ReceivedAt,
+		&i.AppliedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const getEventForUpdate = `-- name: GetEventForUpdate :one
+SELECT id, event_id, event_type, account_id, payload, received_at, applied_at, created_at FROM events WHERE id = $1 FOR UPDATE
+`
+
+func (q *Queries) GetEventForUpdate(ctx context.Context, id int64) (Event, error) {
+	row := q.db.QueryRowContext(ctx, getEventForUpdate, id)
+	var i Event
+	err := row.Scan(
+		&i.ID,
+		&i.EventID,
+		&i.EventType,
+		&i.AccountID,
+		&i.Payload,
+		&i.ReceivedAt,
+		&i.AppliedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listEventsAfter = `-- name: ListEventsAfter :many
+SELECT id, event_id, event_type, account_id, payload, received_at, applied_at, created_at FROM events
+WHERE id > $1
+ORDER BY id
+LIMIT $2
+`
+
+type ListEventsAfterParams struct {
+	ID    int64 `json:"id"`
+	Limit int32 `json:"limit"`
+}
+
+func (q *Queries) ListEventsAfter(ctx context.Context, arg ListEventsAfterParams) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, listEventsAfter, arg.ID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.EventType,
+			&i.AccountID,
+			&i.Payload,
+			&i.ReceivedAt,
+			&i.AppliedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listUnappliedEvents = `-- name: ListUnappliedEvents :many
+SELECT id, event_id, event_type, account_id, payload, received_at, applied_at, created_at FROM events
+WHERE applied_at IS NULL
+ORDER BY id
+LIMIT $1
+`
+
+func (q *Queries) ListUnappliedEvents(ctx context.Context, limit int32) ([]Event, error) {
+	rows, err := q.db.QueryContext(ctx, listUnappliedEvents, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Event{}
+	for rows.Next() {
+		var i Event
+		if err := rows.Scan(
+			&i.ID,
+			&i.EventID,
+			&i.EventType,
+			&i.AccountID,
+			&i.Payload,
+			&i.ReceivedAt,
+			&i.AppliedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markEventApplied = `-- name: MarkEventApplied :exec
+UPDATE events SET applied_at = NOW() WHERE id = $1
+`
+
+func (q *Queries) MarkEventApplied(ctx context.Context, id int64) error {
+	_, err := q.db.ExecContext(ctx, markEventApplied, id)
+	return err
+}
+
+const resetEventsApplied = `-- name: ResetEventsApplied :exec
+UPDATE events SET applied_at = NULL WHERE applied_at IS NOT NULL
+`
+
+func (q *Queries) ResetEventsApplied(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, resetEventsApplied)
+	return err
+}
@@ -0,0 +1,234 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: executions.sql
+
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createExecution = `-- name: CreateExecution :one
+INSERT INTO executions (
+    id, order_id, account_id, event_id, symbol, side, qty, price,
+    position_qty, executed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+)
+ON CONFLICT (id) DO NOTHING
+RETURNING id, order_id, account_id, event_id, symbol, side, qty, price, position_qty, executed_at, created_at
+`
+
+type CreateExecutionParams struct {
+	ID          string              `json:"id"`
+	OrderID     string              `json:"order_id"`
+	AccountID   string              `json:"account_id"`
+	EventID     string              `json:"event_id"`
+	Symbol      string              `json:"symbol"`
+	Side        string              `json:"side"`
+	Qty         decimal.Decimal     `json:"qty"`
+	Price       decimal.Decimal     `json:"price"`
+	PositionQty decimal.NullDecimal `json:"position_qty"`
+	ExecutedAt  time.Time           `json:"executed_at"`
+}
+
+func (q *Queries) CreateExecution(ctx context.Context, arg CreateExecutionParams) (Execution, error) {
+	row := q.db.QueryRowContext(ctx, createExecution,
+		arg.ID,
+		arg.OrderID,
+		arg.AccountID,
+		arg.EventID,
+		arg.Symbol,
+		arg.Side,
+		arg.Qty,
+		arg.Price,
+		arg.PositionQty,
+		arg.ExecutedAt,
+	)
+	var i Execution
+	err := row.Scan(
+		&i.ID,
+		&i.OrderID,
+		&i.AccountID,
+		&i.EventID,
+		&i.Symbol,
+		&i.Side,
+		&i.Qty,
+		&i.Price,
+		&i.PositionQty,
+		&i.ExecutedAt,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listExecutedSymbols = `-- name: ListExecutedSymbols :many
+SELECT DISTINCT account_id, symbol FROM executions
+ORDER BY account_id, symbol
+`
+
+type ListExecutedSymbolsRow struct {
+	AccountID string `json:"account_id"`
+	Symbol    string `json:"symbol"`
+}
+
+func (q *Queries) ListExecutedSymbols(ctx context.Context) ([]ListExecutedSymbolsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listExecutedSymbols)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListExecutedSymbolsRow{}
+	for rows.Next() {
+		var i ListExecutedSymbolsRow
+		if err := rows.Scan(&i.AccountID, &i.Symbol); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExecutions = `-- name: ListExecutions :many
+SELECT id, order_id, account_id, event_id, symbol, side, qty, price, position_qty, executed_at, created_at FROM executions
+WHERE account_id = $1
+ORDER BY executed_at DESC, id
+LIMIT $2
+`
+
+type ListExecutionsParams struct {
+	AccountID string `json:"account_id"`
+	Limit     int32  `json:"limit"`
+}
+
+func (q *Queries) ListExecutions(ctx context.Context, arg ListExecutionsParams) ([]Execution, error) {
+	rows, err := q.db.QueryContext(ctx, listExecutions, arg.AccountID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Execution{}
+	for rows.Next() {
+		var i Execution
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.AccountID,
+			&i.EventID,
+			&i.Symbol,
+			&i.Side,
+			&i.Qty,
+			&i.Price,
+			&i.PositionQty,
+			&i.ExecutedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExecutionsByOrder = `-- name: ListExecutionsByOrder :many
+SELECT id, order_id, account_id, event_id, symbol, side, qty, price, position_qty, executed_at, created_at FROM executions
+WHERE order_id = $1
+ORDER BY executed_at, id
+`
+
+func (q *Queries) ListExecutionsByOrder(ctx context.Context, orderID string) ([]Execution, error) {
+	rows, err := q.db.QueryContext(ctx, listExecutionsByOrder, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Execution{}
+	for rows.Next() {
+		var i Execution
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.AccountID,
+			&i.EventID,
+			&i.Symbol,
+			&i.Side,
+			&i.Qty,
+			&i.Price,
+			&i.PositionQty,
+			&i.ExecutedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listExecutionsBySymbol = `-- name: ListExecutionsBySymbol :many
+SELECT id, order_id, account_id, event_id, symbol, side, qty, price, position_qty, executed_at, created_at FROM executions
+WHERE account_id = $1 AND symbol = $2
+ORDER BY executed_at, id
+`
+
+type ListExecutionsBySymbolParams struct {
+	AccountID string `json:"account_id"`
+	Symbol    string `json:"symbol"`
+}
+
+func (q *Queries) ListExecutionsBySymbol(ctx context.Context, arg ListExecutionsBySymbolParams) ([]Execution, error) {
+	rows, err := q.db.QueryContext(ctx, listExecutionsBySymbol, arg.AccountID, arg.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Execution{}
+	for rows.Next() {
+		var i Execution
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.AccountID,
+			&i.EventID,
+			&i.Symbol,
+			&i.Side,
+			&i.Qty,
+			&i.Price,
+			&i.PositionQty,
+			&i.ExecutedAt,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,249 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+type Account struct {
+	ID              string          `json:"id"`
+	AlpacaAccountID string          `json:"alpaca_account_id"`
+	Status          string          `json:"status"`
+	Currency        string          `json:"currency"`
+	Cash            decimal.Decimal `json:"cash"`
+	PortfolioValue  decimal.Decimal `json:"portfolio_value"`
+	BuyingPower     decimal.Decimal `json:"buying_power"`
+	CreatedAt       time.Time       `json:"created_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+type AccountSnapshot struct {
+	ID             int64               `json:"id"`
+	AccountID      string              `json:"account_id"`
+	TradingDate    time.Time           `json:"trading_date"`
+	Equity         decimal.Decimal     `json:"equity"`
+	Cash           decimal.NullDecimal `json:"cash"`
+	BuyingPower    decimal.NullDecimal `json:"buying_power"`
+	PositionsValue decimal.NullDecimal `json:"positions_value"`
+	DayPl          decimal.Decimal     `json:"day_pl"`
+	Source         string              `json:"source"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+}
+
+type Alert struct {
+	ID              int64          `json:"id"`
+	AccountID       sql.NullString `json:"account_id"`
+	Rule            string         `json:"rule"`
+	Notify          string         `json:"notify"`
+	Enabled         bool           `json:"enabled"`
+	TriggerCount    int64          `json:"trigger_count"`
+	LastTriggeredAt sql.NullTime   `json:"last_triggered_at"`
+	LastMessage     sql.NullString `json:"last_message"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+type AuditLog struct {
+	ID        int64           `json:"id"`
+	Operator  string          `json:"operator"`
+	Host      string          `json:"host"`
+	AccountID string          `json:"account_id"`
+	Action    string          `json:"action"`
+	Request   json.RawMessage `json:"request"`
+	Response  json.RawMessage `json:"response"`
+	Error     string          `json:"error"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+type Bar struct {
+	Symbol     string          `json:"symbol"`
+	Timeframe  string          `json:"timeframe"`
+	StartedAt  time.Time       `json:"started_at"`
+	OpenPrice  decimal.Decimal `json:"open_price"`
+	HighPrice  decimal.Decimal `json:"high_price"`
+	LowPrice   decimal.Decimal `json:"low_price"`
+	ClosePrice decimal.Decimal `json:"close_price"`
+	Volume     int64           `json:"volume"`
+}
+
+type Event struct {
+	ID         int64           `json:"id"`
+	EventID    string          `json:"event_id"`
+	EventType  string          `json:"event_type"`
+	AccountID  string          `json:"account_id"`
+	Payload    json.RawMessage `json:"payload"`
+	ReceivedAt time.Time       `json:"received_at"`
+	AppliedAt  sql.NullTime    `json:"applied_at"`
+	CreatedAt  time.Time       `json:"created_at"`
+}
+
+type Execution struct {
+	ID          string              `json:"id"`
+	OrderID     string              `json:"order_id"`
+	AccountID   string              `json:"account_id"`
+	EventID     string              `json:"event_id"`
+	Symbol      string              `json:"symbol"`
+	Side        string              `json:"side"`
+	Qty         decimal.Decimal     `json:"qty"`
+	Price       decimal.Decimal     `json:"price"`
+	PositionQty decimal.NullDecimal `json:"position_qty"`
+	ExecutedAt  time.Time           `json:"executed_at"`
+	CreatedAt   time.Time           `json:"created_at"`
+}
+
+type LotDisposal struct {
+	ID          int64           `json:"id"`
+	LotID       int64           `json:"lot_id"`
+	AccountID   string          `json:"account_id"`
+	Symbol      string          `json:"symbol"`
+	ExecutionID string          `json:"execution_id"`
+	Qty         decimal.Decimal `json:"qty"`
+	OpenPrice   decimal.Decimal `json:"open_price"`
+	ClosePrice  decimal.Decimal `json:"close_price"`
+	RealizedPl  decimal.Decimal `json:"realized_pl"`
+	OpenedAt    time.Time       `json:"opened_at"`
+	ClosedAt    time.Time       `json:"closed_at"`
+	Term        string          `json:"term"`
+}
+
+type LotSelection struct {
+	OrderID        string          `json:"order_id"`
+	LotExecutionID string          `json:"lot_execution_id"`
+	AccountID      string          `json:"account_id"`
+	Qty            decimal.Decimal `json:"qty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type Order struct {
+	ID             string              `json:"id"`
+	AlpacaOrderID  string              `json:"alpaca_order_id"`
+	AccountID      string              `json:"account_id"`
+	Symbol         string              `json:"symbol"`
+	Side           string              `json:"side"`
+	OrderType      string              `json:"order_type"`
+	Qty            decimal.Decimal     `json:"qty"`
+	FilledQty      decimal.Decimal     `json:"filled_qty"`
+	LimitPrice     decimal.NullDecimal `json:"limit_price"`
+	StopPrice      decimal.NullDecimal `json:"stop_price"`
+	TimeInForce    string              `json:"time_in_force"`
+	Status         string              `json:"status"`
+	FilledAvgPrice decimal.NullDecimal `json:"filled_avg_price"`
+	SubmittedAt    sql.NullTime        `json:"submitted_at"`
+	FilledAt       sql.NullTime        `json:"filled_at"`
+	CanceledAt     sql.NullTime        `json:"canceled_at"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+	Notional       decimal.NullDecimal `json:"notional"`
+}
+
+type OrderIntent struct {
+	ClientOrderID string              `json:"client_order_id"`
+	AccountID     string              `json:"account_id"`
+	Symbol        string              `json:"symbol"`
+	Side          string              `json:"side"`
+	OrderType     string              `json:"order_type"`
+	Qty           decimal.NullDecimal `json:"qty"`
+	LimitPrice    decimal.NullDecimal `json:"limit_price"`
+	StopPrice     decimal.NullDecimal `json:"stop_price"`
+	TimeInForce   string              `json:"time_in_force"`
+	Status        string              `json:"status"`
+	AlpacaOrderID sql.NullString      `json:"alpaca_order_id"`
+	Error         sql.NullString      `json:"error"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Notional      decimal.NullDecimal `json:"notional"`
+}
+
+type Position struct {
+	ID             int32           `json:"id"`
+	AccountID      string          `json:"account_id"`
+	Symbol         string          `json:"symbol"`
+	Qty            decimal.Decimal `json:"qty"`
+	AvgEntryPrice  decimal.Decimal `json:"avg_entry_price"`
+	CurrentPrice   decimal.Decimal `json:"current_price"`
+	MarketValue    decimal.Decimal `json:"market_value"`
+	CostBasis      decimal.Decimal `json:"cost_basis"`
+	UnrealizedPl   decimal.Decimal `json:"unrealized_pl"`
+	UnrealizedPlpc decimal.Decimal `json:"unrealized_plpc"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+}
+
+type ReconcileDrift struct {
+	ID          int64     `json:"id"`
+	AccountID   string    `json:"account_id"`
+	Entity      string    `json:"entity"`
+	EntityID    string    `json:"entity_id"`
+	Field       string    `json:"field"`
+	LocalValue  string    `json:"local_value"`
+	BrokerValue string    `json:"broker_value"`
+	CorrectedAt time.Time `json:"corrected_at"`
+}
+
+type ScheduledOrder struct {
+	ID          int64               `json:"id"`
+	AccountID   string              `json:"account_id"`
+	Schedule    string              `json:"schedule"`
+	TimeZone    string              `json:"time_zone"`
+	Symbol      string              `json:"symbol"`
+	Side        string              `json:"side"`
+	OrderType   string              `json:"order_type"`
+	Qty         decimal.NullDecimal `json:"qty"`
+	Notional    decimal.NullDecimal `json:"notional"`
+	LimitPrice  decimal.NullDecimal `json:"limit_price"`
+	StopPrice   decimal.NullDecimal `json:"stop_price"`
+	TimeInForce string              `json:"time_in_force"`
+	Enabled     bool                `json:"enabled"`
+	NextRunAt   sql.NullTime        `json:"next_run_at"`
+	LastRunAt   sql.NullTime        `json:"last_run_at"`
+	LastResult  sql.NullString      `json:"last_result"`
+	CreatedAt   time.Time           `json:"created_at"`
+	UpdatedAt   time.Time           `json:"updated_at"`
+}
+
+type ScheduledOrderRun struct {
+	ID               int64          `json:"id"`
+	ScheduledOrderID int64          `json:"scheduled_order_id"`
+	ScheduledFor     time.Time      `json:"scheduled_for"`
+	Result           string         `json:"result"`
+	ClientOrderID    sql.NullString `json:"client_order_id"`
+	OrderID          sql.NullString `json:"order_id"`
+	Message          sql.NullString `json:"message"`
+	CreatedAt        time.Time      `json:"created_at"`
+}
+
+type TaxLot struct {
+	ID           int64           `json:"id"`
+	AccountID    string          `json:"account_id"`
+	Symbol       string          `json:"symbol"`
+	ExecutionID  string          `json:"execution_id"`
+	Direction    string          `json:"direction"`
+	Qty          decimal.Decimal `json:"qty"`
+	RemainingQty decimal.Decimal `json:"remaining_qty"`
+	Price        decimal.Decimal `json:"price"`
+	OpenedAt     time.Time       `json:"opened_at"`
+	ClosedAt     sql.NullTime    `json:"closed_at"`
+}
+
+type Watchlist struct {
+	ID        string    `json:"id"`
+	AccountID string    `json:"account_id"`
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type WatchlistItem struct {
+	WatchlistID string    `json:"watchlist_id"`
+	Symbol      string    `json:"symbol"`
+	Position    int32     `json:"position"`
+	CreatedAt   time.Time `json:"created_at"`
+}
@@ -0,0 +1,247 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: order_intents.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createOrderIntent = `-- name: CreateOrderIntent :one
+INSERT INTO order_intents (
+    client_order_id, account_id, symbol, side, order_type, qty, limit_price,
+    stop_price, time_in_force, notional, created_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING client_order_id, account_id, symbol, side, order_type, qty, limit_price, stop_price, time_in_force, status, alpaca_order_id, error, created_at, updated_at, notional
+`
+
+type CreateOrderIntentParams struct {
+	ClientOrderID string              `json:"client_order_id"`
+	AccountID     string              `json:"account_id"`
+	Symbol        string              `json:"symbol"`
+	Side          string              `json:"side"`
+	OrderType     string              `json:"order_type"`
+	Qty           decimal.NullDecimal `json:"qty"`
+	LimitPrice    decimal.NullDecimal `json:"limit_price"`
+	StopPrice     decimal.NullDecimal `json:"stop_price"`
+	TimeInForce   string              `json:"time_in_force"`
+	Notional      decimal.NullDecimal `json:"notional"`
+	CreatedAt     time.Time           `json:"created_at"`
+}
+
+// created_at comes from the caller in UTC, as Resolve measures its age with
+// the Go clock.
+func (q *Queries) CreateOrderIntent(ctx context.Context, arg CreateOrderIntentParams) (OrderIntent, error) {
+	row := q.db.QueryRowContext(ctx, createOrderIntent,
+		arg.ClientOrderID,
+		arg.AccountID,
+		arg.Symbol,
+		arg.Side,
+		arg.OrderType,
+		arg.Qty,
+		arg.LimitPrice,
+		arg.StopPrice,
+		arg.TimeInForce,
+		arg.Notional,
+		arg.CreatedAt,
+	)
+	var i OrderIntent
+	err := row.Scan(
+		&i.ClientOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.AlpacaOrderID,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const listOrderIntents = `-- name: ListOrderIntents :many
+SELECT client_order_id, account_id, symbol, side, order_type, qty, limit_price, stop_price, time_in_force, status, alpaca_order_id, error, created_at, updated_at, notional FROM order_intents
+WHERE ($1::text = '' OR account_id = $1)
+  AND ($2::text = '' OR status = $2)
+ORDER BY created_at DESC
+LIMIT $3
+`
+
+type ListOrderIntentsParams struct {
+	AccountID string `json:"account_id"`
+	Status    string `json:"status"`
+	RowLimit  int32  `json:"row_limit"`
+}
+
+// Newest first. Empty account_id or status match every intent.
+func (q *Queries) ListOrderIntents(ctx context.Context, arg ListOrderIntentsParams) ([]OrderIntent, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderIntents, arg.AccountID, arg.Status, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrderIntent{}
+	for rows.Next() {
+		var i OrderIntent
+		if err := rows.Scan(
+			&i.ClientOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.AlpacaOrderID,
+			&i.Error,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingOrderIntents = `-- name: ListPendingOrderIntents :many
+SELECT client_order_id, account_id, symbol, side, order_type, qty, limit_price, stop_price, time_in_force, status, alpaca_order_id, error, created_at, updated_at, notional FROM order_intents
+WHERE status = 'pending'
+ORDER BY created_at
+`
+
+func (q *Queries) ListPendingOrderIntents(ctx context.Context) ([]OrderIntent, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingOrderIntents)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrderIntent{}
+	for rows.Next() {
+		var i OrderIntent
+		if err := rows.Scan(
+			&i.ClientOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.AlpacaOrderID,
+			&i.Error,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markOrderIntentFailed = `-- name: MarkOrderIntentFailed :one
+UPDATE order_intents SET
+    status = 'failed',
+    error = $2,
+    updated_at = NOW()
+WHERE client_order_id = $1
+RETURNING client_order_id, account_id, symbol, side, order_type, qty, limit_price, stop_price, time_in_force, status, alpaca_order_id, error, created_at, updated_at, notional
+`
+
+type MarkOrderIntentFailedParams struct {
+	ClientOrderID string         `json:"client_order_id"`
+	Error         sql.NullString `json:"error"`
+}
+
+func (q *Queries) MarkOrderIntentFailed(ctx context.Context, arg MarkOrderIntentFailedParams) (OrderIntent, error) {
+	row := q.db.QueryRowContext(ctx, markOrderIntentFailed, arg.ClientOrderID, arg.Error)
+	var i OrderIntent
+	err := row.Scan(
+		&i.ClientOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.AlpacaOrderID,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const markOrderIntentSent = `-- name: MarkOrderIntentSent :one
+UPDATE order_intents SET
+    status = 'sent',
+    alpaca_order_id = $2,
+    updated_at = NOW()
+WHERE client_order_id = $1
+RETURNING client_order_id, account_id, symbol, side, order_type, qty, limit_price, stop_price, time_in_force, status, alpaca_order_id, error, created_at, updated_at, notional
+`
+
+type MarkOrderIntentSentParams struct {
+	ClientOrderID string         `json:"client_order_id"`
+	AlpacaOrderID sql.NullString `json:"alpaca_order_id"`
+}
+
+func (q *Queries) MarkOrderIntentSent(ctx context.Context, arg MarkOrderIntentSentParams) (OrderIntent, error) {
+	row := q.db.QueryRowContext(ctx, markOrderIntentSent, arg.ClientOrderID, arg.AlpacaOrderID)
+	var i OrderIntent
+	err := row.Scan(
+		&i.ClientOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.AlpacaOrderID,
+		&i.Error,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
@@ -0,0 +1,526 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: orders.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/shopspring/decimal"
+)
+
+const createOrder = `-- name: CreateOrder :one
+INSERT INTO orders (
+    id, alpaca_order_id, account_id, symbol, side, order_type, qty,
+    limit_price, stop_price, time_in_force, status, submitted_at, created_at,
+    notional
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14
+) RETURNING id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional
+`
+
+type CreateOrderParams struct {
+	ID            string              `json:"id"`
+	AlpacaOrderID string              `json:"alpaca_order_id"`
+	AccountID     string              `json:"account_id"`
+	Symbol        string              `json:"symbol"`
+	Side          string              `json:"side"`
+	OrderType     string              `json:"order_type"`
+	Qty           decimal.Decimal     `json:"qty"`
+	LimitPrice    decimal.NullDecimal `json:"limit_price"`
+	StopPrice     decimal.NullDecimal `json:"stop_price"`
+	TimeInForce   string              `json:"time_in_force"`
+	Status        string              `json:"status"`
+	SubmittedAt   sql.NullTime        `json:"submitted_at"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Notional      decimal.NullDecimal `json:"notional"`
+}
+
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, createOrder,
+		arg.ID,
+		arg.AlpacaOrderID,
+		arg.AccountID,
+		arg.Symbol,
+		arg.Side,
+		arg.OrderType,
+		arg.Qty,
+		arg.LimitPrice,
+		arg.StopPrice,
+		arg.TimeInForce,
+		arg.Status,
+		arg.SubmittedAt,
+		arg.CreatedAt,
+		arg.Notional,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const deleteAllOrders = `-- name: DeleteAllOrders :exec
+DELETE FROM orders
+`
+
+func (q *Queries) DeleteAllOrders(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllOrders)
+	return err
+}
+
+const getOrder = `-- name: GetOrder :one
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders WHERE id = $1
+`
+
+func (q *Queries) GetOrder(ctx context.Context, id string) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrder, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const getOrderByAlpacaID = `-- name: GetOrderByAlpacaID :one
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders WHERE alpaca_order_id = $1
+`
+
+func (q *Queries) GetOrderByAlpacaID(ctx context.Context, alpacaOrderID string) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrderByAlpacaID, alpacaOrderID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const listOrders = `-- name: ListOrders :many
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders
+WHERE account_id = $1
+ORDER BY created_at DESC
+LIMIT $2 OFFSET $3
+`
+
+type ListOrdersParams struct {
+	AccountID string `json:"account_id"`
+	Limit     int32  `json:"limit"`
+	Offset    int32  `json:"offset"`
+}
+
+func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listOrders, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.AlpacaOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.FilledQty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.FilledAvgPrice,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersByStatus = `-- name: ListOrdersByStatus :many
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders
+WHERE account_id = $1 AND status = $2
+ORDER BY created_at DESC
+`
+
+type ListOrdersByStatusParams struct {
+	AccountID string `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+func (q *Queries) ListOrdersByStatus(ctx context.Context, arg ListOrdersByStatusParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listOrdersByStatus, arg.AccountID, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.AlpacaOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.FilledQty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.FilledAvgPrice,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const orderStatsByDay = `-- name: OrderStatsByDay :many
+SELECT
+    to_char(created_at AT TIME ZONE 'UTC' AT TIME ZONE 'America/New_York', 'YYYY-MM-DD')::text AS day,
+    COUNT(*) AS order_count,
+    COUNT(filled_at) AS filled_count,
+    COALESCE(SUM(filled_qty * filled_avg_price), 0)::numeric AS notional
+FROM orders
+WHERE account_id = $1
+  AND ($2::timestamp IS NULL OR created_at >= $2)
+  AND ($3::timestamp IS NULL OR created_at < $3)
+GROUP BY 1
+ORDER BY 1 DESC
+`
+
+type OrderStatsByDayParams struct {
+	AccountID    string       `json:"account_id"`
+	CreatedFrom  sql.NullTime `json:"created_from"`
+	CreatedUntil sql.NullTime `json:"created_until"`
+}
+
+type OrderStatsByDayRow struct {
+	Day         string          `json:"day"`
+	OrderCount  int64           `json:"order_count"`
+	FilledCount int64           `json:"filled_count"`
+	Notional    decimal.Decimal `json:"notional"`
+}
+
+// Days are New York trading dates, as for account snapshots. Notional is
+// filled quantity times average fill price.
+func (q *Queries) OrderStatsByDay(ctx context.Context, arg OrderStatsByDayParams) ([]OrderStatsByDayRow, error) {
+	rows, err := q.db.QueryContext(ctx, orderStatsByDay, arg.AccountID, arg.CreatedFrom, arg.CreatedUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrderStatsByDayRow{}
+	for rows.Next() {
+		var i OrderStatsByDayRow
+		if err := rows.Scan(
+			&i.Day,
+			&i.OrderCount,
+			&i.FilledCount,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const orderStatsBySymbol = `-- name: OrderStatsBySymbol :many
+SELECT
+    symbol,
+    COUNT(*) AS order_count,
+    COUNT(filled_at) AS filled_count,
+    COALESCE(SUM(filled_qty * filled_avg_price), 0)::numeric AS notional
+FROM orders
+WHERE account_id = $1
+  AND ($2::timestamp IS NULL OR created_at >= $2)
+  AND ($3::timestamp IS NULL OR created_at < $3)
+GROUP BY symbol
+ORDER BY notional DESC, symbol
+`
+
+type OrderStatsBySymbolParams struct {
+	AccountID    string       `json:"account_id"`
+	CreatedFrom  sql.NullTime `json:"created_from"`
+	CreatedUntil sql.NullTime `json:"created_until"`
+}
+
+type OrderStatsBySymbolRow struct {
+	Symbol      string          `json:"symbol"`
+	OrderCount  int64           `json:"order_count"`
+	FilledCount int64           `json:"filled_count"`
+	Notional    decimal.Decimal `json:"notional"`
+}
+
+func (q *Queries) OrderStatsBySymbol(ctx context.Context, arg OrderStatsBySymbolParams) ([]OrderStatsBySymbolRow, error) {
+	rows, err := q.db.QueryContext(ctx, orderStatsBySymbol, arg.AccountID, arg.CreatedFrom, arg.CreatedUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []OrderStatsBySymbolRow{}
+	for rows.Next() {
+		var i OrderStatsBySymbolRow
+		if err := rows.Scan(
+			&i.Symbol,
+			&i.OrderCount,
+			&i.FilledCount,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchOrders = `-- name: SearchOrders :many
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders
+WHERE account_id = $1
+  AND ($2::text = '' OR symbol = $2)
+  AND ($3::text = '' OR side = $3)
+  AND ($4::text = '' OR order_type = $4)
+  AND (cardinality($5::text[]) = 0 OR status = ANY($5::text[]))
+  AND ($6::timestamp IS NULL OR created_at >= $6)
+  AND ($7::timestamp IS NULL OR created_at < $7)
+  AND ($8::timestamp IS NULL OR filled_at >= $8)
+  AND ($9::timestamp IS NULL OR filled_at < $9)
+  AND ($10::timestamp IS NULL
+       OR (created_at, id) < ($10, $11::text))
+ORDER BY created_at DESC, id DESC
+LIMIT $12
+`
+
+type SearchOrdersParams struct {
+	AccountID       string       `json:"account_id"`
+	Symbol          string       `json:"symbol"`
+	Side            string       `json:"side"`
+	OrderType       string       `json:"order_type"`
+	Statuses        []string     `json:"statuses"`
+	CreatedFrom     sql.NullTime `json:"created_from"`
+	CreatedUntil    sql.NullTime `json:"created_until"`
+	FilledFrom      sql.NullTime `json:"filled_from"`
+	FilledUntil     sql.NullTime `json:"filled_until"`
+	BeforeCreatedAt sql.NullTime `json:"before_created_at"`
+	BeforeID        string       `json:"before_id"`
+	RowLimit        int32        `json:"row_limit"`
+}
+
+// Empty text filters and an empty statuses array match every order; NULL
+// times leave that end of the range open. Pages are keyed on
+// (created_at, id): pass the last row of the previous page as
+// before_created_at and before_id, or NULL for the first page.
+func (q *Queries) SearchOrders(ctx context.Context, arg SearchOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, searchOrders,
+		arg.AccountID,
+		arg.Symbol,
+		arg.Side,
+		arg.OrderType,
+		pq.Array(arg.Statuses),
+		arg.CreatedFrom,
+		arg.CreatedUntil,
+		arg.FilledFrom,
+		arg.FilledUntil,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.AlpacaOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.FilledQty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.FilledAvgPrice,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOrder = `-- name: UpdateOrder :one
+UPDATE orders SET
+    status = $2,
+    qty = CASE WHEN notional IS NULL THEN qty ELSE $3 END,
+    filled_qty = $3,
+    filled_avg_price = $4,
+    filled_at = $5,
+    canceled_at = $6,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional
+`
+
+type UpdateOrderParams struct {
+	ID             string              `json:"id"`
+	Status         string              `json:"status"`
+	FilledQty      decimal.Decimal     `json:"filled_qty"`
+	FilledAvgPrice decimal.NullDecimal `json:"filled_avg_price"`
+	FilledAt       sql.NullTime        `json:"filled_at"`
+	CanceledAt     sql.NullTime        `json:"canceled_at"`
+}
+
+// Keeps a notional order's qty at the quantity filled so far.
+func (q *Queries) UpdateOrder(ctx context.Context, arg UpdateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, updateOrder,
+		arg.ID,
+		arg.Status,
+		arg.FilledQty,
+		arg.FilledAvgPrice,
+		arg.FilledAt,
+		arg.CanceledAt,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
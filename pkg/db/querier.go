@@ -0,0 +1,124 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+
+package db
+
+import (
+	"context"
+	"time"
+)
+
+type Querier interface {
+	AddWatchlistItem(ctx context.Context, arg AddWatchlistItemParams) (WatchlistItem, error)
+	AppendEvent(ctx context.Context, arg AppendEventParams) (Event, error)
+	// Moves a schedule on to its next run, unless another process already did,
+	// so each run is placed once.
+	ClaimScheduledOrderRun(ctx context.Context, arg ClaimScheduledOrderRunParams) (int64, error)
+	CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error)
+	CreateAlert(ctx context.Context, arg CreateAlertParams) (Alert, error)
+	CreateAuditEntry(ctx context.Context, arg CreateAuditEntryParams) (AuditLog, error)
+	CreateExecution(ctx context.Context, arg CreateExecutionParams) (Execution, error)
+	CreateLotDisposal(ctx context.Context, arg CreateLotDisposalParams) (LotDisposal, error)
+	// Backfilled snapshots never replace a stored day.
+	CreateMissingAccountSnapshot(ctx context.Context, arg CreateMissingAccountSnapshotParams) (int64, error)
+	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
+	// created_at comes from the caller in UTC, as Resolve measures its age with
+	// the Go clock.
+	CreateOrderIntent(ctx context.Context, arg CreateOrderIntentParams) (OrderIntent, error)
+	CreatePosition(ctx context.Context, arg CreatePositionParams) (Position, error)
+	CreateReconcileDrift(ctx context.Context, arg CreateReconcileDriftParams) (ReconcileDrift, error)
+	CreateScheduledOrder(ctx context.Context, arg CreateScheduledOrderParams) (ScheduledOrder, error)
+	CreateScheduledOrderRun(ctx context.Context, arg CreateScheduledOrderRunParams) (ScheduledOrderRun, error)
+	CreateTaxLot(ctx context.Context, arg CreateTaxLotParams) (TaxLot, error)
+	DeleteAlert(ctx context.Context, id int64) (int64, error)
+	DeleteAllOrders(ctx context.Context) error
+	DeleteAllPositions(ctx context.Context) error
+	DeleteAllTaxLots(ctx context.Context) error
+	DeletePosition(ctx context.Context, arg DeletePositionParams) error
+	DeleteScheduledOrder(ctx context.Context, id int64) (int64, error)
+	DeleteTaxLots(ctx context.Context, arg DeleteTaxLotsParams) error
+	DeleteWatchlist(ctx context.Context, id string) error
+	DeleteWatchlistItems(ctx context.Context, watchlistID string) error
+	GetAccount(ctx context.Context, id string) (Account, error)
+	GetAccountByAlpacaID(ctx context.Context, alpacaAccountID string) (Account, error)
+	GetAlert(ctx context.Context, id int64) (Alert, error)
+	GetEventForUpdate(ctx context.Context, id int64) (Event, error)
+	GetOrder(ctx context.Context, id string) (Order, error)
+	GetOrderByAlpacaID(ctx context.Context, alpacaOrderID string) (Order, error)
+	GetPosition(ctx context.Context, arg GetPositionParams) (Position, error)
+	GetScheduledOrder(ctx context.Context, id int64) (ScheduledOrder, error)
+	GetTaxLotByExecution(ctx context.Context, executionID string) (TaxLot, error)
+	GetWatchlist(ctx context.Context, id string) (Watchlist, error)
+	// Snapshots for trading days in [date_from, date_until], oldest first.
+	ListAccountSnapshots(ctx context.Context, arg ListAccountSnapshotsParams) ([]AccountSnapshot, error)
+	ListAccounts(ctx context.Context) ([]Account, error)
+	ListAlerts(ctx context.Context) ([]Alert, error)
+	// Empty account_id or action match every entry.
+	ListAuditEntries(ctx context.Context, arg ListAuditEntriesParams) ([]AuditLog, error)
+	// Bars that started in [started_from, started_until), oldest first.
+	ListBars(ctx context.Context, arg ListBarsParams) ([]Bar, error)
+	// Enabled schedules whose next run is at or before now, earliest first.
+	ListDueScheduledOrders(ctx context.Context, now time.Time) ([]ScheduledOrder, error)
+	ListEnabledAlerts(ctx context.Context) ([]Alert, error)
+	ListEventsAfter(ctx context.Context, arg ListEventsAfterParams) ([]Event, error)
+	ListExecutedSymbols(ctx context.Context) ([]ListExecutedSymbolsRow, error)
+	ListExecutions(ctx context.Context, arg ListExecutionsParams) ([]Execution, error)
+	ListExecutionsByOrder(ctx context.Context, orderID string) ([]Execution, error)
+	ListExecutionsBySymbol(ctx context.Context, arg ListExecutionsBySymbolParams) ([]Execution, error)
+	// Disposals closed in [closed_from, closed_until). An empty account_id
+	// matches every account.
+	ListLotDisposals(ctx context.Context, arg ListLotDisposalsParams) ([]ListLotDisposalsRow, error)
+	ListLotSelections(ctx context.Context, accountID string) ([]LotSelection, error)
+	// An empty account_id lists the open lots of every account.
+	ListOpenTaxLots(ctx context.Context, accountID string) ([]TaxLot, error)
+	// Newest first. Empty account_id or status match every intent.
+	ListOrderIntents(ctx context.Context, arg ListOrderIntentsParams) ([]OrderIntent, error)
+	ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error)
+	ListOrdersByStatus(ctx context.Context, arg ListOrdersByStatusParams) ([]Order, error)
+	ListPendingOrderIntents(ctx context.Context) ([]OrderIntent, error)
+	// Runs whose order was sent without an answer, with what the outbox has
+	// since made of it: the intent's status, and the order if it was sent.
+	ListPendingScheduledOrderRuns(ctx context.Context) ([]ListPendingScheduledOrderRunsRow, error)
+	ListPositions(ctx context.Context, accountID string) ([]Position, error)
+	ListReconcileDrifts(ctx context.Context, arg ListReconcileDriftsParams) ([]ReconcileDrift, error)
+	// A schedule's runs, newest first, with the status of the order each placed.
+	ListScheduledOrderRuns(ctx context.Context, arg ListScheduledOrderRunsParams) ([]ListScheduledOrderRunsRow, error)
+	ListScheduledOrders(ctx context.Context) ([]ScheduledOrder, error)
+	ListUnappliedEvents(ctx context.Context, limit int32) ([]Event, error)
+	ListWatchlistItems(ctx context.Context, watchlistID string) ([]WatchlistItem, error)
+	ListWatchlists(ctx context.Context, accountID string) ([]Watchlist, error)
+	MarkEventApplied(ctx context.Context, id int64) error
+	MarkOrderIntentFailed(ctx context.Context, arg MarkOrderIntentFailedParams) (OrderIntent, error)
+	MarkOrderIntentSent(ctx context.Context, arg MarkOrderIntentSentParams) (OrderIntent, error)
+	// Days are New York trading dates, as for account snapshots. Notional is
+	// filled quantity times average fill price.
+	OrderStatsByDay(ctx context.Context, arg OrderStatsByDayParams) ([]OrderStatsByDayRow, error)
+	OrderStatsBySymbol(ctx context.Context, arg OrderStatsBySymbolParams) ([]OrderStatsBySymbolRow, error)
+	RecordAlertTrigger(ctx context.Context, arg RecordAlertTriggerParams) error
+	ResetEventsApplied(ctx context.Context) error
+	// Empty text filters and an empty statuses array match every order; NULL
+	// times leave that end of the range open. Pages are keyed on
+	// (created_at, id): pass the last row of the previous page as
+	// before_created_at and before_id, or NULL for the first page.
+	SearchOrders(ctx context.Context, arg SearchOrdersParams) ([]Order, error)
+	SetAlertEnabled(ctx context.Context, arg SetAlertEnabledParams) (Alert, error)
+	SetScheduledOrderEnabled(ctx context.Context, arg SetScheduledOrderEnabledParams) (ScheduledOrder, error)
+	SetScheduledOrderResult(ctx context.Context, arg SetScheduledOrderResultParams) error
+	// Gives the schedule a settled run's result, if that run was its last.
+	SettleScheduledOrderResult(ctx context.Context, arg SettleScheduledOrderResultParams) error
+	// Records what became of a pending run, unless another process already did.
+	SettleScheduledOrderRun(ctx context.Context, arg SettleScheduledOrderRunParams) (int64, error)
+	UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error)
+	// Keeps a notional order's qty at the quantity filled so far.
+	UpdateOrder(ctx context.Context, arg UpdateOrderParams) (Order, error)
+	UpdatePosition(ctx context.Context, arg UpdatePositionParams) (Position, error)
+	// Live snapshots replace whatever was stored for the day.
+	UpsertAccountSnapshot(ctx context.Context, arg UpsertAccountSnapshotParams) (AccountSnapshot, error)
+	// Imported and fetched bars replace whatever was stored for the period.
+	UpsertBar(ctx context.Context, arg UpsertBarParams) error
+	UpsertLotSelection(ctx context.Context, arg UpsertLotSelectionParams) (LotSelection, error)
+	UpsertWatchlist(ctx context.Context, arg UpsertWatchlistParams) (Watchlist, error)
+}
+
+var _ Querier = (*Queries)(nil)
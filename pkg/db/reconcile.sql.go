@@ -0,0 +1,94 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: reconcile.sql
+
+package db
+
+import (
+	"context"
+)
+
+const createReconcileDrift = `-- name: CreateReconcileDrift :one
+INSERT INTO reconcile_drifts (
+    account_id, entity, entity_id, field, local_value, broker_value
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, account_id, entity, entity_id, field, local_value, broker_value, corrected_at
+`
+
+type CreateReconcileDriftParams struct {
+	AccountID   string `json:"account_id"`
+	Entity      string `json:"entity"`
+	EntityID    string `json:"entity_id"`
+	Field       string `json:"field"`
+	LocalValue  string `json:"local_value"`
+	BrokerValue string `json:"broker_value"`
+}
+
+func (q *Queries) CreateReconcileDrift(ctx context.Context, arg CreateReconcileDriftParams) (ReconcileDrift, error) {
+	row := q.db.QueryRowContext(ctx, createReconcileDrift,
+		arg.AccountID,
+		arg.Entity,
+		arg.EntityID,
+		arg.Field,
+		arg.LocalValue,
+		arg.BrokerValue,
+	)
+	var i ReconcileDrift
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Entity,
+		&i.EntityID,
+		&i.Field,
+		&i.LocalValue,
+		&i.BrokerValue,
+		&i.CorrectedAt,
+	)
+	return i, err
+}
+
+const listReconcileDrifts = `-- name: ListReconcileDrifts :many
+SELECT id, account_id, entity, entity_id, field, local_value, broker_value, corrected_at FROM reconcile_drifts
+WHERE account_id = $1
+ORDER BY corrected_at DESC
+LIMIT $2
+`
+
+type ListReconcileDriftsParams struct {
+	AccountID string `json:"account_id"`
+	Limit     int32  `json:"limit"`
+}
+
+func (q *Queries) ListReconcileDrifts(ctx context.Context, arg ListReconcileDriftsParams) ([]ReconcileDrift, error) {
+	rows, err := q.db.QueryContext(ctx, listReconcileDrifts, arg.AccountID, arg.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ReconcileDrift{}
+	for rows.Next() {
+		var i ReconcileDrift
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Entity,
+			&i.EntityID,
+			&i.Field,
+			&i.LocalValue,
+			&i.BrokerValue,
+			&i.CorrectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,514 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: scheduled_orders.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const claimScheduledOrderRun = `-- name: ClaimScheduledOrderRun :execrows
+UPDATE scheduled_orders SET
+    next_run_at = $1,
+    last_run_at = $2::timestamp,
+    updated_at = NOW()
+WHERE id = $3
+  AND enabled
+  AND next_run_at = $2::timestamp
+`
+
+type ClaimScheduledOrderRunParams struct {
+	NextRunAt    sql.NullTime `json:"next_run_at"`
+	ScheduledFor time.Time    `json:"scheduled_for"`
+	ID           int64        `json:"id"`
+}
+
+// Moves a schedule on to its next run, unless another process already did,
+// so each run is placed once.
+func (q *Queries) ClaimScheduledOrderRun(ctx context.Context, arg ClaimScheduledOrderRunParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, claimScheduledOrderRun, arg.NextRunAt, arg.ScheduledFor, arg.ID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const createScheduledOrder = `-- name: CreateScheduledOrder :one
+INSERT INTO scheduled_orders (
+    account_id, schedule, time_zone, symbol, side, order_type, qty, notional,
+    limit_price, stop_price, time_in_force, enabled, next_run_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+) RETURNING id, account_id, schedule, time_zone, symbol, side, order_type, qty, notional, limit_price, stop_price, time_in_force, enabled, next_run_at, last_run_at, last_result, created_at, updated_at
+`
+
+type CreateScheduledOrderParams struct {
+	AccountID   string              `json:"account_id"`
+	Schedule    string              `json:"schedule"`
+	TimeZone    string              `json:"time_zone"`
+	Symbol      string              `json:"symbol"`
+	Side        string              `json:"side"`
+	OrderType   string              `json:"order_type"`
+	Qty         decimal.NullDecimal `json:"qty"`
+	Notional    decimal.NullDecimal `json:"notional"`
+	LimitPrice  decimal.NullDecimal `json:"limit_price"`
+	StopPrice   decimal.NullDecimal `json:"stop_price"`
+	TimeInForce string              `json:"time_in_force"`
+	Enabled     bool                `json:"enabled"`
+	NextRunAt   sql.NullTime        `json:"next_run_at"`
+}
+
+func (q *Queries) CreateScheduledOrder(ctx context.Context, arg CreateScheduledOrderParams) (ScheduledOrder, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledOrder,
+		arg.AccountID,
+		arg.Schedule,
+		arg.TimeZone,
+		arg.Symbol,
+		arg.Side,
+		arg.OrderType,
+		arg.Qty,
+		arg.Notional,
+		arg.LimitPrice,
+		arg.StopPrice,
+		arg.TimeInForce,
+		arg.Enabled,
+		arg.NextRunAt,
+	)
+	var i ScheduledOrder
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Schedule,
+		&i.TimeZone,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.Notional,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Enabled,
+		&i.NextRunAt,
+		&i.LastRunAt,
+		&i.LastResult,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const createScheduledOrderRun = `-- name: CreateScheduledOrderRun :one
+INSERT INTO scheduled_order_runs (
+    scheduled_order_id, scheduled_for, result, client_order_id, order_id, message
+) VALUES (
+    $1, $2, $3, $4, $5, $6
+) RETURNING id, scheduled_order_id, scheduled_for, result, client_order_id, order_id, message, created_at
+`
+
+type CreateScheduledOrderRunParams struct {
+	ScheduledOrderID int64          `json:"scheduled_order_id"`
+	ScheduledFor     time.Time      `json:"scheduled_for"`
+	Result           string         `json:"result"`
+	ClientOrderID    sql.NullString `json:"client_order_id"`
+	OrderID          sql.NullString `json:"order_id"`
+	Message          sql.NullString `json:"message"`
+}
+
+func (q *Queries) CreateScheduledOrderRun(ctx context.Context, arg CreateScheduledOrderRunParams) (ScheduledOrderRun, error) {
+	row := q.db.QueryRowContext(ctx, createScheduledOrderRun,
+		arg.ScheduledOrderID,
+		arg.ScheduledFor,
+		arg.Result,
+		arg.ClientOrderID,
+		arg.OrderID,
+		arg.Message,
+	)
+	var i ScheduledOrderRun
+	err := row.Scan(
+		&i.ID,
+		&i.ScheduledOrderID,
+		&i.ScheduledFor,
+		&i.Result,
+		&i.ClientOrderID,
+		&i.OrderID,
+		&i.Message,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteScheduledOrder = `-- name: DeleteScheduledOrder :execrows
+DELETE FROM scheduled_orders WHERE id = $1
+`
+
+func (q *Queries) DeleteScheduledOrder(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteScheduledOrder, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getScheduledOrder = `-- name: GetScheduledOrder :one
+SELECT id, account_id, schedule, time_zone, symbol, side, order_type, qty, notional, limit_price, stop_price, time_in_force, enabled, next_run_at, last_run_at, last_result, created_at, updated_at FROM scheduled_orders WHERE id = $1
+`
+
+func (q *Queries) GetScheduledOrder(ctx context.Context, id int64) (ScheduledOrder, error) {
+	row := q.db.QueryRowContext(ctx, getScheduledOrder, id)
+	var i ScheduledOrder
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Schedule,
+		&i.TimeZone,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.Notional,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Enabled,
+		&i.NextRunAt,
+		&i.LastRunAt,
+		&i.LastResult,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listDueScheduledOrders = `-- name: ListDueScheduledOrders :many
+SELECT id, account_id, schedule, time_zone, symbol, side, order_type, qty, notional, limit_price, stop_price, time_in_force, enabled, next_run_at, last_run_at, last_result, created_at, updated_at FROM scheduled_orders
+WHERE enabled AND next_run_at <= $1::timestamp
+ORDER BY next_run_at, id
+`
+
+// Enabled schedules whose next run is at or before now, earliest first.
+func (q *Queries) ListDueScheduledOrders(ctx context.Context, now time.Time) ([]ScheduledOrder, error) {
+	rows, err := q.db.QueryContext(ctx, listDueScheduledOrders, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledOrder{}
+	for rows.Next() {
+		var i ScheduledOrder
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Schedule,
+			&i.TimeZone,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.Notional,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Enabled,
+			&i.NextRunAt,
+			&i.LastRunAt,
+			&i.LastResult,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listPendingScheduledOrderRuns = `-- name: ListPendingScheduledOrderRuns :many
+SELECT
+    scheduled_order_runs.id, scheduled_order_runs.scheduled_order_id,
+    scheduled_order_runs.scheduled_for, scheduled_order_runs.client_order_id,
+    order_intents.status AS intent_status,
+    order_intents.error AS intent_error,
+    orders.id AS order_id,
+    orders.status AS order_status
+FROM scheduled_order_runs
+LEFT JOIN order_intents ON order_intents.client_order_id = scheduled_order_runs.client_order_id
+LEFT JOIN orders ON orders.alpaca_order_id = order_intents.alpaca_order_id
+WHERE scheduled_order_runs.result = 'pending'
+ORDER BY scheduled_order_runs.id
+`
+
+type ListPendingScheduledOrderRunsRow struct {
+	ID               int64          `json:"id"`
+	ScheduledOrderID int64          `json:"scheduled_order_id"`
+	ScheduledFor     time.Time      `json:"scheduled_for"`
+	ClientOrderID    sql.NullString `json:"client_order_id"`
+	IntentStatus     sql.NullString `json:"intent_status"`
+	IntentError      sql.NullString `json:"intent_error"`
+	OrderID          sql.NullString `json:"order_id"`
+	OrderStatus      sql.NullString `json:"order_status"`
+}
+
+// Runs whose order was sent without an answer, with what the outbox has
+// since made of it: the intent's status, and the order if it was sent.
+func (q *Queries) ListPendingScheduledOrderRuns(ctx context.Context) ([]ListPendingScheduledOrderRunsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listPendingScheduledOrderRuns)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListPendingScheduledOrderRunsRow{}
+	for rows.Next() {
+		var i ListPendingScheduledOrderRunsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScheduledOrderID,
+			&i.ScheduledFor,
+			&i.ClientOrderID,
+			&i.IntentStatus,
+			&i.IntentError,
+			&i.OrderID,
+			&i.OrderStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledOrderRuns = `-- name: ListScheduledOrderRuns :many
+SELECT
+    scheduled_order_runs.id, scheduled_order_runs.scheduled_order_id,
+    scheduled_order_runs.scheduled_for, scheduled_order_runs.result,
+    scheduled_order_runs.client_order_id, scheduled_order_runs.order_id,
+    scheduled_order_runs.message, scheduled_order_runs.created_at,
+    orders.status AS order_status
+FROM scheduled_order_runs
+LEFT JOIN orders ON orders.id = scheduled_order_runs.order_id
+WHERE scheduled_order_runs.scheduled_order_id = $1
+ORDER BY scheduled_order_runs.scheduled_for DESC
+LIMIT $2
+`
+
+type ListScheduledOrderRunsParams struct {
+	ScheduledOrderID int64 `json:"scheduled_order_id"`
+	RowLimit         int32 `json:"row_limit"`
+}
+
+type ListScheduledOrderRunsRow struct {
+	ID               int64          `json:"id"`
+	ScheduledOrderID int64          `json:"scheduled_order_id"`
+	ScheduledFor     time.Time      `json:"scheduled_for"`
+	Result           string         `json:"result"`
+	ClientOrderID    sql.NullString `json:"client_order_id"`
+	OrderID          sql.NullString `json:"order_id"`
+	Message          sql.NullString `json:"message"`
+	CreatedAt        time.Time      `json:"created_at"`
+	OrderStatus      sql.NullString `json:"order_status"`
+}
+
+// A schedule's runs, newest first, with the status of the order each placed.
+func (q *Queries) ListScheduledOrderRuns(ctx context.Context, arg ListScheduledOrderRunsParams) ([]ListScheduledOrderRunsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledOrderRuns, arg.ScheduledOrderID, arg.RowLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListScheduledOrderRunsRow{}
+	for rows.Next() {
+		var i ListScheduledOrderRunsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ScheduledOrderID,
+			&i.ScheduledFor,
+			&i.Result,
+			&i.ClientOrderID,
+			&i.OrderID,
+			&i.Message,
+			&i.CreatedAt,
+			&i.OrderStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listScheduledOrders = `-- name: ListScheduledOrders :many
+SELECT id, account_id, schedule, time_zone, symbol, side, order_type, qty, notional, limit_price, stop_price, time_in_force, enabled, next_run_at, last_run_at, last_result, created_at, updated_at FROM scheduled_orders ORDER BY id
+`
+
+func (q *Queries) ListScheduledOrders(ctx context.Context) ([]ScheduledOrder, error) {
+	rows, err := q.db.QueryContext(ctx, listScheduledOrders)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ScheduledOrder{}
+	for rows.Next() {
+		var i ScheduledOrder
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Schedule,
+			&i.TimeZone,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.Notional,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Enabled,
+			&i.NextRunAt,
+			&i.LastRunAt,
+			&i.LastResult,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const setScheduledOrderEnabled = `-- name: SetScheduledOrderEnabled :one
+UPDATE scheduled_orders SET
+    enabled = $2,
+    next_run_at = $3,
+    updated_at = NOW()
+WHERE id = $1
+RETURNING id, account_id, schedule, time_zone, symbol, side, order_type, qty, notional, limit_price, stop_price, time_in_force, enabled, next_run_at, last_run_at, last_result, created_at, updated_at
+`
+
+type SetScheduledOrderEnabledParams struct {
+	ID        int64        `json:"id"`
+	Enabled   bool         `json:"enabled"`
+	NextRunAt sql.NullTime `json:"next_run_at"`
+}
+
+func (q *Queries) SetScheduledOrderEnabled(ctx context.Context, arg SetScheduledOrderEnabledParams) (ScheduledOrder, error) {
+	row := q.db.QueryRowContext(ctx, setScheduledOrderEnabled, arg.ID, arg.Enabled, arg.NextRunAt)
+	var i ScheduledOrder
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Schedule,
+		&i.TimeZone,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.Notional,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Enabled,
+		&i.NextRunAt,
+		&i.LastRunAt,
+		&i.LastResult,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const setScheduledOrderResult = `-- name: SetScheduledOrderResult :exec
+UPDATE scheduled_orders SET
+    last_result = $2,
+    updated_at = NOW()
+WHERE id = $1
+`
+
+type SetScheduledOrderResultParams struct {
+	ID         int64          `json:"id"`
+	LastResult sql.NullString `json:"last_result"`
+}
+
+func (q *Queries) SetScheduledOrderResult(ctx context.Context, arg SetScheduledOrderResultParams) error {
+	_, err := q.db.ExecContext(ctx, setScheduledOrderResult, arg.ID, arg.LastResult)
+	return err
+}
+
+const settleScheduledOrderResult = `-- name: SettleScheduledOrderResult :exec
+UPDATE scheduled_orders SET
+    last_result = $1,
+    updated_at = NOW()
+WHERE id = $2
+  AND last_run_at = $3::timestamp
+`
+
+type SettleScheduledOrderResultParams struct {
+	LastResult   sql.NullString `json:"last_result"`
+	ID           int64          `json:"id"`
+	ScheduledFor time.Time      `json:"scheduled_for"`
+}
+
+// Gives the schedule a settled run's result, if that run was its last.
+func (q *Queries) SettleScheduledOrderResult(ctx context.Context, arg SettleScheduledOrderResultParams) error {
+	_, err := q.db.ExecContext(ctx, settleScheduledOrderResult, arg.LastResult, arg.ID, arg.ScheduledFor)
+	return err
+}
+
+const settleScheduledOrderRun = `-- name: SettleScheduledOrderRun :execrows
+UPDATE scheduled_order_runs SET
+    result = $1,
+    order_id = $2,
+    message = $3
+WHERE id = $4 AND result = 'pending'
+`
+
+type SettleScheduledOrderRunParams struct {
+	Result  string         `json:"result"`
+	OrderID sql.NullString `json:"order_id"`
+	Message sql.NullString `json:"message"`
+	ID      int64          `json:"id"`
+}
+
+// Records what became of a pending run, unless another process already did.
+func (q *Queries) SettleScheduledOrderRun(ctx context.Context, arg SettleScheduledOrderRunParams) (int64, error) {
+	result, err := q.db.ExecContext(ctx, settleScheduledOrderRun,
+		arg.Result,
+		arg.OrderID,
+		arg.Message,
+		arg.ID,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
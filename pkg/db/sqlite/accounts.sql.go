@@ -0,0 +1,176 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: accounts.sql
+
+package sqlite
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+const createAccount = `-- name: CreateAccount :one
+INSERT INTO accounts (
+    id, alpaca_account_id, status, currency, cash, portfolio_value, buying_power
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7
+) RETURNING id, alpaca_account_id, status, currency, cash, portfolio_value, buying_power, created_at, updated_at
+`
+
+type CreateAccountParams struct {
+	ID              string          `json:"id"`
+	AlpacaAccountID string          `json:"alpaca_account_id"`
+	Status          string          `json:"status"`
+	Currency        string          `json:"currency"`
+	Cash            decimal.Decimal `json:"cash"`
+	PortfolioValue  decimal.Decimal `json:"portfolio_value"`
+	BuyingPower     decimal.Decimal `json:"buying_power"`
+}
+
+func (q *Queries) CreateAccount(ctx context.Context, arg CreateAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, createAccount,
+		arg.ID,
+		arg.AlpacaAccountID,
+		arg.Status,
+		arg.Currency,
+		arg.Cash,
+		arg.PortfolioValue,
+		arg.BuyingPower,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaAccountID,
+		&i.Status,
+		&i.Currency,
+		&i.Cash,
+		&i.PortfolioValue,
+		&i.BuyingPower,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAccount = `-- name: GetAccount :one
+SELECT id, alpaca_account_id, status, currency, cash, portfolio_value, buying_power, created_at, updated_at FROM accounts WHERE id = ?1
+`
+
+func (q *Queries) GetAccount(ctx context.Context, id string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccount, id)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaAccountID,
+		&i.Status,
+		&i.Currency,
+		&i.Cash,
+		&i.PortfolioValue,
+		&i.BuyingPower,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const getAccountByAlpacaID = `-- name: GetAccountByAlpacaID :one
+SELECT id, alpaca_account_id, status, currency, cash, portfolio_value, buying_power, created_at, updated_at FROM accounts WHERE alpaca_account_id = ?1
+`
+
+func (q *Queries) GetAccountByAlpacaID(ctx context.Context, alpacaAccountID string) (Account, error) {
+	row := q.db.QueryRowContext(ctx, getAccountByAlpacaID, alpacaAccountID)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaAccountID,
+		&i.Status,
+		&i.Currency,
+		&i.Cash,
+		&i.PortfolioValue,
+		&i.BuyingPower,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listAccounts = `-- name: ListAccounts :many
+SELECT id, alpaca_account_id, status, currency, cash, portfolio_value, buying_power, created_at, updated_at FROM accounts ORDER BY created_at DESC
+`
+
+func (q *Queries) ListAccounts(ctx context.Context) ([]Account, error) {
+	rows, err := q.db.QueryContext(ctx, listAccounts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Account{}
+	for rows.Next() {
+		var i Account
+		if err := rows.Scan(
+			&i.ID,
+			&i.AlpacaAccountID,
+			&i.Status,
+			&i.Currency,
+			&i.Cash,
+			&i.PortfolioValue,
+			&i.BuyingPower,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateAccount = `-- name: UpdateAccount :one
+UPDATE accounts SET
+    status = ?2,
+    cash = ?3,
+    portfolio_value = ?4,
+    buying_power = ?5,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?1
+RETURNING id, alpaca_account_id, status, currency, cash, portfolio_value, buying_power, created_at, updated_at
+`
+
+type UpdateAccountParams struct {
+	ID             string          `json:"id"`
+	Status         string          `json:"status"`
+	Cash           decimal.Decimal `json:"cash"`
+	PortfolioValue decimal.Decimal `json:"portfolio_value"`
+	BuyingPower    decimal.Decimal `json:"buying_power"`
+}
+
+func (q *Queries) UpdateAccount(ctx context.Context, arg UpdateAccountParams) (Account, error) {
+	row := q.db.QueryRowContext(ctx, updateAccount,
+		arg.ID,
+		arg.Status,
+		arg.Cash,
+		arg.PortfolioValue,
+		arg.BuyingPower,
+	)
+	var i Account
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaAccountID,
+		&i.Status,
+		&i.Currency,
+		&i.Cash,
+		&i.PortfolioValue,
+		&i.BuyingPower,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
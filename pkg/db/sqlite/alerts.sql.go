@@ -0,0 +1,207 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: alerts.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+)
+
+const createAlert = `-- name: CreateAlert :one
+INSERT INTO alerts (account_id, rule, notify, enabled)
+VALUES (?1, ?2, ?3, ?4)
+RETURNING id, account_id, rule, notify, enabled, trigger_count, last_triggered_at, last_message, created_at, updated_at
+`
+
+type CreateAlertParams struct {
+	AccountID sql.NullString `json:"account_id"`
+	Rule      string         `json:"rule"`
+	Notify    string         `json:"notify"`
+	Enabled   bool           `json:"enabled"`
+}
+
+func (q *Queries) CreateAlert(ctx context.Context, arg CreateAlertParams) (Alert, error) {
+	row := q.db.QueryRowContext(ctx, createAlert,
+		arg.AccountID,
+		arg.Rule,
+		arg.Notify,
+		arg.Enabled,
+	)
+	var i Alert
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Rule,
+		&i.Notify,
+		&i.Enabled,
+		&i.TriggerCount,
+		&i.LastTriggeredAt,
+		&i.LastMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteAlert = `-- name: DeleteAlert :execrows
+DELETE FROM alerts WHERE id = ?1
+`
+
+func (q *Queries) DeleteAlert(ctx context.Context, id int64) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteAlert, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+const getAlert = `-- name: GetAlert :one
+SELECT id, account_id, rule, notify, enabled, trigger_count, last_triggered_at, last_message, created_at, updated_at FROM alerts WHERE id = ?1
+`
+
+func (q *Queries) GetAlert(ctx context.Context, id int64) (Alert, error) {
+	row := q.db.QueryRowContext(ctx, getAlert, id)
+	var i Alert
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Rule,
+		&i.Notify,
+		&i.Enabled,
+		&i.TriggerCount,
+		&i.LastTriggeredAt,
+		&i.LastMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listAlerts = `-- name: ListAlerts :many
+SELECT id, account_id, rule, notify, enabled, trigger_count, last_triggered_at, last_message, created_at, updated_at FROM alerts ORDER BY id
+`
+
+func (q *Queries) ListAlerts(ctx context.Context) ([]Alert, error) {
+	rows, err := q.db.QueryContext(ctx, listAlerts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Alert{}
+	for rows.Next() {
+		var i Alert
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Rule,
+			&i.Notify,
+			&i.Enabled,
+			&i.TriggerCount,
+			&i.LastTriggeredAt,
+			&i.LastMessage,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listEnabledAlerts = `-- name: ListEnabledAlerts :many
+SELECT id, account_id, rule, notify, enabled, trigger_count, last_triggered_at, last_message, created_at, updated_at FROM alerts WHERE enabled ORDER BY id
+`
+
+func (q *Queries) ListEnabledAlerts(ctx context.Context) ([]Alert, error) {
+	rows, err := q.db.QueryContext(ctx, listEnabledAlerts)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Alert{}
+	for rows.Next() {
+		var i Alert
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Rule,
+			&i.Notify,
+			&i.Enabled,
+			&i.TriggerCount,
+			&i.LastTriggeredAt,
+			&i.LastMessage,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const recordAlertTrigger = `-- name: RecordAlertTrigger :exec
+UPDATE alerts SET
+    trigger_count = trigger_count + 1,
+    last_triggered_at = ?2,
+    last_message = ?3
+WHERE id = ?1
+`
+
+type RecordAlertTriggerParams struct {
+	ID              int64          `json:"id"`
+	LastTriggeredAt sql.NullTime   `json:"last_triggered_at"`
+	LastMessage     sql.NullString `json:"last_message"`
+}
+
+func (q *Queries) RecordAlertTrigger(ctx context.Context, arg RecordAlertTriggerParams) error {
+	_, err := q.db.ExecContext(ctx, recordAlertTrigger, arg.ID, arg.LastTriggeredAt, arg.LastMessage)
+	return err
+}
+
+const setAlertEnabled = `-- name: SetAlertEnabled :one
+UPDATE alerts SET
+    enabled = ?2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?1
+RETURNING id, account_id, rule, notify, enabled, trigger_count, last_triggered_at, last_message, created_at, updated_at
+`
+
+type SetAlertEnabledParams struct {
+	ID      int64 `json:"id"`
+	Enabled bool  `json:"enabled"`
+}
+
+func (q *Queries) SetAlertEnabled(ctx context.Context, arg SetAlertEnabledParams) (Alert, error) {
+	row := q.db.QueryRowContext(ctx, setAlertEnabled, arg.ID, arg.Enabled)
+	var i Alert
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Rule,
+		&i.Notify,
+		&i.Enabled,
+		&i.TriggerCount,
+		&i.LastTriggeredAt,
+		&i.LastMessage,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,108 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: bars.sql
+
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const listBars = `-- name: ListBars :many
+SELECT symbol, timeframe, started_at, open_price, high_price, low_price, close_price, volume FROM bars
+WHERE symbol = ?1
+  AND timeframe = ?2
+  AND julianday(started_at) >= julianday(?3)
+  AND julianday(started_at) < julianday(?4)
+ORDER BY julianday(started_at)
+`
+
+type ListBarsParams struct {
+	Symbol       string      `json:"symbol"`
+	Timeframe    string      `json:"timeframe"`
+	StartedFrom  interface{} `json:"started_from"`
+	StartedUntil interface{} `json:"started_until"`
+}
+
+// Bars that started in [started_from, started_until), oldest first.
+func (q *Queries) ListBars(ctx context.Context, arg ListBarsParams) ([]Bar, error) {
+	rows, err := q.db.QueryContext(ctx, listBars,
+		arg.Symbol,
+		arg.Timeframe,
+		arg.StartedFrom,
+		arg.StartedUntil,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Bar{}
+	for rows.Next() {
+		var i Bar
+		if err := rows.Scan(
+			&i.Symbol,
+			&i.Timeframe,
+			&i.StartedAt,
+			&i.OpenPrice,
+			&i.HighPrice,
+			&i.LowPrice,
+			&i.ClosePrice,
+			&i.Volume,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertBar = `-- name: UpsertBar :exec
+INSERT INTO bars (
+    symbol, timeframe, started_at, open_price, high_price, low_price,
+    close_price, volume
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8
+)
+ON CONFLICT (symbol, timeframe, started_at) DO UPDATE SET
+    open_price = excluded.open_price,
+    high_price = excluded.high_price,
+    low_price = excluded.low_price,
+    close_price = excluded.close_price,
+    volume = excluded.volume
+`
+
+type UpsertBarParams struct {
+	Symbol     string          `json:"symbol"`
+	Timeframe  string          `json:"timeframe"`
+	StartedAt  time.Time       `json:"started_at"`
+	OpenPrice  decimal.Decimal `json:"open_price"`
+	HighPrice  decimal.Decimal `json:"high_price"`
+	LowPrice   decimal.Decimal `json:"low_price"`
+	ClosePrice decimal.Decimal `json:"close_price"`
+	Volume     int64           `json:"volume"`
+}
+
+// Imported and fetched bars replace whatever was stored for the period.
+func (q *Queries) UpsertBar(ctx context.Context, arg UpsertBarParams) error {
+	_, err := q.db.ExecContext(ctx, upsertBar,
+		arg.Symbol,
+		arg.Timeframe,
+		arg.StartedAt,
+		arg.OpenPrice,
+		arg.HighPrice,
+		arg.LowPrice,
+		arg.ClosePrice,
+		arg.Volume,
+	)
+	return err
+}
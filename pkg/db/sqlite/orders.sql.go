@@ -0,0 +1,473 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: orders.sql
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createOrder = `-- name: CreateOrder :one
+INSERT INTO orders (
+    id, alpaca_order_id, account_id, symbol, side, order_type, qty,
+    limit_price, stop_price, time_in_force, status, submitted_at, created_at,
+    notional
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9, ?10, ?11, ?12, ?13, ?14
+) RETURNING id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional
+`
+
+type CreateOrderParams struct {
+	ID            string              `json:"id"`
+	AlpacaOrderID string              `json:"alpaca_order_id"`
+	AccountID     string              `json:"account_id"`
+	Symbol        string              `json:"symbol"`
+	Side          string              `json:"side"`
+	OrderType     string              `json:"order_type"`
+	Qty           decimal.Decimal     `json:"qty"`
+	LimitPrice    decimal.NullDecimal `json:"limit_price"`
+	StopPrice     decimal.NullDecimal `json:"stop_price"`
+	TimeInForce   string              `json:"time_in_force"`
+	Status        string              `json:"status"`
+	SubmittedAt   sql.NullTime        `json:"submitted_at"`
+	CreatedAt     time.Time           `json:"created_at"`
+	Notional      decimal.NullDecimal `json:"notional"`
+}
+
+func (q *Queries) CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, createOrder,
+		arg.ID,
+		arg.AlpacaOrderID,
+		arg.AccountID,
+		arg.Symbol,
+		arg.Side,
+		arg.OrderType,
+		arg.Qty,
+		arg.LimitPrice,
+		arg.StopPrice,
+		arg.TimeInForce,
+		arg.Status,
+		arg.SubmittedAt,
+		arg.CreatedAt,
+		arg.Notional,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const deleteAllOrders = `-- name: DeleteAllOrders :exec
+DELETE FROM orders
+`
+
+func (q *Queries) DeleteAllOrders(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllOrders)
+	return err
+}
+
+const getOrder = `-- name: GetOrder :one
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders WHERE id = ?1
+`
+
+func (q *Queries) GetOrder(ctx context.Context, id string) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrder, id)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const getOrderByAlpacaID = `-- name: GetOrderByAlpacaID :one
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders WHERE alpaca_order_id = ?1
+`
+
+func (q *Queries) GetOrderByAlpacaID(ctx context.Context, alpacaOrderID string) (Order, error) {
+	row := q.db.QueryRowContext(ctx, getOrderByAlpacaID, alpacaOrderID)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
+
+const listOrderStatsRows = `-- name: ListOrderStatsRows :many
+SELECT
+    created_at,
+    symbol,
+    filled_at,
+    filled_qty,
+    filled_avg_price
+FROM orders
+WHERE account_id = ?1
+  AND (?2 IS NULL OR julianday(created_at) >= julianday(?2))
+  AND (?3 IS NULL OR julianday(created_at) < julianday(?3))
+`
+
+type ListOrderStatsRowsParams struct {
+	AccountID    string      `json:"account_id"`
+	CreatedFrom  interface{} `json:"created_from"`
+	CreatedUntil interface{} `json:"created_until"`
+}
+
+type ListOrderStatsRowsRow struct {
+	CreatedAt      time.Time           `json:"created_at"`
+	Symbol         string              `json:"symbol"`
+	FilledAt       sql.NullTime        `json:"filled_at"`
+	FilledQty      decimal.Decimal     `json:"filled_qty"`
+	FilledAvgPrice decimal.NullDecimal `json:"filled_avg_price"`
+}
+
+// The rows behind OrderStatsByDay and OrderStatsBySymbol, which sum them in
+// Go: SQLite has no exact decimal arithmetic, nor time zones to find the
+// trading date in.
+func (q *Queries) ListOrderStatsRows(ctx context.Context, arg ListOrderStatsRowsParams) ([]ListOrderStatsRowsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listOrderStatsRows, arg.AccountID, arg.CreatedFrom, arg.CreatedUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListOrderStatsRowsRow{}
+	for rows.Next() {
+		var i ListOrderStatsRowsRow
+		if err := rows.Scan(
+			&i.CreatedAt,
+			&i.Symbol,
+			&i.FilledAt,
+			&i.FilledQty,
+			&i.FilledAvgPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrders = `-- name: ListOrders :many
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders
+WHERE account_id = ?1
+ORDER BY created_at DESC
+LIMIT ?2 OFFSET ?3
+`
+
+type ListOrdersParams struct {
+	AccountID string `json:"account_id"`
+	Limit     int64  `json:"limit"`
+	Offset    int64  `json:"offset"`
+}
+
+func (q *Queries) ListOrders(ctx context.Context, arg ListOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listOrders, arg.AccountID, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.AlpacaOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.FilledQty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.FilledAvgPrice,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersByStatus = `-- name: ListOrdersByStatus :many
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders
+WHERE account_id = ?1 AND status = ?2
+ORDER BY created_at DESC
+`
+
+type ListOrdersByStatusParams struct {
+	AccountID string `json:"account_id"`
+	Status    string `json:"status"`
+}
+
+func (q *Queries) ListOrdersByStatus(ctx context.Context, arg ListOrdersByStatusParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, listOrdersByStatus, arg.AccountID, arg.Status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.AlpacaOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.FilledQty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.FilledAvgPrice,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchOrders = `-- name: SearchOrders :many
+SELECT id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional FROM orders
+WHERE account_id = ?1
+  AND (CAST(?2 AS TEXT) = '' OR symbol = ?2)
+  AND (CAST(?3 AS TEXT) = '' OR side = ?3)
+  AND (CAST(?4 AS TEXT) = '' OR order_type = ?4)
+  AND (json_array_length(CAST(?5 AS TEXT)) = 0
+       OR status IN (SELECT value FROM json_each(CAST(?5 AS TEXT))))
+  AND (?6 IS NULL OR julianday(created_at) >= julianday(?6))
+  AND (?7 IS NULL OR julianday(created_at) < julianday(?7))
+  AND (?8 IS NULL OR julianday(filled_at) >= julianday(?8))
+  AND (?9 IS NULL OR julianday(filled_at) < julianday(?9))
+  AND (?10 IS NULL
+       OR julianday(created_at) < julianday(?10)
+       OR (julianday(created_at) = julianday(?10) AND id < CAST(?11 AS TEXT)))
+ORDER BY julianday(created_at) DESC, id DESC
+LIMIT ?12
+`
+
+type SearchOrdersParams struct {
+	AccountID       string      `json:"account_id"`
+	Symbol          string      `json:"symbol"`
+	Side            string      `json:"side"`
+	OrderType       string      `json:"order_type"`
+	Statuses        string      `json:"statuses"`
+	CreatedFrom     interface{} `json:"created_from"`
+	CreatedUntil    interface{} `json:"created_until"`
+	FilledFrom      interface{} `json:"filled_from"`
+	FilledUntil     interface{} `json:"filled_until"`
+	BeforeCreatedAt interface{} `json:"before_created_at"`
+	BeforeID        string      `json:"before_id"`
+	RowLimit        int64       `json:"row_limit"`
+}
+
+// Same filters as the Postgres query, with statuses passed as a JSON array.
+// Timestamps are compared through julianday() because rows written with
+// CURRENT_TIMESTAMP and times bound from Go are formatted differently.
+func (q *Queries) SearchOrders(ctx context.Context, arg SearchOrdersParams) ([]Order, error) {
+	rows, err := q.db.QueryContext(ctx, searchOrders,
+		arg.AccountID,
+		arg.Symbol,
+		arg.Side,
+		arg.OrderType,
+		arg.Statuses,
+		arg.CreatedFrom,
+		arg.CreatedUntil,
+		arg.FilledFrom,
+		arg.FilledUntil,
+		arg.BeforeCreatedAt,
+		arg.BeforeID,
+		arg.RowLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Order{}
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.AlpacaOrderID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Side,
+			&i.OrderType,
+			&i.Qty,
+			&i.FilledQty,
+			&i.LimitPrice,
+			&i.StopPrice,
+			&i.TimeInForce,
+			&i.Status,
+			&i.FilledAvgPrice,
+			&i.SubmittedAt,
+			&i.FilledAt,
+			&i.CanceledAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Notional,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateOrder = `-- name: UpdateOrder :one
+UPDATE orders SET
+    status = ?2,
+    qty = CASE WHEN notional IS NULL THEN qty ELSE ?3 END,
+    filled_qty = ?3,
+    filled_avg_price = ?4,
+    filled_at = ?5,
+    canceled_at = ?6,
+    updated_at = CURRENT_TIMESTAMP
+WHERE id = ?1
+RETURNING id, alpaca_order_id, account_id, symbol, side, order_type, qty, filled_qty, limit_price, stop_price, time_in_force, status, filled_avg_price, submitted_at, filled_at, canceled_at, created_at, updated_at, notional
+`
+
+type UpdateOrderParams struct {
+	ID             string              `json:"id"`
+	Status         string              `json:"status"`
+	FilledQty      decimal.Decimal     `json:"filled_qty"`
+	FilledAvgPrice decimal.NullDecimal `json:"filled_avg_price"`
+	FilledAt       sql.NullTime        `json:"filled_at"`
+	CanceledAt     sql.NullTime        `json:"canceled_at"`
+}
+
+// Keeps a notional order's qty at the quantity filled so far.
+func (q *Queries) UpdateOrder(ctx context.Context, arg UpdateOrderParams) (Order, error) {
+	row := q.db.QueryRowContext(ctx, updateOrder,
+		arg.ID,
+		arg.Status,
+		arg.FilledQty,
+		arg.FilledAvgPrice,
+		arg.FilledAt,
+		arg.CanceledAt,
+	)
+	var i Order
+	err := row.Scan(
+		&i.ID,
+		&i.AlpacaOrderID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Side,
+		&i.OrderType,
+		&i.Qty,
+		&i.FilledQty,
+		&i.LimitPrice,
+		&i.StopPrice,
+		&i.TimeInForce,
+		&i.Status,
+		&i.FilledAvgPrice,
+		&i.SubmittedAt,
+		&i.FilledAt,
+		&i.CanceledAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Notional,
+	)
+	return i, err
+}
@@ -0,0 +1,215 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: positions.sql
+
+package sqlite
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+const createPosition = `-- name: CreatePosition :one
+INSERT INTO positions (
+    account_id, symbol, qty, avg_entry_price, current_price,
+    market_value, cost_basis, unrealized_pl, unrealized_plpc
+) VALUES (
+    ?1, ?2, ?3, ?4, ?5, ?6, ?7, ?8, ?9
+) RETURNING id, account_id, symbol, qty, avg_entry_price, current_price, market_value, cost_basis, unrealized_pl, unrealized_plpc, created_at, updated_at
+`
+
+type CreatePositionParams struct {
+	AccountID      string          `json:"account_id"`
+	Symbol         string          `json:"symbol"`
+	Qty            decimal.Decimal `json:"qty"`
+	AvgEntryPrice  decimal.Decimal `json:"avg_entry_price"`
+	CurrentPrice   decimal.Decimal `json:"current_price"`
+	MarketValue    decimal.Decimal `json:"market_value"`
+	CostBasis      decimal.Decimal `json:"cost_basis"`
+	UnrealizedPl   decimal.Decimal `json:"unrealized_pl"`
+	UnrealizedPlpc decimal.Decimal `json:"unrealized_plpc"`
+}
+
+func (q *Queries) CreatePosition(ctx context.Context, arg CreatePositionParams) (Position, error) {
+	row := q.db.QueryRowContext(ctx, createPosition,
+		arg.AccountID,
+		arg.Symbol,
+		arg.Qty,
+		arg.AvgEntryPrice,
+		arg.CurrentPrice,
+		arg.MarketValue,
+		arg.CostBasis,
+		arg.UnrealizedPl,
+		arg.UnrealizedPlpc,
+	)
+	var i Position
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Qty,
+		&i.AvgEntryPrice,
+		&i.CurrentPrice,
+		&i.MarketValue,
+		&i.CostBasis,
+		&i.UnrealizedPl,
+		&i.UnrealizedPlpc,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const deleteAllPositions = `-- name: DeleteAllPositions :exec
+DELETE FROM positions
+`
+
+func (q *Queries) DeleteAllPositions(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllPositions)
+	return err
+}
+
+const deletePosition = `-- name: DeletePosition :exec
+DELETE FROM positions
+WHERE account_id = ?1 AND symbol = ?2
+`
+
+type DeletePositionParams struct {
+	AccountID string `json:"account_id"`
+	Symbol    string `json:"symbol"`
+}
+
+func (q *Queries) DeletePosition(ctx context.Context, arg DeletePositionParams) error {
+	_, err := q.db.ExecContext(ctx, deletePosition, arg.AccountID, arg.Symbol)
+	return err
+}
+
+const getPosition = `-- name: GetPosition :one
+SELECT id, account_id, symbol, qty, avg_entry_price, current_price, market_value, cost_basis, unrealized_pl, unrealized_plpc, created_at, updated_at FROM positions
+WHERE account_id = ?1 AND symbol = ?2
+`
+
+type GetPositionParams struct {
+	AccountID string `json:"account_id"`
+	Symbol    string `json:"symbol"`
+}
+
+func (q *Queries) GetPosition(ctx context.Context, arg GetPositionParams) (Position, error) {
+	row := q.db.QueryRowContext(ctx, getPosition, arg.AccountID, arg.Symbol)
+	var i Position
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Qty,
+		&i.AvgEntryPrice,
+		&i.CurrentPrice,
+		&i.MarketValue,
+		&i.CostBasis,
+		&i.UnrealizedPl,
+		&i.UnrealizedPlpc,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listPositions = `-- name: ListPositions :many
+SELECT id, account_id, symbol, qty, avg_entry_price, current_price, market_value, cost_basis, unrealized_pl, unrealized_plpc, created_at, updated_at FROM positions
+WHERE account_id = ?1
+ORDER BY CAST(market_value AS REAL) DESC
+`
+
+func (q *Queries) ListPositions(ctx context.Context, accountID string) ([]Position, error) {
+	rows, err := q.db.QueryContext(ctx, listPositions, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Position{}
+	for rows.Next() {
+		var i Position
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.Qty,
+			&i.AvgEntryPrice,
+			&i.CurrentPrice,
+			&i.MarketValue,
+			&i.CostBasis,
+			&i.UnrealizedPl,
+			&i.UnrealizedPlpc,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updatePosition = `-- name: UpdatePosition :one
+UPDATE positions SET
+    qty = ?3,
+    avg_entry_price = ?4,
+    current_price = ?5,
+    market_value = ?6,
+    cost_basis = ?7,
+    unrealized_pl = ?8,
+    unrealized_plpc = ?9,
+    updated_at = CURRENT_TIMESTAMP
+WHERE account_id = ?1 AND symbol = ?2
+RETURNING id, account_id, symbol, qty, avg_entry_price, current_price, market_value, cost_basis, unrealized_pl, unrealized_plpc, created_at, updated_at
+`
+
+type UpdatePositionParams struct {
+	AccountID      string          `json:"account_id"`
+	Symbol         string          `json:"symbol"`
+	Qty            decimal.Decimal `json:"qty"`
+	AvgEntryPrice  decimal.Decimal `json:"avg_entry_price"`
+	CurrentPrice   decimal.Decimal `json:"current_price"`
+	MarketValue    decimal.Decimal `json:"market_value"`
+	CostBasis      decimal.Decimal `json:"cost_basis"`
+	UnrealizedPl   decimal.Decimal `json:"unrealized_pl"`
+	UnrealizedPlpc decimal.Decimal `json:"unrealized_plpc"`
+}
+
+func (q *Queries) UpdatePosition(ctx context.Context, arg UpdatePositionParams) (Position, error) {
+	row := q.db.QueryRowContext(ctx, updatePosition,
+		arg.AccountID,
+		arg.Symbol,
+		arg.Qty,
+		arg.AvgEntryPrice,
+		arg.CurrentPrice,
+		arg.MarketValue,
+		arg.CostBasis,
+		arg.UnrealizedPl,
+		arg.UnrealizedPlpc,
+	)
+	var i Position
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.Qty,
+		&i.AvgEntryPrice,
+		&i.CurrentPrice,
+		&i.MarketValue,
+		&i.CostBasis,
+		&i.UnrealizedPl,
+		&i.UnrealizedPlpc,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,582 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/snapshot"
+	"github.com/shopspring/decimal"
+)
+
+// Querier runs the SQLite queries behind the same db.Querier interface the
+// Postgres queries implement. The generated row and parameter structs match
+// the Postgres ones field for field (see sqlc.yaml), so most methods are a
+// plain struct conversion. The exceptions are LIMIT and OFFSET arguments,
+// which differ in width, arrays, which SQLite takes as JSON, and order
+// stats, which are summed in Go as SQLite has no exact decimal arithmetic.
+type Querier struct {
+	q *Queries
+}
+
+var _ db.Querier = (*Querier)(nil)
+
+func NewQuerier(dbtx DBTX) *Querier {
+	return &Querier{q: New(dbtx)}
+}
+
+func (s *Querier) AddWatchlistItem(ctx context.Context, arg db.AddWatchlistItemParams) (db.WatchlistItem, error) {
+	row, err := s.q.AddWatchlistItem(ctx, AddWatchlistItemParams(arg))
+	return db.WatchlistItem(row), err
+}
+
+func (s *Querier) AppendEvent(ctx context.Context, arg db.AppendEventParams) (db.Event, error) {
+	row, err := s.q.AppendEvent(ctx, AppendEventParams(arg))
+	return db.Event(row), err
+}
+
+func (s *Querier) ClaimScheduledOrderRun(ctx context.Context, arg db.ClaimScheduledOrderRunParams) (int64, error) {
+	return s.q.ClaimScheduledOrderRun(ctx, ClaimScheduledOrderRunParams{
+		NextRunAt:    arg.NextRunAt,
+		ScheduledFor: sql.NullTime{Time: arg.ScheduledFor, Valid: true},
+		ID:           arg.ID,
+	})
+}
+
+func (s *Querier) CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error) {
+	row, err := s.q.CreateAccount(ctx, CreateAccountParams(arg))
+	return db.Account(row), err
+}
+
+func (s *Querier) CreateAlert(ctx context.Context, arg db.CreateAlertParams) (db.Alert, error) {
+	row, err := s.q.CreateAlert(ctx, CreateAlertParams(arg))
+	return db.Alert(row), err
+}
+
+func (s *Querier) CreateAuditEntry(ctx context.Context, arg db.CreateAuditEntryParams) (db.AuditLog, error) {
+	row, err := s.q.CreateAuditEntry(ctx, CreateAuditEntryParams(arg))
+	return db.AuditLog(row), err
+}
+
+func (s *Querier) CreateExecution(ctx context.Context, arg db.CreateExecutionParams) (db.Execution, error) {
+	row, err := s.q.CreateExecution(ctx, CreateExecutionParams(arg))
+	return db.Execution(row), err
+}
+
+func (s *Querier) CreateLotDisposal(ctx context.Context, arg db.CreateLotDisposalParams) (db.LotDisposal, error) {
+	row, err := s.q.CreateLotDisposal(ctx, CreateLotDisposalParams(arg))
+	return db.LotDisposal(row), err
+}
+
+func (s *Querier) CreateMissingAccountSnapshot(ctx context.Context, arg db.CreateMissingAccountSnapshotParams) (int64, error) {
+	return s.q.CreateMissingAccountSnapshot(ctx, CreateMissingAccountSnapshotParams(arg))
+}
+
+func (s *Querier) CreateOrder(ctx context.Context, arg db.CreateOrderParams) (db.Order, error) {
+	row, err := s.q.CreateOrder(ctx, CreateOrderParams(arg))
+	return db.Order(row), err
+}
+
+func (s *Querier) CreateOrderIntent(ctx context.Context, arg db.CreateOrderIntentParams) (db.OrderIntent, error) {
+	row, err := s.q.CreateOrderIntent(ctx, CreateOrderIntentParams(arg))
+	return db.OrderIntent(row), err
+}
+
+func (s *Querier) CreatePosition(ctx context.Context, arg db.CreatePositionParams) (db.Position, error) {
+	row, err := s.q.CreatePosition(ctx, CreatePositionParams(arg))
+	return db.Position(row), err
+}
+
+func (s *Querier) CreateReconcileDrift(ctx context.Context, arg db.CreateReconcileDriftParams) (db.ReconcileDrift, error) {
+	row, err := s.q.CreateReconcileDrift(ctx, CreateReconcileDriftParams(arg))
+	return db.ReconcileDrift(row), err
+}
+
+func (s *Querier) CreateScheduledOrder(ctx context.Context, arg db.CreateScheduledOrderParams) (db.ScheduledOrder, error) {
+	row, err := s.q.CreateScheduledOrder(ctx, CreateScheduledOrderParams(arg))
+	return db.ScheduledOrder(row), err
+}
+
+func (s *Querier) CreateScheduledOrderRun(ctx context.Context, arg db.CreateScheduledOrderRunParams) (db.ScheduledOrderRun, error) {
+	row, err := s.q.CreateScheduledOrderRun(ctx, CreateScheduledOrderRunParams(arg))
+	return db.ScheduledOrderRun(row), err
+}
+
+func (s *Querier) CreateTaxLot(ctx context.Context, arg db.CreateTaxLotParams) (db.TaxLot, error) {
+	row, err := s.q.CreateTaxLot(ctx, CreateTaxLotParams(arg))
+	return db.TaxLot(row), err
+}
+
+func (s *Querier) DeleteAlert(ctx context.Context, id int64) (int64, error) {
+	return s.q.DeleteAlert(ctx, id)
+}
+
+func (s *Querier) DeleteAllOrders(ctx context.Context) error {
+	return s.q.DeleteAllOrders(ctx)
+}
+
+func (s *Querier) DeleteAllPositions(ctx context.Context) error {
+	return s.q.DeleteAllPositions(ctx)
+}
+
+func (s *Querier) DeleteAllTaxLots(ctx context.Context) error {
+	return s.q.DeleteAllTaxLots(ctx)
+}
+
+func (s *Querier) DeletePosition(ctx context.Context, arg db.DeletePositionParams) error {
+	return s.q.DeletePosition(ctx, DeletePositionParams(arg))
+}
+
+func (s *Querier) DeleteScheduledOrder(ctx context.Context, id int64) (int64, error) {
+	return s.q.DeleteScheduledOrder(ctx, id)
+}
+
+func (s *Querier) DeleteTaxLots(ctx context.Context, arg db.DeleteTaxLotsParams) error {
+	return s.q.DeleteTaxLots(ctx, DeleteTaxLotsParams(arg))
+}
+
+func (s *Querier) DeleteWatchlist(ctx context.Context, id string) error {
+	return s.q.DeleteWatchlist(ctx, id)
+}
+
+func (s *Querier) DeleteWatchlistItems(ctx context.Context, watchlistID string) error {
+	return s.q.DeleteWatchlistItems(ctx, watchlistID)
+}
+
+func (s *Querier) GetAccount(ctx context.Context, id string) (db.Account, error) {
+	row, err := s.q.GetAccount(ctx, id)
+	return db.Account(row), err
+}
+
+func (s *Querier) GetAccountByAlpacaID(ctx context.Context, alpacaAccountID string) (db.Account, error) {
+	row, err := s.q.GetAccountByAlpacaID(ctx, alpacaAccountID)
+	return db.Account(row), err
+}
+
+func (s *Querier) GetAlert(ctx context.Context, id int64) (db.Alert, error) {
+	row, err := s.q.GetAlert(ctx, id)
+	return db.Alert(row), err
+}
+
+func (s *Querier) GetEventForUpdate(ctx context.Context, id int64) (db.Event, error) {
+	row, err := s.q.GetEventForUpdate(ctx, id)
+	return db.Event(row), err
+}
+
+func (s *Querier) GetOrder(ctx context.Context, id string) (db.Order, error) {
+	row, err := s.q.GetOrder(ctx, id)
+	return db.Order(row), err
+}
+
+func (s *Querier) GetOrderByAlpacaID(ctx context.Context, alpacaOrderID string) (db.Order, error) {
+	row, err := s.q.GetOrderByAlpacaID(ctx, alpacaOrderID)
+	return db.Order(row), err
+}
+
+func (s *Querier) GetPosition(ctx context.Context, arg db.GetPositionParams) (db.Position, error) {
+	row, err := s.q.GetPosition(ctx, GetPositionParams(arg))
+	return db.Position(row), err
+}
+
+func (s *Querier) GetScheduledOrder(ctx context.Context, id int64) (db.ScheduledOrder, error) {
+	row, err := s.q.GetScheduledOrder(ctx, id)
+	return db.ScheduledOrder(row), err
+}
+
+func (s *Querier) GetTaxLotByExecution(ctx context.Context, executionID string) (db.TaxLot, error) {
+	row, err := s.q.GetTaxLotByExecution(ctx, executionID)
+	return db.TaxLot(row), err
+}
+
+func (s *Querier) GetWatchlist(ctx context.Context, id string) (db.Watchlist, error) {
+	row, err := s.q.GetWatchlist(ctx, id)
+	return db.Watchlist(row), err
+}
+
+func (s *Querier) ListAccountSnapshots(ctx context.Context, arg db.ListAccountSnapshotsParams) ([]db.AccountSnapshot, error) {
+	rows, err := s.q.ListAccountSnapshots(ctx, ListAccountSnapshotsParams{
+		AccountID: arg.AccountID,
+		DateFrom:  arg.DateFrom,
+		DateUntil: arg.DateUntil,
+	})
+	return convertRows(rows, err, func(r AccountSnapshot) db.AccountSnapshot { return db.AccountSnapshot(r) })
+}
+
+func (s *Querier) ListAccounts(ctx context.Context) ([]db.Account, error) {
+	rows, err := s.q.ListAccounts(ctx)
+	return convertRows(rows, err, func(r Account) db.Account { return db.Account(r) })
+}
+
+func (s *Querier) ListAlerts(ctx context.Context) ([]db.Alert, error) {
+	rows, err := s.q.ListAlerts(ctx)
+	return convertRows(rows, err, func(r Alert) db.Alert { return db.Alert(r) })
+}
+
+func (s *Querier) ListAuditEntries(ctx context.Context, arg db.ListAuditEntriesParams) ([]db.AuditLog, error) {
+	rows, err := s.q.ListAuditEntries(ctx, ListAuditEntriesParams{
+		AccountID: arg.AccountID,
+		Action:    arg.Action,
+		Since:     arg.Since,
+		RowLimit:  int64(arg.RowLimit),
+	})
+	return convertRows(rows, err, func(r AuditLog) db.AuditLog { return db.AuditLog(r) })
+}
+
+func (s *Querier) ListBars(ctx context.Context, arg db.ListBarsParams) ([]db.Bar, error) {
+	rows, err := s.q.ListBars(ctx, ListBarsParams{
+		Symbol:       arg.Symbol,
+		Timeframe:    arg.Timeframe,
+		StartedFrom:  arg.StartedFrom,
+		StartedUntil: arg.StartedUntil,
+	})
+	return convertRows(rows, err, func(r Bar) db.Bar { return db.Bar(r) })
+}
+
+func (s *Querier) ListDueScheduledOrders(ctx context.Context, now time.Time) ([]db.ScheduledOrder, error) {
+	rows, err := s.q.ListDueScheduledOrders(ctx, now)
+	return convertRows(rows, err, func(r ScheduledOrder) db.ScheduledOrder { return db.ScheduledOrder(r) })
+}
+
+func (s *Querier) ListEnabledAlerts(ctx context.Context) ([]db.Alert, error) {
+	rows, err := s.q.ListEnabledAlerts(ctx)
+	return convertRows(rows, err, func(r Alert) db.Alert { return db.Alert(r) })
+}
+
+func (s *Querier) ListEventsAfter(ctx context.Context, arg db.ListEventsAfterParams) ([]db.Event, error) {
+	rows, err := s.q.ListEventsAfter(ctx, ListEventsAfterParams{
+		ID:    arg.ID,
+		Limit: int64(arg.Limit),
+	})
+	return convertRows(rows, err, func(r Event) db.Event { return db.Event(r) })
+}
+
+func (s *Querier) ListExecutedSymbols(ctx context.Context) ([]db.ListExecutedSymbolsRow, error) {
+	rows, err := s.q.ListExecutedSymbols(ctx)
+	return convertRows(rows, err, func(r ListExecutedSymbolsRow) db.ListExecutedSymbolsRow { return db.ListExecutedSymbolsRow(r) })
+}
+
+func (s *Querier) ListExecutions(ctx context.Context, arg db.ListExecutionsParams) ([]db.Execution, error) {
+	rows, err := s.q.ListExecutions(ctx, ListExecutionsParams{
+		AccountID: arg.AccountID,
+		Limit:     int64(arg.Limit),
+	})
+	return convertRows(rows, err, func(r Execution) db.Execution { return db.Execution(r) })
+}
+
+func (s *Querier) ListExecutionsByOrder(ctx context.Context, orderID string) ([]db.Execution, error) {
+	rows, err := s.q.ListExecutionsByOrder(ctx, orderID)
+	return convertRows(rows, err, func(r Execution) db.Execution { return db.Execution(r) })
+}
+
+func (s *Querier) ListExecutionsBySymbol(ctx context.Context, arg db.ListExecutionsBySymbolParams) ([]db.Execution, error) {
+	rows, err := s.q.ListExecutionsBySymbol(ctx, ListExecutionsBySymbolParams(arg))
+	return convertRows(rows, err, func(r Execution) db.Execution { return db.Execution(r) })
+}
+
+func (s *Querier) ListLotDisposals(ctx context.Context, arg db.ListLotDisposalsParams) ([]db.ListLotDisposalsRow, error) {
+	rows, err := s.q.ListLotDisposals(ctx, ListLotDisposalsParams{
+		AccountID:   arg.AccountID,
+		ClosedFrom:  arg.ClosedFrom,
+		ClosedUntil: arg.ClosedUntil,
+	})
+	return convertRows(rows, err, func(r ListLotDisposalsRow) db.ListLotDisposalsRow { return db.ListLotDisposalsRow(r) })
+}
+
+func (s *Querier) ListLotSelections(ctx context.Context, accountID string) ([]db.LotSelection, error) {
+	rows, err := s.q.ListLotSelections(ctx, accountID)
+	return convertRows(rows, err, func(r LotSelection) db.LotSelection { return db.LotSelection(r) })
+}
+
+func (s *Querier) ListOpenTaxLots(ctx context.Context, accountID string) ([]db.TaxLot, error) {
+	rows, err := s.q.ListOpenTaxLots(ctx, accountID)
+	return convertRows(rows, err, func(r TaxLot) db.TaxLot { return db.TaxLot(r) })
+}
+
+func (s *Querier) ListOrderIntents(ctx context.Context, arg db.ListOrderIntentsParams) ([]db.OrderIntent, error) {
+	rows, err := s.q.ListOrderIntents(ctx, ListOrderIntentsParams{
+		AccountID: arg.AccountID,
+		Status:    arg.Status,
+		RowLimit:  int64(arg.RowLimit),
+	})
+	return convertRows(rows, err, func(r OrderIntent) db.OrderIntent { return db.OrderIntent(r) })
+}
+
+func (s *Querier) ListOrders(ctx context.Context, arg db.ListOrdersParams) ([]db.Order, error) {
+	rows, err := s.q.ListOrders(ctx, ListOrdersParams{
+		AccountID: arg.AccountID,
+		Limit:     int64(arg.Limit),
+		Offset:    int64(arg.Offset),
+	})
+	return convertRows(rows, err, func(r Order) db.Order { return db.Order(r) })
+}
+
+func (s *Querier) ListOrdersByStatus(ctx context.Context, arg db.ListOrdersByStatusParams) ([]db.Order, error) {
+	rows, err := s.q.ListOrdersByStatus(ctx, ListOrdersByStatusParams(arg))
+	return convertRows(rows, err, func(r Order) db.Order { return db.Order(r) })
+}
+
+func (s *Querier) ListPendingOrderIntents(ctx context.Context) ([]db.OrderIntent, error) {
+	rows, err := s.q.ListPendingOrderIntents(ctx)
+	return convertRows(rows, err, func(r OrderIntent) db.OrderIntent { return db.OrderIntent(r) })
+}
+
+func (s *Querier) ListPendingScheduledOrderRuns(ctx context.Context) ([]db.ListPendingScheduledOrderRunsRow, error) {
+	rows, err := s.q.ListPendingScheduledOrderRuns(ctx)
+	return convertRows(rows, err, func(r ListPendingScheduledOrderRunsRow) db.ListPendingScheduledOrderRunsRow {
+		return db.ListPendingScheduledOrderRunsRow(r)
+	})
+}
+
+func (s *Querier) ListPositions(ctx context.Context, accountID string) ([]db.Position, error) {
+	rows, err := s.q.ListPositions(ctx, accountID)
+	return convertRows(rows, err, func(r Position) db.Position { return db.Position(r) })
+}
+
+func (s *Querier) ListReconcileDrifts(ctx context.Context, arg db.ListReconcileDriftsParams) ([]db.ReconcileDrift, error) {
+	rows, err := s.q.ListReconcileDrifts(ctx, ListReconcileDriftsParams{
+		AccountID: arg.AccountID,
+		Limit:     int64(arg.Limit),
+	})
+	return convertRows(rows, err, func(r ReconcileDrift) db.ReconcileDrift { return db.ReconcileDrift(r) })
+}
+
+func (s *Querier) ListScheduledOrderRuns(ctx context.Context, arg db.ListScheduledOrderRunsParams) ([]db.ListScheduledOrderRunsRow, error) {
+	rows, err := s.q.ListScheduledOrderRuns(ctx, ListScheduledOrderRunsParams{
+		ScheduledOrderID: arg.ScheduledOrderID,
+		RowLimit:         int64(arg.RowLimit),
+	})
+	return convertRows(rows, err, func(r ListScheduledOrderRunsRow) db.ListScheduledOrderRunsRow { return db.ListScheduledOrderRunsRow(r) })
+}
+
+func (s *Querier) ListScheduledOrders(ctx context.Context) ([]db.ScheduledOrder, error) {
+	rows, err := s.q.ListScheduledOrders(ctx)
+	return convertRows(rows, err, func(r ScheduledOrder) db.ScheduledOrder { return db.ScheduledOrder(r) })
+}
+
+func (s *Querier) ListUnappliedEvents(ctx context.Context, limit int32) ([]db.Event, error) {
+	rows, err := s.q.ListUnappliedEvents(ctx, int64(limit))
+	return convertRows(rows, err, func(r Event) db.Event { return db.Event(r) })
+}
+
+func (s *Querier) ListWatchlistItems(ctx context.Context, watchlistID string) ([]db.WatchlistItem, error) {
+	rows, err := s.q.ListWatchlistItems(ctx, watchlistID)
+	return convertRows(rows, err, func(r WatchlistItem) db.WatchlistItem { return db.WatchlistItem(r) })
+}
+
+func (s *Querier) ListWatchlists(ctx context.Context, accountID string) ([]db.Watchlist, error) {
+	rows, err := s.q.ListWatchlists(ctx, accountID)
+	return convertRows(rows, err, func(r Watchlist) db.Watchlist { return db.Watchlist(r) })
+}
+
+func (s *Querier) MarkEventApplied(ctx context.Context, id int64) error {
+	return s.q.MarkEventApplied(ctx, id)
+}
+
+func (s *Querier) MarkOrderIntentFailed(ctx context.Context, arg db.MarkOrderIntentFailedParams) (db.OrderIntent, error) {
+	row, err := s.q.MarkOrderIntentFailed(ctx, MarkOrderIntentFailedParams(arg))
+	return db.OrderIntent(row), err
+}
+
+func (s *Querier) MarkOrderIntentSent(ctx context.Context, arg db.MarkOrderIntentSentParams) (db.OrderIntent, error) {
+	row, err := s.q.MarkOrderIntentSent(ctx, MarkOrderIntentSentParams(arg))
+	return db.OrderIntent(row), err
+}
+
+func (s *Querier) OrderStatsByDay(ctx context.Context, arg db.OrderStatsByDayParams) ([]db.OrderStatsByDayRow, error) {
+	totals, err := s.sumOrderStats(ctx, arg.AccountID, arg.CreatedFrom, arg.CreatedUntil, func(row ListOrderStatsRowsRow) string {
+		return snapshot.TradingDate(row.CreatedAt).Format("2006-01-02")
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(totals, func(a, b *orderStats) int { return strings.Compare(b.key, a.key) })
+
+	stats := make([]db.OrderStatsByDayRow, 0, len(totals))
+	for _, t := range totals {
+		stats = append(stats, db.OrderStatsByDayRow{
+			Day:         t.key,
+			OrderCount:  t.orderCount,
+			FilledCount: t.filledCount,
+			Notional:    t.notional,
+		})
+	}
+	return stats, nil
+}
+
+func (s *Querier) OrderStatsBySymbol(ctx context.Context, arg db.OrderStatsBySymbolParams) ([]db.OrderStatsBySymbolRow, error) {
+	totals, err := s.sumOrderStats(ctx, arg.AccountID, arg.CreatedFrom, arg.CreatedUntil, func(row ListOrderStatsRowsRow) string {
+		return row.Symbol
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(totals, func(a, b *orderStats) int {
+		if c := b.notional.Cmp(a.notional); c != 0 {
+			return c
+		}
+		return strings.Compare(a.key, b.key)
+	})
+
+	stats := make([]db.OrderStatsBySymbolRow, 0, len(totals))
+	for _, t := range totals {
+		stats = append(stats, db.OrderStatsBySymbolRow{
+			Symbol:      t.key,
+			OrderCount:  t.orderCount,
+			FilledCount: t.filledCount,
+			Notional:    t.notional,
+		})
+	}
+	return stats, nil
+}
+
+func (s *Querier) RecordAlertTrigger(ctx context.Context, arg db.RecordAlertTriggerParams) error {
+	return s.q.RecordAlertTrigger(ctx, RecordAlertTriggerParams(arg))
+}
+
+func (s *Querier) ResetEventsApplied(ctx context.Context) error {
+	return s.q.ResetEventsApplied(ctx)
+}
+
+func (s *Querier) SearchOrders(ctx context.Context, arg db.SearchOrdersParams) ([]db.Order, error) {
+	statuses := arg.Statuses
+	if statuses == nil {
+		statuses = []string{}
+	}
+	statusesJSON, err := json.Marshal(statuses)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.q.SearchOrders(ctx, SearchOrdersParams{
+		AccountID:       arg.AccountID,
+		Symbol:          arg.Symbol,
+		Side:            arg.Side,
+		OrderType:       arg.OrderType,
+		Statuses:        string(statusesJSON),
+		CreatedFrom:     arg.CreatedFrom,
+		CreatedUntil:    arg.CreatedUntil,
+		FilledFrom:      arg.FilledFrom,
+		FilledUntil:     arg.FilledUntil,
+		BeforeCreatedAt: arg.BeforeCreatedAt,
+		BeforeID:        arg.BeforeID,
+		RowLimit:        int64(arg.RowLimit),
+	})
+	return convertRows(rows, err, func(r Order) db.Order { return db.Order(r) })
+}
+
+func (s *Querier) SetAlertEnabled(ctx context.Context, arg db.SetAlertEnabledParams) (db.Alert, error) {
+	row, err := s.q.SetAlertEnabled(ctx, SetAlertEnabledParams(arg))
+	return db.Alert(row), err
+}
+
+func (s *Querier) SetScheduledOrderEnabled(ctx context.Context, arg db.SetScheduledOrderEnabledParams) (db.ScheduledOrder, error) {
+	row, err := s.q.SetScheduledOrderEnabled(ctx, SetScheduledOrderEnabledParams(arg))
+	return db.ScheduledOrder(row), err
+}
+
+func (s *Querier) SetScheduledOrderResult(ctx context.Context, arg db.SetScheduledOrderResultParams) error {
+	return s.q.SetScheduledOrderResult(ctx, SetScheduledOrderResultParams(arg))
+}
+
+func (s *Querier) SettleScheduledOrderResult(ctx context.Context, arg db.SettleScheduledOrderResultParams) error {
+	return s.q.SettleScheduledOrderResult(ctx, SettleScheduledOrderResultParams{
+		LastResult:   arg.LastResult,
+		ID:           arg.ID,
+		ScheduledFor: arg.ScheduledFor,
+	})
+}
+
+func (s *Querier) SettleScheduledOrderRun(ctx context.Context, arg db.SettleScheduledOrderRunParams) (int64, error) {
+	return s.q.SettleScheduledOrderRun(ctx, SettleScheduledOrderRunParams(arg))
+}
+
+func (s *Querier) UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error) {
+	row, err := s.q.UpdateAccount(ctx, UpdateAccountParams(arg))
+	return db.Account(row), err
+}
+
+func (s *Querier) UpdateOrder(ctx context.Context, arg db.UpdateOrderParams) (db.Order, error) {
+	row, err := s.q.UpdateOrder(ctx, UpdateOrderParams(arg))
+	return db.Order(row), err
+}
+
+func (s *Querier) UpdatePosition(ctx context.Context, arg db.UpdatePositionParams) (db.Position, error) {
+	row, err := s.q.UpdatePosition(ctx, UpdatePositionParams(arg))
+	return db.Position(row), err
+}
+
+func (s *Querier) UpsertAccountSnapshot(ctx context.Context, arg db.UpsertAccountSnapshotParams) (db.AccountSnapshot, error) {
+	row, err := s.q.UpsertAccountSnapshot(ctx, UpsertAccountSnapshotParams(arg))
+	return db.AccountSnapshot(row), err
+}
+
+func (s *Querier) UpsertBar(ctx context.Context, arg db.UpsertBarParams) error {
+	return s.q.UpsertBar(ctx, UpsertBarParams(arg))
+}
+
+func (s *Querier) UpsertLotSelection(ctx context.Context, arg db.UpsertLotSelectionParams) (db.LotSelection, error) {
+	row, err := s.q.UpsertLotSelection(ctx, UpsertLotSelectionParams(arg))
+	return db.LotSelection(row), err
+}
+
+func (s *Querier) UpsertWatchlist(ctx context.Context, arg db.UpsertWatchlistParams) (db.Watchlist, error) {
+	row, err := s.q.UpsertWatchlist(ctx, UpsertWatchlistParams(arg))
+	return db.Watchlist(row), err
+}
+
+func convertRows[S, D any](rows []S, err error, convert func(S) D) ([]D, error) {
+	if err != nil {
+		return nil, err
+	}
+	converted := make([]D, 0, len(rows))
+	for _, row := range rows {
+		converted = append(converted, convert(row))
+	}
+	return converted, nil
+}
+
+// orderStats is a group of orders OrderStatsByDay or OrderStatsBySymbol
+// returns
+type orderStats struct {
+	key         string
+	orderCount  int64
+	filledCount int64
+	notional    decimal.Decimal
+}
+
+// sumOrderStats groups the orders created in the range by key, summing the
+// notional as Postgres does: filled quantity times average fill price
+func (s *Querier) sumOrderStats(ctx context.Context, accountID string, from, until sql.NullTime, key func(ListOrderStatsRowsRow) string) ([]*orderStats, error) {
+	rows, err := s.q.ListOrderStatsRows(ctx, ListOrderStatsRowsParams{
+		AccountID:    accountID,
+		CreatedFrom:  from,
+		CreatedUntil: until,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var totals []*orderStats
+	byKey := map[string]*orderStats{}
+	for _, row := range rows {
+		k := key(row)
+		t := byKey[k]
+		if t == nil {
+			t = &orderStats{key: k}
+			byKey[k] = t
+			totals = append(totals, t)
+		}
+		t.orderCount++
+		if row.FilledAt.Valid {
+			t.filledCount++
+		}
+		if row.FilledAvgPrice.Valid {
+			t.notional = t.notional.Add(row.FilledQty.Mul(row.FilledAvgPrice.Decimal))
+		}
+	}
+	return totals, nil
+}
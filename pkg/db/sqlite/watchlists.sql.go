@@ -0,0 +1,171 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: watchlists.sql
+
+package sqlite
+
+import (
+	"context"
+)
+
+const addWatchlistItem = `-- name: AddWatchlistItem :one
+INSERT INTO watchlist_items (
+    watchlist_id, symbol, position
+) VALUES (
+    ?1, ?2, ?3
+) RETURNING watchlist_id, symbol, position, created_at
+`
+
+type AddWatchlistItemParams struct {
+	WatchlistID string `json:"watchlist_id"`
+	Symbol      string `json:"symbol"`
+	Position    int32  `json:"position"`
+}
+
+func (q *Queries) AddWatchlistItem(ctx context.Context, arg AddWatchlistItemParams) (WatchlistItem, error) {
+	row := q.db.QueryRowContext(ctx, addWatchlistItem, arg.WatchlistID, arg.Symbol, arg.Position)
+	var i WatchlistItem
+	err := row.Scan(
+		&i.WatchlistID,
+		&i.Symbol,
+		&i.Position,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const deleteWatchlist = `-- name: DeleteWatchlist :exec
+DELETE FROM watchlists WHERE id = ?1
+`
+
+func (q *Queries) DeleteWatchlist(ctx context.Context, id string) error {
+	_, err := q.db.ExecContext(ctx, deleteWatchlist, id)
+	return err
+}
+
+const deleteWatchlistItems = `-- name: DeleteWatchlistItems :exec
+DELETE FROM watchlist_items WHERE watchlist_id = ?1
+`
+
+func (q *Queries) DeleteWatchlistItems(ctx context.Context, watchlistID string) error {
+	_, err := q.db.ExecContext(ctx, deleteWatchlistItems, watchlistID)
+	return err
+}
+
+const getWatchlist = `-- name: GetWatchlist :one
+SELECT id, account_id, name, created_at, updated_at FROM watchlists WHERE id = ?1
+`
+
+func (q *Queries) GetWatchlist(ctx context.Context, id string) (Watchlist, error) {
+	row := q.db.QueryRowContext(ctx, getWatchlist, id)
+	var i Watchlist
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
+const listWatchlistItems = `-- name: ListWatchlistItems :many
+SELECT watchlist_id, symbol, position, created_at FROM watchlist_items
+WHERE watchlist_id = ?1
+ORDER BY position
+`
+
+func (q *Queries) ListWatchlistItems(ctx context.Context, watchlistID string) ([]WatchlistItem, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchlistItems, watchlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []WatchlistItem{}
+	for rows.Next() {
+		var i WatchlistItem
+		if err := rows.Scan(
+			&i.WatchlistID,
+			&i.Symbol,
+			&i.Position,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listWatchlists = `-- name: ListWatchlists :many
+SELECT id, account_id, name, created_at, updated_at FROM watchlists
+WHERE account_id = ?1
+ORDER BY created_at
+`
+
+func (q *Queries) ListWatchlists(ctx context.Context, accountID string) ([]Watchlist, error) {
+	rows, err := q.db.QueryContext(ctx, listWatchlists, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []Watchlist{}
+	for rows.Next() {
+		var i Watchlist
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Name,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertWatchlist = `-- name: UpsertWatchlist :one
+INSERT INTO watchlists (
+    id, account_id, name
+) VALUES (
+    ?1, ?2, ?3
+)
+ON CONFLICT (id) DO UPDATE SET
+    name = EXCLUDED.name,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, account_id, name, created_at, updated_at
+`
+
+type UpsertWatchlistParams struct {
+	ID        string `json:"id"`
+	AccountID string `json:"account_id"`
+	Name      string `json:"name"`
+}
+
+func (q *Queries) UpsertWatchlist(ctx context.Context, arg UpsertWatchlistParams) (Watchlist, error) {
+	row := q.db.QueryRowContext(ctx, upsertWatchlist, arg.ID, arg.AccountID, arg.Name)
+	var i Watchlist
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Name,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,348 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.31.1
+// source: tax_lots.sql
+
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+const createLotDisposal = `-- name: CreateLotDisposal :one
+INSERT INTO lot_disposals (
+    lot_id, account_id, symbol, execution_id, qty, open_price, close_price,
+    realized_pl, opened_at, closed_at, term
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING id, lot_id, account_id, symbol, execution_id, qty, open_price, close_price, realized_pl, opened_at, closed_at, term
+`
+
+type CreateLotDisposalParams struct {
+	LotID       int64           `json:"lot_id"`
+	AccountID   string          `json:"account_id"`
+	Symbol      string          `json:"symbol"`
+	ExecutionID string          `json:"execution_id"`
+	Qty         decimal.Decimal `json:"qty"`
+	OpenPrice   decimal.Decimal `json:"open_price"`
+	ClosePrice  decimal.Decimal `json:"close_price"`
+	RealizedPl  decimal.Decimal `json:"realized_pl"`
+	OpenedAt    time.Time       `json:"opened_at"`
+	ClosedAt    time.Time       `json:"closed_at"`
+	Term        string          `json:"term"`
+}
+
+func (q *Queries) CreateLotDisposal(ctx context.Context, arg CreateLotDisposalParams) (LotDisposal, error) {
+	row := q.db.QueryRowContext(ctx, createLotDisposal,
+		arg.LotID,
+		arg.AccountID,
+		arg.Symbol,
+		arg.ExecutionID,
+		arg.Qty,
+		arg.OpenPrice,
+		arg.ClosePrice,
+		arg.RealizedPl,
+		arg.OpenedAt,
+		arg.ClosedAt,
+		arg.Term,
+	)
+	var i LotDisposal
+	err := row.Scan(
+		&i.ID,
+		&i.LotID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.ExecutionID,
+		&i.Qty,
+		&i.OpenPrice,
+		&i.ClosePrice,
+		&i.RealizedPl,
+		&i.OpenedAt,
+		&i.ClosedAt,
+		&i.Term,
+	)
+	return i, err
+}
+
+const createTaxLot = `-- name: CreateTaxLot :one
+INSERT INTO tax_lots (
+    account_id, symbol, execution_id, direction, qty, remaining_qty, price,
+    opened_at, closed_at
+) VALUES (
+    $1, $2, $3, $4, $5, $6, $7, $8, $9
+) RETURNING id, account_id, symbol, execution_id, direction, qty, remaining_qty, price, opened_at, closed_at
+`
+
+type CreateTaxLotParams struct {
+	AccountID    string          `json:"account_id"`
+	Symbol       string          `json:"symbol"`
+	ExecutionID  string          `json:"execution_id"`
+	Direction    string          `json:"direction"`
+	Qty          decimal.Decimal `json:"qty"`
+	RemainingQty decimal.Decimal `json:"remaining_qty"`
+	Price        decimal.Decimal `json:"price"`
+	OpenedAt     time.Time       `json:"opened_at"`
+	ClosedAt     sql.NullTime    `json:"closed_at"`
+}
+
+func (q *Queries) CreateTaxLot(ctx context.Context, arg CreateTaxLotParams) (TaxLot, error) {
+	row := q.db.QueryRowContext(ctx, createTaxLot,
+		arg.AccountID,
+		arg.Symbol,
+		arg.ExecutionID,
+		arg.Direction,
+		arg.Qty,
+		arg.RemainingQty,
+		arg.Price,
+		arg.OpenedAt,
+		arg.ClosedAt,
+	)
+	var i TaxLot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.ExecutionID,
+		&i.Direction,
+		&i.Qty,
+		&i.RemainingQty,
+		&i.Price,
+		&i.OpenedAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const deleteAllTaxLots = `-- name: DeleteAllTaxLots :exec
+DELETE FROM tax_lots
+`
+
+func (q *Queries) DeleteAllTaxLots(ctx context.Context) error {
+	_, err := q.db.ExecContext(ctx, deleteAllTaxLots)
+	return err
+}
+
+const deleteTaxLots = `-- name: DeleteTaxLots :exec
+DELETE FROM tax_lots WHERE account_id = $1 AND symbol = $2
+`
+
+type DeleteTaxLotsParams struct {
+	AccountID string `json:"account_id"`
+	Symbol    string `json:"symbol"`
+}
+
+func (q *Queries) DeleteTaxLots(ctx context.Context, arg DeleteTaxLotsParams) error {
+	_, err := q.db.ExecContext(ctx, deleteTaxLots, arg.AccountID, arg.Symbol)
+	return err
+}
+
+const getTaxLotByExecution = `-- name: GetTaxLotByExecution :one
+SELECT id, account_id, symbol, execution_id, direction, qty, remaining_qty, price, opened_at, closed_at FROM tax_lots WHERE execution_id = $1
+`
+
+func (q *Queries) GetTaxLotByExecution(ctx context.Context, executionID string) (TaxLot, error) {
+	row := q.db.QueryRowContext(ctx, getTaxLotByExecution, executionID)
+	var i TaxLot
+	err := row.Scan(
+		&i.ID,
+		&i.AccountID,
+		&i.Symbol,
+		&i.ExecutionID,
+		&i.Direction,
+		&i.Qty,
+		&i.RemainingQty,
+		&i.Price,
+		&i.OpenedAt,
+		&i.ClosedAt,
+	)
+	return i, err
+}
+
+const listLotDisposals = `-- name: ListLotDisposals :many
+SELECT lot_disposals.id, lot_disposals.lot_id, lot_disposals.account_id, lot_disposals.symbol, lot_disposals.execution_id, lot_disposals.qty, lot_disposals.open_price, lot_disposals.close_price, lot_disposals.realized_pl, lot_disposals.opened_at, lot_disposals.closed_at, lot_disposals.term, tax_lots.execution_id AS lot_execution_id, tax_lots.direction
+FROM lot_disposals
+JOIN tax_lots ON tax_lots.id = lot_disposals.lot_id
+WHERE ($1::text = '' OR lot_disposals.account_id = $1)
+  AND lot_disposals.closed_at >= $2
+  AND lot_disposals.closed_at < $3
+ORDER BY lot_disposals.closed_at, lot_disposals.id
+`
+
+type ListLotDisposalsParams struct {
+	AccountID   string    `json:"account_id"`
+	ClosedFrom  time.Time `json:"closed_from"`
+	ClosedUntil time.Time `json:"closed_until"`
+}
+
+type ListLotDisposalsRow struct {
+	ID             int64           `json:"id"`
+	LotID          int64           `json:"lot_id"`
+	AccountID      string          `json:"account_id"`
+	Symbol         string          `json:"symbol"`
+	ExecutionID    string          `json:"execution_id"`
+	Qty            decimal.Decimal `json:"qty"`
+	OpenPrice      decimal.Decimal `json:"open_price"`
+	ClosePrice     decimal.Decimal `json:"close_price"`
+	RealizedPl     decimal.Decimal `json:"realized_pl"`
+	OpenedAt       time.Time       `json:"opened_at"`
+	ClosedAt       time.Time       `json:"closed_at"`
+	Term           string          `json:"term"`
+	LotExecutionID string          `json:"lot_execution_id"`
+	Direction      string          `json:"direction"`
+}
+
+// Disposals closed in [closed_from, closed_until). An empty account_id
+// matches every account.
+func (q *Queries) ListLotDisposals(ctx context.Context, arg ListLotDisposalsParams) ([]ListLotDisposalsRow, error) {
+	rows, err := q.db.QueryContext(ctx, listLotDisposals, arg.AccountID, arg.ClosedFrom, arg.ClosedUntil)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ListLotDisposalsRow{}
+	for rows.Next() {
+		var i ListLotDisposalsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.LotID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.ExecutionID,
+			&i.Qty,
+			&i.OpenPrice,
+			&i.ClosePrice,
+			&i.RealizedPl,
+			&i.OpenedAt,
+			&i.ClosedAt,
+			&i.Term,
+			&i.LotExecutionID,
+			&i.Direction,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listLotSelections = `-- name: ListLotSelections :many
+SELECT order_id, lot_execution_id, account_id, qty, created_at FROM lot_selections
+WHERE account_id = $1
+ORDER BY created_at, order_id, lot_execution_id
+`
+
+func (q *Queries) ListLotSelections(ctx context.Context, accountID string) ([]LotSelection, error) {
+	rows, err := q.db.QueryContext(ctx, listLotSelections, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []LotSelection{}
+	for rows.Next() {
+		var i LotSelection
+		if err := rows.Scan(
+			&i.OrderID,
+			&i.LotExecutionID,
+			&i.AccountID,
+			&i.Qty,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOpenTaxLots = `-- name: ListOpenTaxLots :many
+SELECT id, account_id, symbol, execution_id, direction, qty, remaining_qty, price, opened_at, closed_at FROM tax_lots
+WHERE ($1::text = '' OR account_id = $1)
+  AND closed_at IS NULL
+ORDER BY account_id, symbol, opened_at, id
+`
+
+// An empty account_id lists the open lots of every account.
+func (q *Queries) ListOpenTaxLots(ctx context.Context, accountID string) ([]TaxLot, error) {
+	rows, err := q.db.QueryContext(ctx, listOpenTaxLots, accountID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TaxLot{}
+	for rows.Next() {
+		var i TaxLot
+		if err := rows.Scan(
+			&i.ID,
+			&i.AccountID,
+			&i.Symbol,
+			&i.ExecutionID,
+			&i.Direction,
+			&i.Qty,
+			&i.RemainingQty,
+			&i.Price,
+			&i.OpenedAt,
+			&i.ClosedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertLotSelection = `-- name: UpsertLotSelection :one
+INSERT INTO lot_selections (
+    order_id, lot_execution_id, account_id, qty
+) VALUES (
+    $1, $2, $3, $4
+)
+ON CONFLICT (order_id, lot_execution_id) DO UPDATE SET qty = EXCLUDED.qty
+RETURNING order_id, lot_execution_id, account_id, qty, created_at
+`
+
+type UpsertLotSelectionParams struct {
+	OrderID        string          `json:"order_id"`
+	LotExecutionID string          `json:"lot_execution_id"`
+	AccountID      string          `json:"account_id"`
+	Qty            decimal.Decimal `json:"qty"`
+}
+
+func (q *Queries) UpsertLotSelection(ctx context.Context, arg UpsertLotSelectionParams) (LotSelection, error) {
+	row := q.db.QueryRowContext(ctx, upsertLotSelection,
+		arg.OrderID,
+		arg.LotExecutionID,
+		arg.AccountID,
+		arg.Qty,
+	)
+	var i LotSelection
+	err := row.Scan(
+		&i.OrderID,
+		&i.LotExecutionID,
+		&i.AccountID,
+		&i.Qty,
+		&i.CreatedAt,
+	)
+	return i, err
+}
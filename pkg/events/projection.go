@@ -0,0 +1,215 @@
+package events
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/taxlot"
+)
+
+// applyEvent updates the projections for one logged event. It must run inside
+// the transaction that marks the event applied.
+func applyEvent(ctx context.Context, q db.Querier, row db.Event, lotMethod taxlot.Method) error {
+	event, err := decode(row)
+	if err != nil {
+		return err
+	}
+
+	switch e := event.(type) {
+	case broker.TradeUpdateEvent:
+		return applyTradeUpdate(ctx, q, e, lotMethod)
+	case broker.AccountUpdateEvent:
+		return applyAccountUpdate(ctx, q, e)
+	default:
+		return fmt.Errorf("no projection for event type %q", event.Type())
+	}
+}
+
+func applyAccountUpdate(ctx context.Context, q db.Querier, e broker.AccountUpdateEvent) error {
+	_, err := q.GetAccount(ctx, e.Account.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = q.CreateAccount(ctx, db.NewCreateAccountParams(e.Account))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = q.UpdateAccount(ctx, db.NewUpdateAccountParams(e.Account))
+	return err
+}
+
+// applyTradeUpdate upserts the order snapshot, records the execution of fill
+// events, rematches the symbol's tax lots and moves the position by what
+// was filled.
+func applyTradeUpdate(ctx context.Context, q db.Querier, e broker.TradeUpdateEvent, lotMethod taxlot.Method) error {
+	o := e.Order
+
+	if _, err := q.GetAccount(ctx, o.AccountID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("account %q is not in the database yet; run pony reconcile first", o.AccountID)
+		}
+		return err
+	}
+
+	prevFilledQty := decimal.Zero
+	prevFilledAvg := decimal.Zero
+
+	local, err := q.GetOrderByAlpacaID(ctx, o.AlpacaOrderID)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		if _, err := q.CreateOrder(ctx, db.NewCreateOrderParams(o)); err != nil {
+			return err
+		}
+	case err != nil:
+		return err
+	default:
+		o.ID = local.ID
+		prevFilledQty = local.FilledQty
+		prevFilledAvg = local.FilledAvgPrice.Decimal
+	}
+
+	if _, err := q.UpdateOrder(ctx, db.NewUpdateOrderParams(o)); err != nil {
+		return err
+	}
+
+	if exec := e.Execution(); exec != nil {
+		exec.OrderID = o.ID
+
+		// A reconcile pass may have recorded this fill already, from the
+		// order's filled quantity, when the event was applied late
+		covered, err := filledByExecutions(ctx, q, o.ID)
+		if err != nil {
+			return err
+		}
+		if covered.GreaterThanOrEqual(o.FilledQty) {
+			return nil
+		}
+
+		if _, err := q.CreateExecution(ctx, db.NewCreateExecutionParams(exec, e.ID)); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				// Already recorded from an earlier event
+				return nil
+			}
+			return err
+		}
+
+		if err := taxlot.RebuildSymbol(ctx, q, o.AccountID, o.Symbol, lotMethod); err != nil {
+			return err
+		}
+
+		qty := exec.Qty
+		if exec.Side == order.OrderSideSell {
+			qty = qty.Neg()
+		}
+		return applyFill(ctx, q, o.AccountID, o.Symbol, qty, exec.Price)
+	}
+
+	// Without execution details, fall back to what the order snapshot says
+	// was filled since the previous snapshot of the same order.
+	filled := o.FilledQty.Sub(prevFilledQty)
+	if !filled.IsPositive() || o.FilledAvgPrice == nil {
+		return nil
+	}
+
+	// The snapshot only has the running average, so back out the price of
+	// this fill from the notional before and after it.
+	notional := o.FilledQty.Mul(*o.FilledAvgPrice).Sub(prevFilledQty.Mul(prevFilledAvg))
+	price := notional.Div(filled)
+
+	if o.Side == order.OrderSideSell {
+		filled = filled.Neg()
+	}
+	return applyFill(ctx, q, o.AccountID, o.Symbol, filled, price)
+}
+
+// filledByExecutions sums the quantity of the recorded executions of an order.
+func filledByExecutions(ctx context.Context, q db.Querier, orderID string) (decimal.Decimal, error) {
+	rows, err := q.ListExecutionsByOrder(ctx, orderID)
+	if err != nil {
+		return decimal.Zero, fmt.Errorf("failed to list executions: %w", err)
+	}
+	filled := decimal.Zero
+	for _, row := range rows {
+		filled = filled.Add(row.Qty)
+	}
+	return filled, nil
+}
+
+// applyFill moves a position by a signed quantity at a price, keeping the
+// average entry price of the shares that remain open.
+func applyFill(ctx context.Context, q db.Querier, accountID, symbol string, qty, price decimal.Decimal) error {
+	oldQty := decimal.Zero
+	oldAvg := decimal.Zero
+
+	existing, err := q.GetPosition(ctx, db.GetPositionParams{AccountID: accountID, Symbol: symbol})
+	found := err == nil
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if found {
+		oldQty = existing.Qty
+		oldAvg = existing.AvgEntryPrice
+	}
+
+	newQty := oldQty.Add(qty)
+	if newQty.IsZero() {
+		if !found {
+			return nil
+		}
+		return q.DeletePosition(ctx, db.DeletePositionParams{AccountID: accountID, Symbol: symbol})
+	}
+
+	avg := oldAvg
+	switch {
+	case oldQty.IsZero() || oldQty.Sign() != newQty.Sign():
+		// Opened, or flipped from long to short: the remainder was all bought at this price
+		avg = price
+	case newQty.Abs().GreaterThan(oldQty.Abs()):
+		// Added to the position
+		avg = oldQty.Mul(oldAvg).Add(qty.Mul(price)).Div(newQty)
+	}
+
+	costBasis := newQty.Mul(avg)
+	marketValue := newQty.Mul(price)
+	unrealizedPL := marketValue.Sub(costBasis)
+	unrealizedPLPC := decimal.Zero
+	if !costBasis.IsZero() {
+		unrealizedPLPC = unrealizedPL.Div(costBasis.Abs())
+	}
+
+	if !found {
+		_, err := q.CreatePosition(ctx, db.CreatePositionParams{
+			AccountID:      accountID,
+			Symbol:         symbol,
+			Qty:            newQty,
+			AvgEntryPrice:  avg,
+			CurrentPrice:   price,
+			MarketValue:    marketValue,
+			CostBasis:      costBasis,
+			UnrealizedPl:   unrealizedPL,
+			UnrealizedPlpc: unrealizedPLPC,
+		})
+		return err
+	}
+
+	_, err = q.UpdatePosition(ctx, db.UpdatePositionParams{
+		AccountID:      accountID,
+		Symbol:         symbol,
+		Qty:            newQty,
+		AvgEntryPrice:  avg,
+		CurrentPrice:   price,
+		MarketValue:    marketValue,
+		CostBasis:      costBasis,
+		UnrealizedPl:   unrealizedPL,
+		UnrealizedPlpc: unrealizedPLPC,
+	})
+	return err
+}
@@ -0,0 +1,183 @@
+package events
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/store"
+	"github.com/revrost/pony/pkg/taxlot"
+	"github.com/revrost/pony/pkg/tracing"
+)
+
+// replayBatchSize is how many events are read at a time while replaying
+const replayBatchSize = 500
+
+// Store is the append-only event log. Every broker event is written to the
+// events table first and then applied to the orders, accounts and positions
+// projections exactly once: the projection writes and marking the event
+// applied happen in the same transaction.
+type Store struct {
+	db *store.DB
+
+	// LotMethod is how fills are matched to tax lots
+	LotMethod taxlot.Method
+}
+
+func NewStore(conn *store.DB) *Store {
+	return &Store{db: conn, LotMethod: taxlot.DefaultMethod}
+}
+
+// Record appends an event to the log and applies it to the projections.
+// Events already in the log are ignored. If applying fails the event stays
+// in the log unapplied and is picked up by the next ApplyPending.
+func (s *Store) Record(ctx context.Context, event broker.Event) (err error) {
+	ctx, span := tracing.Tracer().Start(ctx, "events.Record", trace.WithAttributes(tracing.EventAttributes(event)...))
+	defer func() { tracing.End(span, err) }()
+
+	row, err := s.append(ctx, event)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Duplicate delivery; the first copy was already recorded
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return s.apply(ctx, row.ID)
+}
+
+func (s *Store) append(ctx context.Context, event broker.Event) (db.Event, error) {
+	meta := event.Metadata()
+
+	eventID := meta.ID
+	if eventID == "" {
+		// Not every broker event carries an ID; derive a stable one from the payload
+		sum := sha256.Sum256(append([]byte(event.Type()+":"), meta.Payload...))
+		eventID = "sha256:" + hex.EncodeToString(sum[:])
+	}
+
+	payload := meta.Payload
+	if len(payload) == 0 {
+		payload = []byte("{}")
+	}
+
+	return s.db.Queries().AppendEvent(ctx, db.AppendEventParams{
+		EventID:    eventID,
+		EventType:  string(event.Type()),
+		AccountID:  meta.AccountID,
+		Payload:    payload,
+		ReceivedAt: meta.ReceivedAt,
+	})
+}
+
+// ApplyPending applies every event that is in the log but not yet applied,
+// in the order it was received. It returns how many events were applied.
+func (s *Store) ApplyPending(ctx context.Context) (int, error) {
+	applied := 0
+	for {
+		rows, err := s.db.Queries().ListUnappliedEvents(ctx, replayBatchSize)
+		if err != nil {
+			return applied, err
+		}
+		if len(rows) == 0 {
+			return applied, nil
+		}
+
+		for _, row := range rows {
+			if err := s.apply(ctx, row.ID); err != nil {
+				return applied, err
+			}
+			applied++
+		}
+	}
+}
+
+// apply applies one logged event in its own transaction. The row is locked so
+// two processes cannot apply the same event.
+func (s *Store) apply(ctx context.Context, id int64) error {
+	return s.db.InTx(ctx, func(q db.Querier) error {
+		row, err := q.GetEventForUpdate(ctx, id)
+		if err != nil {
+			return err
+		}
+		if row.AppliedAt.Valid {
+			return nil
+		}
+
+		if err := applyEvent(ctx, q, row, s.LotMethod); err != nil {
+			return err
+		}
+		return q.MarkEventApplied(ctx, row.ID)
+	})
+}
+
+// Rebuild empties the order, position and tax lot projections and replays
+// the whole event log into them in a single transaction. It returns the number of
+// events replayed.
+//
+// Accounts are upserted in place rather than truncated, because watchlists
+// and other local-only data hang off them.
+func (s *Store) Rebuild(ctx context.Context) (int, error) {
+	replayed := 0
+	err := s.db.InTx(ctx, func(q db.Querier) error {
+		if err := q.DeleteAllOrders(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllPositions(ctx); err != nil {
+			return err
+		}
+		if err := q.DeleteAllTaxLots(ctx); err != nil {
+			return err
+		}
+		if err := q.ResetEventsApplied(ctx); err != nil {
+			return err
+		}
+
+		var after int64
+		for {
+			rows, err := q.ListEventsAfter(ctx, db.ListEventsAfterParams{
+				ID:    after,
+				Limit: replayBatchSize,
+			})
+			if err != nil {
+				return err
+			}
+			if len(rows) == 0 {
+				return nil
+			}
+
+			for _, row := range rows {
+				if err := applyEvent(ctx, q, row, s.LotMethod); err != nil {
+					return fmt.Errorf("replaying event %s: %w", row.EventID, err)
+				}
+				if err := q.MarkEventApplied(ctx, row.ID); err != nil {
+					return err
+				}
+				after = row.ID
+				replayed++
+			}
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return replayed, nil
+}
+
+func decode(row db.Event) (broker.Event, error) {
+	return broker.DecodeEvent(broker.EventType(row.EventType), broker.EventMeta{
+		ID:         row.EventID,
+		AccountID:  row.AccountID,
+		ReceivedAt: row.ReceivedAt,
+		Payload:    row.Payload,
+	})
+}
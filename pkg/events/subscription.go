@@ -0,0 +1,118 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+)
+
+const (
+	// DefaultRetryInterval is how long a Subscription waits before
+	// reconnecting after its stream broke
+	DefaultRetryInterval = 5 * time.Second
+
+	// settleTime is how long a new stream has to stay up before it counts
+	// as live, as the broker does not say when it has connected
+	settleTime = 3 * time.Second
+)
+
+// State is how a Subscription's connection to the broker is doing
+type State string
+
+const (
+	// StateConnecting is the first connection being opened
+	StateConnecting State = "connecting"
+	// StateLive means events are flowing, or could be
+	StateLive State = "live"
+	// StateDown means the stream broke; it is retried after a while
+	StateDown State = "down"
+	// StateReconnecting is a connection being opened after one broke
+	StateReconnecting State = "reconnecting"
+)
+
+// Recorder persists a broker event before it is handed on. *Store
+// implements it.
+type Recorder interface {
+	Record(ctx context.Context, event broker.Event) error
+}
+
+// Subscription is one long-lived broker event stream that reconnects by
+// itself. Each event is recorded before it is passed on, so a consumer only
+// ever sees events that are already in the database.
+type Subscription struct {
+	brokerClient broker.Client
+	recorder     Recorder
+
+	// RetryInterval is how long to wait before reconnecting
+	RetryInterval time.Duration
+}
+
+func NewSubscription(brokerClient broker.Client, recorder Recorder) *Subscription {
+	return &Subscription{
+		brokerClient:  brokerClient,
+		recorder:      recorder,
+		RetryInterval: DefaultRetryInterval,
+	}
+}
+
+// Run streams until ctx is done and returns once the stream is closed.
+// Every recorded event is passed to handle, and every change of the
+// connection's state to setState, along with the error that took it down.
+func (s *Subscription) Run(ctx context.Context, handle func(broker.Event), setState func(State, error)) {
+	state := StateConnecting
+	for {
+		setState(state, nil)
+		err := s.stream(ctx, handle, func() { setState(StateLive, nil) })
+		if ctx.Err() != nil {
+			return
+		}
+		setState(StateDown, err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(s.RetryInterval):
+		}
+		state = StateReconnecting
+	}
+}
+
+// stream consumes one stream until it breaks, calling live once it has
+// settled
+func (s *Subscription) stream(ctx context.Context, handle func(broker.Event), live func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	eventCh, errCh := s.brokerClient.StreamEvents(ctx, "")
+	defer func() {
+		cancel()
+		// Wait for the stream to let go of its connection
+		for range eventCh {
+		}
+	}()
+
+	settled := time.After(settleTime)
+	for {
+		select {
+		case <-settled:
+			settled = nil
+			live()
+		case event, ok := <-eventCh:
+			if !ok {
+				if err := <-errCh; err != nil {
+					return err
+				}
+				return errors.New("event stream closed")
+			}
+			if settled != nil {
+				settled = nil
+				live()
+			}
+			if err := s.recorder.Record(ctx, event); err != nil {
+				return fmt.Errorf("failed to record event: %w", err)
+			}
+			handle(event)
+		}
+	}
+}
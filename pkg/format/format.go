@@ -0,0 +1,105 @@
+package format
+
+import (
+	"strings"
+
+	"github.com/shopspring/decimal"
+)
+
+// MaxPriceDecimals is the most decimal places a price is shown with. It is
+// enough for sub-penny equities and most crypto pairs.
+const MaxPriceDecimals = 8
+
+// Money formats a cash amount in dollars with thousands separators and two
+// decimals, e.g. "$1,234.50" or "-$12.00".
+func Money(d decimal.Decimal) string {
+	return sign(d, false) + "$" + group(d.Abs().StringFixed(2))
+}
+
+// SignedMoney is Money with an explicit "+" for gains, for P/L columns.
+func SignedMoney(d decimal.Decimal) string {
+	return sign(d, true) + "$" + group(d.Abs().StringFixed(2))
+}
+
+// MoneyOrDash formats an optional cash amount, using "-" when it is not set.
+func MoneyOrDash(d *decimal.Decimal) string {
+	if d == nil {
+		return "-"
+	}
+	return Money(*d)
+}
+
+// Price formats a per-unit price in dollars. It keeps at least two decimals
+// and up to MaxPriceDecimals, so sub-penny and crypto prices are not rounded
+// away, e.g. "$187.25", "$0.0042" or "$64,210.50".
+func Price(d decimal.Decimal) string {
+	return sign(d, false) + "$" + group(minDecimals(d.Abs().Round(MaxPriceDecimals).String(), 2))
+}
+
+// PriceOrDash formats an optional price, using "-" when it is not set.
+func PriceOrDash(d *decimal.Decimal) string {
+	if d == nil {
+		return "-"
+	}
+	return Price(*d)
+}
+
+// Qty formats a share or coin quantity exactly, without trailing zeros,
+// e.g. "100", "0.5" or "1,250.125".
+func Qty(d decimal.Decimal) string {
+	return sign(d, false) + group(d.Abs().String())
+}
+
+// QtyOrDash formats an optional quantity, using "-" when it is not set.
+func QtyOrDash(d *decimal.Decimal) string {
+	if d == nil {
+		return "-"
+	}
+	return Qty(*d)
+}
+
+// Percent formats a fraction as a signed percentage with two decimals,
+// e.g. 0.0123 is "+1.23%".
+func Percent(fraction decimal.Decimal) string {
+	pct := fraction.Shift(2)
+	return sign(pct, true) + pct.Abs().StringFixed(2) + "%"
+}
+
+func sign(d decimal.Decimal, plus bool) string {
+	switch {
+	case d.IsNegative():
+		return "-"
+	case plus && d.IsPositive():
+		return "+"
+	default:
+		return ""
+	}
+}
+
+// minDecimals pads a plain decimal string to at least n decimal places
+func minDecimals(s string, n int) string {
+	intPart, frac, _ := strings.Cut(s, ".")
+	if len(frac) < n {
+		frac += strings.Repeat("0", n-len(frac))
+	}
+	return intPart + "." + frac
+}
+
+// group adds thousands separators to the integer part of an unsigned decimal string
+func group(s string) string {
+	intPart, frac, hasFrac := strings.Cut(s, ".")
+
+	var b strings.Builder
+	for i, r := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+
+	if hasFrac {
+		b.WriteByte('.')
+		b.WriteString(frac)
+	}
+	return b.String()
+}
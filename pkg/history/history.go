@@ -0,0 +1,170 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/order"
+)
+
+// DefaultPageSize is how many orders Search returns when no limit is given
+const DefaultPageSize = 50
+
+// Store is the subset of the sqlc generated Querier order history needs.
+// *db.Queries implements it.
+type Store interface {
+	SearchOrders(ctx context.Context, arg db.SearchOrdersParams) ([]db.Order, error)
+	OrderStatsByDay(ctx context.Context, arg db.OrderStatsByDayParams) ([]db.OrderStatsByDayRow, error)
+	OrderStatsBySymbol(ctx context.Context, arg db.OrderStatsBySymbolParams) ([]db.OrderStatsBySymbolRow, error)
+}
+
+// Filter narrows Search. Zero fields match everything; the date ranges
+// include From and exclude Until.
+type Filter struct {
+	AccountID    string
+	Symbol       string
+	Side         order.OrderSide
+	OrderType    order.OrderType
+	Statuses     []order.OrderStatus
+	CreatedFrom  time.Time
+	CreatedUntil time.Time
+	FilledFrom   time.Time
+	FilledUntil  time.Time
+}
+
+// Cursor marks the last order of a page. The zero Cursor starts at the
+// newest order.
+type Cursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+func (c Cursor) IsZero() bool {
+	return c.ID == ""
+}
+
+// Page is one page of orders, newest first. Next continues after the last
+// order and is only set when there are more.
+type Page struct {
+	Orders  []*order.Order
+	Next    Cursor
+	HasMore bool
+}
+
+// Search returns the page of orders matching filter that comes after the
+// cursor. Paging is keyset based, so it stays fast deep into the history
+// and does not skip or repeat orders when new ones arrive.
+func Search(ctx context.Context, store Store, filter Filter, after Cursor, limit int) (*Page, error) {
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+
+	statuses := make([]string, 0, len(filter.Statuses))
+	for _, s := range filter.Statuses {
+		statuses = append(statuses, string(s))
+	}
+
+	params := db.SearchOrdersParams{
+		AccountID:    filter.AccountID,
+		Symbol:       filter.Symbol,
+		Side:         string(filter.Side),
+		OrderType:    string(filter.OrderType),
+		Statuses:     statuses,
+		CreatedFrom:  nullTime(filter.CreatedFrom),
+		CreatedUntil: nullTime(filter.CreatedUntil),
+		FilledFrom:   nullTime(filter.FilledFrom),
+		FilledUntil:  nullTime(filter.FilledUntil),
+		// One extra row tells whether another page follows
+		RowLimit: int32(limit + 1),
+	}
+	if !after.IsZero() {
+		params.BeforeCreatedAt = nullTime(after.CreatedAt)
+		params.BeforeID = after.ID
+	}
+
+	rows, err := store.SearchOrders(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+
+	page := &Page{}
+	if len(rows) > limit {
+		rows = rows[:limit]
+		last := rows[len(rows)-1]
+		page.Next = Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		page.HasMore = true
+	}
+	page.Orders = db.ToOrders(rows)
+
+	return page, nil
+}
+
+// Stats summarizes the orders in one day or for one symbol. Notional is
+// filled quantity times average fill price.
+type Stats struct {
+	Key      string // the New York trading date as 2006-01-02, or the symbol
+	Orders   int64
+	Filled   int64
+	Notional decimal.Decimal
+}
+
+// StatsByDay returns per-day totals for orders created in [from, until),
+// newest day first. Zero times leave that end open.
+func StatsByDay(ctx context.Context, store Store, accountID string, from, until time.Time) ([]Stats, error) {
+	rows, err := store.OrderStatsByDay(ctx, db.OrderStatsByDayParams{
+		AccountID:    accountID,
+		CreatedFrom:  nullTime(from),
+		CreatedUntil: nullTime(until),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order stats by day: %w", err)
+	}
+
+	stats := make([]Stats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, Stats{
+			Key:      row.Day,
+			Orders:   row.OrderCount,
+			Filled:   row.FilledCount,
+			Notional: row.Notional,
+		})
+	}
+	return stats, nil
+}
+
+// StatsBySymbol returns per-symbol totals for orders created in
+// [from, until), largest notional first. Zero times leave that end open.
+func StatsBySymbol(ctx context.Context, store Store, accountID string, from, until time.Time) ([]Stats, error) {
+	rows, err := store.OrderStatsBySymbol(ctx, db.OrderStatsBySymbolParams{
+		AccountID:    accountID,
+		CreatedFrom:  nullTime(from),
+		CreatedUntil: nullTime(until),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load order stats by symbol: %w", err)
+	}
+
+	stats := make([]Stats, 0, len(rows))
+	for _, row := range rows {
+		stats = append(stats, Stats{
+			Key:      row.Symbol,
+			Orders:   row.OrderCount,
+			Filled:   row.FilledCount,
+			Notional: row.Notional,
+		})
+	}
+	return stats, nil
+}
+
+// nullTime maps the zero time to NULL, which the queries treat as unbounded
+func nullTime(t time.Time) sql.NullTime {
+	if t.IsZero() {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: t.UTC(), Valid: true}
+}
@@ -0,0 +1,151 @@
+// Package logging sets up pony's structured log: a rotating file, with
+// secrets redacted, and an in-memory ring of recent entries the TUI shows.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// DefaultMaxSizeMB is how large the log file grows before it is rotated
+	DefaultMaxSizeMB = 10
+
+	// DefaultMaxFiles is how many rotated files are kept besides the current one
+	DefaultMaxFiles = 5
+
+	// DefaultRingSize is how many recent entries the ring keeps
+	DefaultRingSize = 500
+)
+
+// Options configures Open. Zero sizes use the defaults.
+type Options struct {
+	Path      string
+	Level     slog.Level
+	MaxSizeMB int
+	MaxFiles  int
+	RingSize  int
+}
+
+// DefaultPath is pony.log in the user's cache directory, or in the current
+// directory when there is none
+func DefaultPath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "pony.log"
+	}
+	return filepath.Join(dir, "pony", "pony.log")
+}
+
+// ParseLevel parses debug, info, warn or error
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("unknown log level %q, expected debug, info, warn or error", s)
+	}
+	return level, nil
+}
+
+// Open returns a logger that writes JSON lines to the file at opts.Path and
+// keeps recent entries in the returned ring. The file is rotated once it
+// reaches opts.MaxSizeMB. Close the returned closer when done logging.
+func Open(opts Options) (*slog.Logger, *Ring, io.Closer, error) {
+	if opts.MaxSizeMB <= 0 {
+		opts.MaxSizeMB = DefaultMaxSizeMB
+	}
+	if opts.MaxFiles <= 0 {
+		opts.MaxFiles = DefaultMaxFiles
+	}
+	if opts.RingSize <= 0 {
+		opts.RingSize = DefaultRingSize
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opts.Path), 0o755); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file := &lumberjack.Logger{
+		Filename:   opts.Path,
+		MaxSize:    opts.MaxSizeMB,
+		MaxBackups: opts.MaxFiles,
+	}
+
+	ring := NewRing(opts.RingSize)
+	handler := fanout{
+		slog.NewJSONHandler(file, &slog.HandlerOptions{
+			Level:       opts.Level,
+			ReplaceAttr: redactAttr,
+		}),
+		ring.handler(opts.Level),
+	}
+	return slog.New(handler), ring, file, nil
+}
+
+// sensitiveKeys are parts of attribute, header and JSON field names whose
+// values are never logged
+var sensitiveKeys = []string{"secret", "token", "password", "authorization", "api_key", "apikey", "api-key", "cookie"}
+
+// Redacted replaces the value of anything sensitive
+const Redacted = "[REDACTED]"
+
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, s := range sensitiveKeys {
+		if strings.Contains(key, s) {
+			return true
+		}
+	}
+	return false
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if sensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	return a
+}
+
+// fanout passes each record to every handler that wants it
+type fanout []slog.Handler
+
+func (f fanout) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanout) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanout) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanout, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanout) WithGroup(name string) slog.Handler {
+	handlers := make(fanout, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
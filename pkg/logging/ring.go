@@ -0,0 +1,161 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Entry is one log record as the TUI shows it
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	// Attrs are the record's attributes as key=value pairs, secrets redacted
+	Attrs string
+}
+
+// Ring keeps the most recent log entries in memory
+type Ring struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	full    bool
+	updated chan struct{}
+}
+
+func NewRing(size int) *Ring {
+	return &Ring{
+		entries: make([]Entry, size),
+		updated: make(chan struct{}, 1),
+	}
+}
+
+// Entries returns the kept entries at level or above, oldest first
+func (r *Ring) Entries(level slog.Level) []Entry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered := r.entries[:r.next]
+	if r.full {
+		ordered = append(r.entries[r.next:len(r.entries):len(r.entries)], r.entries[:r.next]...)
+	}
+
+	var entries []Entry
+	for _, e := range ordered {
+		if e.Level >= level {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Updated receives a value after entries were added. Several additions
+// may share one value, so read Entries again after each.
+func (r *Ring) Updated() <-chan struct{} {
+	return r.updated
+}
+
+func (r *Ring) add(e Entry) {
+	r.mu.Lock()
+	r.entries[r.next] = e
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+	r.mu.Unlock()
+
+	select {
+	case r.updated <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Ring) handler(level slog.Level) slog.Handler {
+	return &ringHandler{ring: r, level: level}
+}
+
+// ringHandler formats records into the ring. Attributes are flattened to
+// key=value, with group names as dotted prefixes.
+type ringHandler struct {
+	ring   *Ring
+	level  slog.Level
+	attrs  string
+	prefix string
+}
+
+func (h *ringHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *ringHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		appendAttr(&b, h.prefix, a)
+		return true
+	})
+
+	h.ring.add(Entry{
+		Time:    r.Time,
+		Level:   r.Level,
+		Message: r.Message,
+		Attrs:   strings.TrimSpace(b.String()),
+	})
+	return nil
+}
+
+func (h *ringHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	b.WriteString(h.attrs)
+	for _, a := range attrs {
+		appendAttr(&b, h.prefix, a)
+	}
+
+	h2 := *h
+	h2.attrs = b.String()
+	return &h2
+}
+
+func (h *ringHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.prefix += name + "."
+	return &h2
+}
+
+func appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			prefix += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			appendAttr(b, prefix, ga)
+		}
+		return
+	}
+
+	value := a.Value.String()
+	if sensitive(a.Key) {
+		value = Redacted
+	}
+	fmt.Fprintf(b, " %s%s=%s", prefix, a.Key, quote(value))
+}
+
+// quote quotes values that would not read as a single word
+func quote(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+		return fmt.Sprintf("%q", s)
+	}
+	return s
+}
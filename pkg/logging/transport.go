@@ -0,0 +1,162 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"time"
+)
+
+// maxBody is how much of a request or response body is logged
+const maxBody = 4 << 10
+
+// sensitiveHeaders carry credentials without saying so in their names
+var sensitiveHeaders = []string{"Apca-Api-Key-Id"}
+
+// Transport logs each broker request and its response through next. Failed
+// requests and error responses are logged at warn; everything else, with
+// headers and bodies, only at debug. Credentials are redacted.
+func Transport(logger *slog.Logger) func(http.RoundTripper) http.RoundTripper {
+	return func(next http.RoundTripper) http.RoundTripper {
+		return &transport{next: next, logger: logger}
+	}
+}
+
+type transport struct {
+	next   http.RoundTripper
+	logger *slog.Logger
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	debug := t.logger.Enabled(ctx, slog.LevelDebug)
+
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.Redacted()),
+	}
+	if debug {
+		attrs = append(attrs, slog.Any("request_headers", redactHeaders(req.Header)))
+		if body := requestBody(req); body != nil {
+			attrs = append(attrs, slog.String("request_body", redactBody(body)))
+		}
+	}
+
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	attrs = append(attrs, slog.Duration("took", time.Since(start)))
+
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+		t.logger.LogAttrs(ctx, slog.LevelWarn, "broker request failed", attrs...)
+		return resp, err
+	}
+
+	failed := resp.StatusCode >= 400
+	if !debug && !failed {
+		return resp, nil
+	}
+
+	attrs = append(attrs, slog.Int("status", resp.StatusCode))
+	if debug {
+		attrs = append(attrs, slog.Any("response_headers", redactHeaders(resp.Header)))
+	}
+	if body := responseBody(resp); body != nil {
+		attrs = append(attrs, slog.String("response_body", redactBody(body)))
+	}
+
+	level := slog.LevelDebug
+	if failed {
+		level = slog.LevelWarn
+	}
+	t.logger.LogAttrs(ctx, level, "broker request", attrs...)
+	return resp, nil
+}
+
+// requestBody reads the body without using it up, if the request can be
+// replayed
+func requestBody(req *http.Request) []byte {
+	if req.Body == nil || req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	defer body.Close()
+
+	b, _ := io.ReadAll(io.LimitReader(body, maxBody))
+	return b
+}
+
+// responseBody reads the body and puts it back for the caller. Event
+// streams never end, so they are left alone.
+func responseBody(resp *http.Response) []byte {
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.Body == nil || mediaType == "text/event-stream" {
+		return nil
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(b))
+	if err != nil {
+		return nil
+	}
+	return b[:min(len(b), maxBody)]
+}
+
+func redactHeaders(h http.Header) map[string]string {
+	headers := make(map[string]string, len(h))
+	for key, values := range h {
+		value := values[0]
+		if sensitive(key) {
+			value = Redacted
+		}
+		for _, s := range sensitiveHeaders {
+			if http.CanonicalHeaderKey(key) == s {
+				value = Redacted
+			}
+		}
+		headers[key] = value
+	}
+	return headers
+}
+
+// redactBody redacts sensitive fields of a JSON body. Bodies that are not
+// JSON, or were cut off, are logged as they are.
+func redactBody(body []byte) string {
+	// Numbers are kept as they were written
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var v any
+	if err := decoder.Decode(&v); err != nil {
+		return string(body)
+	}
+	redacted, err := json.Marshal(redactJSON(v))
+	if err != nil {
+		return string(body)
+	}
+	return string(redacted)
+}
+
+func redactJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if sensitive(key) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactJSON(value)
+			}
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = redactJSON(value)
+		}
+	}
+	return v
+}
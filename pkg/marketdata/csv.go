@@ -0,0 +1,123 @@
+package marketdata
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// csvTimeLayouts are the times ReadBarsCSV accepts. Times without a zone are
+// taken as UTC.
+var csvTimeLayouts = []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"}
+
+// ReadBarsCSV reads bars from CSV with a header row naming the columns time
+// (or date or timestamp), open, high, low, close and, optionally, volume and
+// symbol. Rows without a symbol column are for symbol. Bars come back in
+// the file's order.
+func ReadBarsCSV(r io.Reader, symbol string) ([]Bar, error) {
+	cr := csv.NewReader(r)
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	col := map[string]int{}
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(name))
+		switch name {
+		case "date", "timestamp":
+			name = "time"
+		}
+		col[name] = i
+	}
+	for _, name := range []string{"time", "open", "high", "low", "close"} {
+		if _, ok := col[name]; !ok {
+			return nil, fmt.Errorf("CSV has no %s column", name)
+		}
+	}
+	if _, ok := col["symbol"]; !ok && symbol == "" {
+		return nil, errors.New("CSV has no symbol column and no symbol was given")
+	}
+
+	var bars []Bar
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return bars, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CSV: %w", err)
+		}
+
+		bar, err := parseCSVBar(row, col, symbol)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		bars = append(bars, bar)
+	}
+}
+
+func parseCSVBar(row []string, col map[string]int, symbol string) (Bar, error) {
+	field := func(name string) string {
+		if i, ok := col[name]; ok && i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	bar := Bar{Symbol: strings.ToUpper(field("symbol"))}
+	if bar.Symbol == "" {
+		bar.Symbol = symbol
+	}
+
+	t, err := parseCSVTime(field("time"))
+	if err != nil {
+		return Bar{}, err
+	}
+	bar.Time = t
+
+	for name, price := range map[string]*decimal.Decimal{
+		"open": &bar.Open, "high": &bar.High, "low": &bar.Low, "close": &bar.Close,
+	} {
+		d, err := decimal.NewFromString(field(name))
+		if err != nil {
+			return Bar{}, fmt.Errorf("invalid %s %q", name, field(name))
+		}
+		*price = d
+	}
+	if bar.Low.GreaterThan(bar.High) {
+		return Bar{}, fmt.Errorf("low %s is above high %s", bar.Low, bar.High)
+	}
+
+	if v := field("volume"); v != "" {
+		// Some exports write volume as a float
+		volume, err := strconv.ParseFloat(v, 64)
+		if err != nil || volume < 0 {
+			return Bar{}, fmt.Errorf("invalid volume %q", v)
+		}
+		bar.Volume = uint64(volume)
+	}
+	return bar, nil
+}
+
+func parseCSVTime(s string) (time.Time, error) {
+	for _, layout := range csvTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, expected RFC 3339 or YYYY-MM-DD", s)
+}
+
+// SortBars orders bars by time, keeping the order of bars at the same time
+func SortBars(bars []Bar) {
+	slices.SortStableFunc(bars, func(a, b Bar) int { return a.Time.Compare(b.Time) })
+}
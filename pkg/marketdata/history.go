@@ -0,0 +1,89 @@
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	alpacadata "github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/shopspring/decimal"
+)
+
+// Timeframe is the period each historical bar covers
+type Timeframe string
+
+const (
+	OneMinute Timeframe = "1Min"
+	OneHour   Timeframe = "1Hour"
+	OneDay    Timeframe = "1Day"
+)
+
+// ParseTimeframe parses 1Min, 1Hour or 1Day
+func ParseTimeframe(s string) (Timeframe, error) {
+	switch tf := Timeframe(s); tf {
+	case OneMinute, OneHour, OneDay:
+		return tf, nil
+	default:
+		return "", fmt.Errorf("unknown timeframe %q, expected 1Min, 1Hour or 1Day", s)
+	}
+}
+
+func (tf Timeframe) alpaca() alpacadata.TimeFrame {
+	switch tf {
+	case OneMinute:
+		return alpacadata.OneMin
+	case OneHour:
+		return alpacadata.OneHour
+	default:
+		return alpacadata.OneDay
+	}
+}
+
+// AlpacaHistory fetches historical bars from Alpaca's market data API
+type AlpacaHistory struct {
+	client *alpacadata.Client
+}
+
+// NewAlpacaHistory fetches from the named feed, iex or sip
+func NewAlpacaHistory(apiKey, apiSecret, feed string) *AlpacaHistory {
+	return &AlpacaHistory{
+		client: alpacadata.NewClient(alpacadata.ClientOpts{
+			APIKey:    apiKey,
+			APISecret: apiSecret,
+			Feed:      alpacadata.Feed(feed),
+		}),
+	}
+}
+
+// Bars returns symbol's bars that started in [start, end], oldest first,
+// adjusted for splits and dividends. The SDK takes no context, so ctx is
+// only checked before the request.
+func (h *AlpacaHistory) Bars(ctx context.Context, symbol string, timeframe Timeframe, start, end time.Time) ([]Bar, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	bars, err := h.client.GetBars(symbol, alpacadata.GetBarsRequest{
+		TimeFrame:  timeframe.alpaca(),
+		Adjustment: alpacadata.All,
+		Start:      start,
+		End:        end,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s bars for %s: %w", timeframe, symbol, err)
+	}
+
+	out := make([]Bar, 0, len(bars))
+	for _, b := range bars {
+		out = append(out, Bar{
+			Symbol: symbol,
+			Time:   b.Timestamp,
+			Open:   decimal.NewFromFloat(b.Open),
+			High:   decimal.NewFromFloat(b.High),
+			Low:    decimal.NewFromFloat(b.Low),
+			Close:  decimal.NewFromFloat(b.Close),
+			Volume: b.Volume,
+		})
+	}
+	return out, nil
+}
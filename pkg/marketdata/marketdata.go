@@ -0,0 +1,153 @@
+// Package marketdata streams stock bars and quotes from the broker's market
+// data feed.
+package marketdata
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	alpacadata "github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/stream"
+	"github.com/shopspring/decimal"
+)
+
+// DefaultFeed is the free IEX feed; sip needs a market data subscription
+const DefaultFeed = "iex"
+
+// Bar is one symbol's trading over a minute, or whatever period its source
+// aggregates over. Time is the start of the period.
+type Bar struct {
+	Symbol string
+	Time   time.Time
+	Open   decimal.Decimal
+	High   decimal.Decimal
+	Low    decimal.Decimal
+	Close  decimal.Decimal
+	Volume uint64
+}
+
+// Quote is a symbol's best bid and ask
+type Quote struct {
+	Symbol   string
+	Time     time.Time
+	BidPrice decimal.Decimal
+	BidSize  uint32
+	AskPrice decimal.Decimal
+	AskSize  uint32
+}
+
+// Mid is halfway between the bid and the ask, or whichever of them is set
+func (q Quote) Mid() decimal.Decimal {
+	switch {
+	case !q.BidPrice.IsPositive():
+		return q.AskPrice
+	case !q.AskPrice.IsPositive():
+		return q.BidPrice
+	default:
+		return q.BidPrice.Add(q.AskPrice).Div(decimal.NewFromInt(2))
+	}
+}
+
+// Feed streams market data
+type Feed interface {
+	// Stream passes bars and quotes for symbols to onBar and onQuote, one
+	// at a time, until ctx is done or the stream fails for good
+	Stream(ctx context.Context, symbols []string, onBar func(Bar), onQuote func(Quote)) error
+}
+
+// AlpacaFeed streams from Alpaca's real-time stock data
+type AlpacaFeed struct {
+	apiKey    string
+	apiSecret string
+	feed      string
+
+	// BaseURL overrides the stream's URL, such as for the sandbox
+	BaseURL string
+}
+
+var _ Feed = (*AlpacaFeed)(nil)
+
+// NewAlpacaFeed streams the named feed, iex or sip
+func NewAlpacaFeed(apiKey, apiSecret, feed string) *AlpacaFeed {
+	return &AlpacaFeed{
+		apiKey:    apiKey,
+		apiSecret: apiSecret,
+		feed:      feed,
+	}
+}
+
+// Stream subscribes to minute bars and quotes for symbols. The SDK
+// reconnects by itself; Stream returns once it gives up.
+func (f *AlpacaFeed) Stream(ctx context.Context, symbols []string, onBar func(Bar), onQuote func(Quote)) error {
+	opts := []stream.StockOption{
+		stream.WithCredentials(f.apiKey, f.apiSecret),
+		stream.WithLogger(sdkLogger{}),
+		stream.WithBars(func(b stream.Bar) {
+			onBar(BarFromAlpaca(b))
+		}, symbols...),
+		stream.WithQuotes(func(q stream.Quote) {
+			onQuote(QuoteFromAlpaca(q))
+		}, symbols...),
+	}
+	if f.BaseURL != "" {
+		opts = append(opts, stream.WithBaseURL(f.BaseURL))
+	}
+
+	client := stream.NewStocksClient(alpacadata.Feed(f.feed), opts...)
+	if err := client.Connect(ctx); err != nil {
+		return fmt.Errorf("failed to connect to market data stream: %w", err)
+	}
+
+	select {
+	case <-ctx.Done():
+		// The client closes its connection on its way out
+		<-client.Terminated()
+		return ctx.Err()
+	case err := <-client.Terminated():
+		if err == nil {
+			return fmt.Errorf("market data stream ended")
+		}
+		return fmt.Errorf("market data stream failed: %w", err)
+	}
+}
+
+func BarFromAlpaca(b stream.Bar) Bar {
+	return Bar{
+		Symbol: b.Symbol,
+		Time:   b.Timestamp,
+		Open:   decimal.NewFromFloat(b.Open),
+		High:   decimal.NewFromFloat(b.High),
+		Low:    decimal.NewFromFloat(b.Low),
+		Close:  decimal.NewFromFloat(b.Close),
+		Volume: b.Volume,
+	}
+}
+
+func QuoteFromAlpaca(q stream.Quote) Quote {
+	return Quote{
+		Symbol:   q.Symbol,
+		Time:     q.Timestamp,
+		BidPrice: decimal.NewFromFloat(q.BidPrice),
+		BidSize:  q.BidSize,
+		AskPrice: decimal.NewFromFloat(q.AskPrice),
+		AskSize:  q.AskSize,
+	}
+}
+
+// sdkLogger sends the SDK's connection messages to the structured log
+// rather than the terminal
+type sdkLogger struct{}
+
+func (sdkLogger) Infof(format string, v ...any) {
+	slog.Info("market data: " + fmt.Sprintf(format, v...))
+}
+
+func (sdkLogger) Warnf(format string, v ...any) {
+	slog.Warn("market data: " + fmt.Sprintf(format, v...))
+}
+
+func (sdkLogger) Errorf(format string, v ...any) {
+	slog.Error("market data: " + fmt.Sprintf(format, v...))
+}
@@ -0,0 +1,187 @@
+package metrics
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/watchlist"
+)
+
+// Client wraps a broker.Client and records how long each call takes and
+// how it failed, and what arrives on the event stream.
+type Client struct {
+	broker.Client
+
+	streams atomic.Int64
+}
+
+var _ broker.Client = (*Client)(nil)
+
+func NewClient(client broker.Client) *Client {
+	return &Client{Client: client}
+}
+
+// observe records a call to method that started at start; deferred with a
+// pointer to the call's error
+func observe(method string, start time.Time, err *error) {
+	brokerDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if *err != nil {
+		brokerErrors.WithLabelValues(method, errorKind(*err)).Inc()
+	}
+}
+
+func (c *Client) GetAccount(ctx context.Context, accountID string) (_ *account.Account, err error) {
+	defer observe("GetAccount", time.Now(), &err)
+	return c.Client.GetAccount(ctx, accountID)
+}
+
+func (c *Client) ListAccounts(ctx context.Context) (_ []*account.Account, err error) {
+	defer observe("ListAccounts", time.Now(), &err)
+	return c.Client.ListAccounts(ctx)
+}
+
+func (c *Client) GetPortfolioHistory(ctx context.Context, accountID, period string) (_ []*account.EquityPoint, err error) {
+	defer observe("GetPortfolioHistory", time.Now(), &err)
+	return c.Client.GetPortfolioHistory(ctx, accountID, period)
+}
+
+func (c *Client) ListTradingDays(ctx context.Context, start, end time.Time) (_ []time.Time, err error) {
+	defer observe("ListTradingDays", time.Now(), &err)
+	return c.Client.ListTradingDays(ctx, start, end)
+}
+
+func (c *Client) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (_ *order.Order, err error) {
+	start := time.Now()
+	defer observe("CreateOrder", start, &err)
+
+	o, err := c.Client.CreateOrder(ctx, req)
+	if err == nil {
+		orderAck.Observe(time.Since(start).Seconds())
+	}
+	return o, err
+}
+
+func (c *Client) GetOrder(ctx context.Context, orderID string) (_ *order.Order, err error) {
+	defer observe("GetOrder", time.Now(), &err)
+	return c.Client.GetOrder(ctx, orderID)
+}
+
+func (c *Client) GetOrderByClientOrderID(ctx context.Context, clientOrderID string) (_ *order.Order, err error) {
+	defer observe("GetOrderByClientOrderID", time.Now(), &err)
+	return c.Client.GetOrderByClientOrderID(ctx, clientOrderID)
+}
+
+func (c *Client) ListOrders(ctx context.Context, accountID string, req *order.ListOrdersRequest) (_ []*order.Order, err error) {
+	defer observe("ListOrders", time.Now(), &err)
+	return c.Client.ListOrders(ctx, accountID, req)
+}
+
+func (c *Client) ReplaceOrder(ctx context.Context, orderID string, req *order.ReplaceOrderRequest) (_ *order.Order, err error) {
+	defer observe("ReplaceOrder", time.Now(), &err)
+	return c.Client.ReplaceOrder(ctx, orderID, req)
+}
+
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (err error) {
+	defer observe("CancelOrder", time.Now(), &err)
+	return c.Client.CancelOrder(ctx, orderID)
+}
+
+func (c *Client) ListPositions(ctx context.Context, accountID string) (_ []*position.Position, err error) {
+	defer observe("ListPositions", time.Now(), &err)
+	return c.Client.ListPositions(ctx, accountID)
+}
+
+func (c *Client) ClosePosition(ctx context.Context, accountID, symbol string) (_ *order.Order, err error) {
+	defer observe("ClosePosition", time.Now(), &err)
+	return c.Client.ClosePosition(ctx, accountID, symbol)
+}
+
+func (c *Client) ListWatchlists(ctx context.Context, accountID string) (_ []*watchlist.Watchlist, err error) {
+	defer observe("ListWatchlists", time.Now(), &err)
+	return c.Client.ListWatchlists(ctx, accountID)
+}
+
+func (c *Client) GetWatchlist(ctx context.Context, watchlistID string) (_ *watchlist.Watchlist, err error) {
+	defer observe("GetWatchlist", time.Now(), &err)
+	return c.Client.GetWatchlist(ctx, watchlistID)
+}
+
+func (c *Client) CreateWatchlist(ctx context.Context, req *watchlist.CreateWatchlistRequest) (_ *watchlist.Watchlist, err error) {
+	defer observe("CreateWatchlist", time.Now(), &err)
+	return c.Client.CreateWatchlist(ctx, req)
+}
+
+func (c *Client) UpdateWatchlist(ctx context.Context, watchlistID string, req *watchlist.UpdateWatchlistRequest) (_ *watchlist.Watchlist, err error) {
+	defer observe("UpdateWatchlist", time.Now(), &err)
+	return c.Client.UpdateWatchlist(ctx, watchlistID, req)
+}
+
+func (c *Client) AddWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (_ *watchlist.Watchlist, err error) {
+	defer observe("AddWatchlistSymbol", time.Now(), &err)
+	return c.Client.AddWatchlistSymbol(ctx, watchlistID, symbol)
+}
+
+func (c *Client) RemoveWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (err error) {
+	defer observe("RemoveWatchlistSymbol", time.Now(), &err)
+	return c.Client.RemoveWatchlistSymbol(ctx, watchlistID, symbol)
+}
+
+func (c *Client) DeleteWatchlist(ctx context.Context, watchlistID string) (err error) {
+	defer observe("DeleteWatchlist", time.Now(), &err)
+	return c.Client.DeleteWatchlist(ctx, watchlistID)
+}
+
+// StreamEvents passes the stream on, counting it as connected until it
+// ends and recording every event that comes through
+func (c *Client) StreamEvents(ctx context.Context, accountID string) (<-chan broker.Event, <-chan error) {
+	if c.streams.Add(1) > 1 {
+		streamReconnects.Inc()
+	}
+
+	events, errs := c.Client.StreamEvents(ctx, accountID)
+	out := make(chan broker.Event)
+
+	streamConnected.Inc()
+	go func() {
+		defer streamConnected.Dec()
+		defer close(out)
+
+		for event := range events {
+			observeEvent(event)
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				// Keep draining so the stream can finish
+			}
+		}
+	}()
+
+	return out, errs
+}
+
+func observeEvent(event broker.Event) {
+	eventType := string(event.Type())
+	eventsReceived.WithLabelValues(eventType).Inc()
+
+	meta := event.Metadata()
+	var changedAt time.Time
+	if e, ok := event.(broker.TradeUpdateEvent); ok {
+		if e.ExecutedAt != nil {
+			changedAt = *e.ExecutedAt
+		} else if e.Order != nil {
+			changedAt = e.Order.UpdatedAt
+		}
+
+		if e.IsFill() && e.ExecutedAt != nil && e.Order != nil && !e.Order.SubmittedAt.IsZero() {
+			orderFill.Observe(e.ExecutedAt.Sub(e.Order.SubmittedAt).Seconds())
+		}
+	}
+	if !changedAt.IsZero() {
+		eventLag.WithLabelValues(eventType).Observe(meta.ReceivedAt.Sub(changedAt).Seconds())
+	}
+}
@@ -0,0 +1,144 @@
+// Package metrics exposes Prometheus metrics for broker calls, the broker
+// event stream and database queries. Metrics are registered on the default
+// registry, so every process that imports the package serves the same set
+// from Handler.
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/revrost/pony/pkg/broker"
+)
+
+// Order latencies run from under a second for market orders to days for
+// limit orders that wait for their price
+var orderBuckets = []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300, 900, 3600, 14400, 86400}
+
+var (
+	brokerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pony_broker_request_duration_seconds",
+		Help:    "Time taken by broker API calls, by client method.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method"})
+
+	brokerErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pony_broker_errors_total",
+		Help: "Failed broker API calls, by client method and kind of error.",
+	}, []string{"method", "error"})
+
+	brokerThrottled = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pony_broker_throttled_total",
+		Help: "Broker responses refusing a request for exceeding the rate limit, counting each retry.",
+	})
+
+	orderAck = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pony_order_ack_seconds",
+		Help:    "Time from submitting an order until the broker accepted it.",
+		Buckets: orderBuckets,
+	})
+
+	orderFill = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "pony_order_fill_seconds",
+		Help:    "Time from submitting an order until each of its fills.",
+		Buckets: orderBuckets,
+	})
+
+	streamConnected = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "pony_stream_connected",
+		Help: "Number of broker event streams currently open.",
+	})
+
+	streamReconnects = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "pony_stream_reconnects_total",
+		Help: "Broker event streams opened after the first one.",
+	})
+
+	eventLag = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pony_event_lag_seconds",
+		Help:    "Time from a change at the broker until its event arrived, by event type.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"type"})
+
+	eventsReceived = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pony_events_total",
+		Help: "Broker events received, by event type.",
+	}, []string{"type"})
+
+	queryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "pony_db_query_duration_seconds",
+		Help:    "Time taken by database queries, by query name.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"query"})
+
+	queryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "pony_db_query_errors_total",
+		Help: "Failed database queries, by query name.",
+	}, []string{"query"})
+)
+
+// Handler serves the metrics in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// QueryHook times each database query, as a store.QueryHook
+func QueryHook(_ context.Context, name string) func(error) {
+	start := time.Now()
+	return func(err error) {
+		queryDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+		if err != nil {
+			queryErrors.WithLabelValues(name).Inc()
+		}
+	}
+}
+
+// Transport counts rate limited broker responses on their way through next.
+// The SDK retries those by itself, so they never show up as errors unless
+// the retries run out too.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		resp, err := next.RoundTrip(req)
+		if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+			brokerThrottled.Inc()
+		}
+		return resp, err
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+// errorKind is the label a failed broker call is counted under
+func errorKind(err error) string {
+	switch {
+	case errors.Is(err, broker.ErrRateLimited):
+		return "rate_limited"
+	case errors.Is(err, broker.ErrNotFound):
+		return "not_found"
+	case errors.Is(err, broker.ErrRejected):
+		return "rejected"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), isTimeout(err):
+		return "timeout"
+	default:
+		return "other"
+	}
+}
+
+// isTimeout reports whether err is a network timeout, such as the HTTP
+// client's own
+func isTimeout(err error) bool {
+	var timeout interface{ Timeout() bool }
+	return errors.As(err, &timeout) && timeout.Timeout()
+}
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS watchlist_items;
+DROP TABLE IF EXISTS watchlists;
+DROP TABLE IF EXISTS positions;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS accounts;
@@ -33,10 +33,10 @@ CREATE TABLE IF NOT EXISTS orders (
     updated_at TIMESTAMP NOT NULL DEFAULT NOW()
 );
 
-CREATE INDEX idx_orders_account_id ON orders(account_id);
-CREATE INDEX idx_orders_status ON orders(status);
-CREATE INDEX idx_orders_symbol ON orders(symbol);
-CREATE INDEX idx_orders_created_at ON orders(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_account_id ON orders(account_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
+CREATE INDEX IF NOT EXISTS idx_orders_symbol ON orders(symbol);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at DESC);
 
 CREATE TABLE IF NOT EXISTS positions (
     id SERIAL PRIMARY KEY,
@@ -54,4 +54,22 @@ CREATE TABLE IF NOT EXISTS positions (
     UNIQUE(account_id, symbol)
 );
 
-CREATE INDEX idx_positions_account_id ON positions(account_id);
+CREATE INDEX IF NOT EXISTS idx_positions_account_id ON positions(account_id);
+
+CREATE TABLE IF NOT EXISTS watchlists (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlists_account_id ON watchlists(account_id);
+
+CREATE TABLE IF NOT EXISTS watchlist_items (
+    watchlist_id TEXT NOT NULL REFERENCES watchlists(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    position INTEGER NOT NULL, -- display order within the watchlist, 0-based
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (watchlist_id, symbol)
+);
@@ -0,0 +1,14 @@
+-- Differences between the local database and the broker that the
+-- reconciliation engine found and corrected.
+CREATE TABLE IF NOT EXISTS reconcile_drifts (
+    id BIGSERIAL PRIMARY KEY,
+    account_id TEXT NOT NULL,
+    entity TEXT NOT NULL, -- account, order, position
+    entity_id TEXT NOT NULL, -- account ID, Alpaca order ID or position symbol
+    field TEXT NOT NULL,
+    local_value TEXT NOT NULL,
+    broker_value TEXT NOT NULL,
+    corrected_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconcile_drifts_account_id ON reconcile_drifts(account_id, corrected_at DESC);
@@ -0,0 +1,37 @@
+-- Append-only log of every event received from the broker. The orders,
+-- accounts and positions tables are projections of this log and can be
+-- rebuilt by replaying it.
+CREATE TABLE IF NOT EXISTS events (
+    id BIGSERIAL PRIMARY KEY,
+    event_id TEXT UNIQUE NOT NULL, -- the broker's event ID
+    event_type TEXT NOT NULL, -- trade_update, account_update
+    account_id TEXT NOT NULL,
+    payload JSONB NOT NULL, -- raw event as received
+    received_at TIMESTAMP NOT NULL,
+    applied_at TIMESTAMP, -- set once the event has been applied to the projections
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_account_id ON events(account_id, id);
+CREATE INDEX IF NOT EXISTS idx_events_unapplied ON events(id) WHERE applied_at IS NULL;
+
+-- Events are never changed after the fact, except for marking them applied
+CREATE OR REPLACE FUNCTION events_append_only() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        RAISE EXCEPTION 'events is append-only';
+    END IF;
+    IF NEW.event_id IS DISTINCT FROM OLD.event_id
+        OR NEW.event_type IS DISTINCT FROM OLD.event_type
+        OR NEW.account_id IS DISTINCT FROM OLD.account_id
+        OR NEW.payload IS DISTINCT FROM OLD.payload
+        OR NEW.received_at IS DISTINCT FROM OLD.received_at THEN
+        RAISE EXCEPTION 'events is append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER events_append_only
+    BEFORE UPDATE OR DELETE ON events
+    FOR EACH ROW EXECUTE FUNCTION events_append_only();
@@ -0,0 +1,17 @@
+-- Individual fills of an order, one row per execution reported by the broker
+CREATE TABLE IF NOT EXISTS executions (
+    id TEXT PRIMARY KEY, -- the broker's execution ID
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL, -- the trade update that reported it
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL, -- buy or sell
+    qty DECIMAL(20, 8) NOT NULL,
+    price DECIMAL(20, 2) NOT NULL,
+    position_qty DECIMAL(20, 8), -- position size right after the fill
+    executed_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_executions_order_id ON executions(order_id, executed_at);
+CREATE INDEX IF NOT EXISTS idx_executions_account_id ON executions(account_id, executed_at DESC);
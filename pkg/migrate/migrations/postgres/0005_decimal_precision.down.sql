@@ -0,0 +1,26 @@
+-- Rounds values back to the original scales
+ALTER TABLE executions
+    ALTER COLUMN qty TYPE DECIMAL(20, 8),
+    ALTER COLUMN price TYPE DECIMAL(20, 2),
+    ALTER COLUMN position_qty TYPE DECIMAL(20, 8);
+
+ALTER TABLE positions
+    ALTER COLUMN qty TYPE DECIMAL(20, 8),
+    ALTER COLUMN avg_entry_price TYPE DECIMAL(20, 2),
+    ALTER COLUMN current_price TYPE DECIMAL(20, 2),
+    ALTER COLUMN market_value TYPE DECIMAL(20, 2),
+    ALTER COLUMN cost_basis TYPE DECIMAL(20, 2),
+    ALTER COLUMN unrealized_pl TYPE DECIMAL(20, 2),
+    ALTER COLUMN unrealized_plpc TYPE DECIMAL(10, 4);
+
+ALTER TABLE orders
+    ALTER COLUMN qty TYPE DECIMAL(20, 8),
+    ALTER COLUMN filled_qty TYPE DECIMAL(20, 8),
+    ALTER COLUMN limit_price TYPE DECIMAL(20, 2),
+    ALTER COLUMN stop_price TYPE DECIMAL(20, 2),
+    ALTER COLUMN filled_avg_price TYPE DECIMAL(20, 2);
+
+ALTER TABLE accounts
+    ALTER COLUMN cash TYPE DECIMAL(20, 2),
+    ALTER COLUMN portfolio_value TYPE DECIMAL(20, 2),
+    ALTER COLUMN buying_power TYPE DECIMAL(20, 2);
@@ -0,0 +1,28 @@
+-- Widen money, price and quantity columns so sub-penny equities and crypto
+-- pairs are stored exactly. Prices and quantities keep 10 decimal places,
+-- enough for every asset Alpaca trades.
+ALTER TABLE accounts
+    ALTER COLUMN cash TYPE DECIMAL(28, 10),
+    ALTER COLUMN portfolio_value TYPE DECIMAL(28, 10),
+    ALTER COLUMN buying_power TYPE DECIMAL(28, 10);
+
+ALTER TABLE orders
+    ALTER COLUMN qty TYPE DECIMAL(28, 10),
+    ALTER COLUMN filled_qty TYPE DECIMAL(28, 10),
+    ALTER COLUMN limit_price TYPE DECIMAL(28, 10),
+    ALTER COLUMN stop_price TYPE DECIMAL(28, 10),
+    ALTER COLUMN filled_avg_price TYPE DECIMAL(28, 10);
+
+ALTER TABLE positions
+    ALTER COLUMN qty TYPE DECIMAL(28, 10),
+    ALTER COLUMN avg_entry_price TYPE DECIMAL(28, 10),
+    ALTER COLUMN current_price TYPE DECIMAL(28, 10),
+    ALTER COLUMN market_value TYPE DECIMAL(28, 10),
+    ALTER COLUMN cost_basis TYPE DECIMAL(28, 10),
+    ALTER COLUMN unrealized_pl TYPE DECIMAL(28, 10),
+    ALTER COLUMN unrealized_plpc TYPE DECIMAL(20, 10); -- a fraction, 0.05 is 5%
+
+ALTER TABLE executions
+    ALTER COLUMN qty TYPE DECIMAL(28, 10),
+    ALTER COLUMN price TYPE DECIMAL(28, 10),
+    ALTER COLUMN position_qty TYPE DECIMAL(28, 10);
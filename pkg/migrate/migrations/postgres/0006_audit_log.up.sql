@@ -0,0 +1,31 @@
+-- Immutable record of every trading action taken from the TUI or CLI: who
+-- did it, from where, what was sent and what the broker answered.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    operator TEXT NOT NULL, -- PONY_OPERATOR or the OS user
+    host TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    action TEXT NOT NULL, -- order_submit, order_replace, order_cancel, position_close, config_change
+    request JSONB NOT NULL,
+    response JSONB NOT NULL, -- broker response, JSON null if the action failed
+    error TEXT NOT NULL DEFAULT '', -- broker error, empty if the action succeeded
+    created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_account_id ON audit_log(account_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC);
+
+CREATE OR REPLACE FUNCTION audit_log_immutable() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is immutable';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_immutable
+    BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_immutable();
+
+-- Row triggers do not fire on TRUNCATE
+CREATE TRIGGER audit_log_no_truncate
+    BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_immutable();
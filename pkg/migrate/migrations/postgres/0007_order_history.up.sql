@@ -0,0 +1,4 @@
+-- Order history is searched per account, newest first, and paged on
+-- (created_at, id)
+CREATE INDEX IF NOT EXISTS idx_orders_account_created ON orders(account_id, created_at DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_orders_account_filled ON orders(account_id, filled_at DESC);
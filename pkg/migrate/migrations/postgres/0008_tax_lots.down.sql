@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS lot_selections;
+DROP TABLE IF EXISTS lot_disposals;
+DROP TABLE IF EXISTS tax_lots;
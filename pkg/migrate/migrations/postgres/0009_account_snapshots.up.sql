@@ -0,0 +1,17 @@
+-- One row per account per trading day, taken from the live account during
+-- the day or backfilled from the broker's portfolio history. Backfilled rows
+-- only know equity and P&L, so the balance columns are nullable.
+CREATE TABLE IF NOT EXISTS account_snapshots (
+    id BIGSERIAL PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    trading_date DATE NOT NULL,
+    equity DECIMAL(28, 10) NOT NULL,
+    cash DECIMAL(28, 10),
+    buying_power DECIMAL(28, 10),
+    positions_value DECIMAL(28, 10),
+    day_pl DECIMAL(28, 10) NOT NULL,
+    source TEXT NOT NULL, -- live or history
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (account_id, trading_date)
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS accounts_notify_change ON accounts;
+DROP TRIGGER IF EXISTS positions_notify_change ON positions;
+DROP TRIGGER IF EXISTS orders_notify_change ON orders;
+DROP FUNCTION IF EXISTS pony_notify_change();
@@ -0,0 +1,33 @@
+-- Notify the pony_changes channel whenever orders, positions or accounts
+-- change, so other processes can refresh without polling. The payload only
+-- names the table and account, which lets Postgres fold the repeats of a
+-- transaction into one notification.
+CREATE OR REPLACE FUNCTION pony_notify_change() RETURNS trigger AS $$
+DECLARE
+    changed JSONB;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        changed := to_jsonb(OLD);
+    ELSE
+        changed := to_jsonb(NEW);
+    END IF;
+
+    PERFORM pg_notify('pony_changes', json_build_object(
+        'table', TG_TABLE_NAME,
+        'account_id', CASE WHEN TG_TABLE_NAME = 'accounts' THEN changed->>'id' ELSE changed->>'account_id' END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER orders_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON orders
+    FOR EACH ROW EXECUTE FUNCTION pony_notify_change();
+
+CREATE TRIGGER positions_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON positions
+    FOR EACH ROW EXECUTE FUNCTION pony_notify_change();
+
+CREATE TRIGGER accounts_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON accounts
+    FOR EACH ROW EXECUTE FUNCTION pony_notify_change();
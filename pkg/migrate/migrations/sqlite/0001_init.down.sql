@@ -0,0 +1,10 @@
+DROP TABLE IF EXISTS executions;
+DROP TRIGGER IF EXISTS events_append_only;
+DROP TRIGGER IF EXISTS events_no_delete;
+DROP TABLE IF EXISTS events;
+DROP TABLE IF EXISTS reconcile_drifts;
+DROP TABLE IF EXISTS watchlist_items;
+DROP TABLE IF EXISTS watchlists;
+DROP TABLE IF EXISTS positions;
+DROP TABLE IF EXISTS orders;
+DROP TABLE IF EXISTS accounts;
@@ -0,0 +1,147 @@
+-- Schema for Pony Trading App on SQLite. This one migration matches
+-- postgres/0001 through 0005; later migrations share version numbers with
+-- their Postgres counterparts.
+--
+-- Money, prices and quantities are stored as TEXT so they round-trip
+-- through decimal.Decimal exactly; SQLite's NUMERIC affinity would turn
+-- them into floats. Queries that sort or sum them CAST to REAL.
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id TEXT PRIMARY KEY,
+    alpaca_account_id TEXT UNIQUE NOT NULL,
+    status TEXT NOT NULL,
+    currency TEXT NOT NULL,
+    cash TEXT NOT NULL DEFAULT '0',
+    portfolio_value TEXT NOT NULL DEFAULT '0',
+    buying_power TEXT NOT NULL DEFAULT '0',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS orders (
+    id TEXT PRIMARY KEY,
+    alpaca_order_id TEXT UNIQUE NOT NULL,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL, -- buy or sell
+    order_type TEXT NOT NULL, -- market, limit, stop, stop_limit
+    qty TEXT NOT NULL,
+    filled_qty TEXT NOT NULL DEFAULT '0',
+    limit_price TEXT,
+    stop_price TEXT,
+    time_in_force TEXT NOT NULL, -- day, gtc, ioc, fok
+    status TEXT NOT NULL, -- new, partially_filled, filled, canceled, rejected
+    filled_avg_price TEXT,
+    submitted_at TIMESTAMP,
+    filled_at TIMESTAMP,
+    canceled_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_account_id ON orders(account_id);
+CREATE INDEX IF NOT EXISTS idx_orders_status ON orders(status);
+CREATE INDEX IF NOT EXISTS idx_orders_symbol ON orders(symbol);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at DESC);
+
+CREATE TABLE IF NOT EXISTS positions (
+    id INTEGER PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    qty TEXT NOT NULL,
+    avg_entry_price TEXT NOT NULL,
+    current_price TEXT NOT NULL,
+    market_value TEXT NOT NULL,
+    cost_basis TEXT NOT NULL,
+    unrealized_pl TEXT NOT NULL,
+    unrealized_plpc TEXT NOT NULL, -- a fraction, 0.05 is 5%
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(account_id, symbol)
+);
+
+CREATE INDEX IF NOT EXISTS idx_positions_account_id ON positions(account_id);
+
+CREATE TABLE IF NOT EXISTS watchlists (
+    id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_watchlists_account_id ON watchlists(account_id);
+
+CREATE TABLE IF NOT EXISTS watchlist_items (
+    watchlist_id TEXT NOT NULL REFERENCES watchlists(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    position INTEGER NOT NULL, -- display order within the watchlist, 0-based
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (watchlist_id, symbol)
+);
+
+-- Differences between the local database and the broker that the
+-- reconciliation engine found and corrected.
+CREATE TABLE IF NOT EXISTS reconcile_drifts (
+    id INTEGER PRIMARY KEY,
+    account_id TEXT NOT NULL,
+    entity TEXT NOT NULL, -- account, order, position
+    entity_id TEXT NOT NULL, -- account ID, Alpaca order ID or position symbol
+    field TEXT NOT NULL,
+    local_value TEXT NOT NULL,
+    broker_value TEXT NOT NULL,
+    corrected_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_reconcile_drifts_account_id ON reconcile_drifts(account_id, corrected_at DESC);
+
+-- Append-only log of every event received from the broker
+CREATE TABLE IF NOT EXISTS events (
+    id INTEGER PRIMARY KEY,
+    event_id TEXT UNIQUE NOT NULL, -- the broker's event ID
+    event_type TEXT NOT NULL, -- trade_update, account_update
+    account_id TEXT NOT NULL,
+    payload JSON NOT NULL, -- raw event as received
+    received_at TIMESTAMP NOT NULL,
+    applied_at TIMESTAMP, -- set once the event has been applied to the projections
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_events_account_id ON events(account_id, id);
+CREATE INDEX IF NOT EXISTS idx_events_unapplied ON events(id) WHERE applied_at IS NULL;
+
+-- Events are never changed after the fact, except for marking them applied
+CREATE TRIGGER IF NOT EXISTS events_no_delete
+    BEFORE DELETE ON events
+BEGIN
+    SELECT RAISE(ABORT, 'events is append-only');
+END;
+
+CREATE TRIGGER IF NOT EXISTS events_append_only
+    BEFORE UPDATE ON events
+    WHEN NEW.event_id IS NOT OLD.event_id
+        OR NEW.event_type IS NOT OLD.event_type
+        OR NEW.account_id IS NOT OLD.account_id
+        OR NEW.payload IS NOT OLD.payload
+        OR NEW.received_at IS NOT OLD.received_at
+BEGIN
+    SELECT RAISE(ABORT, 'events is append-only');
+END;
+
+-- Individual fills of an order, one row per execution reported by the broker
+CREATE TABLE IF NOT EXISTS executions (
+    id TEXT PRIMARY KEY, -- the broker's execution ID
+    order_id TEXT NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    event_id TEXT NOT NULL, -- the trade update that reported it
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL, -- buy or sell
+    qty TEXT NOT NULL,
+    price TEXT NOT NULL,
+    position_qty TEXT, -- position size right after the fill
+    executed_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_executions_order_id ON executions(order_id, executed_at);
+CREATE INDEX IF NOT EXISTS idx_executions_account_id ON executions(account_id, executed_at DESC);
@@ -0,0 +1,28 @@
+-- Immutable record of every trading action taken from the TUI or CLI: who
+-- did it, from where, what was sent and what the broker answered.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id INTEGER PRIMARY KEY,
+    operator TEXT NOT NULL, -- PONY_OPERATOR or the OS user
+    host TEXT NOT NULL,
+    account_id TEXT NOT NULL,
+    action TEXT NOT NULL, -- order_submit, order_replace, order_cancel, position_close, config_change
+    request JSON NOT NULL,
+    response JSON NOT NULL, -- broker response, JSON null if the action failed
+    error TEXT NOT NULL DEFAULT '', -- broker error, empty if the action succeeded
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_log_account_id ON audit_log(account_id, id DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_log_created_at ON audit_log(created_at DESC);
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_update
+    BEFORE UPDATE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is immutable');
+END;
+
+CREATE TRIGGER IF NOT EXISTS audit_log_no_delete
+    BEFORE DELETE ON audit_log
+BEGIN
+    SELECT RAISE(ABORT, 'audit_log is immutable');
+END;
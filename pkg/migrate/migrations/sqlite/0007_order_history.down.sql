@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_orders_account_filled;
+DROP INDEX IF EXISTS idx_orders_account_created;
@@ -0,0 +1,45 @@
+-- Tax lots built from executions. Every opening fill starts a lot and every
+-- closing fill disposes of open lots in the order the lot method picks.
+-- Both tables are derived and are rebuilt from executions per symbol.
+CREATE TABLE IF NOT EXISTS tax_lots (
+    id INTEGER PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    execution_id TEXT UNIQUE NOT NULL, -- the fill that opened the lot
+    direction TEXT NOT NULL, -- long or short
+    qty TEXT NOT NULL,
+    remaining_qty TEXT NOT NULL,
+    price TEXT NOT NULL, -- cost per share, or proceeds per share for a short
+    opened_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP -- set once remaining_qty reaches zero
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_lots_account_symbol ON tax_lots(account_id, symbol, opened_at);
+
+CREATE TABLE IF NOT EXISTS lot_disposals (
+    id INTEGER PRIMARY KEY,
+    lot_id INTEGER NOT NULL REFERENCES tax_lots(id) ON DELETE CASCADE,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    execution_id TEXT NOT NULL, -- the fill that closed this part of the lot
+    qty TEXT NOT NULL,
+    open_price TEXT NOT NULL,
+    close_price TEXT NOT NULL,
+    realized_pl TEXT NOT NULL,
+    opened_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP NOT NULL,
+    term TEXT NOT NULL -- short or long
+);
+
+CREATE INDEX IF NOT EXISTS idx_lot_disposals_account_closed ON lot_disposals(account_id, closed_at);
+
+-- Specific identification: which lots a closing order should dispose of.
+-- Orders are referenced by ID only, so choices survive an event rebuild.
+CREATE TABLE IF NOT EXISTS lot_selections (
+    order_id TEXT NOT NULL,
+    lot_execution_id TEXT NOT NULL, -- the fill that opened the chosen lot
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    qty TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (order_id, lot_execution_id)
+);
@@ -0,0 +1,17 @@
+-- One row per account per trading day, taken from the live account during
+-- the day or backfilled from the broker's portfolio history. Backfilled rows
+-- only know equity and P&L, so the balance columns are nullable.
+CREATE TABLE IF NOT EXISTS account_snapshots (
+    id INTEGER PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    trading_date DATE NOT NULL,
+    equity TEXT NOT NULL,
+    cash TEXT,
+    buying_power TEXT,
+    positions_value TEXT,
+    day_pl TEXT NOT NULL,
+    source TEXT NOT NULL, -- live or history
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (account_id, trading_date)
+);
@@ -0,0 +1,22 @@
+-- Order outbox. Each order is written here with its client order ID before
+-- it is sent to the broker and marked sent or failed afterwards, so an order
+-- sent just before a crash can still be found at the broker.
+CREATE TABLE IF NOT EXISTS order_intents (
+    client_order_id TEXT PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL,
+    order_type TEXT NOT NULL,
+    qty TEXT,
+    limit_price TEXT,
+    stop_price TEXT,
+    time_in_force TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending', -- pending, sent or failed
+    alpaca_order_id TEXT, -- set once sent
+    error TEXT, -- set once failed
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_intents_pending ON order_intents(created_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_order_intents_account_created ON order_intents(account_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- SQLite has no LISTEN/NOTIFY, so there is no change feed to set up. The
+-- migration exists to keep the versions of both dialects in step.
+SELECT 1;
@@ -0,0 +1,14 @@
+-- Historical price bars that backtests replay, imported from CSV files or
+-- fetched from the market data API. started_at is the start of the bar's
+-- period, in UTC.
+CREATE TABLE IF NOT EXISTS bars (
+    symbol TEXT NOT NULL,
+    timeframe TEXT NOT NULL, -- 1Min, 1Hour or 1Day
+    started_at TIMESTAMP NOT NULL,
+    open_price TEXT NOT NULL,
+    high_price TEXT NOT NULL,
+    low_price TEXT NOT NULL,
+    close_price TEXT NOT NULL,
+    volume INTEGER NOT NULL,
+    PRIMARY KEY (symbol, timeframe, started_at)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS scheduled_order_runs;
+DROP TABLE IF EXISTS scheduled_orders;
+ALTER TABLE order_intents DROP COLUMN notional;
@@ -0,0 +1,41 @@
+-- Orders can be for a dollar amount rather than a quantity, such as the
+-- ones scheduled below
+ALTER TABLE order_intents ADD COLUMN notional TEXT;
+
+-- Orders placed again and again on a schedule, such as buying $100 of VTI
+-- every Monday. The order columns are the template each run places.
+CREATE TABLE IF NOT EXISTS scheduled_orders (
+    id INTEGER PRIMARY KEY,
+    account_id TEXT NOT NULL REFERENCES accounts(id) ON DELETE CASCADE,
+    schedule TEXT NOT NULL, -- five-field cron expression
+    time_zone TEXT NOT NULL, -- IANA time zone the schedule is read in
+    symbol TEXT NOT NULL,
+    side TEXT NOT NULL,
+    order_type TEXT NOT NULL,
+    qty TEXT,
+    notional TEXT,
+    limit_price TEXT,
+    stop_price TEXT,
+    time_in_force TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at TIMESTAMP, -- null while disabled
+    last_run_at TIMESTAMP,
+    last_result TEXT, -- placed, skipped or failed
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_orders_due ON scheduled_orders(next_run_at) WHERE enabled = 1;
+
+-- One row per time a schedule came due, whether it placed an order or not
+CREATE TABLE IF NOT EXISTS scheduled_order_runs (
+    id INTEGER PRIMARY KEY,
+    scheduled_order_id INTEGER NOT NULL REFERENCES scheduled_orders(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP NOT NULL,
+    result TEXT NOT NULL, -- placed, skipped or failed
+    client_order_id TEXT, -- set if an order was sent
+    order_id TEXT, -- set once the broker took it
+    message TEXT, -- why it was skipped or failed
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (scheduled_order_id, scheduled_for)
+);
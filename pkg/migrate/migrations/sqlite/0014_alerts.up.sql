@@ -0,0 +1,15 @@
+-- Rules such as "AAPL last > 200" or "order rejected", checked against
+-- market data, broker events and the stored accounts and positions, and
+-- where to deliver an alert when one fires
+CREATE TABLE IF NOT EXISTS alerts (
+    id INTEGER PRIMARY KEY,
+    account_id TEXT REFERENCES accounts(id) ON DELETE CASCADE, -- null for every account
+    rule TEXT NOT NULL,
+    notify TEXT NOT NULL, -- comma-separated channels: toast, bell, webhook, command
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    trigger_count INTEGER NOT NULL DEFAULT 0,
+    last_triggered_at TIMESTAMP,
+    last_message TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- The dollar amount of a notional order. Such an order has no quantity until
+-- it fills, so its qty is kept at the quantity filled so far.
+ALTER TABLE orders ADD COLUMN notional TEXT;
@@ -0,0 +1,37 @@
+package order
+
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Execution is a single fill (or partial fill) of an order
+type Execution struct {
+	ID        string
+	OrderID   string
+	AccountID string
+	Symbol    string
+	Side      OrderSide
+	Qty       decimal.Decimal
+	Price     decimal.Decimal
+	// PositionQty is the position size right after this execution, if known
+	PositionQty *decimal.Decimal
+	ExecutedAt  time.Time
+}
+
+// VWAP returns the volume-weighted average price of the executions, and
+// false if there are none.
+func VWAP(executions []*Execution) (decimal.Decimal, bool) {
+	qty := decimal.Zero
+	notional := decimal.Zero
+	for _, e := range executions {
+		qty = qty.Add(e.Qty)
+		notional = notional.Add(e.Qty.Mul(e.Price))
+	}
+
+	if qty.IsZero() {
+		return decimal.Zero, false
+	}
+	return notional.Div(qty), true
+}
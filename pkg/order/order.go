@@ -1,6 +1,10 @@
 package order
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/shopspring/decimal"
@@ -10,6 +14,7 @@ type OrderSide string
 type OrderType string
 type OrderStatus string
 type TimeInForce string
+type QueryStatus string
 
 const (
 	OrderSideBuy  OrderSide = "buy"
@@ -23,14 +28,42 @@ const (
 	OrderTypeStopLimit OrderType = "stop_limit"
 )
 
+// Order statuses, as Alpaca names them
 const (
-	OrderStatusNew             OrderStatus = "new"
-	OrderStatusPartiallyFilled OrderStatus = "partially_filled"
-	OrderStatusFilled          OrderStatus = "filled"
-	OrderStatusCanceled        OrderStatus = "canceled"
-	OrderStatusRejected        OrderStatus = "rejected"
+	OrderStatusNew                OrderStatus = "new"
+	OrderStatusPartiallyFilled    OrderStatus = "partially_filled"
+	OrderStatusFilled             OrderStatus = "filled"
+	OrderStatusDoneForDay         OrderStatus = "done_for_day"
+	OrderStatusCanceled           OrderStatus = "canceled"
+	OrderStatusExpired            OrderStatus = "expired"
+	OrderStatusReplaced           OrderStatus = "replaced"
+	OrderStatusPendingCancel      OrderStatus = "pending_cancel"
+	OrderStatusPendingReplace     OrderStatus = "pending_replace"
+	OrderStatusPendingNew         OrderStatus = "pending_new"
+	OrderStatusAccepted           OrderStatus = "accepted"
+	OrderStatusAcceptedForBidding OrderStatus = "accepted_for_bidding"
+	OrderStatusStopped            OrderStatus = "stopped"
+	OrderStatusRejected           OrderStatus = "rejected"
+	OrderStatusSuspended          OrderStatus = "suspended"
+	OrderStatusCalculated         OrderStatus = "calculated"
+	OrderStatusHeld               OrderStatus = "held"
 )
 
+// OpenStatuses are the statuses of orders that are still working, and so
+// can still fill. done_for_day orders resume on the next trading day.
+var OpenStatuses = []OrderStatus{
+	OrderStatusNew,
+	OrderStatusPartiallyFilled,
+	OrderStatusDoneForDay,
+	OrderStatusPendingCancel,
+	OrderStatusPendingReplace,
+	OrderStatusPendingNew,
+	OrderStatusAccepted,
+	OrderStatusAcceptedForBidding,
+	OrderStatusStopped,
+	OrderStatusHeld,
+}
+
 const (
 	TimeInForceDay TimeInForce = "day"
 	TimeInForceGTC TimeInForce = "gtc"
@@ -38,6 +71,13 @@ const (
 	TimeInForceFOK TimeInForce = "fok"
 )
 
+// QueryStatus selects which orders ListOrdersRequest returns
+const (
+	QueryStatusOpen   QueryStatus = "open"
+	QueryStatusClosed QueryStatus = "closed"
+	QueryStatusAll    QueryStatus = "all"
+)
+
 type Order struct {
 	ID             string
 	StakeOrderID   string
@@ -47,6 +87,7 @@ type Order struct {
 	Side           OrderSide
 	OrderType      OrderType
 	Qty            *decimal.Decimal
+	Notional       *decimal.Decimal
 	FilledQty      decimal.Decimal
 	LimitPrice     *decimal.Decimal
 	StopPrice      *decimal.Decimal
@@ -63,13 +104,108 @@ type Order struct {
 	UpdatedAt      time.Time
 }
 
+// ParseStatuses reads a comma-separated list of statuses, where open stands
+// for every one of OpenStatuses
+func ParseStatuses(list string) []OrderStatus {
+	var statuses []OrderStatus
+	for _, s := range strings.Split(list, ",") {
+		switch s = strings.TrimSpace(s); s {
+		case "":
+		case "open":
+			statuses = append(statuses, OpenStatuses...)
+		default:
+			statuses = append(statuses, OrderStatus(s))
+		}
+	}
+	return statuses
+}
+
+// IsOpen reports whether the order can still fill, and so can be canceled
+// or replaced.
+func (o *Order) IsOpen() bool {
+	return slices.Contains(OpenStatuses, o.Status)
+}
+
 type CreateOrderRequest struct {
-	AccountID   string
-	Symbol      string
-	Side        OrderSide
-	OrderType   OrderType
+	AccountID string
+	Symbol    string
+	Side      OrderSide
+	OrderType OrderType
+	Qty       *decimal.Decimal
+	// Notional buys or sells a dollar amount instead of a quantity, for
+	// market day orders only
+	Notional    *decimal.Decimal
+	LimitPrice  *decimal.Decimal
+	StopPrice   *decimal.Decimal
+	TimeInForce TimeInForce
+
+	// ClientOrderID is our own ID for the order, unique per account, which
+	// the broker can be asked for the order by. The outbox fills it in.
+	ClientOrderID string
+}
+
+// Validate checks that the request names a known side, type and time in
+// force, has a positive quantity or notional amount and carries the prices
+// its type needs.
+func (r *CreateOrderRequest) Validate() error {
+	if r.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+	if !slices.Contains([]OrderSide{OrderSideBuy, OrderSideSell}, r.Side) {
+		return fmt.Errorf("side must be buy or sell, not %q", r.Side)
+	}
+	if !slices.Contains([]OrderType{OrderTypeMarket, OrderTypeLimit, OrderTypeStop, OrderTypeStopLimit}, r.OrderType) {
+		return fmt.Errorf("order type must be market, limit, stop or stop_limit, not %q", r.OrderType)
+	}
+	if !slices.Contains([]TimeInForce{TimeInForceDay, TimeInForceGTC, TimeInForceIOC, TimeInForceFOK}, r.TimeInForce) {
+		return fmt.Errorf("time in force must be day, gtc, ioc or fok, not %q", r.TimeInForce)
+	}
+	switch {
+	case r.Qty != nil && r.Notional != nil:
+		return errors.New("an order has a quantity or a notional amount, not both")
+	case r.Notional != nil:
+		if !r.Notional.IsPositive() {
+			return errors.New("notional amount must be a positive number")
+		}
+		if r.OrderType != OrderTypeMarket || r.TimeInForce != TimeInForceDay {
+			return errors.New("a notional amount is for market orders with time in force day only")
+		}
+	case r.Qty == nil || !r.Qty.IsPositive():
+		return errors.New("quantity must be a positive number")
+	}
+
+	needsLimit := r.OrderType == OrderTypeLimit || r.OrderType == OrderTypeStopLimit
+	if needsLimit != (r.LimitPrice != nil) {
+		return errors.New("a limit price is needed for limit and stop_limit orders only")
+	}
+	if r.LimitPrice != nil && !r.LimitPrice.IsPositive() {
+		return errors.New("limit price must be a positive number")
+	}
+
+	needsStop := r.OrderType == OrderTypeStop || r.OrderType == OrderTypeStopLimit
+	if needsStop != (r.StopPrice != nil) {
+		return errors.New("a stop price is needed for stop and stop_limit orders only")
+	}
+	if r.StopPrice != nil && !r.StopPrice.IsPositive() {
+		return errors.New("stop price must be a positive number")
+	}
+
+	return nil
+}
+
+// ReplaceOrderRequest changes an open order. Nil fields and an empty
+// TimeInForce keep the order's current values.
+type ReplaceOrderRequest struct {
 	Qty         *decimal.Decimal
 	LimitPrice  *decimal.Decimal
 	StopPrice   *decimal.Decimal
 	TimeInForce TimeInForce
 }
+
+type ListOrdersRequest struct {
+	Status  QueryStatus
+	Limit   int
+	After   time.Time
+	Until   time.Time
+	Symbols []string
+}
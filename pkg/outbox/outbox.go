@@ -0,0 +1,214 @@
+package outbox
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/store"
+)
+
+// Status is how far an order intent got
+type Status string
+
+const (
+	StatusPending Status = "pending" // written, but the broker's answer was never recorded
+	StatusSent    Status = "sent"
+	StatusFailed  Status = "failed"
+)
+
+// ResolveGrace is how old a pending intent must be before Resolve decides
+// an order the broker does not know was never placed, so a request still
+// in flight from another process is not marked failed
+const ResolveGrace = time.Minute
+
+// DefaultLimit is how many intents ListIntents returns when Filter.Limit is unset
+const DefaultLimit = 100
+
+// Intent is an order as it was about to be sent to the broker, and what
+// became of it
+type Intent struct {
+	order.CreateOrderRequest
+	Status        Status
+	AlpacaOrderID string
+	Error         string
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// Filter narrows ListIntents. Zero fields match everything.
+type Filter struct {
+	AccountID string
+	Status    Status
+	Limit     int
+}
+
+// Reader is the subset of the sqlc generated Querier ListIntents needs.
+type Reader interface {
+	ListOrderIntents(ctx context.Context, arg db.ListOrderIntentsParams) ([]db.OrderIntent, error)
+}
+
+// Client wraps a broker.Client so every order it creates goes through the
+// outbox: the intent is written with a client order ID before the broker is
+// called, and marked sent or failed once the broker answers. Intents left
+// pending by a crash or a lost response are settled by Resolve.
+type Client struct {
+	broker.Client
+	db *store.DB
+}
+
+var _ broker.Client = (*Client)(nil)
+
+func NewClient(client broker.Client, conn *store.DB) *Client {
+	return &Client{Client: client, db: conn}
+}
+
+// CreateOrder places the order through the outbox. A request without a
+// ClientOrderID is given a new one.
+func (c *Client) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.Order, error) {
+	if req.ClientOrderID == "" {
+		r := *req
+		r.ClientOrderID = NewClientOrderID()
+		req = &r
+	}
+
+	if _, err := c.db.Queries().CreateOrderIntent(ctx, db.NewCreateOrderIntentParams(req)); err != nil {
+		return nil, fmt.Errorf("failed to record order intent: %w", err)
+	}
+
+	o, err := c.Client.CreateOrder(ctx, req)
+	switch {
+	case o != nil:
+		// Placed, though a wrapped client may still report an error of its own
+		o.AccountID = req.AccountID
+		if sentErr := markSent(ctx, c.db, req.ClientOrderID, o); sentErr != nil {
+			err = errors.Join(err, fmt.Errorf("order %s was placed but not recorded: %w", o.AlpacaOrderID, sentErr))
+		}
+		return o, err
+	case errors.Is(err, broker.ErrRejected):
+		return nil, errors.Join(err, markFailed(ctx, c.db, req.ClientOrderID, err))
+	default:
+		// The broker may have the order even so; Resolve finds out
+		return nil, err
+	}
+}
+
+// Resolve settles every pending intent by looking its client order ID up at
+// the broker. An order the broker has is recorded and its intent marked
+// sent; one it does not have is marked failed once the intent is older than
+// ResolveGrace. It returns the intents it settled.
+func Resolve(ctx context.Context, client broker.Client, conn *store.DB) ([]*Intent, error) {
+	rows, err := conn.Queries().ListPendingOrderIntents(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending order intents: %w", err)
+	}
+
+	var resolved []*Intent
+	for _, row := range rows {
+		intent := toIntent(row)
+
+		o, err := client.GetOrderByClientOrderID(ctx, intent.ClientOrderID)
+		switch {
+		case errors.Is(err, broker.ErrNotFound):
+			if time.Since(intent.CreatedAt) < ResolveGrace {
+				continue
+			}
+			if markErr := markFailed(ctx, conn, intent.ClientOrderID, err); markErr != nil {
+				return resolved, markErr
+			}
+			intent.Status = StatusFailed
+			intent.Error = err.Error()
+		case err != nil:
+			return resolved, fmt.Errorf("order intent %s: %w", intent.ClientOrderID, err)
+		default:
+			o.AccountID = intent.AccountID
+			if err := markSent(ctx, conn, intent.ClientOrderID, o); err != nil {
+				return resolved, err
+			}
+			intent.Status = StatusSent
+			intent.AlpacaOrderID = o.AlpacaOrderID
+		}
+		resolved = append(resolved, intent)
+	}
+
+	return resolved, nil
+}
+
+// ListIntents returns the intents matching filter, newest first.
+func ListIntents(ctx context.Context, r Reader, filter Filter) ([]*Intent, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+
+	rows, err := r.ListOrderIntents(ctx, db.ListOrderIntentsParams{
+		AccountID: filter.AccountID,
+		Status:    string(filter.Status),
+		RowLimit:  int32(limit),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list order intents: %w", err)
+	}
+
+	intents := make([]*Intent, 0, len(rows))
+	for _, row := range rows {
+		intents = append(intents, toIntent(row))
+	}
+	return intents, nil
+}
+
+// NewClientOrderID returns a random ID to submit an order with
+func NewClientOrderID() string {
+	return "pony-" + rand.Text()
+}
+
+// markSent marks the intent sent and records the order, in one transaction
+func markSent(ctx context.Context, conn *store.DB, clientOrderID string, o *order.Order) error {
+	return conn.InTx(ctx, func(q db.Querier) error {
+		if _, err := q.MarkOrderIntentSent(ctx, db.MarkOrderIntentSentParams{
+			ClientOrderID: clientOrderID,
+			AlpacaOrderID: sql.NullString{String: o.AlpacaOrderID, Valid: true},
+		}); err != nil {
+			return fmt.Errorf("failed to mark order intent sent: %w", err)
+		}
+
+		// The event stream or reconciliation may have recorded it already.
+		// Fills are left to them, so only the submitted fields are written.
+		_, err := q.GetOrderByAlpacaID(ctx, o.AlpacaOrderID)
+		if !errors.Is(err, sql.ErrNoRows) {
+			return err
+		}
+		if _, err := q.CreateOrder(ctx, db.NewCreateOrderParams(o)); err != nil {
+			return fmt.Errorf("failed to record order: %w", err)
+		}
+		return nil
+	})
+}
+
+func markFailed(ctx context.Context, conn *store.DB, clientOrderID string, cause error) error {
+	_, err := conn.Queries().MarkOrderIntentFailed(ctx, db.MarkOrderIntentFailedParams{
+		ClientOrderID: clientOrderID,
+		Error:         sql.NullString{String: cause.Error(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark order intent failed: %w", err)
+	}
+	return nil
+}
+
+func toIntent(row db.OrderIntent) *Intent {
+	return &Intent{
+		CreateOrderRequest: *db.ToCreateOrderRequest(row),
+		Status:             Status(row.Status),
+		AlpacaOrderID:      row.AlpacaOrderID.String,
+		Error:              row.Error.String,
+		CreatedAt:          row.CreatedAt,
+		UpdatedAt:          row.UpdatedAt,
+	}
+}
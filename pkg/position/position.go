@@ -1,18 +1,22 @@
 package position
 
-import "time"
+import (
+	"time"
+
+	"github.com/shopspring/decimal"
+)
 
 type Position struct {
 	ID             int64
 	AccountID      string
 	Symbol         string
-	Qty            float64
-	AvgEntryPrice  float64
-	CurrentPrice   float64
-	MarketValue    float64
-	CostBasis      float64
-	UnrealizedPL   float64
-	UnrealizedPLPC float64
+	Qty            decimal.Decimal
+	AvgEntryPrice  decimal.Decimal
+	CurrentPrice   decimal.Decimal
+	MarketValue    decimal.Decimal
+	CostBasis      decimal.Decimal
+	UnrealizedPL   decimal.Decimal
+	UnrealizedPLPC decimal.Decimal
 	CreatedAt      time.Time
 	UpdatedAt      time.Time
 }
@@ -0,0 +1,573 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/taxlot"
+)
+
+const (
+	DefaultInterval = time.Minute
+
+	// DefaultRecentWindow is how far back closed orders are reconciled.
+	// Open orders are always reconciled regardless of age.
+	DefaultRecentWindow = 7 * 24 * time.Hour
+
+	// orderPageSize is the most orders Alpaca returns in one page
+	orderPageSize = 500
+
+	// executionEventID is recorded as the event of the executions a pass
+	// backfills, since no trade update reported them
+	executionEventID = "reconcile"
+)
+
+// Store is the subset of the sqlc generated Querier the engine needs.
+// *db.Queries implements it.
+type Store interface {
+	GetAccount(ctx context.Context, id string) (db.Account, error)
+	CreateAccount(ctx context.Context, arg db.CreateAccountParams) (db.Account, error)
+	UpdateAccount(ctx context.Context, arg db.UpdateAccountParams) (db.Account, error)
+
+	GetOrderByAlpacaID(ctx context.Context, alpacaOrderID string) (db.Order, error)
+	CreateOrder(ctx context.Context, arg db.CreateOrderParams) (db.Order, error)
+	UpdateOrder(ctx context.Context, arg db.UpdateOrderParams) (db.Order, error)
+
+	ListPositions(ctx context.Context, accountID string) ([]db.Position, error)
+	CreatePosition(ctx context.Context, arg db.CreatePositionParams) (db.Position, error)
+	UpdatePosition(ctx context.Context, arg db.UpdatePositionParams) (db.Position, error)
+	DeletePosition(ctx context.Context, arg db.DeletePositionParams) error
+
+	ListExecutionsByOrder(ctx context.Context, orderID string) ([]db.Execution, error)
+	CreateExecution(ctx context.Context, arg db.CreateExecutionParams) (db.Execution, error)
+	taxlot.Store
+
+	CreateReconcileDrift(ctx context.Context, arg db.CreateReconcileDriftParams) (db.ReconcileDrift, error)
+}
+
+type Entity string
+
+const (
+	EntityAccount  Entity = "account"
+	EntityOrder    Entity = "order"
+	EntityPosition Entity = "position"
+)
+
+// Drift is a single field where the local database disagreed with the broker.
+// A missing or extra row is reported with Field "*".
+type Drift struct {
+	AccountID   string
+	Entity      Entity
+	EntityID    string
+	Field       string
+	LocalValue  string
+	BrokerValue string
+}
+
+func (d Drift) String() string {
+	return fmt.Sprintf("%s %s %s: %s -> %s", d.Entity, d.EntityID, d.Field, d.LocalValue, d.BrokerValue)
+}
+
+type Report struct {
+	StartedAt time.Time
+	DryRun    bool
+	Accounts  int
+	Orders    int
+	Positions int
+	Drifts    []Drift
+}
+
+// Engine pulls accounts, orders and positions from the broker and writes them
+// into the database, recording every drift it corrects.
+type Engine struct {
+	brokerClient broker.Client
+	store        Store
+
+	Interval     time.Duration
+	RecentWindow time.Duration
+	// LotMethod is how backfilled executions are matched to tax lots
+	LotMethod taxlot.Method
+}
+
+func NewEngine(brokerClient broker.Client, store Store) *Engine {
+	return &Engine{
+		brokerClient: brokerClient,
+		store:        store,
+		Interval:     DefaultInterval,
+		RecentWindow: DefaultRecentWindow,
+		LotMethod:    taxlot.DefaultMethod,
+	}
+}
+
+// Run reconciles immediately and then every Interval until ctx is done.
+// Each result is passed to handle, which may be nil.
+func (e *Engine) Run(ctx context.Context, handle func(*Report, error)) {
+	ticker := time.NewTicker(e.Interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := e.Reconcile(ctx, false)
+		if handle != nil && ctx.Err() == nil {
+			handle(report, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile brings the database in line with the broker once. With dryRun set
+// it only reports the drifts and writes nothing.
+func (e *Engine) Reconcile(ctx context.Context, dryRun bool) (*Report, error) {
+	r := &run{
+		engine: e,
+		dryRun: dryRun,
+		report: &Report{StartedAt: time.Now(), DryRun: dryRun},
+	}
+
+	accounts, err := e.brokerClient.ListAccounts(ctx)
+	if err != nil {
+		return r.report, err
+	}
+
+	for _, acc := range accounts {
+		if err := r.account(ctx, acc); err != nil {
+			return r.report, fmt.Errorf("account %s: %w", acc.ID, err)
+		}
+		if err := r.orders(ctx, acc.ID); err != nil {
+			return r.report, fmt.Errorf("orders for account %s: %w", acc.ID, err)
+		}
+		if err := r.positions(ctx, acc.ID); err != nil {
+			return r.report, fmt.Errorf("positions for account %s: %w", acc.ID, err)
+		}
+	}
+
+	return r.report, nil
+}
+
+// run holds the state of a single reconciliation pass
+type run struct {
+	engine *Engine
+	dryRun bool
+	report *Report
+}
+
+func (r *run) drift(ctx context.Context, d Drift) error {
+	r.report.Drifts = append(r.report.Drifts, d)
+	if r.dryRun {
+		return nil
+	}
+
+	_, err := r.engine.store.CreateReconcileDrift(ctx, db.CreateReconcileDriftParams{
+		AccountID:   d.AccountID,
+		Entity:      string(d.Entity),
+		EntityID:    d.EntityID,
+		Field:       d.Field,
+		LocalValue:  d.LocalValue,
+		BrokerValue: d.BrokerValue,
+	})
+	return err
+}
+
+func (r *run) account(ctx context.Context, acc *account.Account) error {
+	r.report.Accounts++
+	store := r.engine.store
+
+	local, err := store.GetAccount(ctx, acc.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := r.drift(ctx, missing(acc.ID, EntityAccount, acc.ID)); err != nil {
+			return err
+		}
+		if r.dryRun {
+			return nil
+		}
+		_, err = store.CreateAccount(ctx, db.NewCreateAccountParams(acc))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	diff := differ{accountID: acc.ID, entity: EntityAccount, entityID: acc.ID}
+	diff.text("status", local.Status, acc.Status)
+	diff.decimal("cash", local.Cash, acc.Cash)
+	diff.decimal("buying_power", local.BuyingPower, acc.BuyingPower)
+
+	for _, d := range diff.drifts {
+		if err := r.drift(ctx, d); err != nil {
+			return err
+		}
+	}
+	if r.dryRun {
+		return nil
+	}
+
+	// Portfolio value moves with the market, so it is refreshed every pass
+	// without being recorded as drift.
+	_, err = store.UpdateAccount(ctx, db.NewUpdateAccountParams(acc))
+	return err
+}
+
+func (r *run) orders(ctx context.Context, accountID string) error {
+	open, err := r.listOrders(ctx, accountID, order.ListOrdersRequest{
+		Status: order.QueryStatusOpen,
+	})
+	if err != nil {
+		return err
+	}
+
+	closed, err := r.listOrders(ctx, accountID, order.ListOrdersRequest{
+		Status: order.QueryStatusClosed,
+		After:  time.Now().Add(-r.engine.RecentWindow),
+	})
+	if err != nil {
+		return err
+	}
+
+	// symbols whose executions were backfilled and need their lots rematched
+	filled := map[string]bool{}
+	for _, o := range append(open, closed...) {
+		if err := r.order(ctx, accountID, o); err != nil {
+			return fmt.Errorf("order %s: %w", o.AlpacaOrderID, err)
+		}
+		added, err := r.executions(ctx, o)
+		if err != nil {
+			return fmt.Errorf("executions of order %s: %w", o.AlpacaOrderID, err)
+		}
+		if added {
+			filled[o.Symbol] = true
+		}
+	}
+
+	for symbol := range filled {
+		if err := taxlot.RebuildSymbol(ctx, r.engine.store, accountID, symbol, r.engine.LotMethod); err != nil {
+			return fmt.Errorf("lots for %s: %w", symbol, err)
+		}
+	}
+	return nil
+}
+
+// listOrders lists every order req matches, a page at a time. Pages run
+// newest first, and each one ends where the last one did.
+func (r *run) listOrders(ctx context.Context, accountID string, req order.ListOrdersRequest) ([]*order.Order, error) {
+	req.Limit = orderPageSize
+
+	var orders []*order.Order
+	seen := map[string]bool{}
+	for {
+		page, err := r.engine.brokerClient.ListOrders(ctx, accountID, &req)
+		if err != nil {
+			return nil, err
+		}
+
+		added := 0
+		for _, o := range page {
+			if !seen[o.AlpacaOrderID] {
+				seen[o.AlpacaOrderID] = true
+				orders = append(orders, o)
+				added++
+			}
+		}
+		if len(page) < req.Limit {
+			return orders, nil
+		}
+		if added == 0 {
+			return nil, fmt.Errorf("more than %d orders created within a second of %s", req.Limit, req.Until.Format(time.RFC3339))
+		}
+
+		// until is sent in whole seconds, so the next page starts with the
+		// last second of this one again rather than miss orders from it
+		req.Until = page[len(page)-1].CreatedAt.Truncate(time.Second).Add(time.Second)
+	}
+}
+
+func (r *run) order(ctx context.Context, accountID string, o *order.Order) error {
+	r.report.Orders++
+	store := r.engine.store
+	o.AccountID = accountID
+
+	local, err := store.GetOrderByAlpacaID(ctx, o.AlpacaOrderID)
+	if errors.Is(err, sql.ErrNoRows) {
+		if err := r.drift(ctx, missing(accountID, EntityOrder, o.AlpacaOrderID)); err != nil {
+			return err
+		}
+		if r.dryRun {
+			return nil
+		}
+		if _, err := store.CreateOrder(ctx, db.NewCreateOrderParams(o)); err != nil {
+			return err
+		}
+		// CreateOrder only covers the submitted fields; fills come from UpdateOrder
+		_, err = store.UpdateOrder(ctx, db.NewUpdateOrderParams(o))
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// The broker's ID for the order may differ from the local primary key
+	o.ID = local.ID
+
+	diff := differ{accountID: accountID, entity: EntityOrder, entityID: o.AlpacaOrderID}
+	diff.text("status", local.Status, string(o.Status))
+	diff.decimal("filled_qty", local.FilledQty, o.FilledQty)
+	diff.nullDecimal("filled_avg_price", local.FilledAvgPrice, o.FilledAvgPrice)
+	diff.time("filled_at", local.FilledAt, o.FilledAt)
+	diff.time("canceled_at", local.CanceledAt, o.CanceledAt)
+
+	if len(diff.drifts) == 0 {
+		return nil
+	}
+	for _, d := range diff.drifts {
+		if err := r.drift(ctx, d); err != nil {
+			return err
+		}
+	}
+	if r.dryRun {
+		return nil
+	}
+
+	_, err = store.UpdateOrder(ctx, db.NewUpdateOrderParams(o))
+	return err
+}
+
+// executions records the part of an order's fills that no execution covers,
+// such as fills made while no stream was running, as one execution at the
+// price the change in the order's average price implies. It reports whether
+// one was added.
+func (r *run) executions(ctx context.Context, o *order.Order) (bool, error) {
+	if r.dryRun || !o.FilledQty.IsPositive() || o.FilledAvgPrice == nil {
+		return false, nil
+	}
+	store := r.engine.store
+
+	local, err := store.GetOrderByAlpacaID(ctx, o.AlpacaOrderID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := store.ListExecutionsByOrder(ctx, local.ID)
+	if err != nil {
+		return false, err
+	}
+
+	coveredQty := decimal.Zero
+	coveredNotional := decimal.Zero
+	for _, row := range rows {
+		coveredQty = coveredQty.Add(row.Qty)
+		coveredNotional = coveredNotional.Add(row.Qty.Mul(row.Price))
+	}
+
+	qty := o.FilledQty.Round(executionQtyScale).Sub(coveredQty)
+	if !qty.IsPositive() {
+		return false, nil
+	}
+	notional := o.FilledQty.Mul(*o.FilledAvgPrice).Sub(coveredNotional)
+
+	executedAt := o.UpdatedAt
+	if o.FilledAt != nil {
+		executedAt = *o.FilledAt
+	}
+
+	exec := &order.Execution{
+		// One ID per fill level, so a later pass finding the same gap is a no-op
+		ID:         fmt.Sprintf("%s:%s:%s", executionEventID, o.AlpacaOrderID, o.FilledQty.String()),
+		OrderID:    local.ID,
+		AccountID:  local.AccountID,
+		Symbol:     o.Symbol,
+		Side:       o.Side,
+		Qty:        qty,
+		Price:      notional.Div(qty),
+		ExecutedAt: executedAt,
+	}
+	if _, err := store.CreateExecution(ctx, db.NewCreateExecutionParams(exec, executionEventID)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *run) positions(ctx context.Context, accountID string) error {
+	store := r.engine.store
+
+	remote, err := r.engine.brokerClient.ListPositions(ctx, accountID)
+	if err != nil {
+		return err
+	}
+
+	rows, err := store.ListPositions(ctx, accountID)
+	if err != nil {
+		return err
+	}
+	local := make(map[string]db.Position, len(rows))
+	for _, row := range rows {
+		local[row.Symbol] = row
+	}
+
+	for _, p := range remote {
+		r.report.Positions++
+		p.AccountID = accountID
+
+		row, ok := local[p.Symbol]
+		delete(local, p.Symbol)
+
+		if !ok {
+			if err := r.drift(ctx, missing(accountID, EntityPosition, p.Symbol)); err != nil {
+				return err
+			}
+			if r.dryRun {
+				continue
+			}
+			if _, err := store.CreatePosition(ctx, db.NewCreatePositionParams(p)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := r.position(ctx, row, p); err != nil {
+			return err
+		}
+	}
+
+	// Whatever is left locally has been closed at the broker
+	for symbol, row := range local {
+		if err := r.drift(ctx, Drift{
+			AccountID:   accountID,
+			Entity:      EntityPosition,
+			EntityID:    symbol,
+			Field:       "*",
+			LocalValue:  row.Qty.String(),
+			BrokerValue: "closed",
+		}); err != nil {
+			return err
+		}
+		if r.dryRun {
+			continue
+		}
+		if err := store.DeletePosition(ctx, db.DeletePositionParams{
+			AccountID: accountID,
+			Symbol:    symbol,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *run) position(ctx context.Context, local db.Position, p *position.Position) error {
+	params := db.NewUpdatePositionParams(p)
+
+	diff := differ{accountID: p.AccountID, entity: EntityPosition, entityID: p.Symbol}
+	diff.decimal("qty", local.Qty, params.Qty)
+	diff.decimal("avg_entry_price", local.AvgEntryPrice, params.AvgEntryPrice)
+	diff.decimal("cost_basis", local.CostBasis, params.CostBasis)
+
+	for _, d := range diff.drifts {
+		if err := r.drift(ctx, d); err != nil {
+			return err
+		}
+	}
+	if r.dryRun {
+		return nil
+	}
+
+	// Prices and P/L are marked to market every pass without counting as drift
+	_, err := r.engine.store.UpdatePosition(ctx, params)
+	return err
+}
+
+func missing(accountID string, entity Entity, entityID string) Drift {
+	return Drift{
+		AccountID:   accountID,
+		Entity:      entity,
+		EntityID:    entityID,
+		Field:       "*",
+		LocalValue:  "missing",
+		BrokerValue: "present",
+	}
+}
+
+// columnScale is the number of decimal places the money, price and quantity
+// columns store.
+const columnScale = 10
+
+// executionQtyScale is the number of decimal places executions.qty stores.
+const executionQtyScale = 8
+
+// differ collects drifts for one entity. Broker values are rounded to the
+// scale of the database columns before comparing, so precision the schema
+// cannot store is not reported as drift on every pass.
+type differ struct {
+	accountID string
+	entity    Entity
+	entityID  string
+	drifts    []Drift
+}
+
+func (d *differ) add(field, local, remote string) {
+	d.drifts = append(d.drifts, Drift{
+		AccountID:   d.accountID,
+		Entity:      d.entity,
+		EntityID:    d.entityID,
+		Field:       field,
+		LocalValue:  local,
+		BrokerValue: remote,
+	})
+}
+
+func (d *differ) text(field, local, remote string) {
+	if local != remote {
+		d.add(field, local, remote)
+	}
+}
+
+func (d *differ) decimal(field string, local, remote decimal.Decimal) {
+	remote = remote.Round(columnScale)
+	if !local.Equal(remote) {
+		d.add(field, local.String(), remote.String())
+	}
+}
+
+func (d *differ) nullDecimal(field string, local decimal.NullDecimal, remote *decimal.Decimal) {
+	switch {
+	case !local.Valid && remote == nil:
+	case !local.Valid:
+		d.add(field, "null", remote.Round(columnScale).String())
+	case remote == nil:
+		d.add(field, local.Decimal.String(), "null")
+	default:
+		d.decimal(field, local.Decimal, *remote)
+	}
+}
+
+func (d *differ) time(field string, local sql.NullTime, remote *time.Time) {
+	switch {
+	case !local.Valid && remote == nil:
+	case !local.Valid:
+		d.add(field, "null", remote.UTC().Format(time.RFC3339))
+	case remote == nil:
+		d.add(field, local.Time.UTC().Format(time.RFC3339), "null")
+	default:
+		// Postgres keeps microseconds; compare at that precision
+		l := local.Time.Truncate(time.Microsecond)
+		r := remote.Truncate(time.Microsecond)
+		if !l.Equal(r) {
+			d.add(field, l.UTC().Format(time.RFC3339Nano), r.UTC().Format(time.RFC3339Nano))
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // schedules are read in their own zone wherever pony runs
+)
+
+// Cron is a parsed five-field cron expression: minute, hour, day of month,
+// month and day of week. Fields take *, numbers, names (jan, mon), ranges
+// (1-5), steps (*/15, 9-17/2) and comma-separated lists of these. As in
+// cron, when both day fields are restricted a day matching either is due.
+//
+// The shortcuts @hourly, @daily (or @midnight), @weekly, @monthly and
+// @yearly (or @annually) are accepted too.
+type Cron struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a day field left as *
+	domAny, dowAny bool
+	loc            *time.Location
+}
+
+var shortcuts = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    []string // names[i] stands for min+i
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12,
+		names: []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	// 7 is Sunday as well as 0
+	{name: "day of week", min: 0, max: 7,
+		names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Parse parses a cron expression to be read in the IANA time zone tz, or in
+// DefaultTimeZone if tz is empty
+func Parse(spec, tz string) (*Cron, error) {
+	if tz == "" {
+		tz = DefaultTimeZone
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("unknown time zone %q", tz)
+	}
+
+	expr := strings.TrimSpace(spec)
+	if s, ok := shortcuts[strings.ToLower(expr)]; ok {
+		expr = s
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("schedule %q must have 5 fields: minute hour day-of-month month day-of-week", spec)
+	}
+
+	c := &Cron{loc: loc, domAny: parts[2] == "*", dowAny: parts[4] == "*"}
+	for i, dest := range []*uint64{&c.minute, &c.hour, &c.dom, &c.month, &c.dow} {
+		if *dest, err = fields[i].parse(parts[i]); err != nil {
+			return nil, fmt.Errorf("schedule %q: %w", spec, err)
+		}
+	}
+	if c.dow&(1<<7) != 0 {
+		c.dow |= 1
+	}
+	return c, nil
+}
+
+// parse turns a field into a bit set of the values it matches
+func (f field) parse(s string) (uint64, error) {
+	var bits uint64
+	for item := range strings.SplitSeq(s, ",") {
+		rng, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepText)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepText, f.name)
+			}
+			step = n
+		}
+
+		lo, hi := f.min, f.max
+		if rng != "*" {
+			loText, hiText, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = f.value(loText); err != nil {
+				return 0, err
+			}
+			hi = lo
+			if isRange {
+				if hi, err = f.value(hiText); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// 5/15 means from 5 on, every 15
+				hi = f.max
+			}
+			if hi < lo {
+				return 0, fmt.Errorf("range %q in %s runs backwards", rng, f.name)
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %q, expected %d to %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Location is the time zone the expression is read in
+func (c *Cron) Location() *time.Location {
+	return c.loc
+}
+
+// maxSearch bounds how far ahead Next looks, for expressions such as
+// 0 0 30 2 * that never come due
+const maxSearch = 5
+
+// Next returns the first time after t the expression matches, or the zero
+// time if it does not within the next five years. A time a daylight saving
+// change skips does not come due that day, and one it repeats comes due
+// once.
+func (c *Cron) Next(t time.Time) time.Time {
+	t = t.In(c.loc).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(maxSearch, 0, 0)
+
+	for t.Before(limit) {
+		y, mo, d := t.Date()
+		h, mi := t.Hour(), t.Minute()
+		switch {
+		case c.month&(1<<uint(mo)) == 0:
+			t = time.Date(y, mo+1, 1, 0, 0, 0, 0, c.loc)
+		case !c.dayMatches(t):
+			t = time.Date(y, mo, d+1, 0, 0, 0, 0, c.loc)
+		case c.hour&(1<<uint(h)) == 0:
+			t = next(t, time.Date(y, mo, d, h+1, 0, 0, 0, c.loc))
+		case c.minute&(1<<uint(mi)) == 0, repeated(t):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// next is to, unless a daylight saving change put it at or before from, in
+// which case it is the minute after from
+func next(from, to time.Time) time.Time {
+	if to.After(from) {
+		return to
+	}
+	return from.Add(time.Minute)
+}
+
+// repeated reports whether the clock read the same an hour before t, as it
+// does for an hour after clocks go back
+func repeated(t time.Time) bool {
+	before := t.Add(-time.Hour)
+	return before.Hour() == t.Hour() && before.Minute() == t.Minute()
+}
+
+func (c *Cron) dayMatches(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
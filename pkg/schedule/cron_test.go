@@ -0,0 +1,56 @@
+package schedule_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/revrost/pony/pkg/schedule"
+)
+
+const newYork = "America/New_York"
+
+func TestCronNext(t *testing.T) {
+	loc, err := time.LoadLocation(newYork)
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(month time.Month, day, hour, min int) time.Time {
+		return time.Date(2026, month, day, hour, min, 0, 0, loc)
+	}
+
+	tests := []struct {
+		name  string
+		spec  string
+		after time.Time
+		want  time.Time
+	}{
+		{"later the same day", "30 9 * * *", at(time.March, 2, 8, 0), at(time.March, 2, 9, 30)},
+		{"strictly after", "30 9 * * *", at(time.March, 2, 9, 30), at(time.March, 3, 9, 30)},
+		{"weekdays skip the weekend", "0 10 * * mon-fri", at(time.March, 6, 11, 0), at(time.March, 9, 10, 0)},
+		{"either day field", "0 0 13 * fri", at(time.March, 1, 0, 0), at(time.March, 6, 0, 0)},
+		{"shortcut", "@monthly", at(time.March, 2, 0, 0), at(time.April, 1, 0, 0)},
+		{"never", "0 0 30 2 *", at(time.March, 2, 0, 0), time.Time{}},
+
+		// Clocks go forward at 2:00 on March 8, so 2:30 does not happen
+		// that day
+		{"skipped time waits a day", "30 2 * * *", at(time.March, 7, 12, 0), at(time.March, 9, 2, 30)},
+		{"hourly across the gap", "0 * * * *", at(time.March, 8, 1, 30), time.Date(2026, time.March, 8, 7, 0, 0, 0, time.UTC)},
+
+		// Clocks go back at 2:00 on November 1, so 1:00 to 1:59 happens
+		// twice and comes due the first time only
+		{"repeated time comes due once", "30 1 * * *", at(time.October, 31, 12, 0), time.Date(2026, time.November, 1, 5, 30, 0, 0, time.UTC)},
+		{"repeated time not again", "30 1 * * *", time.Date(2026, time.November, 1, 5, 30, 0, 0, time.UTC), at(time.November, 2, 1, 30)},
+		{"half hourly skips the repeat", "*/30 * * * *", time.Date(2026, time.November, 1, 5, 30, 0, 0, time.UTC), time.Date(2026, time.November, 1, 7, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := schedule.Parse(tt.spec, newYork)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := c.Next(tt.after); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.after, got.In(loc), tt.want.In(loc))
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+// Package schedule describes orders placed again and again on a cron
+// schedule, such as buying $100 of VTI every Monday at 10:00 New York time,
+// and the runs that placed them.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/revrost/pony/pkg/order"
+)
+
+// DefaultTimeZone is the zone schedules are read in unless they name one,
+// so they follow the exchange's clock
+const DefaultTimeZone = "America/New_York"
+
+// Result is what became of a run
+type Result string
+
+const (
+	ResultPlaced  Result = "placed"
+	ResultSkipped Result = "skipped" // the market was closed, or the run was missed
+	ResultFailed  Result = "failed"
+	ResultPending Result = "pending" // sent without an answer; settled from the outbox
+)
+
+// ScheduledOrder places Request every time Schedule comes due
+type ScheduledOrder struct {
+	ID int64
+	// Schedule is a five-field cron expression read in TimeZone
+	Schedule string
+	TimeZone string
+	// Request is the order each run places. Its ClientOrderID is unset;
+	// each run gets its own.
+	Request order.CreateOrderRequest
+	Enabled bool
+	// NextRunAt is nil while the schedule is disabled
+	NextRunAt  *time.Time
+	LastRunAt  *time.Time
+	LastResult Result
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// New returns an enabled schedule placing req whenever spec comes due, read
+// in the time zone tz (default DefaultTimeZone), with its first run after
+// now
+func New(req order.CreateOrderRequest, spec, tz string, now time.Time) (*ScheduledOrder, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if tz == "" {
+		tz = DefaultTimeZone
+	}
+	req.ClientOrderID = ""
+
+	s := &ScheduledOrder{Schedule: strings.TrimSpace(spec), TimeZone: tz, Request: req, Enabled: true}
+	next, err := s.Next(now)
+	if err != nil {
+		return nil, err
+	}
+	s.NextRunAt = &next
+	return s, nil
+}
+
+// Next returns the first time after t the schedule comes due
+func (s *ScheduledOrder) Next(t time.Time) (time.Time, error) {
+	c, err := Parse(s.Schedule, s.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := c.Next(t)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("schedule %q never comes due", s.Schedule)
+	}
+	return next, nil
+}
+
+// Run is one time a schedule came due
+type Run struct {
+	ID           int64
+	ScheduleID   int64
+	ScheduledFor time.Time
+	Result       Result
+	// ClientOrderID is set if an order was sent, and OrderID once the
+	// broker took it. OrderStatus is the order's latest known status.
+	ClientOrderID string
+	OrderID       string
+	OrderStatus   order.OrderStatus
+	// Message says why the run was skipped or failed
+	Message   string
+	CreatedAt time.Time
+}
+
+// ClientOrderID is the client order ID of the order placed for a run, the
+// same every time so a run is never placed twice
+func ClientOrderID(scheduleID int64, scheduledFor time.Time) string {
+	return fmt.Sprintf("schedule-%d-%d", scheduleID, scheduledFor.Unix())
+}
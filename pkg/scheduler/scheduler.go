@@ -0,0 +1,286 @@
+// Package scheduler places the orders of schedules as they come due.
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/outbox"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/snapshot"
+)
+
+// DefaultInterval is how often Run looks for schedules that came due
+const DefaultInterval = 30 * time.Second
+
+// DefaultGrace is how late a run may be placed. A run found later than
+// this, such as one due while pony was not running, is skipped as missed.
+const DefaultGrace = 15 * time.Minute
+
+// Store is the subset of the sqlc generated Querier the Scheduler needs.
+// *db.Queries implements it.
+type Store interface {
+	ListDueScheduledOrders(ctx context.Context, now time.Time) ([]db.ScheduledOrder, error)
+	ClaimScheduledOrderRun(ctx context.Context, arg db.ClaimScheduledOrderRunParams) (int64, error)
+	CreateScheduledOrderRun(ctx context.Context, arg db.CreateScheduledOrderRunParams) (db.ScheduledOrderRun, error)
+	SetScheduledOrderResult(ctx context.Context, arg db.SetScheduledOrderResultParams) error
+	ListPendingScheduledOrderRuns(ctx context.Context) ([]db.ListPendingScheduledOrderRunsRow, error)
+	SettleScheduledOrderRun(ctx context.Context, arg db.SettleScheduledOrderRunParams) (int64, error)
+	SettleScheduledOrderResult(ctx context.Context, arg db.SettleScheduledOrderResultParams) error
+}
+
+// Scheduler places each due run of the stored schedules once, through the
+// broker client, on days the market is open
+type Scheduler struct {
+	brokerClient broker.Client
+	store        Store
+
+	Interval time.Duration
+	Grace    time.Duration
+	// ResolveIntents, if set, asks the broker about the order intents it
+	// never answered for, before pending runs are settled from them
+	ResolveIntents func(ctx context.Context) error
+}
+
+func NewScheduler(brokerClient broker.Client, store Store) *Scheduler {
+	return &Scheduler{
+		brokerClient: brokerClient,
+		store:        store,
+		Interval:     DefaultInterval,
+		Grace:        DefaultGrace,
+	}
+}
+
+// Run places due runs immediately and then every Interval until ctx is
+// done. The runs of each pass are passed to handle, which may be nil; passes
+// with no runs and no error are not.
+func (s *Scheduler) Run(ctx context.Context, handle func([]*schedule.Run, error)) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		runs, err := s.RunDue(ctx, time.Now())
+		if handle != nil && ctx.Err() == nil && (len(runs) > 0 || err != nil) {
+			handle(runs, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunDue settles the pending runs the outbox has an answer for, then places
+// the run of every enabled schedule due at or before now and moves each
+// schedule on to its next run after now. Runs another process claimed first
+// are left to it. A run is skipped if the market is closed on the day it was
+// due, or if it is more than Grace late; several runs missed in a row are
+// recorded as the one.
+func (s *Scheduler) RunDue(ctx context.Context, now time.Time) ([]*schedule.Run, error) {
+	runs, err := s.settle(ctx)
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	rows, err := s.store.ListDueScheduledOrders(ctx, now.UTC())
+	if err != nil {
+		errs = append(errs, fmt.Errorf("failed to list due schedules: %w", err))
+		return runs, errors.Join(errs...)
+	}
+
+	for _, row := range rows {
+		run, err := s.runOnce(ctx, db.ToScheduledOrder(row), now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", row.ID, err))
+		}
+		if run != nil {
+			runs = append(runs, run)
+		}
+	}
+	return runs, errors.Join(errs...)
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, so *schedule.ScheduledOrder, now time.Time) (*schedule.Run, error) {
+	scheduledFor := *so.NextRunAt
+	next, err := so.Next(now)
+	if err != nil {
+		return nil, err
+	}
+
+	claimed, err := s.store.ClaimScheduledOrderRun(ctx, db.ClaimScheduledOrderRunParams{
+		ID:           so.ID,
+		ScheduledFor: scheduledFor,
+		NextRunAt:    sql.NullTime{Time: next.UTC(), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim run: %w", err)
+	}
+	if claimed == 0 {
+		return nil, nil
+	}
+
+	run := &schedule.Run{ScheduleID: so.ID, ScheduledFor: scheduledFor}
+	if late := now.Sub(scheduledFor); late > s.Grace {
+		run.Result = schedule.ResultSkipped
+		run.Message = fmt.Sprintf("missed by %s", late.Round(time.Minute))
+	} else if open, err := s.marketOpen(ctx, scheduledFor); err != nil {
+		run.Result = schedule.ResultFailed
+		run.Message = fmt.Sprintf("failed to check the market calendar: %v", err)
+	} else if !open {
+		run.Result = schedule.ResultSkipped
+		run.Message = "market closed on " + snapshot.TradingDate(scheduledFor).Format("2006-01-02")
+	} else {
+		s.place(ctx, so, run)
+	}
+
+	return run, s.record(ctx, run)
+}
+
+// marketOpen reports whether t falls on a trading day. Day orders placed
+// outside trading hours wait for the next session.
+func (s *Scheduler) marketOpen(ctx context.Context, t time.Time) (bool, error) {
+	day := snapshot.TradingDate(t)
+	days, err := s.brokerClient.ListTradingDays(ctx, day, day)
+	if err != nil {
+		return false, err
+	}
+	return len(days) > 0 && days[0].Equal(day), nil
+}
+
+// place sends the run's order under a client order ID derived from the run,
+// so a retry cannot place it twice
+func (s *Scheduler) place(ctx context.Context, so *schedule.ScheduledOrder, run *schedule.Run) {
+	req := so.Request
+	req.ClientOrderID = schedule.ClientOrderID(so.ID, run.ScheduledFor)
+	run.ClientOrderID = req.ClientOrderID
+
+	o, err := s.brokerClient.CreateOrder(ctx, &req)
+	if o == nil {
+		// Only a rejection says the order was not placed. Anything else,
+		// such as a timeout, may have placed it, and settle finds out which
+		// once the outbox has asked the broker.
+		run.Result = schedule.ResultPending
+		if errors.Is(err, broker.ErrRejected) {
+			run.Result = schedule.ResultFailed
+		}
+		run.Message = err.Error()
+		return
+	}
+	run.Result = schedule.ResultPlaced
+	run.OrderID = o.ID
+	run.OrderStatus = o.Status
+	if err != nil {
+		// Placed, though not everything about it was recorded
+		run.Message = err.Error()
+	}
+}
+
+func (s *Scheduler) record(ctx context.Context, run *schedule.Run) error {
+	row, err := s.store.CreateScheduledOrderRun(ctx, db.NewCreateScheduledOrderRunParams(run))
+	if err != nil {
+		return fmt.Errorf("failed to record run: %w", err)
+	}
+	run.ID = row.ID
+	run.CreatedAt = row.CreatedAt
+
+	if err := s.store.SetScheduledOrderResult(ctx, db.SetScheduledOrderResultParams{
+		ID:         run.ScheduleID,
+		LastResult: sql.NullString{String: string(run.Result), Valid: true},
+	}); err != nil {
+		return fmt.Errorf("failed to record result: %w", err)
+	}
+	return nil
+}
+
+// settle records what became of the pending runs whose order intent has
+// since been sent or failed. Runs whose intent is still pending are left for
+// a later pass.
+func (s *Scheduler) settle(ctx context.Context) ([]*schedule.Run, error) {
+	rows, err := s.store.ListPendingScheduledOrderRuns(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending runs: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	if s.ResolveIntents != nil {
+		if err := s.ResolveIntents(ctx); err != nil {
+			return nil, fmt.Errorf("failed to resolve pending orders: %w", err)
+		}
+		if rows, err = s.store.ListPendingScheduledOrderRuns(ctx); err != nil {
+			return nil, fmt.Errorf("failed to list pending runs: %w", err)
+		}
+	}
+
+	var runs []*schedule.Run
+	var errs []error
+	for _, row := range rows {
+		run := &schedule.Run{
+			ID:            row.ID,
+			ScheduleID:    row.ScheduledOrderID,
+			ScheduledFor:  row.ScheduledFor,
+			ClientOrderID: row.ClientOrderID.String,
+		}
+		switch {
+		case !row.IntentStatus.Valid:
+			// The intent is recorded before the order is sent, so without
+			// one nothing was sent
+			run.Result = schedule.ResultFailed
+			run.Message = "order intent was never recorded"
+		case row.IntentStatus.String == string(outbox.StatusSent):
+			run.Result = schedule.ResultPlaced
+			run.OrderID = row.OrderID.String
+			run.OrderStatus = order.OrderStatus(row.OrderStatus.String)
+		case row.IntentStatus.String == string(outbox.StatusFailed):
+			run.Result = schedule.ResultFailed
+			run.Message = row.IntentError.String
+		default:
+			continue
+		}
+
+		settled, err := s.settleRun(ctx, run)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("schedule %d: %w", run.ScheduleID, err))
+		}
+		if settled {
+			runs = append(runs, run)
+		}
+	}
+	return runs, errors.Join(errs...)
+}
+
+// settleRun records the result of a pending run, and reports false if
+// another process settled it first
+func (s *Scheduler) settleRun(ctx context.Context, run *schedule.Run) (bool, error) {
+	n, err := s.store.SettleScheduledOrderRun(ctx, db.SettleScheduledOrderRunParams{
+		ID:      run.ID,
+		Result:  string(run.Result),
+		OrderID: sql.NullString{String: run.OrderID, Valid: run.OrderID != ""},
+		Message: sql.NullString{String: run.Message, Valid: run.Message != ""},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to settle run: %w", err)
+	}
+	if n == 0 {
+		return false, nil
+	}
+
+	if err := s.store.SettleScheduledOrderResult(ctx, db.SettleScheduledOrderResultParams{
+		ID:           run.ScheduleID,
+		ScheduledFor: run.ScheduledFor,
+		LastResult:   sql.NullString{String: string(run.Result), Valid: true},
+	}); err != nil {
+		return true, fmt.Errorf("failed to record result: %w", err)
+	}
+	return true, nil
+}
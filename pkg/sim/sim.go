@@ -0,0 +1,759 @@
+// Package sim is a broker that keeps its account in memory and fills
+// orders against the market prices it is given, for running strategies
+// without trading.
+package sim
+
+import (
+	"cmp"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/watchlist"
+)
+
+// AccountID is the ID of the simulated account
+const AccountID = "sim"
+
+// DefaultCash is what the simulated account starts with
+var DefaultCash = decimal.NewFromInt(100_000)
+
+// fractionalDecimals is how finely notional orders are sized, as at the
+// broker
+const fractionalDecimals = 9
+
+// ErrNotSimulated is returned for the parts of the broker API the
+// simulation has no use for, such as watchlists
+var ErrNotSimulated = errors.New("not available in the simulated broker")
+
+// Broker simulates an account at a broker. Orders are filled in full as
+// soon as the price allows: market orders at the latest price, limit orders
+// once the price reaches the limit, and stop orders at the price that
+// triggered them. Every order and fill is sent to StreamEvents as a trade
+// update, like the broker's own. Buying power is not checked, so cash can
+// go negative.
+type Broker struct {
+	// FillModel is the slippage and commissions fills pay
+	FillModel FillModel
+
+	// NextBar holds new and replaced orders until the next SetBar, rather
+	// than filling them at the latest price. Backtests set it, as the
+	// latest price there is the close of a bar that is already over.
+	NextBar bool
+
+	mu        sync.Mutex
+	cash      decimal.Decimal
+	now       time.Time
+	prices    map[string]decimal.Decimal
+	orders    []*order.Order
+	positions map[string]*position.Position
+	fills     []Fill
+	streams   []*EventQueue
+}
+
+// FillModel is what filling an order costs beyond its price
+type FillModel struct {
+	// SlippageBps moves market and stop fills against the order, in basis
+	// points of the price. Limit fills are never worse than the limit.
+	SlippageBps decimal.Decimal
+
+	// Commissions are charged per share and per fill, and taken from cash
+	CommissionPerShare decimal.Decimal
+	CommissionPerOrder decimal.Decimal
+}
+
+// Fill is one order filled by the simulation
+type Fill struct {
+	OrderID       string
+	ClientOrderID string
+	Symbol        string
+	Side          order.OrderSide
+	Qty           decimal.Decimal
+	// Price includes slippage
+	Price      decimal.Decimal
+	Commission decimal.Decimal
+	Time       time.Time
+}
+
+var _ broker.Client = (*Broker)(nil)
+
+func NewBroker(cash decimal.Decimal) *Broker {
+	return &Broker{
+		cash:      cash,
+		prices:    map[string]decimal.Decimal{},
+		positions: map[string]*position.Position{},
+	}
+}
+
+// SetPrice moves symbol's price at time at and fills the orders it reaches
+func (b *Broker) SetPrice(symbol string, price decimal.Decimal, at time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if at.After(b.now) {
+		b.now = at
+	}
+	b.prices[symbol] = price
+	for _, o := range b.orders {
+		if o.IsOpen() && o.Symbol == symbol {
+			b.match(o)
+		}
+	}
+}
+
+// SetBar fills the open orders in the bar's symbol as the bar's prices
+// allow, then moves the price to its close. Market orders fill at the open.
+// Limit and stop orders fill at the open if it is already past their
+// price, or at their price if the bar's range reaches it.
+func (b *Broker) SetBar(bar marketdata.Bar) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if bar.Time.After(b.now) {
+		b.now = bar.Time
+	}
+	for _, o := range b.orders {
+		if o.IsOpen() && o.Symbol == bar.Symbol {
+			if price, marketable, ok := barFillPrice(o, bar); ok {
+				b.fill(o, price, marketable)
+			}
+		}
+	}
+	b.prices[bar.Symbol] = bar.Close
+}
+
+// Fills returns every fill so far, oldest first
+func (b *Broker) Fills() []Fill {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return slices.Clone(b.fills)
+}
+
+// Feed passes feed's bars and quotes on after setting the prices they
+// carry, so orders are filled against live market data
+func (b *Broker) Feed(feed marketdata.Feed) marketdata.Feed {
+	return pricedFeed{broker: b, feed: feed}
+}
+
+type pricedFeed struct {
+	broker *Broker
+	feed   marketdata.Feed
+}
+
+func (f pricedFeed) Stream(ctx context.Context, symbols []string, onBar func(marketdata.Bar), onQuote func(marketdata.Quote)) error {
+	return f.feed.Stream(ctx, symbols, func(bar marketdata.Bar) {
+		f.broker.SetPrice(bar.Symbol, bar.Close, bar.Time)
+		onBar(bar)
+	}, func(quote marketdata.Quote) {
+		if mid := quote.Mid(); mid.IsPositive() {
+			f.broker.SetPrice(quote.Symbol, mid, quote.Time)
+		}
+		onQuote(quote)
+	})
+}
+
+// clock is the time of the latest price, or the wall clock before any
+func (b *Broker) clock() time.Time {
+	if b.now.IsZero() {
+		return time.Now()
+	}
+	return b.now
+}
+
+func (b *Broker) GetAccount(ctx context.Context, accountID string) (*account.Account, error) {
+	if accountID != AccountID {
+		return nil, broker.ErrNotFound
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.account(), nil
+}
+
+func (b *Broker) ListAccounts(ctx context.Context) ([]*account.Account, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return []*account.Account{b.account()}, nil
+}
+
+func (b *Broker) account() *account.Account {
+	positionsValue := decimal.Zero
+	for _, p := range b.positions {
+		positionsValue = positionsValue.Add(b.mark(p).MarketValue)
+	}
+	equity := b.cash.Add(positionsValue)
+
+	return &account.Account{
+		ID:              AccountID,
+		AlpacaAccountID: AccountID,
+		Status:          "ACTIVE",
+		Currency:        "USD",
+		Cash:            b.cash,
+		PortfolioValue:  equity,
+		BuyingPower:     b.cash,
+		Equity:          equity,
+		PositionsValue:  positionsValue,
+	}
+}
+
+func (b *Broker) GetPortfolioHistory(ctx context.Context, accountID, period string) ([]*account.EquityPoint, error) {
+	return nil, ErrNotSimulated
+}
+
+// ListTradingDays treats every weekday as a trading day
+func (b *Broker) ListTradingDays(ctx context.Context, start, end time.Time) ([]time.Time, error) {
+	var days []time.Time
+	for day := start.Truncate(24 * time.Hour); !day.After(end); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() != time.Saturday && day.Weekday() != time.Sunday {
+			days = append(days, day)
+		}
+	}
+	return days, nil
+}
+
+func (b *Broker) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.Order, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid order: %w: %w", err, broker.ErrRejected)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if req.ClientOrderID != "" && slices.ContainsFunc(b.orders, func(o *order.Order) bool {
+		return o.StakeOrderID == req.ClientOrderID
+	}) {
+		return nil, fmt.Errorf("client order ID %s is taken: %w", req.ClientOrderID, broker.ErrRejected)
+	}
+
+	// A notional order is for as many shares as the amount buys now
+	qty := req.Qty
+	if req.Notional != nil {
+		price, ok := b.prices[req.Symbol]
+		if !ok {
+			return nil, fmt.Errorf("no price for %s to size a notional order: %w", req.Symbol, broker.ErrRejected)
+		}
+		shares := req.Notional.Div(price).RoundDown(fractionalDecimals)
+		qty = &shares
+	}
+
+	now := b.clock()
+	id := rand.Text()
+	o := &order.Order{
+		ID:            id,
+		AlpacaOrderID: id,
+		StakeOrderID:  req.ClientOrderID,
+		AccountID:     AccountID,
+		Symbol:        req.Symbol,
+		Side:          req.Side,
+		OrderType:     req.OrderType,
+		Qty:           qty,
+		Notional:      req.Notional,
+		LimitPrice:    req.LimitPrice,
+		StopPrice:     req.StopPrice,
+		TimeInForce:   req.TimeInForce,
+		Status:        order.OrderStatusNew,
+		SubmittedAt:   now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	b.orders = append(b.orders, o)
+	b.publish(o, broker.TradeEventNew, nil)
+
+	if !b.NextBar {
+		b.match(o)
+	}
+	return copyOrder(o), nil
+}
+
+func (b *Broker) GetOrder(ctx context.Context, orderID string) (*order.Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, o := range b.orders {
+		if o.ID == orderID {
+			return copyOrder(o), nil
+		}
+	}
+	return nil, fmt.Errorf("order %s: %w", orderID, broker.ErrNotFound)
+}
+
+func (b *Broker) GetOrderByClientOrderID(ctx context.Context, clientOrderID string) (*order.Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, o := range b.orders {
+		if o.StakeOrderID == clientOrderID {
+			return copyOrder(o), nil
+		}
+	}
+	return nil, fmt.Errorf("order %s: %w", clientOrderID, broker.ErrNotFound)
+}
+
+// ListOrders returns the matching orders, newest first
+func (b *Broker) ListOrders(ctx context.Context, accountID string, req *order.ListOrdersRequest) ([]*order.Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var orders []*order.Order
+	for _, o := range slices.Backward(b.orders) {
+		switch {
+		case req.Status == order.QueryStatusOpen && !o.IsOpen(),
+			req.Status == order.QueryStatusClosed && o.IsOpen(),
+			!req.After.IsZero() && !o.SubmittedAt.After(req.After),
+			!req.Until.IsZero() && o.SubmittedAt.After(req.Until),
+			len(req.Symbols) > 0 && !slices.Contains(req.Symbols, o.Symbol):
+			continue
+		}
+		orders = append(orders, copyOrder(o))
+		if req.Limit > 0 && len(orders) == req.Limit {
+			break
+		}
+	}
+	return orders, nil
+}
+
+func (b *Broker) ReplaceOrder(ctx context.Context, orderID string, req *order.ReplaceOrderRequest) (*order.Order, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	o, err := b.openOrder(orderID)
+	if err != nil {
+		return nil, err
+	}
+	if req.Qty != nil {
+		o.Qty = req.Qty
+	}
+	if req.LimitPrice != nil {
+		o.LimitPrice = req.LimitPrice
+	}
+	if req.StopPrice != nil {
+		o.StopPrice = req.StopPrice
+	}
+	if req.TimeInForce != "" {
+		o.TimeInForce = req.TimeInForce
+	}
+	o.UpdatedAt = b.clock()
+	b.publish(o, broker.TradeEventReplaced, nil)
+
+	if !b.NextBar {
+		b.match(o)
+	}
+	return copyOrder(o), nil
+}
+
+func (b *Broker) CancelOrder(ctx context.Context, orderID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	o, err := b.openOrder(orderID)
+	if err != nil {
+		return err
+	}
+	now := b.clock()
+	o.Status = order.OrderStatusCanceled
+	o.CanceledAt = &now
+	o.UpdatedAt = now
+	b.publish(o, broker.TradeEventCanceled, nil)
+	return nil
+}
+
+func (b *Broker) openOrder(orderID string) (*order.Order, error) {
+	for _, o := range b.orders {
+		if o.ID != orderID {
+			continue
+		}
+		if !o.IsOpen() {
+			return nil, fmt.Errorf("order %s is %s: %w", orderID, o.Status, broker.ErrRejected)
+		}
+		return o, nil
+	}
+	return nil, fmt.Errorf("order %s: %w", orderID, broker.ErrNotFound)
+}
+
+func (b *Broker) ListPositions(ctx context.Context, accountID string) ([]*position.Position, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var positions []*position.Position
+	for _, p := range b.positions {
+		positions = append(positions, b.mark(p))
+	}
+	slices.SortFunc(positions, func(a, b *position.Position) int {
+		return cmp.Compare(a.Symbol, b.Symbol)
+	})
+	return positions, nil
+}
+
+func (b *Broker) ClosePosition(ctx context.Context, accountID, symbol string) (*order.Order, error) {
+	b.mu.Lock()
+	p, ok := b.positions[symbol]
+	var qty decimal.Decimal
+	if ok {
+		qty = p.Qty
+	}
+	b.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("position in %s: %w", symbol, broker.ErrNotFound)
+	}
+
+	side := order.OrderSideSell
+	if qty.IsNegative() {
+		side = order.OrderSideBuy
+	}
+	qty = qty.Abs()
+	return b.CreateOrder(ctx, &order.CreateOrderRequest{
+		AccountID:   accountID,
+		Symbol:      symbol,
+		Side:        side,
+		OrderType:   order.OrderTypeMarket,
+		Qty:         &qty,
+		TimeInForce: order.TimeInForceDay,
+	})
+}
+
+func (b *Broker) ListWatchlists(ctx context.Context, accountID string) ([]*watchlist.Watchlist, error) {
+	return nil, nil
+}
+
+func (b *Broker) GetWatchlist(ctx context.Context, watchlistID string) (*watchlist.Watchlist, error) {
+	return nil, broker.ErrNotFound
+}
+
+func (b *Broker) CreateWatchlist(ctx context.Context, req *watchlist.CreateWatchlistRequest) (*watchlist.Watchlist, error) {
+	return nil, ErrNotSimulated
+}
+
+func (b *Broker) UpdateWatchlist(ctx context.Context, watchlistID string, req *watchlist.UpdateWatchlistRequest) (*watchlist.Watchlist, error) {
+	return nil, ErrNotSimulated
+}
+
+func (b *Broker) AddWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (*watchlist.Watchlist, error) {
+	return nil, ErrNotSimulated
+}
+
+func (b *Broker) RemoveWatchlistSymbol(ctx context.Context, watchlistID, symbol string) error {
+	return ErrNotSimulated
+}
+
+func (b *Broker) DeleteWatchlist(ctx context.Context, watchlistID string) error {
+	return ErrNotSimulated
+}
+
+// StreamEvents sends the trade updates of every order from now on until
+// ctx is done. Updates wait in memory for as long as the caller needs to
+// read them, so placing an order never blocks on the stream.
+func (b *Broker) StreamEvents(ctx context.Context, accountID string) (<-chan broker.Event, <-chan error) {
+	queue := b.Subscribe()
+
+	eventCh := make(chan broker.Event)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(eventCh)
+		defer close(errCh)
+		defer func() {
+			b.mu.Lock()
+			b.streams = slices.DeleteFunc(b.streams, func(q *EventQueue) bool { return q == queue })
+			b.mu.Unlock()
+		}()
+
+		for {
+			event, ok := queue.next(ctx)
+			if !ok {
+				errCh <- ctx.Err()
+				return
+			}
+			select {
+			case eventCh <- event:
+			case <-ctx.Done():
+				errCh <- ctx.Err()
+				return
+			}
+		}
+	}()
+	return eventCh, errCh
+}
+
+// Subscribe queues the trade updates of every order from now on, for a
+// caller that steps the simulation itself and takes them with Drain
+func (b *Broker) Subscribe() *EventQueue {
+	queue := newEventQueue()
+	b.mu.Lock()
+	b.streams = append(b.streams, queue)
+	b.mu.Unlock()
+	return queue
+}
+
+// match fills o if the price allows
+func (b *Broker) match(o *order.Order) {
+	price, ok := b.prices[o.Symbol]
+	if !ok {
+		return
+	}
+	fill, ok := fillPrice(o, price)
+	if !ok {
+		return
+	}
+	b.fill(o, fill, o.OrderType == order.OrderTypeMarket || o.OrderType == order.OrderTypeStop)
+}
+
+// fill fills the rest of o at price, after slippage if the fill took
+// whatever the market offered
+func (b *Broker) fill(o *order.Order, fill decimal.Decimal, marketable bool) {
+	qty := o.Qty.Sub(o.FilledQty)
+	signed := qty
+	if o.Side == order.OrderSideSell {
+		signed = qty.Neg()
+	}
+
+	if marketable && b.FillModel.SlippageBps.IsPositive() {
+		slip := fill.Mul(b.FillModel.SlippageBps).Div(decimal.NewFromInt(10_000))
+		if o.Side == order.OrderSideSell {
+			slip = slip.Neg()
+		}
+		fill = fill.Add(slip)
+	}
+	commission := b.FillModel.CommissionPerShare.Mul(qty).Add(b.FillModel.CommissionPerOrder)
+	b.cash = b.cash.Sub(signed.Mul(fill)).Sub(commission)
+
+	p, ok := b.positions[o.Symbol]
+	if !ok {
+		p = &position.Position{AccountID: AccountID, Symbol: o.Symbol, CreatedAt: b.clock()}
+		b.positions[o.Symbol] = p
+	}
+	addToPosition(p, signed, fill)
+	p.UpdatedAt = b.clock()
+	positionQty := p.Qty
+	if p.Qty.IsZero() {
+		delete(b.positions, o.Symbol)
+	}
+
+	now := b.clock()
+	o.FilledQty = *o.Qty
+	o.FilledAvgPrice = &fill
+	o.FilledAt = &now
+	o.UpdatedAt = now
+	o.Status = order.OrderStatusFilled
+	b.fills = append(b.fills, Fill{
+		OrderID:       o.ID,
+		ClientOrderID: o.StakeOrderID,
+		Symbol:        o.Symbol,
+		Side:          o.Side,
+		Qty:           qty,
+		Price:         fill,
+		Commission:    commission,
+		Time:          now,
+	})
+	b.publish(o, broker.TradeEventFill, &execution{price: fill, qty: qty, positionQty: positionQty})
+}
+
+// fillPrice is what o fills at with the market at price, if it fills
+func fillPrice(o *order.Order, price decimal.Decimal) (decimal.Decimal, bool) {
+	buy := o.Side == order.OrderSideBuy
+
+	if o.OrderType == order.OrderTypeStop || o.OrderType == order.OrderTypeStopLimit {
+		triggered := buy && price.GreaterThanOrEqual(*o.StopPrice) ||
+			!buy && price.LessThanOrEqual(*o.StopPrice)
+		if !triggered {
+			return decimal.Zero, false
+		}
+	}
+
+	if o.OrderType == order.OrderTypeLimit || o.OrderType == order.OrderTypeStopLimit {
+		limit := *o.LimitPrice
+		switch {
+		case buy && price.LessThanOrEqual(limit), !buy && price.GreaterThanOrEqual(limit):
+			return price, true
+		default:
+			return decimal.Zero, false
+		}
+	}
+	return price, true
+}
+
+// barFillPrice is what o fills at during bar, if it fills, and whether the
+// fill took the market's price rather than o's limit. A stop limit order
+// triggered within the bar only fills if its stop is within its limit, as
+// the bar does not tell where the price went after.
+func barFillPrice(o *order.Order, bar marketdata.Bar) (decimal.Decimal, bool, bool) {
+	buy := o.Side == order.OrderSideBuy
+
+	// reached tells when, if at all, the price got to p coming from the
+	// side an order for a better price waits on: at the open, or later
+	reached := func(p decimal.Decimal, up bool) (decimal.Decimal, bool) {
+		switch {
+		case up && bar.Open.GreaterThanOrEqual(p), !up && bar.Open.LessThanOrEqual(p):
+			return bar.Open, true
+		case up && bar.High.GreaterThanOrEqual(p), !up && bar.Low.LessThanOrEqual(p):
+			return p, true
+		default:
+			return decimal.Zero, false
+		}
+	}
+
+	switch o.OrderType {
+	case order.OrderTypeMarket:
+		return bar.Open, true, true
+
+	case order.OrderTypeStop:
+		price, ok := reached(*o.StopPrice, buy)
+		return price, true, ok
+
+	case order.OrderTypeLimit:
+		price, ok := reached(*o.LimitPrice, !buy)
+		return price, false, ok
+
+	case order.OrderTypeStopLimit:
+		trigger, ok := reached(*o.StopPrice, buy)
+		if !ok {
+			return decimal.Zero, false, false
+		}
+		limit := *o.LimitPrice
+		if buy && trigger.LessThanOrEqual(limit) || !buy && trigger.GreaterThanOrEqual(limit) {
+			return trigger, false, true
+		}
+		if !trigger.Equal(bar.Open) {
+			return decimal.Zero, false, false
+		}
+		// Triggered at the open beyond the limit, so it waits for the
+		// price to come back within the bar
+		price, ok := reached(limit, !buy)
+		return price, false, ok
+	}
+	return decimal.Zero, false, false
+}
+
+// addToPosition moves p by a signed quantity at price. The average entry
+// price only changes while the position grows, and starts over when it
+// flips from long to short or back.
+func addToPosition(p *position.Position, qty, price decimal.Decimal) {
+	newQty := p.Qty.Add(qty)
+	switch {
+	case newQty.IsZero():
+		p.AvgEntryPrice = decimal.Zero
+	case p.Qty.IsZero() || p.Qty.Sign() != newQty.Sign():
+		p.AvgEntryPrice = price
+	case p.Qty.Sign() == qty.Sign():
+		p.AvgEntryPrice = p.AvgEntryPrice.Mul(p.Qty).Add(price.Mul(qty)).Div(newQty)
+	}
+	p.Qty = newQty
+	p.CostBasis = p.AvgEntryPrice.Mul(newQty)
+}
+
+// mark values a copy of p at the latest price
+func (b *Broker) mark(p *position.Position) *position.Position {
+	marked := *p
+	marked.CurrentPrice = p.AvgEntryPrice
+	if price, ok := b.prices[p.Symbol]; ok {
+		marked.CurrentPrice = price
+	}
+	marked.MarketValue = marked.CurrentPrice.Mul(p.Qty)
+	marked.UnrealizedPL = marked.MarketValue.Sub(p.CostBasis)
+	if !p.CostBasis.IsZero() {
+		marked.UnrealizedPLPC = marked.UnrealizedPL.Div(p.CostBasis.Abs())
+	}
+	return &marked
+}
+
+type execution struct {
+	price       decimal.Decimal
+	qty         decimal.Decimal
+	positionQty decimal.Decimal
+}
+
+// publish queues a trade update for o on every open stream
+func (b *Broker) publish(o *order.Order, kind broker.TradeEventKind, exec *execution) {
+	now := b.clock()
+	event := broker.TradeUpdateEvent{
+		EventMeta: broker.EventMeta{
+			ID:         rand.Text(),
+			AccountID:  AccountID,
+			ReceivedAt: now,
+		},
+		Event: kind,
+		Order: copyOrder(o),
+	}
+	if exec != nil {
+		event.ExecutionID = rand.Text()
+		event.Price = &exec.price
+		event.Qty = &exec.qty
+		event.PositionQty = &exec.positionQty
+		event.ExecutedAt = &now
+	}
+	event.Payload, _ = json.Marshal(struct {
+		Event string       `json:"event"`
+		Order *order.Order `json:"order"`
+	}{string(kind), event.Order})
+
+	for _, q := range b.streams {
+		q.push(event)
+	}
+}
+
+func copyOrder(o *order.Order) *order.Order {
+	c := *o
+	return &c
+}
+
+// EventQueue holds a subscriber's events until it reads them
+type EventQueue struct {
+	mu     sync.Mutex
+	events []broker.Event
+	ready  chan struct{}
+}
+
+func newEventQueue() *EventQueue {
+	return &EventQueue{ready: make(chan struct{}, 1)}
+}
+
+func (q *EventQueue) push(event broker.Event) {
+	q.mu.Lock()
+	q.events = append(q.events, event)
+	q.mu.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+}
+
+// Drain takes every queued event, oldest first, without waiting
+func (q *EventQueue) Drain() []broker.Event {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	events := q.events
+	q.events = nil
+	return events
+}
+
+// next waits for the oldest event, unless ctx is done first
+func (q *EventQueue) next(ctx context.Context) (broker.Event, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.events) > 0 {
+			event := q.events[0]
+			q.events = q.events[1:]
+			q.mu.Unlock()
+			return event, true
+		}
+		q.mu.Unlock()
+
+		select {
+		case <-q.ready:
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
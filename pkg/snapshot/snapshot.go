@@ -0,0 +1,223 @@
+package snapshot
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata" // trading days follow New York time wherever pony runs
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+)
+
+// DefaultInterval is how often Run refreshes today's snapshots. The last
+// refresh after the close is the one that sticks.
+const DefaultInterval = 15 * time.Minute
+
+// DefaultBackfillPeriod is how much portfolio history Backfill reads, in
+// Alpaca's period notation
+const DefaultBackfillPeriod = "1A"
+
+// market is the time zone the exchange calendar is kept in
+var market = mustLoadLocation("America/New_York")
+
+// Store is the subset of the sqlc generated Querier the Recorder needs.
+// *db.Queries implements it.
+type Store interface {
+	SummaryStore
+	UpsertAccountSnapshot(ctx context.Context, arg db.UpsertAccountSnapshotParams) (db.AccountSnapshot, error)
+	CreateMissingAccountSnapshot(ctx context.Context, arg db.CreateMissingAccountSnapshotParams) (int64, error)
+}
+
+// SummaryStore is what LoadSummary reads snapshots through
+type SummaryStore interface {
+	ListAccountSnapshots(ctx context.Context, arg db.ListAccountSnapshotsParams) ([]db.AccountSnapshot, error)
+}
+
+// Recorder keeps one snapshot per account per trading day
+type Recorder struct {
+	brokerClient broker.Client
+	store        Store
+
+	Interval time.Duration
+}
+
+func NewRecorder(brokerClient broker.Client, store Store) *Recorder {
+	return &Recorder{
+		brokerClient: brokerClient,
+		store:        store,
+		Interval:     DefaultInterval,
+	}
+}
+
+// Run takes snapshots immediately and then every Interval until ctx is
+// done. Each result is passed to handle, which may be nil.
+func (r *Recorder) Run(ctx context.Context, handle func([]*account.Snapshot, error)) {
+	ticker := time.NewTicker(r.Interval)
+	defer ticker.Stop()
+
+	for {
+		snapshots, err := r.Take(ctx)
+		if handle != nil && ctx.Err() == nil {
+			handle(snapshots, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Take stores today's snapshot of every broker account, replacing any taken
+// earlier in the day. On days the market is closed it stores nothing.
+func (r *Recorder) Take(ctx context.Context) ([]*account.Snapshot, error) {
+	today := TradingDate(time.Now())
+	days, err := r.brokerClient.ListTradingDays(ctx, today, today)
+	if err != nil {
+		return nil, err
+	}
+	if len(days) == 0 || !days[0].Equal(today) {
+		return nil, nil
+	}
+
+	accounts, err := r.brokerClient.ListAccounts(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*account.Snapshot, 0, len(accounts))
+	for _, acc := range accounts {
+		s := &account.Snapshot{
+			AccountID:      acc.ID,
+			Date:           today,
+			Equity:         acc.Equity,
+			Cash:           &acc.Cash,
+			BuyingPower:    &acc.BuyingPower,
+			PositionsValue: &acc.PositionsValue,
+			DayPL:          acc.Equity.Sub(acc.LastEquity),
+			Source:         account.SnapshotLive,
+		}
+		if _, err := r.store.UpsertAccountSnapshot(ctx, db.NewUpsertAccountSnapshotParams(s)); err != nil {
+			return snapshots, fmt.Errorf("failed to save snapshot for account %s: %w", acc.ID, err)
+		}
+		snapshots = append(snapshots, s)
+	}
+
+	return snapshots, nil
+}
+
+// Backfill stores a snapshot for every past trading day in the broker's
+// portfolio history over period that has none yet, and returns how many it
+// stored. Today is left to Take.
+func (r *Recorder) Backfill(ctx context.Context, period string) (int, error) {
+	accounts, err := r.brokerClient.ListAccounts(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	today := TradingDate(time.Now())
+	stored := 0
+	for _, acc := range accounts {
+		points, err := r.brokerClient.GetPortfolioHistory(ctx, acc.ID, period)
+		if err != nil {
+			return stored, err
+		}
+
+		// The history's P&L is cumulative, so each day's is the change
+		// from the day before
+		prevPL := decimal.Zero
+		for _, p := range points {
+			date := TradingDate(p.Time)
+			dayPL := p.ProfitLoss.Sub(prevPL)
+			prevPL = p.ProfitLoss
+
+			// Days before the account was funded have no equity
+			if !date.Before(today) || p.Equity.IsZero() {
+				continue
+			}
+
+			n, err := r.store.CreateMissingAccountSnapshot(ctx, db.NewCreateMissingAccountSnapshotParams(&account.Snapshot{
+				AccountID: acc.ID,
+				Date:      date,
+				Equity:    p.Equity,
+				DayPL:     dayPL,
+				Source:    account.SnapshotHistory,
+			}))
+			if err != nil {
+				return stored, fmt.Errorf("failed to save snapshot for account %s: %w", acc.ID, err)
+			}
+			stored += int(n)
+		}
+	}
+
+	return stored, nil
+}
+
+// Summary is an account's P&L as of its latest snapshot, summed from the
+// day P&L of its snapshots, so deposits and withdrawals are left out
+type Summary struct {
+	AsOf   time.Time
+	Equity decimal.Decimal
+	Day    decimal.Decimal
+	MTD    decimal.Decimal
+	YTD    decimal.Decimal
+}
+
+// Summarize adds up snapshots, oldest first, into the P&L for the day, month
+// and year of the latest one. It returns nil when there are none.
+func Summarize(snapshots []*account.Snapshot) *Summary {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	latest := snapshots[len(snapshots)-1]
+	s := &Summary{AsOf: latest.Date, Equity: latest.Equity, Day: latest.DayPL}
+	for _, snap := range snapshots {
+		if snap.Date.Year() != latest.Date.Year() {
+			continue
+		}
+		s.YTD = s.YTD.Add(snap.DayPL)
+		if snap.Date.Month() == latest.Date.Month() {
+			s.MTD = s.MTD.Add(snap.DayPL)
+		}
+	}
+	return s
+}
+
+// LoadSummary summarizes the stored snapshots of one account up to now. It
+// returns nil when the account has no snapshots in the last year or so.
+func LoadSummary(ctx context.Context, store SummaryStore, accountID string, now time.Time) (*Summary, error) {
+	until := TradingDate(now)
+	// From the start of last year, in case the latest snapshot is from then
+	from := time.Date(until.Year()-1, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	rows, err := store.ListAccountSnapshots(ctx, db.ListAccountSnapshotsParams{
+		AccountID: accountID,
+		DateFrom:  from,
+		DateUntil: until,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list account snapshots: %w", err)
+	}
+
+	return Summarize(db.ToAccountSnapshots(rows)), nil
+}
+
+// TradingDate is the exchange's calendar date at t, as midnight UTC
+func TradingDate(t time.Time) time.Time {
+	y, m, d := t.In(market).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func mustLoadLocation(name string) *time.Location {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		panic(err)
+	}
+	return loc
+}
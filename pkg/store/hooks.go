@@ -0,0 +1,64 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/revrost/pony/pkg/db"
+)
+
+// QueryHook is called as a query starts, with its sqlc name, and returns a
+// function that is called with the query's error once it is done.
+type QueryHook func(ctx context.Context, name string) (done func(err error))
+
+// hookedDBTX runs the hooks around every query run through it
+type hookedDBTX struct {
+	db.DBTX
+	hooks []QueryHook
+}
+
+func (h hookedDBTX) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	done := h.start(ctx, query)
+	result, err := h.DBTX.ExecContext(ctx, query, args...)
+	done(err)
+	return result, err
+}
+
+func (h hookedDBTX) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	done := h.start(ctx, query)
+	rows, err := h.DBTX.QueryContext(ctx, query, args...)
+	done(err)
+	return rows, err
+}
+
+func (h hookedDBTX) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	done := h.start(ctx, query)
+	row := h.DBTX.QueryRowContext(ctx, query, args...)
+	done(row.Err())
+	return row
+}
+
+func (h hookedDBTX) start(ctx context.Context, query string) func(error) {
+	name := queryName(query)
+	dones := make([]func(error), len(h.hooks))
+	for i, hook := range h.hooks {
+		dones[i] = hook(ctx, name)
+	}
+	return func(err error) {
+		for _, done := range dones {
+			done(err)
+		}
+	}
+}
+
+// queryName is the name from the "-- name: X :one" line sqlc starts each
+// query with
+func queryName(query string) string {
+	line, _, _ := strings.Cut(query, "\n")
+	if rest, ok := strings.CutPrefix(line, "-- name: "); ok {
+		name, _, _ := strings.Cut(rest, " ")
+		return name
+	}
+	return "other"
+}
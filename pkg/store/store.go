@@ -0,0 +1,171 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	_ "modernc.org/sqlite"
+
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/db/sqlite"
+)
+
+// Dialect is the SQL database behind a DB.
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// sqlitePragmas are set on every SQLite connection. Foreign keys are off by
+// default in SQLite, WAL lets the TUI read while the event log writes, and
+// immediate transactions take the write lock up front instead of failing
+// with SQLITE_BUSY halfway through. Times are written in a format SQLite's
+// date functions can parse, so queries can compare them with julianday().
+const sqlitePragmas = "_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_txlock=immediate&_time_format=sqlite"
+
+// DB is an open database together with the dialect its queries are
+// written in.
+type DB struct {
+	*sql.DB
+	Dialect Dialect
+
+	// QueryHooks, if set before the first query, are called around every
+	// query, such as to time or trace it
+	QueryHooks []QueryHook
+
+	dsn string
+}
+
+// Open connects to the database at url. The scheme picks the backend:
+// postgres:// and postgresql:// use Postgres, while sqlite://, file: or a
+// plain file path use an SQLite database file, created if it does not exist.
+func Open(url string) (*DB, error) {
+	dialect, driver, dsn, err := parseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to database: %w", err)
+	}
+
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	return &DB{DB: conn, Dialect: dialect, dsn: dsn}, nil
+}
+
+func parseURL(url string) (dialect Dialect, driver, dsn string, err error) {
+	scheme, rest, hasScheme := strings.Cut(url, "://")
+	switch {
+	case hasScheme && (scheme == "postgres" || scheme == "postgresql"):
+		return Postgres, "postgres", url, nil
+	case hasScheme && scheme == "sqlite":
+		return SQLite, "sqlite", sqliteDSN(rest), nil
+	case strings.HasPrefix(url, "sqlite:"):
+		return SQLite, "sqlite", sqliteDSN(strings.TrimPrefix(url, "sqlite:")), nil
+	case strings.HasPrefix(url, "file:"):
+		return SQLite, "sqlite", sqliteDSN(strings.TrimPrefix(url, "file:")), nil
+	case !hasScheme:
+		return SQLite, "sqlite", sqliteDSN(url), nil
+	default:
+		return "", "", "", fmt.Errorf("unsupported DATABASE_URL scheme %q", scheme)
+	}
+}
+
+func sqliteDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return "file:" + path + sep + sqlitePragmas
+}
+
+// Queries returns the sqlc queries for the dialect, running on the pool.
+func (d *DB) Queries() db.Querier {
+	return d.queries(d.DB)
+}
+
+// InTx runs fn with queries bound to a single transaction, which is
+// committed if fn returns nil and rolled back otherwise.
+func (d *DB) InTx(ctx context.Context, fn func(q db.Querier) error) error {
+	tx, err := d.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(d.queries(tx)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (d *DB) queries(dbtx db.DBTX) db.Querier {
+	if len(d.QueryHooks) > 0 {
+		dbtx = hookedDBTX{DBTX: dbtx, hooks: d.QueryHooks}
+	}
+	if d.Dialect == SQLite {
+		return sqlite.NewQuerier(dbtx)
+	}
+	return db.New(dbtx)
+}
+
+// Listen delivers the payload of every notification on a Postgres channel
+// until ctx is done, when the returned channel is closed. The listener
+// reconnects by itself and sends an empty payload once it is back, as
+// notifications may have been missed meanwhile. SQLite has no
+// notifications, so on SQLite the channel is closed straight away.
+func (d *DB) Listen(ctx context.Context, channel string) (<-chan string, error) {
+	payloads := make(chan string)
+	if d.Dialect != Postgres {
+		close(payloads)
+		return payloads, nil
+	}
+
+	listener := pq.NewListener(d.dsn, time.Second, time.Minute, nil)
+	if err := listener.Listen(channel); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", channel, err)
+	}
+
+	go func() {
+		defer close(payloads)
+		defer listener.Close()
+
+		for {
+			var payload string
+			select {
+			case <-ctx.Done():
+				return
+			case n := <-listener.Notify:
+				// nil follows a reconnect
+				if n != nil {
+					payload = n.Extra
+				}
+			case <-time.After(90 * time.Second):
+				// A quiet connection may have died without anyone noticing
+				go listener.Ping()
+				continue
+			}
+
+			select {
+			case payloads <- payload:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return payloads, nil
+}
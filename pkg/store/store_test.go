@@ -0,0 +1,941 @@
+package store_test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/migrate"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/store"
+)
+
+// postgresURLEnv names the Postgres database the suite also runs against.
+// Every table in it is emptied before each test, so it must not hold
+// anything worth keeping.
+const postgresURLEnv = "PONY_TEST_POSTGRES_URL"
+
+const testAccountID = "acct-1"
+
+// day is a fixed UTC time the tests build their timestamps from
+var day = time.Date(2026, 10, 1, 14, 30, 0, 0, time.UTC)
+
+// TestStore runs every case against each backend, on a freshly migrated and
+// empty database
+func TestStore(t *testing.T) {
+	backends := []struct {
+		name string
+		url  func(t *testing.T) string
+	}{
+		{"sqlite", func(t *testing.T) string {
+			return "sqlite://" + filepath.Join(t.TempDir(), "pony.db")
+		}},
+		{"postgres", func(t *testing.T) string {
+			url := os.Getenv(postgresURLEnv)
+			if url == "" {
+				t.Skip(postgresURLEnv + " is not set")
+			}
+			return url
+		}},
+	}
+
+	tests := []struct {
+		name string
+		run  func(t *testing.T, conn *store.DB)
+	}{
+		{"accounts", testAccounts},
+		{"orders", testOrders},
+		{"notional orders", testNotionalOrders},
+		{"order stats", testOrderStats},
+		{"positions", testPositions},
+		{"events", testEvents},
+		{"executions", testExecutions},
+		{"watchlists", testWatchlists},
+		{"schedules", testSchedules},
+		{"alerts", testAlerts},
+	}
+
+	for _, backend := range backends {
+		t.Run(backend.name, func(t *testing.T) {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					tt.run(t, openStore(t, backend.url(t)))
+				})
+			}
+		})
+	}
+}
+
+func openStore(t *testing.T, url string) *store.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	conn, err := store.Open(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	migrator, err := migrate.New(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if conn.Dialect == store.Postgres {
+		_, err := conn.ExecContext(ctx, `DO $$
+DECLARE tables text;
+BEGIN
+    SELECT string_agg(quote_ident(tablename), ', ') INTO tables
+    FROM pg_tables
+    WHERE schemaname = current_schema() AND tablename <> 'schema_migrations';
+    EXECUTE 'TRUNCATE ' || tables || ' RESTART IDENTITY CASCADE';
+END $$`)
+		if err != nil {
+			t.Fatalf("failed to empty tables: %v", err)
+		}
+	}
+	return conn
+}
+
+func testAccounts(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+
+	created := createAccount(t, q)
+	if !created.Cash.Equal(dec("1000.10")) {
+		t.Errorf("cash = %s, want 1000.10", created.Cash)
+	}
+
+	updated, err := q.UpdateAccount(ctx, db.UpdateAccountParams{
+		ID:             testAccountID,
+		Status:         "ACCOUNT_CLOSED",
+		Cash:           dec("0.01"),
+		PortfolioValue: dec("12345.678901"),
+		BuyingPower:    dec("0"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != "ACCOUNT_CLOSED" || !updated.PortfolioValue.Equal(dec("12345.678901")) {
+		t.Errorf("updated account = %+v", updated)
+	}
+
+	got, err := q.GetAccountByAlpacaID(ctx, "PA123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != testAccountID || !got.Cash.Equal(dec("0.01")) {
+		t.Errorf("account by Alpaca ID = %+v", got)
+	}
+
+	accounts, err := q.ListAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(accounts) != 1 {
+		t.Errorf("got %d accounts, want 1", len(accounts))
+	}
+
+	if _, err := q.GetAccount(ctx, "missing"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("missing account: err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func testOrders(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+
+	createOrder(t, q, "o1", "AAPL", day)
+	createOrder(t, q, "o2", "AAPL", day.Add(time.Hour))
+	createOrder(t, q, "o3", "MSFT", day.Add(24*time.Hour))
+
+	got, err := q.GetOrder(ctx, "o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.CreatedAt.Equal(day) {
+		t.Errorf("created at = %s, want the broker's %s", got.CreatedAt, day)
+	}
+
+	filledAt := day.Add(time.Minute)
+	updated, err := q.UpdateOrder(ctx, db.UpdateOrderParams{
+		ID:             "o1",
+		Status:         string(order.OrderStatusFilled),
+		FilledQty:      dec("3"),
+		FilledAvgPrice: decimal.NewNullDecimal(dec("0.1")),
+		FilledAt:       sql.NullTime{Time: filledAt, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Status != "filled" || !updated.FilledQty.Equal(dec("3")) || !updated.FilledAt.Time.Equal(filledAt) {
+		t.Errorf("updated order = %+v", updated)
+	}
+
+	byAlpacaID, err := q.GetOrderByAlpacaID(ctx, "alpaca-o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if byAlpacaID.ID != "o1" || byAlpacaID.Status != "filled" {
+		t.Errorf("order by Alpaca ID = %+v", byAlpacaID)
+	}
+
+	searches := []struct {
+		name string
+		arg  db.SearchOrdersParams
+		want []string
+	}{
+		{"all, newest first", db.SearchOrdersParams{}, []string{"o3", "o2", "o1"}},
+		{"symbol", db.SearchOrdersParams{Symbol: "AAPL"}, []string{"o2", "o1"}},
+		{"statuses", db.SearchOrdersParams{Statuses: []string{"filled", "canceled"}}, []string{"o1"}},
+		{"open statuses", db.SearchOrdersParams{Statuses: []string{"new"}}, []string{"o3", "o2"}},
+		{"created range", db.SearchOrdersParams{
+			CreatedFrom:  sql.NullTime{Time: day, Valid: true},
+			CreatedUntil: sql.NullTime{Time: day.Add(time.Hour), Valid: true},
+		}, []string{"o1"}},
+		{"filled range", db.SearchOrdersParams{
+			FilledFrom: sql.NullTime{Time: day, Valid: true},
+		}, []string{"o1"}},
+		{"next page", db.SearchOrdersParams{
+			BeforeCreatedAt: sql.NullTime{Time: day.Add(time.Hour), Valid: true},
+			BeforeID:        "o2",
+		}, []string{"o1"}},
+		{"limit", db.SearchOrdersParams{RowLimit: 1}, []string{"o3"}},
+	}
+	for _, tt := range searches {
+		t.Run(tt.name, func(t *testing.T) {
+			arg := tt.arg
+			arg.AccountID = testAccountID
+			if arg.RowLimit == 0 {
+				arg.RowLimit = 50
+			}
+			rows, err := q.SearchOrders(ctx, arg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, row := range rows {
+				ids = append(ids, row.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+// testNotionalOrders checks an order for a dollar amount keeps it, and takes
+// its quantity from what has filled
+func testNotionalOrders(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+
+	if _, err := q.CreateOrder(ctx, db.NewCreateOrderParams(&order.Order{
+		ID:            "n1",
+		AlpacaOrderID: "alpaca-n1",
+		AccountID:     testAccountID,
+		Symbol:        "AAPL",
+		Side:          order.OrderSideBuy,
+		OrderType:     order.OrderTypeMarket,
+		Notional:      ptr(dec("100")),
+		TimeInForce:   order.TimeInForceDay,
+		Status:        order.OrderStatusNew,
+		SubmittedAt:   day,
+		CreatedAt:     day,
+	})); err != nil {
+		t.Fatal(err)
+	}
+
+	row, err := q.GetOrder(ctx, "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	o := db.ToOrder(row)
+	if o.Qty != nil || o.Notional == nil || !o.Notional.Equal(dec("100")) {
+		t.Errorf("new notional order has qty %v and notional %v, want none and 100", o.Qty, o.Notional)
+	}
+
+	row, err = q.UpdateOrder(ctx, db.UpdateOrderParams{
+		ID:             "n1",
+		Status:         string(order.OrderStatusFilled),
+		FilledQty:      dec("0.666"),
+		FilledAvgPrice: decimal.NewNullDecimal(dec("150.15")),
+		FilledAt:       sql.NullTime{Time: day.Add(time.Minute), Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	o = db.ToOrder(row)
+	if o.Qty == nil || !o.Qty.Equal(dec("0.666")) {
+		t.Errorf("filled notional order has qty %v, want 0.666", o.Qty)
+	}
+
+	// An order for a quantity keeps it however much has filled
+	createOrder(t, q, "o1", "AAPL", day)
+	row, err = q.UpdateOrder(ctx, db.UpdateOrderParams{
+		ID:        "o1",
+		Status:    string(order.OrderStatusPartiallyFilled),
+		FilledQty: dec("0.5"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !row.Qty.Equal(dec("1")) || row.Notional.Valid {
+		t.Errorf("partly filled order has qty %s and notional %v, want 1 and none", row.Qty, row.Notional)
+	}
+}
+
+// testOrderStats checks notional is summed as exact decimals, as floats
+// 3 × 0.1 + 0.1 × 0.2 is not 0.32, and that orders count on the New York
+// trading date they were placed on, not the UTC day
+func testOrderStats(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+
+	fills := []struct {
+		id, symbol  string
+		createdAt   time.Time
+		qty, price  string
+		unfilledQty bool
+	}{
+		{id: "o1", symbol: "AAPL", createdAt: day, qty: "3", price: "0.1"},
+		// 22:00 in New York, the next day in UTC
+		{id: "o2", symbol: "AAPL", createdAt: day.Add(11*time.Hour + 30*time.Minute), qty: "0.1", price: "0.2"},
+		{id: "o3", symbol: "MSFT", createdAt: day.Add(24 * time.Hour), unfilledQty: true},
+	}
+	for _, f := range fills {
+		createOrder(t, q, f.id, f.symbol, f.createdAt)
+		if f.unfilledQty {
+			continue
+		}
+		if _, err := q.UpdateOrder(ctx, db.UpdateOrderParams{
+			ID:             f.id,
+			Status:         string(order.OrderStatusFilled),
+			FilledQty:      dec(f.qty),
+			FilledAvgPrice: decimal.NewNullDecimal(dec(f.price)),
+			FilledAt:       sql.NullTime{Time: f.createdAt.Add(time.Minute), Valid: true},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	byDay, err := q.OrderStatsByDay(ctx, db.OrderStatsByDayParams{AccountID: testAccountID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantByDay := []db.OrderStatsByDayRow{
+		{Day: "2026-10-02", OrderCount: 1, FilledCount: 0, Notional: dec("0")},
+		{Day: "2026-10-01", OrderCount: 2, FilledCount: 2, Notional: dec("0.32")},
+	}
+	if len(byDay) != len(wantByDay) {
+		t.Fatalf("by day = %+v, want %+v", byDay, wantByDay)
+	}
+	for i, want := range wantByDay {
+		got := byDay[i]
+		if got.Day != want.Day || got.OrderCount != want.OrderCount || got.FilledCount != want.FilledCount || !got.Notional.Equal(want.Notional) {
+			t.Errorf("by day[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+
+	bySymbol, err := q.OrderStatsBySymbol(ctx, db.OrderStatsBySymbolParams{
+		AccountID:   testAccountID,
+		CreatedFrom: sql.NullTime{Time: day, Valid: true},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantBySymbol := []db.OrderStatsBySymbolRow{
+		{Symbol: "AAPL", OrderCount: 2, FilledCount: 2, Notional: dec("0.32")},
+		{Symbol: "MSFT", OrderCount: 1, FilledCount: 0, Notional: dec("0")},
+	}
+	if len(bySymbol) != len(wantBySymbol) {
+		t.Fatalf("by symbol = %+v, want %+v", bySymbol, wantBySymbol)
+	}
+	for i, want := range wantBySymbol {
+		got := bySymbol[i]
+		if got.Symbol != want.Symbol || got.OrderCount != want.OrderCount || got.FilledCount != want.FilledCount || !got.Notional.Equal(want.Notional) {
+			t.Errorf("by symbol[%d] = %+v, want %+v", i, got, want)
+		}
+	}
+}
+
+func testPositions(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+
+	for _, p := range []db.CreatePositionParams{
+		{AccountID: testAccountID, Symbol: "AAPL", Qty: dec("10"), AvgEntryPrice: dec("150.25"), MarketValue: dec("1502.5")},
+		{AccountID: testAccountID, Symbol: "MSFT", Qty: dec("0.5"), AvgEntryPrice: dec("400"), MarketValue: dec("200")},
+	} {
+		if _, err := q.CreatePosition(ctx, p); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	updated, err := q.UpdatePosition(ctx, db.UpdatePositionParams{
+		AccountID:     testAccountID,
+		Symbol:        "MSFT",
+		Qty:           dec("5.5"),
+		AvgEntryPrice: dec("401.123456"),
+		MarketValue:   dec("2206.18"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated.Qty.Equal(dec("5.5")) || !updated.AvgEntryPrice.Equal(dec("401.123456")) {
+		t.Errorf("updated position = %+v", updated)
+	}
+
+	positions, err := q.ListPositions(ctx, testAccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(positions) != 2 || positions[0].Symbol != "MSFT" || positions[1].Symbol != "AAPL" {
+		t.Errorf("positions = %+v, want MSFT then AAPL by market value", positions)
+	}
+
+	if err := q.DeletePosition(ctx, db.DeletePositionParams{AccountID: testAccountID, Symbol: "AAPL"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetPosition(ctx, db.GetPositionParams{AccountID: testAccountID, Symbol: "AAPL"}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleted position: err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+// testEvents records a fill twice and checks it is logged and applied once,
+// and that replaying the log rebuilds the same projections
+func testEvents(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+	eventLog := events.NewStore(conn)
+
+	fill := tradeUpdate(t, "event-1", "fill")
+	for range 2 {
+		if err := eventLog.Record(ctx, fill); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	logged, err := q.ListEventsAfter(ctx, db.ListEventsAfterParams{Limit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(logged) != 1 || !logged[0].AppliedAt.Valid {
+		t.Fatalf("logged events = %+v, want one applied", logged)
+	}
+	if _, err := q.AppendEvent(ctx, db.AppendEventParams{
+		EventID:    "event-1",
+		EventType:  string(broker.EventTypeTradeUpdate),
+		AccountID:  testAccountID,
+		Payload:    fill.Payload,
+		ReceivedAt: day,
+	}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("appending a duplicate: err = %v, want sql.ErrNoRows", err)
+	}
+
+	// An event logged without being applied, as after a crash
+	canceled := tradeUpdate(t, "event-2", "canceled")
+	if _, err := q.AppendEvent(ctx, db.AppendEventParams{
+		EventID:    canceled.ID,
+		EventType:  string(broker.EventTypeTradeUpdate),
+		AccountID:  testAccountID,
+		Payload:    canceled.Payload,
+		ReceivedAt: day.Add(time.Minute),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []int{1, 0} {
+		applied, err := eventLog.ApplyPending(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if applied != want {
+			t.Errorf("applied %d events, want %d", applied, want)
+		}
+	}
+
+	check := func(t *testing.T) {
+		t.Helper()
+		o, err := q.GetOrderByAlpacaID(ctx, "alpaca-o1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if o.Status != "canceled" || !o.FilledQty.Equal(dec("2")) {
+			t.Errorf("order = %+v, want canceled with 2 filled", o)
+		}
+		execs, err := q.ListExecutionsByOrder(ctx, o.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(execs) != 1 || execs[0].EventID != "event-1" {
+			t.Errorf("executions = %+v, want the one fill", execs)
+		}
+		p, err := q.GetPosition(ctx, db.GetPositionParams{AccountID: testAccountID, Symbol: "AAPL"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !p.Qty.Equal(dec("2")) || !p.AvgEntryPrice.Equal(dec("150.01")) {
+			t.Errorf("position = %+v, want 2 at 150.01", p)
+		}
+	}
+	check(t)
+
+	replayed, err := eventLog.Rebuild(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed != 2 {
+		t.Errorf("replayed %d events, want 2", replayed)
+	}
+	check(t)
+}
+
+func testExecutions(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+	createOrder(t, q, "o1", "AAPL", day)
+	createOrder(t, q, "o2", "MSFT", day)
+
+	for i, e := range []db.CreateExecutionParams{
+		{ID: "x1", OrderID: "o1", Symbol: "AAPL", Qty: dec("1"), Price: dec("150.5"), ExecutedAt: day.Add(time.Minute)},
+		{ID: "x2", OrderID: "o1", Symbol: "AAPL", Qty: dec("0.25"), Price: dec("150.75"), ExecutedAt: day.Add(2 * time.Minute)},
+		{ID: "x3", OrderID: "o2", Symbol: "MSFT", Qty: dec("2"), Price: dec("400"), ExecutedAt: day.Add(3 * time.Minute)},
+	} {
+		e.AccountID = testAccountID
+		e.EventID = "event-" + e.ID
+		e.Side = string(order.OrderSideBuy)
+		e.PositionQty = decimal.NewNullDecimal(decimal.NewFromInt(int64(i + 1)))
+		if _, err := q.CreateExecution(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := q.CreateExecution(ctx, db.CreateExecutionParams{
+		ID: "x1", OrderID: "o1", AccountID: testAccountID, Symbol: "AAPL", Side: "buy",
+		Qty: dec("1"), Price: dec("150.5"), ExecutedAt: day,
+	}); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("recording an execution twice: err = %v, want sql.ErrNoRows", err)
+	}
+
+	lists := []struct {
+		name string
+		list func() ([]db.Execution, error)
+		want []string
+	}{
+		{"by order", func() ([]db.Execution, error) { return q.ListExecutionsByOrder(ctx, "o1") }, []string{"x1", "x2"}},
+		{"by symbol", func() ([]db.Execution, error) {
+			return q.ListExecutionsBySymbol(ctx, db.ListExecutionsBySymbolParams{AccountID: testAccountID, Symbol: "MSFT"})
+		}, []string{"x3"}},
+		{"newest first", func() ([]db.Execution, error) {
+			return q.ListExecutions(ctx, db.ListExecutionsParams{AccountID: testAccountID, Limit: 2})
+		}, []string{"x3", "x2"}},
+	}
+	for _, tt := range lists {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := tt.list()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ids []string
+			for _, row := range rows {
+				ids = append(ids, row.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("got %v, want %v", ids, tt.want)
+			}
+		})
+	}
+
+	x2, err := q.ListExecutionsByOrder(ctx, "o1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !x2[1].Price.Equal(dec("150.75")) || !x2[1].PositionQty.Decimal.Equal(dec("2")) || !x2[1].ExecutedAt.Equal(day.Add(2*time.Minute)) {
+		t.Errorf("execution = %+v", x2[1])
+	}
+
+	symbols, err := q.ListExecutedSymbols(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(symbols) != 2 || symbols[0].Symbol != "AAPL" || symbols[1].Symbol != "MSFT" {
+		t.Errorf("executed symbols = %+v", symbols)
+	}
+}
+
+func testWatchlists(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+
+	for _, name := range []string{"Tech", "Megacaps"} {
+		if _, err := q.UpsertWatchlist(ctx, db.UpsertWatchlistParams{ID: "w1", AccountID: testAccountID, Name: name}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, symbol := range []string{"NVDA", "AAPL", "MSFT"} {
+		if _, err := q.AddWatchlistItem(ctx, db.AddWatchlistItemParams{WatchlistID: "w1", Symbol: symbol, Position: int32(2 - i)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	lists, err := q.ListWatchlists(ctx, testAccountID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lists) != 1 || lists[0].Name != "Megacaps" {
+		t.Errorf("watchlists = %+v, want the one renamed", lists)
+	}
+
+	items, err := q.ListWatchlistItems(ctx, "w1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var symbols []string
+	for _, item := range items {
+		symbols = append(symbols, item.Symbol)
+	}
+	if want := []string{"MSFT", "AAPL", "NVDA"}; !slices.Equal(symbols, want) {
+		t.Errorf("items = %v, want %v", symbols, want)
+	}
+
+	if err := q.DeleteWatchlistItems(ctx, "w1"); err != nil {
+		t.Fatal(err)
+	}
+	if items, err := q.ListWatchlistItems(ctx, "w1"); err != nil || len(items) != 0 {
+		t.Errorf("items after delete = %+v, %v", items, err)
+	}
+	if err := q.DeleteWatchlist(ctx, "w1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.GetWatchlist(ctx, "w1"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("deleted watchlist: err = %v, want sql.ErrNoRows", err)
+	}
+}
+
+// testSchedules takes a schedule through a claimed run that is settled from
+// its order intent
+func testSchedules(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+
+	so, err := schedule.New(order.CreateOrderRequest{
+		AccountID:   testAccountID,
+		Symbol:      "VTI",
+		Side:        order.OrderSideBuy,
+		OrderType:   order.OrderTypeMarket,
+		Notional:    ptr(dec("100.50")),
+		TimeInForce: order.TimeInForceDay,
+	}, "0 10 * * mon", "", day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row, err := q.CreateScheduledOrder(ctx, db.NewCreateScheduledOrderParams(so))
+	if err != nil {
+		t.Fatal(err)
+	}
+	scheduledFor := row.NextRunAt.Time
+	if !scheduledFor.Equal(*so.NextRunAt) || !row.Notional.Decimal.Equal(dec("100.50")) {
+		t.Errorf("schedule = %+v", row)
+	}
+
+	if due, err := q.ListDueScheduledOrders(ctx, scheduledFor.Add(-time.Second)); err != nil || len(due) != 0 {
+		t.Errorf("due before the run = %+v, %v", due, err)
+	}
+	due, err := q.ListDueScheduledOrders(ctx, scheduledFor)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(due) != 1 || due[0].ID != row.ID {
+		t.Fatalf("due = %+v, want the schedule", due)
+	}
+
+	claim := db.ClaimScheduledOrderRunParams{
+		ID:           row.ID,
+		ScheduledFor: scheduledFor,
+		NextRunAt:    sql.NullTime{Time: scheduledFor.Add(7 * 24 * time.Hour), Valid: true},
+	}
+	for _, want := range []int64{1, 0} {
+		claimed, err := q.ClaimScheduledOrderRun(ctx, claim)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if claimed != want {
+			t.Errorf("claimed %d, want %d", claimed, want)
+		}
+	}
+
+	// Sent without an answer, then resolved by the outbox
+	clientOrderID := schedule.ClientOrderID(row.ID, scheduledFor)
+	run, err := q.CreateScheduledOrderRun(ctx, db.NewCreateScheduledOrderRunParams(&schedule.Run{
+		ScheduleID:    row.ID,
+		ScheduledFor:  scheduledFor,
+		Result:        schedule.ResultPending,
+		ClientOrderID: clientOrderID,
+		Message:       "timeout",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := q.SetScheduledOrderResult(ctx, db.SetScheduledOrderResultParams{
+		ID:         row.ID,
+		LastResult: sql.NullString{String: string(schedule.ResultPending), Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.CreateOrderIntent(ctx, db.CreateOrderIntentParams{
+		ClientOrderID: clientOrderID,
+		AccountID:     testAccountID,
+		Symbol:        "VTI",
+		Side:          "buy",
+		OrderType:     "market",
+		Notional:      decimal.NewNullDecimal(dec("100.50")),
+		TimeInForce:   "day",
+		CreatedAt:     day,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := q.MarkOrderIntentSent(ctx, db.MarkOrderIntentSentParams{
+		ClientOrderID: clientOrderID,
+		AlpacaOrderID: sql.NullString{String: "alpaca-o1", Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	createOrder(t, q, "o1", "VTI", scheduledFor)
+
+	pending, err := q.ListPendingScheduledOrderRuns(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != run.ID || pending[0].IntentStatus.String != "sent" || pending[0].OrderID.String != "o1" {
+		t.Fatalf("pending runs = %+v, want the run with its sent order", pending)
+	}
+
+	settle := db.SettleScheduledOrderRunParams{
+		ID:      run.ID,
+		Result:  string(schedule.ResultPlaced),
+		OrderID: sql.NullString{String: "o1", Valid: true},
+	}
+	for _, want := range []int64{1, 0} {
+		settled, err := q.SettleScheduledOrderRun(ctx, settle)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if settled != want {
+			t.Errorf("settled %d, want %d", settled, want)
+		}
+	}
+	if err := q.SettleScheduledOrderResult(ctx, db.SettleScheduledOrderResultParams{
+		ID:           row.ID,
+		ScheduledFor: scheduledFor,
+		LastResult:   sql.NullString{String: string(schedule.ResultPlaced), Valid: true},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := q.GetScheduledOrder(ctx, row.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.LastResult.String != "placed" || !got.LastRunAt.Time.Equal(scheduledFor) || !got.NextRunAt.Time.Equal(claim.NextRunAt.Time) {
+		t.Errorf("schedule after the run = %+v", got)
+	}
+
+	runs, err := q.ListScheduledOrderRuns(ctx, db.ListScheduledOrderRunsParams{ScheduledOrderID: row.ID, RowLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 1 || runs[0].Result != "placed" || runs[0].OrderStatus.String != "new" || runs[0].Message.Valid {
+		t.Errorf("runs = %+v, want the placed run", runs)
+	}
+
+	if _, err := q.SetScheduledOrderEnabled(ctx, db.SetScheduledOrderEnabledParams{ID: row.ID, Enabled: false}); err != nil {
+		t.Fatal(err)
+	}
+	if due, err := q.ListDueScheduledOrders(ctx, claim.NextRunAt.Time); err != nil || len(due) != 0 {
+		t.Errorf("due while disabled = %+v, %v", due, err)
+	}
+	if deleted, err := q.DeleteScheduledOrder(ctx, row.ID); err != nil || deleted != 1 {
+		t.Errorf("deleted %d, %v; want 1", deleted, err)
+	}
+}
+
+func testAlerts(t *testing.T, conn *store.DB) {
+	ctx := context.Background()
+	q := conn.Queries()
+	createAccount(t, q)
+
+	var ids []int64
+	for _, r := range []*alert.Rule{
+		{Expr: "AAPL last > 200", Notify: []alert.Channel{alert.ChannelToast}, Enabled: true},
+		{AccountID: testAccountID, Expr: "buying power < 1000", Notify: []alert.Channel{alert.ChannelToast, alert.ChannelBell}, Enabled: true},
+	} {
+		row, err := q.CreateAlert(ctx, db.NewCreateAlertParams(r))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, row.ID)
+	}
+
+	if _, err := q.SetAlertEnabled(ctx, db.SetAlertEnabledParams{ID: ids[0], Enabled: false}); err != nil {
+		t.Fatal(err)
+	}
+	enabled, err := q.ListEnabledAlerts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enabled) != 1 || enabled[0].ID != ids[1] || enabled[0].AccountID.String != testAccountID {
+		t.Errorf("enabled alerts = %+v", enabled)
+	}
+
+	triggeredAt := day.Add(time.Minute)
+	for range 2 {
+		if err := q.RecordAlertTrigger(ctx, db.NewRecordAlertTriggerParams(&alert.Alert{
+			RuleID:      ids[1],
+			Message:     "buying power is $999",
+			TriggeredAt: triggeredAt,
+		})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := q.GetAlert(ctx, ids[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.TriggerCount != 2 || !got.LastTriggeredAt.Time.Equal(triggeredAt) || got.LastMessage.String != "buying power is $999" {
+		t.Errorf("alert after firing = %+v", got)
+	}
+	if rule := db.ToAlertRule(got); len(rule.Notify) != 2 {
+		t.Errorf("notify = %v, want toast and bell", rule.Notify)
+	}
+
+	if deleted, err := q.DeleteAlert(ctx, ids[0]); err != nil || deleted != 1 {
+		t.Errorf("deleted %d, %v; want 1", deleted, err)
+	}
+	all, err := q.ListAlerts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 1 || all[0].ID != ids[1] {
+		t.Errorf("alerts = %+v, want the one left", all)
+	}
+}
+
+func createAccount(t *testing.T, q db.Querier) db.Account {
+	t.Helper()
+	row, err := q.CreateAccount(context.Background(), db.NewCreateAccountParams(&account.Account{
+		ID:              testAccountID,
+		AlpacaAccountID: "PA123",
+		Status:          "ACTIVE",
+		Currency:        "USD",
+		Cash:            dec("1000.10"),
+		PortfolioValue:  dec("2500.25"),
+		BuyingPower:     dec("2000.20"),
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+// createOrder records a new buy of one share, as placed by the broker at
+// createdAt
+func createOrder(t *testing.T, q db.Querier, id, symbol string, createdAt time.Time) {
+	t.Helper()
+	if _, err := q.CreateOrder(context.Background(), db.NewCreateOrderParams(&order.Order{
+		ID:            id,
+		AlpacaOrderID: "alpaca-" + id,
+		AccountID:     testAccountID,
+		Symbol:        symbol,
+		Side:          order.OrderSideBuy,
+		OrderType:     order.OrderTypeMarket,
+		Qty:           ptr(dec("1")),
+		TimeInForce:   order.TimeInForceDay,
+		Status:        order.OrderStatusNew,
+		SubmittedAt:   createdAt,
+		CreatedAt:     createdAt,
+	})); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tradeUpdate is a trade update for a buy of 2 AAPL at 150.01, as received
+// from the broker. Fills carry the execution.
+func tradeUpdate(t *testing.T, eventID, event string) broker.TradeUpdateEvent {
+	t.Helper()
+	filledAt := day.Add(time.Minute)
+	tu := alpaca.TradeUpdate{
+		At:      filledAt,
+		Event:   event,
+		EventID: eventID,
+		Order: alpaca.Order{
+			ID:             "alpaca-o1",
+			ClientOrderID:  "client-o1",
+			CreatedAt:      day,
+			UpdatedAt:      filledAt,
+			SubmittedAt:    day,
+			FilledAt:       &filledAt,
+			Symbol:         "AAPL",
+			Type:           alpaca.Market,
+			Side:           alpaca.Buy,
+			TimeInForce:    alpaca.Day,
+			Qty:            ptr(dec("5")),
+			FilledQty:      dec("2"),
+			FilledAvgPrice: ptr(dec("150.01")),
+		},
+	}
+	if event == "fill" {
+		tu.Order.Status = "partially_filled"
+		tu.ExecutionID = "x1"
+		tu.Price = ptr(dec("150.01"))
+		tu.Qty = ptr(dec("2"))
+		tu.PositionQty = ptr(dec("2"))
+		tu.Timestamp = &filledAt
+	} else {
+		tu.Order.Status = event
+	}
+
+	payload, err := json.Marshal(tu)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return broker.TradeUpdateEventFromAlpaca(tu, broker.EventMeta{
+		ID:         eventID,
+		AccountID:  testAccountID,
+		ReceivedAt: filledAt,
+		Payload:    payload,
+	})
+}
+
+func dec(s string) decimal.Decimal {
+	return decimal.RequireFromString(s)
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,150 @@
+package strategy
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/order"
+)
+
+// Holding is a strategy's position in one symbol, at its average cost
+type Holding struct {
+	Symbol string
+	// Qty is negative for a short position
+	Qty      decimal.Decimal
+	AvgPrice decimal.Decimal
+	// LastPrice is the latest market price seen, zero before any
+	LastPrice decimal.Decimal
+	// RealizedPL is what closing the position, in part or in full, made
+	RealizedPL decimal.Decimal
+}
+
+// UnrealizedPL is what the open position would make if closed at LastPrice
+func (h Holding) UnrealizedPL() decimal.Decimal {
+	if h.LastPrice.IsZero() {
+		return decimal.Zero
+	}
+	return h.LastPrice.Sub(h.AvgPrice).Mul(h.Qty)
+}
+
+// Book keeps a strategy's holdings from the fills of its own orders. It is
+// safe to read while the strategy runs.
+type Book struct {
+	mu       sync.Mutex
+	holdings map[string]*Holding
+	// booked are the executions already booked, as the broker may deliver
+	// an update more than once
+	booked map[string]bool
+}
+
+func NewBook() *Book {
+	return &Book{
+		holdings: map[string]*Holding{},
+		booked:   map[string]bool{},
+	}
+}
+
+// Position is the holding in symbol, which is flat if there is none
+func (b *Book) Position(symbol string) Holding {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if h, ok := b.holdings[symbol]; ok {
+		return *h
+	}
+	return Holding{Symbol: symbol}
+}
+
+// Holdings returns every symbol traded so far, by symbol
+func (b *Book) Holdings() []Holding {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	holdings := make([]Holding, 0, len(b.holdings))
+	for _, h := range slices.SortedFunc(maps.Values(b.holdings), func(a, b *Holding) int {
+		return cmp.Compare(a.Symbol, b.Symbol)
+	}) {
+		holdings = append(holdings, *h)
+	}
+	return holdings
+}
+
+// RealizedPL is the P&L of everything closed so far
+func (b *Book) RealizedPL() decimal.Decimal {
+	total := decimal.Zero
+	for _, h := range b.Holdings() {
+		total = total.Add(h.RealizedPL)
+	}
+	return total
+}
+
+// UnrealizedPL is the P&L of the open positions at their last prices
+func (b *Book) UnrealizedPL() decimal.Decimal {
+	total := decimal.Zero
+	for _, h := range b.Holdings() {
+		total = total.Add(h.UnrealizedPL())
+	}
+	return total
+}
+
+// fill books an execution, unless it was booked already
+func (b *Book) fill(exec *order.Execution) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.booked[exec.ID] {
+		return
+	}
+	b.booked[exec.ID] = true
+
+	h := b.holding(exec.Symbol)
+	qty := exec.Qty
+	if exec.Side == order.OrderSideSell {
+		qty = qty.Neg()
+	}
+	newQty := h.Qty.Add(qty)
+
+	// The part of the fill that closes the position realizes its P&L
+	if !h.Qty.IsZero() && h.Qty.Sign() != qty.Sign() {
+		closed := decimal.Min(h.Qty.Abs(), qty.Abs())
+		if h.Qty.IsNegative() {
+			closed = closed.Neg()
+		}
+		h.RealizedPL = h.RealizedPL.Add(exec.Price.Sub(h.AvgPrice).Mul(closed))
+	}
+
+	switch {
+	case newQty.IsZero():
+		h.AvgPrice = decimal.Zero
+	case h.Qty.IsZero() || h.Qty.Sign() != newQty.Sign():
+		// Opened, or flipped from long to short or back
+		h.AvgPrice = exec.Price
+	case h.Qty.Sign() == qty.Sign():
+		h.AvgPrice = h.AvgPrice.Mul(h.Qty).Add(exec.Price.Mul(qty)).Div(newQty)
+	}
+	h.Qty = newQty
+	h.LastPrice = exec.Price
+}
+
+// mark sets the latest price of symbol, if the strategy holds or held it
+func (b *Book) mark(symbol string, price decimal.Decimal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if h, ok := b.holdings[symbol]; ok {
+		h.LastPrice = price
+	}
+}
+
+func (b *Book) holding(symbol string) *Holding {
+	h, ok := b.holdings[symbol]
+	if !ok {
+		h = &Holding{Symbol: symbol}
+		b.holdings[symbol] = h
+	}
+	return h
+}
@@ -0,0 +1,487 @@
+package strategy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/order"
+)
+
+// DefaultRetryInterval is how long the engine waits before reopening a
+// market data or event stream that broke
+const DefaultRetryInterval = 5 * time.Second
+
+// State is whether a strategy is being called
+type State string
+
+const (
+	// StateStopped strategies get no callbacks and have no open orders
+	StateStopped State = "stopped"
+	// StateRunning strategies get every callback
+	StateRunning State = "running"
+	// StatePaused strategies still get the updates of the orders they
+	// have open, but no market data or timer ticks to act on
+	StatePaused State = "paused"
+)
+
+// Status is a strategy's state and P&L
+type Status struct {
+	Name         string
+	Symbols      []string
+	State        State
+	Holdings     []Holding
+	RealizedPL   decimal.Decimal
+	UnrealizedPL decimal.Decimal
+	// Err is why the strategy last stopped by itself, if it did
+	Err error
+}
+
+type instance struct {
+	cfg       Config
+	strategy  Strategy
+	env       *Env
+	state     State
+	err       error
+	nextTimer time.Time
+}
+
+// Engine runs strategies against a broker and a market data feed. Added
+// strategies start out stopped.
+type Engine struct {
+	brokerClient broker.Client
+	feed         marketdata.Feed
+
+	// RetryInterval is how long to wait before reopening a broken stream
+	RetryInterval time.Duration
+
+	// calls is held for each strategy callback, so a strategy is never
+	// called concurrently, and for starting and stopping
+	calls sync.Mutex
+
+	mu        sync.Mutex
+	instances []*instance
+	now       time.Time
+	updated   chan struct{}
+}
+
+func NewEngine(brokerClient broker.Client, feed marketdata.Feed) *Engine {
+	return &Engine{
+		brokerClient:  brokerClient,
+		feed:          feed,
+		RetryInterval: DefaultRetryInterval,
+		updated:       make(chan struct{}, 1),
+	}
+}
+
+// Add adds a stopped strategy
+func (e *Engine) Add(cfg Config, strategy Strategy) error {
+	if cfg.Name == "" || strings.ContainsAny(cfg.Name, " \t") {
+		return fmt.Errorf("strategy name %q must be a single word", cfg.Name)
+	}
+	if len(cfg.Symbols) == 0 {
+		return fmt.Errorf("strategy %s has no symbols", cfg.Name)
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if slices.ContainsFunc(e.instances, func(in *instance) bool { return in.cfg.Name == cfg.Name }) {
+		return fmt.Errorf("strategy %s is added twice", cfg.Name)
+	}
+	e.instances = append(e.instances, &instance{
+		cfg:      cfg,
+		strategy: strategy,
+		state:    StateStopped,
+		env: &Env{
+			Name:    cfg.Name,
+			Symbols: cfg.Symbols,
+			Broker:  newScopedClient(e.brokerClient, cfg),
+			Book:    NewBook(),
+			Logger:  slog.With("strategy", cfg.Name),
+			engine:  e,
+		},
+	})
+	return nil
+}
+
+// Status returns every strategy's, in the order they were added
+func (e *Engine) Status() []Status {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	statuses := make([]Status, 0, len(e.instances))
+	for _, in := range e.instances {
+		book := in.env.Book
+		statuses = append(statuses, Status{
+			Name:         in.cfg.Name,
+			Symbols:      in.cfg.Symbols,
+			State:        in.state,
+			Holdings:     book.Holdings(),
+			RealizedPL:   book.RealizedPL(),
+			UnrealizedPL: book.UnrealizedPL(),
+			Err:          in.err,
+		})
+	}
+	return statuses
+}
+
+// Updated receives a value after a strategy's state or book changed.
+// Several changes may share one value, so read Status again after each.
+func (e *Engine) Updated() <-chan struct{} {
+	return e.updated
+}
+
+func (e *Engine) notify() {
+	select {
+	case e.updated <- struct{}{}:
+	default:
+	}
+}
+
+// Start starts a stopped strategy, or resumes a paused one
+func (e *Engine) Start(ctx context.Context, name string) error {
+	e.calls.Lock()
+	defer e.calls.Unlock()
+
+	in, err := e.instance(name)
+	if err != nil {
+		return err
+	}
+
+	switch e.state(in) {
+	case StateRunning:
+		return fmt.Errorf("strategy %s is already running", name)
+	case StatePaused:
+		e.setState(in, StateRunning, nil)
+		in.env.Logger.Info("strategy resumed")
+		return nil
+	}
+
+	e.setState(in, StateRunning, nil)
+	in.nextTimer = e.clock().Add(in.cfg.Interval)
+	in.env.Logger.Info("strategy started")
+	return e.call(ctx, in, func() error {
+		return in.strategy.OnStart(ctx, in.env)
+	})
+}
+
+// Pause pauses a running strategy
+func (e *Engine) Pause(name string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	in, err := e.lockedInstance(name)
+	if err != nil {
+		return err
+	}
+	if in.state != StateRunning {
+		return fmt.Errorf("strategy %s is %s, not running", name, in.state)
+	}
+	in.state = StatePaused
+	in.env.Logger.Info("strategy paused")
+	e.notify()
+	return nil
+}
+
+// Stop stops a strategy and cancels the orders it has open. Its positions
+// are left as they are.
+func (e *Engine) Stop(ctx context.Context, name string) error {
+	e.calls.Lock()
+	defer e.calls.Unlock()
+
+	in, err := e.instance(name)
+	if err != nil {
+		return err
+	}
+	if e.state(in) == StateStopped {
+		return fmt.Errorf("strategy %s is not running", name)
+	}
+	e.setState(in, StateStopped, nil)
+	in.env.Logger.Info("strategy stopped")
+	return e.cancelOpenOrders(ctx, in)
+}
+
+// Run streams market data and order updates to the strategies, and ticks
+// their timers, until ctx is done
+func (e *Engine) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Go(func() { e.streamMarketData(ctx) })
+	wg.Go(func() { e.streamEvents(ctx) })
+	wg.Go(func() {
+		ticker := time.NewTicker(time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				e.HandleTime(ctx, now)
+			}
+		}
+	})
+	wg.Wait()
+}
+
+func (e *Engine) streamMarketData(ctx context.Context) {
+	var symbols []string
+	e.mu.Lock()
+	for _, in := range e.instances {
+		for _, symbol := range in.cfg.Symbols {
+			if !slices.Contains(symbols, symbol) {
+				symbols = append(symbols, symbol)
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	for {
+		err := e.feed.Stream(ctx, symbols, func(bar marketdata.Bar) {
+			e.HandleBar(ctx, bar)
+		}, func(quote marketdata.Quote) {
+			e.HandleQuote(ctx, quote)
+		})
+		if !e.retry(ctx, "market data stream down", err) {
+			return
+		}
+	}
+}
+
+func (e *Engine) streamEvents(ctx context.Context) {
+	for {
+		eventCh, errCh := e.brokerClient.StreamEvents(ctx, "")
+		for event := range eventCh {
+			e.HandleEvent(ctx, event)
+		}
+		if !e.retry(ctx, "order update stream down", <-errCh) {
+			return
+		}
+	}
+}
+
+// retry logs why a stream broke and waits to reopen it, unless ctx is done
+func (e *Engine) retry(ctx context.Context, msg string, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	slog.Warn(msg, "error", err, "retry_in", e.RetryInterval)
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(e.RetryInterval):
+		return true
+	}
+}
+
+// HandleBar passes a bar to the running strategies that trade its symbol.
+// Run calls it for every bar from the feed; a backtest calls it itself.
+func (e *Engine) HandleBar(ctx context.Context, bar marketdata.Bar) {
+	e.calls.Lock()
+	defer e.calls.Unlock()
+
+	e.setClock(bar.Time)
+	for _, in := range e.trading(bar.Symbol) {
+		in.env.Book.mark(bar.Symbol, bar.Close)
+		if e.state(in) == StateRunning {
+			e.call(ctx, in, func() error {
+				return in.strategy.OnBar(ctx, in.env, bar)
+			})
+		}
+	}
+	e.notify()
+}
+
+// HandleQuote passes a quote to the running strategies that trade its
+// symbol
+func (e *Engine) HandleQuote(ctx context.Context, quote marketdata.Quote) {
+	e.calls.Lock()
+	defer e.calls.Unlock()
+
+	e.setClock(quote.Time)
+	for _, in := range e.trading(quote.Symbol) {
+		if mid := quote.Mid(); mid.IsPositive() {
+			in.env.Book.mark(quote.Symbol, mid)
+		}
+		if e.state(in) == StateRunning {
+			e.call(ctx, in, func() error {
+				return in.strategy.OnQuote(ctx, in.env, quote)
+			})
+		}
+	}
+	e.notify()
+}
+
+// HandleEvent books a trade update's fill to the strategy that placed the
+// order, and passes the update on to it unless it is stopped. Updates of
+// orders no strategy placed are ignored.
+func (e *Engine) HandleEvent(ctx context.Context, event broker.Event) {
+	update, ok := event.(broker.TradeUpdateEvent)
+	if !ok || update.Order == nil {
+		return
+	}
+
+	e.calls.Lock()
+	defer e.calls.Unlock()
+
+	in := e.owner(update.Order)
+	if in == nil {
+		return
+	}
+	if exec := update.Execution(); exec != nil {
+		in.env.Book.fill(exec)
+	}
+	if e.state(in) != StateStopped {
+		e.call(ctx, in, func() error {
+			return in.strategy.OnOrderUpdate(ctx, in.env, update)
+		})
+	}
+	e.notify()
+}
+
+// HandleTime calls the timers of the running strategies that are due at
+// now. Run calls it every second.
+func (e *Engine) HandleTime(ctx context.Context, now time.Time) {
+	e.calls.Lock()
+	defer e.calls.Unlock()
+
+	e.setClock(now)
+	e.mu.Lock()
+	instances := slices.Clone(e.instances)
+	e.mu.Unlock()
+
+	for _, in := range instances {
+		if in.cfg.Interval <= 0 || e.state(in) != StateRunning || now.Before(in.nextTimer) {
+			continue
+		}
+		in.nextTimer = now.Add(in.cfg.Interval)
+		e.call(ctx, in, func() error {
+			return in.strategy.OnTimer(ctx, in.env, now)
+		})
+	}
+}
+
+// call runs one of in's callbacks. A callback that fails or panics stops
+// the strategy and cancels its open orders.
+func (e *Engine) call(ctx context.Context, in *instance, callback func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("strategy panicked: %v", r)
+		}
+		if err == nil {
+			return
+		}
+		in.env.Logger.Error("strategy failed and was stopped", "error", err)
+		e.setState(in, StateStopped, err)
+		if cancelErr := e.cancelOpenOrders(ctx, in); cancelErr != nil {
+			err = errors.Join(err, cancelErr)
+		}
+	}()
+	return callback()
+}
+
+// cancelOpenOrders cancels every order in has open
+func (e *Engine) cancelOpenOrders(ctx context.Context, in *instance) error {
+	orders, err := in.env.Broker.ListOrders(ctx, "", &order.ListOrdersRequest{Status: order.QueryStatusOpen})
+	if err != nil {
+		return fmt.Errorf("failed to list strategy %s's open orders: %w", in.cfg.Name, err)
+	}
+
+	var errs []error
+	for _, o := range orders {
+		if err := in.env.Broker.CancelOrder(ctx, o.ID); err != nil {
+			errs = append(errs, fmt.Errorf("failed to cancel order %s: %w", o.ID, err))
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		in.env.Logger.Error("failed to cancel open orders", "error", err)
+		return err
+	}
+	return nil
+}
+
+func (e *Engine) instance(name string) (*instance, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.lockedInstance(name)
+}
+
+func (e *Engine) lockedInstance(name string) (*instance, error) {
+	for _, in := range e.instances {
+		if in.cfg.Name == name {
+			return in, nil
+		}
+	}
+	return nil, fmt.Errorf("no strategy named %s", name)
+}
+
+// trading returns the strategies that trade symbol
+func (e *Engine) trading(symbol string) []*instance {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var instances []*instance
+	for _, in := range e.instances {
+		if slices.Contains(in.cfg.Symbols, symbol) {
+			instances = append(instances, in)
+		}
+	}
+	return instances
+}
+
+// owner returns the strategy that placed o, if any did
+func (e *Engine) owner(o *order.Order) *instance {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for _, in := range e.instances {
+		if placedBy(o.StakeOrderID, in.cfg.Name) {
+			return in
+		}
+	}
+	return nil
+}
+
+func (e *Engine) state(in *instance) State {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return in.state
+}
+
+func (e *Engine) setState(in *instance, state State, err error) {
+	e.mu.Lock()
+	in.state = state
+	in.err = err
+	e.mu.Unlock()
+	e.notify()
+}
+
+// clock is the time of the latest market data or tick, or the wall clock
+// before any
+func (e *Engine) clock() time.Time {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.now.IsZero() {
+		return time.Now()
+	}
+	return e.now
+}
+
+func (e *Engine) setClock(now time.Time) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if now.After(e.now) {
+		e.now = now
+	}
+}
@@ -0,0 +1,192 @@
+package strategy
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/watchlist"
+)
+
+// ErrOutOfScope means a strategy asked for something outside its account,
+// symbols or orders
+var ErrOutOfScope = errors.New("outside the strategy's scope")
+
+// ClientOrderIDPrefix starts the client order ID of every order the named
+// strategy places, which is how its order updates are told apart
+func ClientOrderIDPrefix(name string) string {
+	return "strategy-" + name + "-"
+}
+
+// placedBy reports whether the named strategy placed the order with
+// clientOrderID. The random part after the prefix never has a dash, so
+// strategy a-b's orders are not taken for strategy a's.
+func placedBy(clientOrderID, name string) bool {
+	rest, ok := strings.CutPrefix(clientOrderID, ClientOrderIDPrefix(name))
+	return ok && rest != "" && !strings.Contains(rest, "-")
+}
+
+// scopedClient is the broker client a strategy gets. It pins every call to
+// the strategy's account, lets it trade only its own symbols, and hides
+// and protects every order it did not place.
+type scopedClient struct {
+	broker.Client
+	name      string
+	accountID string
+	symbols   []string
+}
+
+var _ broker.Client = (*scopedClient)(nil)
+
+func newScopedClient(client broker.Client, cfg Config) *scopedClient {
+	return &scopedClient{
+		Client:    client,
+		name:      cfg.Name,
+		accountID: cfg.AccountID,
+		symbols:   cfg.Symbols,
+	}
+}
+
+func (c *scopedClient) owns(o *order.Order) bool {
+	return placedBy(o.StakeOrderID, c.name)
+}
+
+func (c *scopedClient) GetAccount(ctx context.Context, accountID string) (*account.Account, error) {
+	return c.Client.GetAccount(ctx, c.accountID)
+}
+
+func (c *scopedClient) ListAccounts(ctx context.Context) ([]*account.Account, error) {
+	acc, err := c.Client.GetAccount(ctx, c.accountID)
+	if err != nil {
+		return nil, err
+	}
+	return []*account.Account{acc}, nil
+}
+
+func (c *scopedClient) GetPortfolioHistory(ctx context.Context, accountID, period string) ([]*account.EquityPoint, error) {
+	return c.Client.GetPortfolioHistory(ctx, c.accountID, period)
+}
+
+// CreateOrder places the order in the strategy's account under a client
+// order ID of its own
+func (c *scopedClient) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (*order.Order, error) {
+	if !slices.Contains(c.symbols, req.Symbol) {
+		return nil, fmt.Errorf("order in %s: %w", req.Symbol, ErrOutOfScope)
+	}
+
+	r := *req
+	r.AccountID = c.accountID
+	r.ClientOrderID = ClientOrderIDPrefix(c.name) + rand.Text()
+	return c.Client.CreateOrder(ctx, &r)
+}
+
+func (c *scopedClient) GetOrder(ctx context.Context, orderID string) (*order.Order, error) {
+	o, err := c.Client.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.owns(o) {
+		return nil, fmt.Errorf("order %s: %w", orderID, ErrOutOfScope)
+	}
+	return o, nil
+}
+
+func (c *scopedClient) GetOrderByClientOrderID(ctx context.Context, clientOrderID string) (*order.Order, error) {
+	if !placedBy(clientOrderID, c.name) {
+		return nil, fmt.Errorf("order %s: %w", clientOrderID, ErrOutOfScope)
+	}
+	return c.Client.GetOrderByClientOrderID(ctx, clientOrderID)
+}
+
+// ListOrders returns only the strategy's orders, so a limit may leave it
+// with fewer than asked for
+func (c *scopedClient) ListOrders(ctx context.Context, accountID string, req *order.ListOrdersRequest) ([]*order.Order, error) {
+	r := *req
+	if len(r.Symbols) == 0 {
+		r.Symbols = c.symbols
+	}
+	orders, err := c.Client.ListOrders(ctx, c.accountID, &r)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(orders, func(o *order.Order) bool { return !c.owns(o) }), nil
+}
+
+func (c *scopedClient) ReplaceOrder(ctx context.Context, orderID string, req *order.ReplaceOrderRequest) (*order.Order, error) {
+	if _, err := c.GetOrder(ctx, orderID); err != nil {
+		return nil, err
+	}
+	return c.Client.ReplaceOrder(ctx, orderID, req)
+}
+
+func (c *scopedClient) CancelOrder(ctx context.Context, orderID string) error {
+	if _, err := c.GetOrder(ctx, orderID); err != nil {
+		return err
+	}
+	return c.Client.CancelOrder(ctx, orderID)
+}
+
+// ListPositions returns the account's positions in the strategy's symbols,
+// which other strategies and manual trades may share. Env.Book has the
+// strategy's own.
+func (c *scopedClient) ListPositions(ctx context.Context, accountID string) ([]*position.Position, error) {
+	positions, err := c.Client.ListPositions(ctx, c.accountID)
+	if err != nil {
+		return nil, err
+	}
+	return slices.DeleteFunc(positions, func(p *position.Position) bool {
+		return !slices.Contains(c.symbols, p.Symbol)
+	}), nil
+}
+
+// ClosePosition would close the whole account's position, not the
+// strategy's, so strategies place their own closing orders instead
+func (c *scopedClient) ClosePosition(ctx context.Context, accountID, symbol string) (*order.Order, error) {
+	return nil, fmt.Errorf("closing the account's position in %s: %w", symbol, ErrOutOfScope)
+}
+
+func (c *scopedClient) ListWatchlists(ctx context.Context, accountID string) ([]*watchlist.Watchlist, error) {
+	return nil, fmt.Errorf("watchlists: %w", ErrOutOfScope)
+}
+
+func (c *scopedClient) GetWatchlist(ctx context.Context, watchlistID string) (*watchlist.Watchlist, error) {
+	return nil, fmt.Errorf("watchlists: %w", ErrOutOfScope)
+}
+
+func (c *scopedClient) CreateWatchlist(ctx context.Context, req *watchlist.CreateWatchlistRequest) (*watchlist.Watchlist, error) {
+	return nil, fmt.Errorf("watchlists: %w", ErrOutOfScope)
+}
+
+func (c *scopedClient) UpdateWatchlist(ctx context.Context, watchlistID string, req *watchlist.UpdateWatchlistRequest) (*watchlist.Watchlist, error) {
+	return nil, fmt.Errorf("watchlists: %w", ErrOutOfScope)
+}
+
+func (c *scopedClient) AddWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (*watchlist.Watchlist, error) {
+	return nil, fmt.Errorf("watchlists: %w", ErrOutOfScope)
+}
+
+func (c *scopedClient) RemoveWatchlistSymbol(ctx context.Context, watchlistID, symbol string) error {
+	return fmt.Errorf("watchlists: %w", ErrOutOfScope)
+}
+
+func (c *scopedClient) DeleteWatchlist(ctx context.Context, watchlistID string) error {
+	return fmt.Errorf("watchlists: %w", ErrOutOfScope)
+}
+
+// StreamEvents is not for strategies: the engine passes them their order
+// updates
+func (c *scopedClient) StreamEvents(ctx context.Context, accountID string) (<-chan broker.Event, <-chan error) {
+	eventCh := make(chan broker.Event)
+	errCh := make(chan error, 1)
+	errCh <- fmt.Errorf("event stream: %w", ErrOutOfScope)
+	close(eventCh)
+	close(errCh)
+	return eventCh, errCh
+}
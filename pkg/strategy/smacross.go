@@ -0,0 +1,101 @@
+package strategy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/marketdata"
+	"github.com/revrost/pony/pkg/order"
+)
+
+// SMACross buys when the fast simple moving average of the closes crosses
+// above the slow one, and sells what it holds when it crosses back below
+type SMACross struct {
+	Base
+
+	// Fast and Slow are how many bars each average is over
+	Fast int
+	Slow int
+	// Qty is how many shares each entry buys
+	Qty decimal.Decimal
+
+	closes []decimal.Decimal
+	// above is whether the fast average was above the slow one at the
+	// previous bar, once there were enough bars for both
+	above   *bool
+	pending bool
+}
+
+func NewSMACross() *SMACross {
+	return &SMACross{
+		Fast: 10,
+		Slow: 30,
+		Qty:  decimal.NewFromInt(1),
+	}
+}
+
+func (s *SMACross) OnStart(ctx context.Context, env *Env) error {
+	if s.Fast <= 0 || s.Slow <= s.Fast {
+		return fmt.Errorf("sma_cross needs 0 < fast < slow, not %d and %d", s.Fast, s.Slow)
+	}
+	// Averages from before a stop would cross against stale prices
+	s.closes = nil
+	s.above = nil
+	return nil
+}
+
+func (s *SMACross) OnBar(ctx context.Context, env *Env, bar marketdata.Bar) error {
+	s.closes = append(s.closes, bar.Close)
+	if len(s.closes) > s.Slow {
+		s.closes = s.closes[1:]
+	}
+	if len(s.closes) < s.Slow {
+		return nil
+	}
+
+	above := average(s.closes[len(s.closes)-s.Fast:]).GreaterThan(average(s.closes))
+	crossed := s.above != nil && *s.above != above
+	s.above = &above
+	if !crossed || s.pending {
+		return nil
+	}
+
+	held := env.Book.Position(bar.Symbol).Qty
+	switch {
+	case above && !held.IsPositive():
+		return s.submit(ctx, env, bar.Symbol, order.OrderSideBuy, s.Qty)
+	case !above && held.IsPositive():
+		return s.submit(ctx, env, bar.Symbol, order.OrderSideSell, held)
+	}
+	return nil
+}
+
+func (s *SMACross) OnOrderUpdate(ctx context.Context, env *Env, update broker.TradeUpdateEvent) error {
+	if !update.Order.IsOpen() {
+		s.pending = false
+	}
+	return nil
+}
+
+func (s *SMACross) submit(ctx context.Context, env *Env, symbol string, side order.OrderSide, qty decimal.Decimal) error {
+	o, err := env.Broker.CreateOrder(ctx, &order.CreateOrderRequest{
+		Symbol:      symbol,
+		Side:        side,
+		OrderType:   order.OrderTypeMarket,
+		Qty:         &qty,
+		TimeInForce: order.TimeInForceDay,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to place %s order: %w", side, err)
+	}
+	s.pending = o.IsOpen()
+	env.Logger.Info("sma cross", "side", side, "symbol", symbol, "qty", qty, "order_id", o.ID)
+	return nil
+}
+
+func average(values []decimal.Decimal) decimal.Decimal {
+	return decimal.Sum(values[0], values[1:]...).Div(decimal.NewFromInt(int64(len(values))))
+}
@@ -0,0 +1,166 @@
+// Package strategy runs automated trading strategies. Each strategy is fed
+// market data, its own order updates and timer ticks, places orders
+// through a broker client scoped to it, and keeps its own positions and
+// P&L apart from the rest of the account.
+package strategy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/marketdata"
+)
+
+// Strategy is an automated trading strategy. Its callbacks are called one
+// at a time, never concurrently, with the Env it was started with. A
+// callback that returns an error stops the strategy.
+//
+// Embed Base to implement only the callbacks a strategy needs.
+type Strategy interface {
+	// OnStart is called each time the strategy is started
+	OnStart(ctx context.Context, env *Env) error
+
+	// OnBar and OnQuote get market data for the strategy's symbols
+	OnBar(ctx context.Context, env *Env, bar marketdata.Bar) error
+	OnQuote(ctx context.Context, env *Env, quote marketdata.Quote) error
+
+	// OnOrderUpdate gets the trade updates of the strategy's own orders,
+	// after fills have been booked
+	OnOrderUpdate(ctx context.Context, env *Env, update broker.TradeUpdateEvent) error
+
+	// OnTimer is called every Config.Interval while the strategy runs
+	OnTimer(ctx context.Context, env *Env, now time.Time) error
+}
+
+// Base implements every Strategy callback by doing nothing
+type Base struct{}
+
+func (Base) OnStart(context.Context, *Env) error                                { return nil }
+func (Base) OnBar(context.Context, *Env, marketdata.Bar) error                  { return nil }
+func (Base) OnQuote(context.Context, *Env, marketdata.Quote) error              { return nil }
+func (Base) OnOrderUpdate(context.Context, *Env, broker.TradeUpdateEvent) error { return nil }
+func (Base) OnTimer(context.Context, *Env, time.Time) error                     { return nil }
+
+// Config is what a strategy trades and where
+type Config struct {
+	// Name identifies the strategy, and is part of the client order ID of
+	// every order it places, so it must be unique and stay the same
+	// across restarts
+	Name      string
+	AccountID string
+	// Symbols are the only ones the strategy gets market data for and may
+	// place orders in
+	Symbols []string
+	// Interval is how often OnTimer is called; zero means never
+	Interval time.Duration
+}
+
+// Env is what a strategy's callbacks work with
+type Env struct {
+	Name    string
+	Symbols []string
+
+	// Broker places and manages the strategy's orders. It only lets the
+	// strategy trade its own symbols in its own account, and only sees
+	// the orders the strategy placed.
+	Broker broker.Client
+
+	// Book is the strategy's own positions and P&L
+	Book *Book
+
+	// Logger logs with the strategy's name attached
+	Logger *slog.Logger
+
+	engine *Engine
+}
+
+// Now is the time of the latest market data or timer tick, which in a
+// backtest is the time being replayed
+func (e *Env) Now() time.Time {
+	return e.engine.clock()
+}
+
+// Factory makes a new strategy of some kind for a symbol
+type Factory func(symbol string) Strategy
+
+// kinds are the built-in strategies STRATEGIES can name
+var kinds = map[string]Factory{
+	"sma_cross": func(symbol string) Strategy { return NewSMACross() },
+}
+
+// Kinds lists the built-in strategies
+func Kinds() []string {
+	names := make([]string, 0, len(kinds))
+	for name := range kinds {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Spec names a built-in strategy and the symbol to run it on
+type Spec struct {
+	Kind   string
+	Symbol string
+}
+
+// Name is the strategy's name, such as sma_cross-AAPL
+func (s Spec) Name() string {
+	return s.Kind + "-" + s.Symbol
+}
+
+// New makes the strategy
+func (s Spec) New() Strategy {
+	return kinds[s.Kind](s.Symbol)
+}
+
+// ParseSpecs parses a comma separated list of kind:SYMBOL pairs, such as
+// sma_cross:AAPL,sma_cross:MSFT
+func ParseSpecs(s string) ([]Spec, error) {
+	var specs []Spec
+	for item := range strings.SplitSeq(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		kind, symbol, ok := strings.Cut(item, ":")
+		if !ok || symbol == "" {
+			return nil, fmt.Errorf("strategy %q must be written kind:SYMBOL", item)
+		}
+		if _, ok := kinds[kind]; !ok {
+			return nil, fmt.Errorf("unknown strategy %q, expected one of %s", kind, strings.Join(Kinds(), ", "))
+		}
+		spec := Spec{Kind: kind, Symbol: strings.ToUpper(symbol)}
+		if slices.Contains(specs, spec) {
+			return nil, fmt.Errorf("strategy %s is listed twice", spec.Name())
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// Venue is where strategies place their orders
+type Venue string
+
+const (
+	// VenueSim fills orders in memory against the market data feed, and
+	// is the default
+	VenueSim Venue = "sim"
+	// VenueAlpaca places real orders with the broker
+	VenueAlpaca Venue = "alpaca"
+)
+
+// ParseVenue parses sim or alpaca
+func ParseVenue(s string) (Venue, error) {
+	switch v := Venue(s); v {
+	case VenueSim, VenueAlpaca:
+		return v, nil
+	default:
+		return "", fmt.Errorf("unknown strategy broker %q, expected sim or alpaca", s)
+	}
+}
@@ -0,0 +1,261 @@
+package taxlot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/store"
+)
+
+// Store is the subset of the sqlc generated Querier RebuildSymbol needs.
+// *db.Queries implements it.
+type Store interface {
+	ListExecutionsBySymbol(ctx context.Context, arg db.ListExecutionsBySymbolParams) ([]db.Execution, error)
+	ListLotSelections(ctx context.Context, accountID string) ([]db.LotSelection, error)
+	DeleteTaxLots(ctx context.Context, arg db.DeleteTaxLotsParams) error
+	CreateTaxLot(ctx context.Context, arg db.CreateTaxLotParams) (db.TaxLot, error)
+	CreateLotDisposal(ctx context.Context, arg db.CreateLotDisposalParams) (db.LotDisposal, error)
+}
+
+// RebuildSymbol replaces the lots and disposals of one account and symbol
+// with those matched from its executions. Call it inside the transaction
+// that changed the executions.
+func RebuildSymbol(ctx context.Context, q Store, accountID, symbol string, method Method) error {
+	rows, err := q.ListExecutionsBySymbol(ctx, db.ListExecutionsBySymbolParams{
+		AccountID: accountID,
+		Symbol:    symbol,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list executions: %w", err)
+	}
+
+	var selections []Selection
+	if method == SpecificID {
+		rows, err := q.ListLotSelections(ctx, accountID)
+		if err != nil {
+			return fmt.Errorf("failed to list lot selections: %w", err)
+		}
+		for _, row := range rows {
+			selections = append(selections, Selection{
+				OrderID:        row.OrderID,
+				LotExecutionID: row.LotExecutionID,
+				Qty:            row.Qty,
+			})
+		}
+	}
+
+	lots, disposals := Match(db.ToExecutions(rows), method, selections)
+
+	if err := q.DeleteTaxLots(ctx, db.DeleteTaxLotsParams{AccountID: accountID, Symbol: symbol}); err != nil {
+		return fmt.Errorf("failed to delete tax lots: %w", err)
+	}
+
+	lotIDs := make(map[string]int64, len(lots))
+	for _, lot := range lots {
+		row, err := q.CreateTaxLot(ctx, db.CreateTaxLotParams{
+			AccountID:    lot.AccountID,
+			Symbol:       lot.Symbol,
+			ExecutionID:  lot.ExecutionID,
+			Direction:    string(lot.Direction),
+			Qty:          lot.Qty,
+			RemainingQty: lot.Remaining,
+			Price:        lot.Price,
+			OpenedAt:     lot.OpenedAt,
+			ClosedAt:     nullTime(lot.ClosedAt),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create tax lot: %w", err)
+		}
+		lotIDs[lot.ExecutionID] = row.ID
+	}
+
+	for _, d := range disposals {
+		if _, err := q.CreateLotDisposal(ctx, db.CreateLotDisposalParams{
+			LotID:       lotIDs[d.LotExecutionID],
+			AccountID:   d.AccountID,
+			Symbol:      d.Symbol,
+			ExecutionID: d.ExecutionID,
+			Qty:         d.Qty,
+			OpenPrice:   d.OpenPrice,
+			ClosePrice:  d.ClosePrice,
+			RealizedPl:  d.RealizedPL,
+			OpenedAt:    d.OpenedAt,
+			ClosedAt:    d.ClosedAt,
+			Term:        string(d.Term),
+		}); err != nil {
+			return fmt.Errorf("failed to create lot disposal: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Ledger reads tax lots and realized gains and rebuilds them from
+// executions with one lot method.
+type Ledger struct {
+	db     *store.DB
+	method Method
+}
+
+func NewLedger(conn *store.DB, method Method) *Ledger {
+	return &Ledger{db: conn, method: method}
+}
+
+func (l *Ledger) Method() Method {
+	return l.method
+}
+
+// Rebuild rematches every account and symbol with executions in a single
+// transaction, for example after changing the lot method. It returns how
+// many symbols were rebuilt.
+func (l *Ledger) Rebuild(ctx context.Context) (int, error) {
+	rebuilt := 0
+	err := l.db.InTx(ctx, func(q db.Querier) error {
+		if err := q.DeleteAllTaxLots(ctx); err != nil {
+			return err
+		}
+
+		symbols, err := q.ListExecutedSymbols(ctx)
+		if err != nil {
+			return err
+		}
+		for _, s := range symbols {
+			if err := RebuildSymbol(ctx, q, s.AccountID, s.Symbol, l.method); err != nil {
+				return fmt.Errorf("rebuilding %s: %w", s.Symbol, err)
+			}
+			rebuilt++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return rebuilt, nil
+}
+
+// Select chooses qty of the lot opened by lotExecutionID to be closed by an
+// order, for the SpecificID method, and rematches the order's symbol. The
+// order may be given by its ID or its Alpaca order ID. Selecting the same
+// lot for the same order again replaces the quantity.
+func (l *Ledger) Select(ctx context.Context, orderID, lotExecutionID string, qty decimal.Decimal) error {
+	if !qty.IsPositive() {
+		return errors.New("quantity must be positive")
+	}
+
+	return l.db.InTx(ctx, func(q db.Querier) error {
+		o, err := q.GetOrder(ctx, orderID)
+		if errors.Is(err, sql.ErrNoRows) {
+			o, err = q.GetOrderByAlpacaID(ctx, orderID)
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("order %q not found", orderID)
+		}
+		if err != nil {
+			return err
+		}
+
+		lot, err := q.GetTaxLotByExecution(ctx, lotExecutionID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("no lot was opened by execution %q", lotExecutionID)
+		}
+		if err != nil {
+			return err
+		}
+
+		if lot.AccountID != o.AccountID || lot.Symbol != o.Symbol {
+			return fmt.Errorf("lot %s is %s in account %s, but the order is %s in account %s",
+				lotExecutionID, lot.Symbol, lot.AccountID, o.Symbol, o.AccountID)
+		}
+		closes := Long
+		if order.OrderSide(o.Side) == order.OrderSideBuy {
+			closes = Short
+		}
+		if Direction(lot.Direction) != closes {
+			return fmt.Errorf("a %s order cannot close %s lot %s", o.Side, lot.Direction, lotExecutionID)
+		}
+
+		if _, err := q.UpsertLotSelection(ctx, db.UpsertLotSelectionParams{
+			OrderID:        o.ID,
+			LotExecutionID: lotExecutionID,
+			AccountID:      o.AccountID,
+			Qty:            qty,
+		}); err != nil {
+			return fmt.Errorf("failed to save lot selection: %w", err)
+		}
+
+		return RebuildSymbol(ctx, q, o.AccountID, o.Symbol, l.method)
+	})
+}
+
+// OpenLots returns the lots with quantity left, by account, symbol and age.
+// An empty accountID returns the lots of every account.
+func (l *Ledger) OpenLots(ctx context.Context, accountID string) ([]*Lot, error) {
+	rows, err := l.db.Queries().ListOpenTaxLots(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tax lots: %w", err)
+	}
+
+	lots := make([]*Lot, 0, len(rows))
+	for _, row := range rows {
+		lots = append(lots, &Lot{
+			ID:          row.ID,
+			AccountID:   row.AccountID,
+			Symbol:      row.Symbol,
+			ExecutionID: row.ExecutionID,
+			Direction:   Direction(row.Direction),
+			Qty:         row.Qty,
+			Remaining:   row.RemainingQty,
+			Price:       row.Price,
+			OpenedAt:    row.OpenedAt,
+		})
+	}
+	return lots, nil
+}
+
+// Disposals returns the disposals closed in [from, until), oldest first. An
+// empty accountID returns the disposals of every account.
+func (l *Ledger) Disposals(ctx context.Context, accountID string, from, until time.Time) ([]*Disposal, error) {
+	rows, err := l.db.Queries().ListLotDisposals(ctx, db.ListLotDisposalsParams{
+		AccountID:   accountID,
+		ClosedFrom:  from.UTC(),
+		ClosedUntil: until.UTC(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list lot disposals: %w", err)
+	}
+
+	disposals := make([]*Disposal, 0, len(rows))
+	for _, row := range rows {
+		disposals = append(disposals, &Disposal{
+			ID:             row.ID,
+			AccountID:      row.AccountID,
+			Symbol:         row.Symbol,
+			LotExecutionID: row.LotExecutionID,
+			ExecutionID:    row.ExecutionID,
+			Direction:      Direction(row.Direction),
+			Qty:            row.Qty,
+			OpenPrice:      row.OpenPrice,
+			ClosePrice:     row.ClosePrice,
+			RealizedPL:     row.RealizedPl,
+			OpenedAt:       row.OpenedAt,
+			ClosedAt:       row.ClosedAt,
+			Term:           Term(row.Term),
+		})
+	}
+	return disposals, nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,265 @@
+package taxlot
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/snapshot"
+)
+
+// Method picks which open lots a closing fill disposes of first
+type Method string
+
+const (
+	FIFO Method = "fifo" // oldest lot first
+	LIFO Method = "lifo" // newest lot first
+	HIFO Method = "hifo" // highest cost lot first, which realizes the smallest gain
+
+	// SpecificID disposes of the lots chosen for each closing order with
+	// Select, and falls back to FIFO for any quantity left over
+	SpecificID Method = "specific"
+)
+
+// DefaultMethod is used when no method is configured
+const DefaultMethod = FIFO
+
+func ParseMethod(s string) (Method, error) {
+	switch m := Method(s); m {
+	case FIFO, LIFO, HIFO, SpecificID:
+		return m, nil
+	default:
+		return "", fmt.Errorf("unknown lot method %q: use fifo, lifo, hifo or specific", s)
+	}
+}
+
+type Direction string
+
+const (
+	Long  Direction = "long"
+	Short Direction = "short"
+)
+
+// Term is the holding period of a disposal for tax purposes
+type Term string
+
+const (
+	ShortTerm Term = "short"
+	LongTerm  Term = "long"
+)
+
+// Lot is the quantity opened by one fill. Price is the cost per share of a
+// long lot and the proceeds per share of a short one.
+type Lot struct {
+	ID          int64
+	AccountID   string
+	Symbol      string
+	ExecutionID string
+	Direction   Direction
+	Qty         decimal.Decimal
+	Remaining   decimal.Decimal
+	Price       decimal.Decimal
+	OpenedAt    time.Time
+	ClosedAt    *time.Time
+}
+
+func (l *Lot) IsOpen() bool {
+	return l.Remaining.IsPositive()
+}
+
+// CostBasis is what the remaining quantity cost to open
+func (l *Lot) CostBasis() decimal.Decimal {
+	return l.Remaining.Mul(l.Price)
+}
+
+// Disposal is the part of a lot closed by one fill
+type Disposal struct {
+	ID             int64
+	AccountID      string
+	Symbol         string
+	LotExecutionID string
+	ExecutionID    string
+	Direction      Direction
+	Qty            decimal.Decimal
+	OpenPrice      decimal.Decimal
+	ClosePrice     decimal.Decimal
+	RealizedPL     decimal.Decimal
+	OpenedAt       time.Time
+	ClosedAt       time.Time
+	Term           Term
+}
+
+// Proceeds is what the disposal sold for: the closing sale of a long lot,
+// or the opening short sale of a short one.
+func (d *Disposal) Proceeds() decimal.Decimal {
+	if d.Direction == Short {
+		return d.Qty.Mul(d.OpenPrice)
+	}
+	return d.Qty.Mul(d.ClosePrice)
+}
+
+// CostBasis is what the disposal cost: the opening purchase of a long lot,
+// or the closing buy-to-cover of a short one.
+func (d *Disposal) CostBasis() decimal.Decimal {
+	if d.Direction == Short {
+		return d.Qty.Mul(d.ClosePrice)
+	}
+	return d.Qty.Mul(d.OpenPrice)
+}
+
+// Selection asks for a closing order to dispose of part of a specific lot
+type Selection struct {
+	OrderID        string
+	LotExecutionID string
+	Qty            decimal.Decimal
+}
+
+// Match replays the executions of one account and symbol, oldest first, and
+// returns every lot they opened and every disposal that closed part of one.
+// Fills close open lots in the other direction first; whatever is left
+// opens a new lot. Selections are only used by SpecificID.
+func Match(executions []*order.Execution, method Method, selections []Selection) ([]*Lot, []*Disposal) {
+	var lots []*Lot
+	var disposals []*Disposal
+
+	// What is left of each selection, as an order may close in several fills
+	selected := make(map[string][]*Selection)
+	if method == SpecificID {
+		for _, s := range selections {
+			selected[s.OrderID] = append(selected[s.OrderID], &s)
+		}
+	}
+
+	for _, e := range executions {
+		closes := Long
+		if e.Side == order.OrderSideBuy {
+			closes = Short
+		}
+		remaining := e.Qty
+
+		dispose := func(lot *Lot, qty decimal.Decimal) {
+			lot.Remaining = lot.Remaining.Sub(qty)
+			if !lot.IsOpen() {
+				closedAt := e.ExecutedAt
+				lot.ClosedAt = &closedAt
+			}
+			remaining = remaining.Sub(qty)
+			disposals = append(disposals, newDisposal(lot, e, qty))
+		}
+
+		for _, s := range selected[e.OrderID] {
+			lot := findOpen(lots, s.LotExecutionID, closes)
+			if lot == nil || !remaining.IsPositive() {
+				continue
+			}
+			qty := decimal.Min(s.Qty, lot.Remaining, remaining)
+			if qty.IsPositive() {
+				s.Qty = s.Qty.Sub(qty)
+				dispose(lot, qty)
+			}
+		}
+
+		for _, lot := range disposalOrder(lots, closes, method) {
+			if !remaining.IsPositive() {
+				break
+			}
+			dispose(lot, decimal.Min(lot.Remaining, remaining))
+		}
+
+		if remaining.IsPositive() {
+			direction := Long
+			if e.Side == order.OrderSideSell {
+				direction = Short
+			}
+			lots = append(lots, &Lot{
+				AccountID:   e.AccountID,
+				Symbol:      e.Symbol,
+				ExecutionID: e.ID,
+				Direction:   direction,
+				Qty:         remaining,
+				Remaining:   remaining,
+				Price:       e.Price,
+				OpenedAt:    e.ExecutedAt,
+			})
+		}
+	}
+
+	return lots, disposals
+}
+
+func findOpen(lots []*Lot, executionID string, direction Direction) *Lot {
+	for _, lot := range lots {
+		if lot.ExecutionID == executionID && lot.Direction == direction && lot.IsOpen() {
+			return lot
+		}
+	}
+	return nil
+}
+
+// disposalOrder returns the open lots in one direction in the order method
+// closes them. lots is in the order the lots were opened.
+func disposalOrder(lots []*Lot, direction Direction, method Method) []*Lot {
+	var open []*Lot
+	for _, lot := range lots {
+		if lot.Direction == direction && lot.IsOpen() {
+			open = append(open, lot)
+		}
+	}
+
+	switch method {
+	case LIFO:
+		for i, j := 0, len(open)-1; i < j; i, j = i+1, j-1 {
+			open[i], open[j] = open[j], open[i]
+		}
+	case HIFO:
+		// For a short lot the price is the proceeds, so the lowest one
+		// realizes the smallest gain
+		sort.SliceStable(open, func(i, j int) bool {
+			if direction == Short {
+				return open[i].Price.LessThan(open[j].Price)
+			}
+			return open[i].Price.GreaterThan(open[j].Price)
+		})
+	}
+	return open
+}
+
+func newDisposal(lot *Lot, e *order.Execution, qty decimal.Decimal) *Disposal {
+	pl := e.Price.Sub(lot.Price).Mul(qty)
+	term := holdingTerm(lot.OpenedAt, e.ExecutedAt)
+	if lot.Direction == Short {
+		pl = pl.Neg()
+		// Gains on a short sale are short-term however long it was open
+		term = ShortTerm
+	}
+
+	return &Disposal{
+		AccountID:      lot.AccountID,
+		Symbol:         lot.Symbol,
+		LotExecutionID: lot.ExecutionID,
+		ExecutionID:    e.ID,
+		Direction:      lot.Direction,
+		Qty:            qty,
+		OpenPrice:      lot.Price,
+		ClosePrice:     e.Price,
+		RealizedPL:     pl,
+		OpenedAt:       lot.OpenedAt,
+		ClosedAt:       e.ExecutedAt,
+		Term:           term,
+	}
+}
+
+// holdingTerm is long-term when the lot was held for more than a year, that
+// is, closed after the anniversary of the trade date it was opened on.
+// Trade dates are the exchange's, so an evening fill counts on the day it
+// was made in New York.
+func holdingTerm(openedAt, closedAt time.Time) Term {
+	anniversary := snapshot.TradingDate(openedAt).AddDate(1, 0, 0)
+	if snapshot.TradingDate(closedAt).After(anniversary) {
+		return LongTerm
+	}
+	return ShortTerm
+}
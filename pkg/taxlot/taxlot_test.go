@@ -0,0 +1,165 @@
+package taxlot_test
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/taxlot"
+)
+
+// day is a fixed time during New York trading hours
+var day = time.Date(2025, time.March, 3, 15, 0, 0, 0, time.UTC)
+
+func execution(id string, side order.OrderSide, qty, price string, at time.Time) *order.Execution {
+	return &order.Execution{
+		ID:         id,
+		OrderID:    "order-" + id,
+		AccountID:  "acct-1",
+		Symbol:     "AAPL",
+		Side:       side,
+		Qty:        decimal.RequireFromString(qty),
+		Price:      decimal.RequireFromString(price),
+		ExecutedAt: at,
+	}
+}
+
+// Three buys at different prices, then a sale of more than one lot
+var buys = []*order.Execution{
+	execution("b1", order.OrderSideBuy, "10", "100", day),
+	execution("b2", order.OrderSideBuy, "10", "120", day.Add(24*time.Hour)),
+	execution("b3", order.OrderSideBuy, "10", "110", day.Add(48*time.Hour)),
+}
+
+var sale = execution("s1", order.OrderSideSell, "15", "130", day.Add(72*time.Hour))
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     taxlot.Method
+		selections []taxlot.Selection
+		// disposals are lot:qty:realized P/L, and open lots lot:remaining
+		disposals []string
+		open      []string
+	}{
+		{"fifo", taxlot.FIFO, nil,
+			[]string{"b1:10:300", "b2:5:50"}, []string{"b2:5", "b3:10"}},
+		{"lifo", taxlot.LIFO, nil,
+			[]string{"b3:10:200", "b2:5:50"}, []string{"b1:10", "b2:5"}},
+		{"hifo", taxlot.HIFO, nil,
+			[]string{"b2:10:100", "b3:5:100"}, []string{"b1:10", "b3:5"}},
+		{"specific, then fifo for the rest", taxlot.SpecificID,
+			[]taxlot.Selection{{OrderID: sale.OrderID, LotExecutionID: "b3", Qty: decimal.NewFromInt(4)}},
+			[]string{"b3:4:80", "b1:10:300", "b2:1:10"}, []string{"b2:9", "b3:6"}},
+		{"selections only for specific", taxlot.FIFO,
+			[]taxlot.Selection{{OrderID: sale.OrderID, LotExecutionID: "b3", Qty: decimal.NewFromInt(4)}},
+			[]string{"b1:10:300", "b2:5:50"}, []string{"b2:5", "b3:10"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lots, disposals := taxlot.Match(append(slices.Clone(buys), sale), tt.method, tt.selections)
+
+			var gotDisposals []string
+			for _, d := range disposals {
+				gotDisposals = append(gotDisposals, fmt.Sprintf("%s:%s:%s", d.LotExecutionID, d.Qty, d.RealizedPL))
+			}
+			if !slices.Equal(gotDisposals, tt.disposals) {
+				t.Errorf("disposals = %v, want %v", gotDisposals, tt.disposals)
+			}
+
+			var gotOpen []string
+			for _, lot := range lots {
+				if lot.IsOpen() {
+					gotOpen = append(gotOpen, fmt.Sprintf("%s:%s", lot.ExecutionID, lot.Remaining))
+				} else if lot.ClosedAt == nil || !lot.ClosedAt.Equal(sale.ExecutedAt) {
+					t.Errorf("lot %s closed at %v, want %s", lot.ExecutionID, lot.ClosedAt, sale.ExecutedAt)
+				}
+			}
+			if !slices.Equal(gotOpen, tt.open) {
+				t.Errorf("open lots = %v, want %v", gotOpen, tt.open)
+			}
+		})
+	}
+}
+
+// TestMatchPartialCloses checks a lot closed over several fills, and a sale
+// larger than the position opening a short lot with what is left
+func TestMatchPartialCloses(t *testing.T) {
+	lots, disposals := taxlot.Match([]*order.Execution{
+		execution("b1", order.OrderSideBuy, "10", "100", day),
+		execution("s1", order.OrderSideSell, "4", "105", day.Add(time.Hour)),
+		execution("s2", order.OrderSideSell, "10", "95", day.Add(2*time.Hour)),
+		execution("b2", order.OrderSideBuy, "4", "90", day.Add(3*time.Hour)),
+	}, taxlot.FIFO, nil)
+
+	want := []string{
+		"long b1 by s1: 4 for 20",
+		"long b1 by s2: 6 for -30",
+		"short s2 by b2: 4 for 20",
+	}
+	var got []string
+	for _, d := range disposals {
+		got = append(got, fmt.Sprintf("%s %s by %s: %s for %s", d.Direction, d.LotExecutionID, d.ExecutionID, d.Qty, d.RealizedPL))
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("disposals = %v, want %v", got, want)
+	}
+
+	for _, lot := range lots {
+		if lot.IsOpen() {
+			t.Errorf("lot %s still has %s open", lot.ExecutionID, lot.Remaining)
+		}
+	}
+}
+
+func TestMatchHoldingTerm(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		side     order.OrderSide
+		openedAt time.Time
+		closedAt time.Time
+		want     taxlot.Term
+	}{
+		{"within a year", order.OrderSideBuy, day, day.AddDate(0, 6, 0), taxlot.ShortTerm},
+		{"on the anniversary", order.OrderSideBuy, day, day.AddDate(1, 0, 0), taxlot.ShortTerm},
+		{"the day after", order.OrderSideBuy, day, day.AddDate(1, 0, 1), taxlot.LongTerm},
+		// 11pm in New York is the next day in UTC, but the trade date is the
+		// 3rd, so a sale on the 4th is a year and a day later
+		{"evening buy", order.OrderSideBuy,
+			time.Date(2025, time.March, 3, 23, 0, 0, 0, newYork),
+			time.Date(2026, time.March, 4, 10, 0, 0, 0, newYork), taxlot.LongTerm},
+		// And an evening sale on the anniversary is still on it
+		{"evening sale", order.OrderSideBuy,
+			time.Date(2025, time.June, 2, 10, 0, 0, 0, newYork),
+			time.Date(2026, time.June, 2, 22, 0, 0, 0, newYork), taxlot.ShortTerm},
+		{"short sale", order.OrderSideSell, day, day.AddDate(2, 0, 0), taxlot.ShortTerm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closing := order.OrderSideSell
+			if tt.side == order.OrderSideSell {
+				closing = order.OrderSideBuy
+			}
+			_, disposals := taxlot.Match([]*order.Execution{
+				execution("open", tt.side, "1", "100", tt.openedAt),
+				execution("close", closing, "1", "110", tt.closedAt),
+			}, taxlot.FIFO, nil)
+
+			if len(disposals) != 1 {
+				t.Fatalf("got %d disposals, want 1", len(disposals))
+			}
+			if got := disposals[0].Term; got != tt.want {
+				t.Errorf("term = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
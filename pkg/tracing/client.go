@@ -0,0 +1,182 @@
+package tracing
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/watchlist"
+)
+
+// Client wraps a broker.Client with a span for every call, carrying the
+// IDs the call works on. The HTTP round trips show up inside it when the
+// client below is an AlpacaClient whose transport is wrapped with Transport.
+// The event stream is passed through untraced, as it lasts as long as the
+// process.
+type Client struct {
+	broker.Client
+}
+
+var _ broker.Client = (*Client)(nil)
+
+func NewClient(client broker.Client) *Client {
+	return &Client{Client: client}
+}
+
+// start starts the span for a call to method; attributes with empty values
+// are left out
+func start(ctx context.Context, method string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	set := attrs[:0]
+	for _, a := range attrs {
+		if a.Value.AsString() != "" {
+			set = append(set, a)
+		}
+	}
+	return Tracer().Start(ctx, "broker."+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(set...))
+}
+
+// end ends span with the call's error; deferred with a pointer to it
+func end(span trace.Span, err *error) {
+	End(span, *err)
+}
+
+// setOrder adds the ID of the order a call returned
+func setOrder(span trace.Span, o *order.Order) {
+	if o != nil {
+		span.SetAttributes(OrderIDKey.String(o.AlpacaOrderID), SymbolKey.String(o.Symbol))
+	}
+}
+
+func (c *Client) GetAccount(ctx context.Context, accountID string) (_ *account.Account, err error) {
+	ctx, span := start(ctx, "GetAccount", AccountIDKey.String(accountID))
+	defer end(span, &err)
+	return c.Client.GetAccount(ctx, accountID)
+}
+
+func (c *Client) ListAccounts(ctx context.Context) (_ []*account.Account, err error) {
+	ctx, span := start(ctx, "ListAccounts")
+	defer end(span, &err)
+	return c.Client.ListAccounts(ctx)
+}
+
+func (c *Client) GetPortfolioHistory(ctx context.Context, accountID, period string) (_ []*account.EquityPoint, err error) {
+	ctx, span := start(ctx, "GetPortfolioHistory", AccountIDKey.String(accountID))
+	defer end(span, &err)
+	return c.Client.GetPortfolioHistory(ctx, accountID, period)
+}
+
+func (c *Client) ListTradingDays(ctx context.Context, from, to time.Time) (_ []time.Time, err error) {
+	ctx, span := start(ctx, "ListTradingDays")
+	defer end(span, &err)
+	return c.Client.ListTradingDays(ctx, from, to)
+}
+
+func (c *Client) CreateOrder(ctx context.Context, req *order.CreateOrderRequest) (_ *order.Order, err error) {
+	ctx, span := start(ctx, "CreateOrder",
+		AccountIDKey.String(req.AccountID),
+		SymbolKey.String(req.Symbol),
+		ClientOrderIDKey.String(req.ClientOrderID))
+	defer end(span, &err)
+
+	o, err := c.Client.CreateOrder(ctx, req)
+	setOrder(span, o)
+	return o, err
+}
+
+func (c *Client) GetOrder(ctx context.Context, orderID string) (_ *order.Order, err error) {
+	ctx, span := start(ctx, "GetOrder", OrderIDKey.String(orderID))
+	defer end(span, &err)
+	return c.Client.GetOrder(ctx, orderID)
+}
+
+func (c *Client) GetOrderByClientOrderID(ctx context.Context, clientOrderID string) (_ *order.Order, err error) {
+	ctx, span := start(ctx, "GetOrderByClientOrderID", ClientOrderIDKey.String(clientOrderID))
+	defer end(span, &err)
+
+	o, err := c.Client.GetOrderByClientOrderID(ctx, clientOrderID)
+	setOrder(span, o)
+	return o, err
+}
+
+func (c *Client) ListOrders(ctx context.Context, accountID string, req *order.ListOrdersRequest) (_ []*order.Order, err error) {
+	ctx, span := start(ctx, "ListOrders", AccountIDKey.String(accountID))
+	defer end(span, &err)
+	return c.Client.ListOrders(ctx, accountID, req)
+}
+
+func (c *Client) ReplaceOrder(ctx context.Context, orderID string, req *order.ReplaceOrderRequest) (_ *order.Order, err error) {
+	ctx, span := start(ctx, "ReplaceOrder", OrderIDKey.String(orderID))
+	defer end(span, &err)
+	return c.Client.ReplaceOrder(ctx, orderID, req)
+}
+
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (err error) {
+	ctx, span := start(ctx, "CancelOrder", OrderIDKey.String(orderID))
+	defer end(span, &err)
+	return c.Client.CancelOrder(ctx, orderID)
+}
+
+func (c *Client) ListPositions(ctx context.Context, accountID string) (_ []*position.Position, err error) {
+	ctx, span := start(ctx, "ListPositions", AccountIDKey.String(accountID))
+	defer end(span, &err)
+	return c.Client.ListPositions(ctx, accountID)
+}
+
+func (c *Client) ClosePosition(ctx context.Context, accountID, symbol string) (_ *order.Order, err error) {
+	ctx, span := start(ctx, "ClosePosition", AccountIDKey.String(accountID), SymbolKey.String(symbol))
+	defer end(span, &err)
+
+	o, err := c.Client.ClosePosition(ctx, accountID, symbol)
+	setOrder(span, o)
+	return o, err
+}
+
+func (c *Client) ListWatchlists(ctx context.Context, accountID string) (_ []*watchlist.Watchlist, err error) {
+	ctx, span := start(ctx, "ListWatchlists", AccountIDKey.String(accountID))
+	defer end(span, &err)
+	return c.Client.ListWatchlists(ctx, accountID)
+}
+
+func (c *Client) GetWatchlist(ctx context.Context, watchlistID string) (_ *watchlist.Watchlist, err error) {
+	ctx, span := start(ctx, "GetWatchlist", WatchlistIDKey.String(watchlistID))
+	defer end(span, &err)
+	return c.Client.GetWatchlist(ctx, watchlistID)
+}
+
+func (c *Client) CreateWatchlist(ctx context.Context, req *watchlist.CreateWatchlistRequest) (_ *watchlist.Watchlist, err error) {
+	ctx, span := start(ctx, "CreateWatchlist")
+	defer end(span, &err)
+	return c.Client.CreateWatchlist(ctx, req)
+}
+
+func (c *Client) UpdateWatchlist(ctx context.Context, watchlistID string, req *watchlist.UpdateWatchlistRequest) (_ *watchlist.Watchlist, err error) {
+	ctx, span := start(ctx, "UpdateWatchlist", WatchlistIDKey.String(watchlistID))
+	defer end(span, &err)
+	return c.Client.UpdateWatchlist(ctx, watchlistID, req)
+}
+
+func (c *Client) AddWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (_ *watchlist.Watchlist, err error) {
+	ctx, span := start(ctx, "AddWatchlistSymbol", WatchlistIDKey.String(watchlistID), SymbolKey.String(symbol))
+	defer end(span, &err)
+	return c.Client.AddWatchlistSymbol(ctx, watchlistID, symbol)
+}
+
+func (c *Client) RemoveWatchlistSymbol(ctx context.Context, watchlistID, symbol string) (err error) {
+	ctx, span := start(ctx, "RemoveWatchlistSymbol", WatchlistIDKey.String(watchlistID), SymbolKey.String(symbol))
+	defer end(span, &err)
+	return c.Client.RemoveWatchlistSymbol(ctx, watchlistID, symbol)
+}
+
+func (c *Client) DeleteWatchlist(ctx context.Context, watchlistID string) (err error) {
+	ctx, span := start(ctx, "DeleteWatchlist", WatchlistIDKey.String(watchlistID))
+	defer end(span, &err)
+	return c.Client.DeleteWatchlist(ctx, watchlistID)
+}
@@ -0,0 +1,196 @@
+// Package tracing sets up OpenTelemetry tracing for TUI commands, broker
+// calls and database queries, exported over OTLP or to a file.
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/revrost/pony/pkg/broker"
+)
+
+// Exporter is where finished spans go
+type Exporter string
+
+const (
+	// ExporterNone turns tracing off
+	ExporterNone Exporter = ""
+
+	// ExporterOTLP sends spans over OTLP/HTTP to the collector at
+	// OTEL_EXPORTER_OTLP_ENDPOINT, http://localhost:4318 by default
+	ExporterOTLP Exporter = "otlp"
+
+	// ExporterFile appends spans to a file as JSON, one per line
+	ExporterFile Exporter = "file"
+)
+
+// ParseExporter parses none, otlp or file
+func ParseExporter(s string) (Exporter, error) {
+	switch e := Exporter(s); e {
+	case ExporterOTLP, ExporterFile:
+		return e, nil
+	case "none", ExporterNone:
+		return ExporterNone, nil
+	default:
+		return "", fmt.Errorf("unknown trace exporter %q, expected none, otlp or file", s)
+	}
+}
+
+// Options configures Setup
+type Options struct {
+	// Service names the process in the traces, such as pony or pony-worker
+	Service  string
+	Exporter Exporter
+	// File is where ExporterFile writes
+	File string
+}
+
+// DefaultFile is traces.json in pony's directory in the user's cache
+// directory, or in the current directory when there is none
+func DefaultFile() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "traces.json"
+	}
+	return filepath.Join(dir, "pony", "traces.json")
+}
+
+const tracerName = "github.com/revrost/pony"
+
+// Tracer is the tracer pony's spans are started from. Until Setup installs
+// an exporter its spans are not recorded.
+func Tracer() trace.Tracer {
+	return otel.Tracer(tracerName)
+}
+
+// Setup installs the global tracer provider for opts. The returned shutdown
+// flushes the spans not exported yet, and must be called before exiting.
+func Setup(ctx context.Context, opts Options) (shutdown func(context.Context) error, err error) {
+	var exporter sdktrace.SpanExporter
+	var file *os.File
+	switch opts.Exporter {
+	case ExporterNone:
+		return func(context.Context) error { return nil }, nil
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create OTLP trace exporter: %w", err)
+		}
+	case ExporterFile:
+		if err := os.MkdirAll(filepath.Dir(opts.File), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create trace directory: %w", err)
+		}
+		file, err = os.OpenFile(opts.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open trace file: %w", err)
+		}
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(file))
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to create trace file exporter: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("unknown trace exporter %q", opts.Exporter)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(opts.Service),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe trace resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return func(ctx context.Context) error {
+		err := provider.Shutdown(ctx)
+		if file != nil {
+			err = errors.Join(err, file.Close())
+		}
+		return err
+	}, nil
+}
+
+// Attribute keys for the objects a span works on
+const (
+	AccountIDKey     = attribute.Key("pony.account_id")
+	OrderIDKey       = attribute.Key("pony.order_id")
+	ClientOrderIDKey = attribute.Key("pony.client_order_id")
+	SymbolKey        = attribute.Key("pony.symbol")
+	WatchlistIDKey   = attribute.Key("pony.watchlist_id")
+	EventIDKey       = attribute.Key("pony.event_id")
+	EventTypeKey     = attribute.Key("pony.event_type")
+	StrategyKey      = attribute.Key("pony.strategy")
+	ScheduleIDKey    = attribute.Key("pony.schedule_id")
+	AlertIDKey       = attribute.Key("pony.alert_id")
+)
+
+// EventAttributes describe a broker event and the account and order it is
+// about
+func EventAttributes(event broker.Event) []attribute.KeyValue {
+	meta := event.Metadata()
+	attrs := []attribute.KeyValue{
+		EventIDKey.String(meta.ID),
+		EventTypeKey.String(string(event.Type())),
+		AccountIDKey.String(meta.AccountID),
+	}
+	if e, ok := event.(broker.TradeUpdateEvent); ok && e.Order != nil {
+		attrs = append(attrs, OrderIDKey.String(e.Order.AlpacaOrderID), SymbolKey.String(e.Order.Symbol))
+	}
+	return attrs
+}
+
+// End records err on span, if any, and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Transport adds a client span for every HTTP round trip through next,
+// parented to the span in the request's context
+func Transport(next http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(next)
+}
+
+// QueryHook traces each database query, named after its sqlc query, as a
+// store.QueryHook. dialect is the database's, postgres or sqlite.
+func QueryHook(dialect string) func(ctx context.Context, name string) func(error) {
+	system := semconv.DBSystemNameKey.String(dialect)
+	if dialect == "postgres" {
+		system = semconv.DBSystemNamePostgreSQL
+	}
+
+	return func(ctx context.Context, name string) func(error) {
+		_, span := Tracer().Start(ctx, "db."+name,
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(system, semconv.DBOperationName(name)))
+		return func(err error) {
+			End(span, err)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package tui
+
+import (
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"github.com/revrost/pony/pkg/alert"
+)
+
+// alertFormFields is how many fields AlertForm has
+const alertFormFields = 2
+
+// AlertForm is the form the Alerts view creates a rule with
+type AlertForm struct {
+	rule       string
+	notify     string
+	focusIndex int
+}
+
+func NewAlertForm() AlertForm {
+	return AlertForm{notify: alert.JoinChannels(alert.DefaultChannels)}
+}
+
+// Update handles navigation and input. Enter is handled by the Model, which
+// saves Rule().
+func (f AlertForm) Update(msg tea.KeyMsg) (AlertForm, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "down", "shift+tab", "up":
+		f.focusIndex = (f.focusIndex + 1) % alertFormFields
+		return f, nil
+
+	default:
+		if f.focusIndex == 0 {
+			f.rule = editText(f.rule, msg.String())
+		} else {
+			f.notify = editText(f.notify, msg.String())
+		}
+		return f, nil
+	}
+}
+
+// Rule validates the form and builds the rule to save, for every account
+func (f AlertForm) Rule() (*alert.Rule, error) {
+	channels, err := alert.ParseChannels(f.notify)
+	if err != nil {
+		return nil, err
+	}
+	return alert.New("", f.rule, channels)
+}
+
+func (f AlertForm) View() string {
+	cursor := func(active bool) string {
+		if active {
+			return ">"
+		}
+		return " "
+	}
+
+	return fmt.Sprintf(`
+%s Rule:    %s
+%s Notify:  %s  (toast, bell, webhook or command, comma-separated)
+
+Rules look like:
+  AAPL last > 200          (or bid, ask, mid)
+  position P/L < -5%%       position TSLA P/L < -$500
+  buying power < $1k       (or cash, portfolio value)
+  order rejected           order AAPL filled
+  account status changed
+
+Press [Enter] to save
+`,
+		cursor(f.focusIndex == 0), f.rule,
+		cursor(f.focusIndex == 1), f.notify,
+	)
+}
@@ -0,0 +1,266 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/tracing"
+)
+
+// toastDuration is how long an alert's toast stays up
+const toastDuration = 8 * time.Second
+
+var toastStyle = lipgloss.NewStyle().
+	Foreground(lipgloss.Color("214")).
+	Bold(true).
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("214")).
+	Padding(0, 1)
+
+type alertsLoadedMsg struct {
+	rules []*alert.Rule
+}
+
+// alertSavedMsg means a rule was created, changed or deleted
+type alertSavedMsg struct {
+	status string
+}
+
+// toastExpiredMsg takes down the toast it was started for, unless a newer
+// one replaced it
+type toastExpiredMsg struct {
+	seq int
+}
+
+// toast is the latest alert shown over the views
+type toast struct {
+	alert *alert.Alert
+	// more is how many other alerts arrived with it
+	more int
+	seq  int
+}
+
+func loadAlerts(store Store) tea.Cmd {
+	return traced("loadAlerts", nil, func(ctx context.Context) tea.Msg {
+		rows, err := store.ListAlerts(ctx)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return alertsLoadedMsg{rules: db.ToAlertRules(rows)}
+	})
+}
+
+func createAlert(store Store, r *alert.Rule) tea.Cmd {
+	return traced("createAlert", nil, func(ctx context.Context) tea.Msg {
+		row, err := store.CreateAlert(ctx, db.NewCreateAlertParams(r))
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return alertSavedMsg{status: fmt.Sprintf("Saved alert %d", row.ID)}
+	})
+}
+
+func setAlertEnabled(store Store, id int64, enabled bool) tea.Cmd {
+	return traced("setAlertEnabled", alertAttrs(id), func(ctx context.Context) tea.Msg {
+		if _, err := store.SetAlertEnabled(ctx, db.SetAlertEnabledParams{ID: id, Enabled: enabled}); err != nil {
+			return errMsg{err: err}
+		}
+		if enabled {
+			return alertSavedMsg{status: fmt.Sprintf("Enabled alert %d", id)}
+		}
+		return alertSavedMsg{status: fmt.Sprintf("Disabled alert %d", id)}
+	})
+}
+
+func deleteAlert(store Store, id int64) tea.Cmd {
+	return traced("deleteAlert", alertAttrs(id), func(ctx context.Context) tea.Msg {
+		if _, err := store.DeleteAlert(ctx, id); err != nil {
+			return errMsg{err: err}
+		}
+		return alertSavedMsg{status: fmt.Sprintf("Deleted alert %d", id)}
+	})
+}
+
+func alertAttrs(id int64) []attribute.KeyValue {
+	return []attribute.KeyValue{tracing.AlertIDKey.Int64(id)}
+}
+
+func (m Model) handleAlertsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "n":
+		m.currentView = ViewNewAlert
+		m.alertForm = NewAlertForm()
+		m.status = ""
+		return m, nil
+	}
+
+	if len(m.alertRules) == 0 {
+		return m, nil
+	}
+	selected := m.alertRules[m.alertCursor]
+
+	switch msg.String() {
+	case "down", "j":
+		if m.alertCursor < len(m.alertRules)-1 {
+			m.alertCursor++
+		}
+		return m, nil
+
+	case "up", "k":
+		if m.alertCursor > 0 {
+			m.alertCursor--
+		}
+		return m, nil
+
+	case "e":
+		return m, setAlertEnabled(m.store, selected.ID, !selected.Enabled)
+
+	case "d":
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Delete alert %d (%s)?", selected.ID, selected.Expr),
+			cmd:    deleteAlert(m.store, selected.ID),
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) handleNewAlertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = ViewAlerts
+		return m, nil
+
+	case "enter":
+		r, err := m.alertForm.Rule()
+		if err != nil {
+			m.status = "Invalid alert: " + err.Error()
+			return m, nil
+		}
+		m.status = ""
+		return m, createAlert(m.store, r)
+	}
+
+	updatedForm, cmd := m.alertForm.Update(msg)
+	m.alertForm = updatedForm
+	return m, cmd
+}
+
+// handleAlert shows the alerts fired in the background as a toast, and
+// reports the errors of checking and delivering them
+func (m Model) handleAlert(msg AlertMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		slog.Error("alerts failed", "error", msg.Err)
+		m.err = msg.Err
+	}
+
+	var shown []*alert.Alert
+	for _, a := range msg.Alerts {
+		if a.Notifies(alert.ChannelToast) {
+			shown = append(shown, a)
+		}
+	}
+
+	var cmds []tea.Cmd
+	if len(shown) > 0 {
+		seq := 1
+		if m.toast != nil {
+			seq = m.toast.seq + 1
+		}
+		m.toast = &toast{alert: shown[len(shown)-1], more: len(shown) - 1, seq: seq}
+		cmds = append(cmds, tea.Tick(toastDuration, func(time.Time) tea.Msg {
+			return toastExpiredMsg{seq: seq}
+		}))
+	}
+	if len(msg.Alerts) > 0 && m.currentView == ViewAlerts {
+		cmds = append(cmds, loadAlerts(m.store))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func renderToast(m Model) string {
+	a := m.toast.alert
+	text := fmt.Sprintf("Alert: %s\n%s", a.Rule, a.Message)
+	if m.toast.more > 0 {
+		text += fmt.Sprintf("\n(and %d more; see the Alerts view)", m.toast.more)
+	}
+	return toastStyle.Render(text)
+}
+
+func renderAlerts(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Alerts"))
+	b.WriteString("\n\n")
+
+	if len(m.alertRules) == 0 {
+		b.WriteString(infoStyle.Render("No alerts; press 'n' to add one"))
+		b.WriteString("\n\n")
+	} else {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("  %-4s %-30s %-16s %-8s %-6s %-18s %s",
+			"ID", "Rule", "Notify", "Enabled", "Fired", "Last Fired", "Last Message")))
+		b.WriteString("\n")
+
+		for i, r := range m.alertRules {
+			cursor := " "
+			if i == m.alertCursor {
+				cursor = ">"
+			}
+
+			last := "-"
+			if r.LastTriggeredAt != nil {
+				last = r.LastTriggeredAt.Local().Format("2006-01-02 15:04")
+			}
+			enabled := successStyle.Render(fmt.Sprintf("%-8s", "yes"))
+			if !r.Enabled {
+				enabled = infoStyle.Render(fmt.Sprintf("%-8s", "no"))
+			}
+
+			b.WriteString(fmt.Sprintf("%s %-4d %-30s %-16s %s %-6d %-18s %s\n",
+				cursor,
+				r.ID,
+				r.Expr,
+				alert.JoinChannels(r.Notify),
+				enabled,
+				r.TriggerCount,
+				last,
+				orDash(r.LastMessage),
+			))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(renderPrompt(m, "[n] New  [e] Enable/disable  [d] Delete"))
+	b.WriteString(renderNavigation())
+
+	return b.String()
+}
+
+func renderNewAlert(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("New Alert"))
+	b.WriteString("\n\n")
+
+	b.WriteString(m.alertForm.View())
+	b.WriteString("\n")
+	b.WriteString(infoStyle.Render("Alerts cover every account, and new rules are picked up within 30 seconds"))
+	b.WriteString("\n\n")
+
+	b.WriteString(renderPrompt(m, "Press 'esc' to cancel"))
+
+	return b.String()
+}
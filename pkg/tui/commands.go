@@ -2,57 +2,303 @@ package tui
 
 import (
 	"context"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/revrost/pony/pkg/account"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/revrost/pony/pkg/audit"
 	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/history"
+	"github.com/revrost/pony/pkg/logging"
 	"github.com/revrost/pony/pkg/order"
-	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/snapshot"
+	"github.com/revrost/pony/pkg/tracing"
+	"github.com/revrost/pony/pkg/watchlist"
 )
 
-// Commands for async operations
-// These will use sqlc generated methods once we run `sqlc generate`
+// Commands for async operations. Each runs in its own trace span, so the
+// broker calls and queries it makes show up together.
+
+const (
+	// ordersPageSize is how many orders one page of the Orders view shows
+	ordersPageSize = 20
+
+	// statsWindow is how far back the order stats view looks
+	statsWindow = 30 * 24 * time.Hour
+)
 
 func loadAccounts(store Store) tea.Cmd {
-	return func() tea.Msg {
-		// TODO: Use store.ListAccounts() once sqlc generates it
-		// For now, return empty list
-		return accountsLoadedMsg{accounts: []*account.Account{}}
+	return traced("loadAccounts", nil, func(ctx context.Context) tea.Msg {
+		rows, err := store.ListAccounts(ctx)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return accountsLoadedMsg{accounts: db.ToAccounts(rows)}
+	})
+}
+
+func loadPerformance(store Store, accountID string) tea.Cmd {
+	return traced("loadPerformance", accountAttrs(accountID), func(ctx context.Context) tea.Msg {
+		summary, err := snapshot.LoadSummary(ctx, store, accountID, time.Now())
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return performanceLoadedMsg{summary: summary}
+	})
+}
+
+func loadOrders(store Store, filter history.Filter, after history.Cursor) tea.Cmd {
+	return traced("loadOrders", accountAttrs(filter.AccountID), func(ctx context.Context) tea.Msg {
+		page, err := history.Search(ctx, store, filter, after, ordersPageSize)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return ordersLoadedMsg{page: page}
+	})
+}
+
+func loadOrderStats(store Store, accountID string) tea.Cmd {
+	return traced("loadOrderStats", accountAttrs(accountID), func(ctx context.Context) tea.Msg {
+		from := time.Now().Add(-statsWindow)
+		byDay, err := history.StatsByDay(ctx, store, accountID, from, time.Time{})
+		if err != nil {
+			return errMsg{err: err}
+		}
+		bySymbol, err := history.StatsBySymbol(ctx, store, accountID, from, time.Time{})
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return orderStatsLoadedMsg{byDay: byDay, bySymbol: bySymbol}
+	})
+}
+
+func loadExecutions(store Store, orderID string) tea.Cmd {
+	return traced("loadExecutions", []attribute.KeyValue{tracing.OrderIDKey.String(orderID)}, func(ctx context.Context) tea.Msg {
+		rows, err := store.ListExecutionsByOrder(ctx, orderID)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return executionsLoadedMsg{orderID: orderID, executions: db.ToExecutions(rows)}
+	})
+}
+
+func loadPositions(store Store, accountID string) tea.Cmd {
+	return traced("loadPositions", accountAttrs(accountID), func(ctx context.Context) tea.Msg {
+		rows, err := store.ListPositions(ctx, accountID)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return positionsLoadedMsg{positions: db.ToPositions(rows)}
+	})
+}
+
+func loadAudit(store Store, accountID string) tea.Cmd {
+	return traced("loadAudit", accountAttrs(accountID), func(ctx context.Context) tea.Msg {
+		entries, err := audit.ListEntries(ctx, store, audit.Filter{AccountID: accountID})
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return auditLoadedMsg{entries: entries}
+	})
+}
+
+// Trading actions go through the broker client, which cmd/pony wraps with
+// the audit log, so each of them is recorded.
+
+func submitOrder(client broker.Client, req *order.CreateOrderRequest) tea.Cmd {
+	attrs := []attribute.KeyValue{
+		tracing.AccountIDKey.String(req.AccountID),
+		tracing.SymbolKey.String(req.Symbol),
 	}
+	return traced("submitOrder", attrs, func(ctx context.Context) tea.Msg {
+		o, err := client.CreateOrder(ctx, req)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return orderSubmittedMsg{order: o}
+	})
 }
 
-func loadOrders(store Store, accountID string) tea.Cmd {
-	return func() tea.Msg {
-		// TODO: Use store.ListOrders() once sqlc generates it
-		// For now, return empty list
-		return ordersLoadedMsg{orders: []*order.Order{}}
+func cancelOrder(client broker.Client, alpacaOrderID, symbol string) tea.Cmd {
+	attrs := []attribute.KeyValue{
+		tracing.OrderIDKey.String(alpacaOrderID),
+		tracing.SymbolKey.String(symbol),
 	}
+	return traced("cancelOrder", attrs, func(ctx context.Context) tea.Msg {
+		if err := client.CancelOrder(ctx, alpacaOrderID); err != nil {
+			return errMsg{err: err}
+		}
+		return orderCanceledMsg{symbol: symbol}
+	})
 }
 
-func loadPositions(store Store, accountID string) tea.Cmd {
-	return func() tea.Msg {
-		// TODO: Use store.ListPositions() once sqlc generates it
-		// For now, return empty list
-		return positionsLoadedMsg{positions: []*position.Position{}}
+func closePosition(client broker.Client, accountID, symbol string) tea.Cmd {
+	attrs := []attribute.KeyValue{
+		tracing.AccountIDKey.String(accountID),
+		tracing.SymbolKey.String(symbol),
 	}
+	return traced("closePosition", attrs, func(ctx context.Context) tea.Msg {
+		if _, err := client.ClosePosition(ctx, accountID, symbol); err != nil {
+			return errMsg{err: err}
+		}
+		return positionClosedMsg{symbol: symbol}
+	})
 }
 
-func listenForEvents(client broker.Client) tea.Cmd {
-	return func() tea.Msg {
-		// This is a simplified event listener
-		// In a real implementation, you'd want to handle context properly
-		ctx := context.Background()
-		eventCh, errCh := client.StreamEvents(ctx, "")
-
-		select {
-		case event := <-eventCh:
-			return eventMsg{event: event}
-		case err := <-errCh:
-			if err != nil {
+func loadWatchlists(client broker.Client, store Store, accountID string) tea.Cmd {
+	return traced("loadWatchlists", accountAttrs(accountID), func(ctx context.Context) tea.Msg {
+		watchlists, err := client.ListWatchlists(ctx, accountID)
+		if err != nil {
+			return errMsg{err: err}
+		}
+
+		// Drop local watchlists that no longer exist at the broker
+		rows, err := store.ListWatchlists(ctx, accountID)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		remote := make(map[string]bool, len(watchlists))
+		for _, w := range watchlists {
+			remote[w.ID] = true
+		}
+		for _, row := range rows {
+			if !remote[row.ID] {
+				if err := store.DeleteWatchlist(ctx, row.ID); err != nil {
+					return errMsg{err: err}
+				}
+			}
+		}
+
+		for _, w := range watchlists {
+			if err := saveWatchlist(ctx, store, w); err != nil {
 				return errMsg{err: err}
 			}
 		}
+		return watchlistsLoadedMsg{watchlists: watchlists}
+	})
+}
+
+// saveWatchlist writes a watchlist as returned by the broker to the local tables,
+// replacing its items so their positions match the broker's order. It is
+// one transaction, so a failure cannot leave the items half replaced.
+func saveWatchlist(ctx context.Context, store Store, w *watchlist.Watchlist) error {
+	return store.InTx(ctx, func(q db.Querier) error {
+		if _, err := q.UpsertWatchlist(ctx, db.UpsertWatchlistParams{
+			ID:        w.ID,
+			AccountID: w.AccountID,
+			Name:      w.Name,
+		}); err != nil {
+			return err
+		}
 
+		if err := q.DeleteWatchlistItems(ctx, w.ID); err != nil {
+			return err
+		}
+		for i, symbol := range w.Symbols {
+			if _, err := q.AddWatchlistItem(ctx, db.AddWatchlistItemParams{
+				WatchlistID: w.ID,
+				Symbol:      symbol,
+				Position:    int32(i),
+			}); err != nil {
+				return err
+			}
+		}
 		return nil
+	})
+}
+
+func createWatchlist(client broker.Client, store Store, req *watchlist.CreateWatchlistRequest) tea.Cmd {
+	return traced("createWatchlist", nil, func(ctx context.Context) tea.Msg {
+		w, err := client.CreateWatchlist(ctx, req)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if err := saveWatchlist(ctx, store, w); err != nil {
+			return errMsg{err: err}
+		}
+		return watchlistUpdatedMsg{watchlist: w}
+	})
+}
+
+func updateWatchlist(client broker.Client, store Store, watchlistID string, req *watchlist.UpdateWatchlistRequest) tea.Cmd {
+	return traced("updateWatchlist", []attribute.KeyValue{tracing.WatchlistIDKey.String(watchlistID)}, func(ctx context.Context) tea.Msg {
+		w, err := client.UpdateWatchlist(ctx, watchlistID, req)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if err := saveWatchlist(ctx, store, w); err != nil {
+			return errMsg{err: err}
+		}
+		return watchlistUpdatedMsg{watchlist: w}
+	})
+}
+
+func addWatchlistSymbol(client broker.Client, store Store, watchlistID, symbol string) tea.Cmd {
+	attrs := []attribute.KeyValue{
+		tracing.WatchlistIDKey.String(watchlistID),
+		tracing.SymbolKey.String(symbol),
+	}
+	return traced("addWatchlistSymbol", attrs, func(ctx context.Context) tea.Msg {
+		w, err := client.AddWatchlistSymbol(ctx, watchlistID, symbol)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if err := saveWatchlist(ctx, store, w); err != nil {
+			return errMsg{err: err}
+		}
+		return watchlistUpdatedMsg{watchlist: w}
+	})
+}
+
+func removeWatchlistSymbol(client broker.Client, store Store, watchlistID, symbol string) tea.Cmd {
+	attrs := []attribute.KeyValue{
+		tracing.WatchlistIDKey.String(watchlistID),
+		tracing.SymbolKey.String(symbol),
+	}
+	return traced("removeWatchlistSymbol", attrs, func(ctx context.Context) tea.Msg {
+		if err := client.RemoveWatchlistSymbol(ctx, watchlistID, symbol); err != nil {
+			return errMsg{err: err}
+		}
+
+		// Alpaca returns no body on removal, so fetch the list again
+		w, err := client.GetWatchlist(ctx, watchlistID)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		if err := saveWatchlist(ctx, store, w); err != nil {
+			return errMsg{err: err}
+		}
+		return watchlistUpdatedMsg{watchlist: w}
+	})
+}
+
+// waitForLogs waits for new log entries, so the log pane is redrawn
+func waitForLogs(logs *logging.Ring) tea.Cmd {
+	return func() tea.Msg {
+		<-logs.Updated()
+		return logsUpdatedMsg{}
+	}
+}
+
+// traced runs a command in a span named after it. A command that ends in
+// an errMsg marks its span failed.
+func traced(name string, attrs []attribute.KeyValue, fn func(ctx context.Context) tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		ctx, span := tracing.Tracer().Start(context.Background(), "tui."+name, trace.WithAttributes(attrs...))
+		msg := fn(ctx)
+
+		var err error
+		if msg, ok := msg.(errMsg); ok {
+			err = msg.err
+		}
+		tracing.End(span, err)
+		return msg
 	}
 }
+
+func accountAttrs(accountID string) []attribute.KeyValue {
+	return []attribute.KeyValue{tracing.AccountIDKey.String(accountID)}
+}
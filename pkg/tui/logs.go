@@ -0,0 +1,77 @@
+package tui
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+
+	"github.com/revrost/pony/pkg/logging"
+)
+
+// logPaneLines is how many entries the log pane shows
+const logPaneLines = 10
+
+// logLevels are the levels 'V' cycles the log pane through
+var logLevels = []slog.Level{slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError}
+
+var (
+	logPaneStyle = lipgloss.NewStyle().
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderTop(true).
+			BorderForeground(lipgloss.Color("240"))
+
+	warnStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("214"))
+)
+
+func nextLogLevel(level slog.Level) slog.Level {
+	for i, l := range logLevels {
+		if l == level {
+			return logLevels[(i+1)%len(logLevels)]
+		}
+	}
+	return logLevels[0]
+}
+
+// renderLogs shows the most recent log entries at the pane's level or above
+func renderLogs(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(headerStyle.Render(fmt.Sprintf("Log (%s and above)", m.logLevel)))
+	b.WriteString(infoStyle.Render("  [V] Level  [L] Hide"))
+	b.WriteString("\n")
+
+	var entries []string
+	if m.logs != nil {
+		for _, e := range m.logs.Entries(m.logLevel) {
+			entries = append(entries, renderLogEntry(e, m.width))
+		}
+	}
+	if len(entries) == 0 {
+		b.WriteString(infoStyle.Render("No log entries"))
+	}
+	b.WriteString(strings.Join(entries[max(len(entries)-logPaneLines, 0):], "\n"))
+
+	return logPaneStyle.Width(m.width).Render(b.String())
+}
+
+// renderLogEntry shows an entry on one line, cut off at width
+func renderLogEntry(e logging.Entry, width int) string {
+	line := fmt.Sprintf("%s %-5s %s %s", e.Time.Format("15:04:05"), e.Level, e.Message, e.Attrs)
+	if runes := []rune(line); width > 0 && len(runes) > width {
+		line = string(runes[:width-1]) + "…"
+	}
+
+	switch {
+	case e.Level >= slog.LevelError:
+		return errorStyle.Render(line)
+	case e.Level >= slog.LevelWarn:
+		return warnStyle.Render(line)
+	case e.Level < slog.LevelInfo:
+		return infoStyle.Render(line)
+	default:
+		return line
+	}
+}
@@ -2,9 +2,17 @@ package tui
 
 import (
 	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/audit"
 	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/changes"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/history"
 	"github.com/revrost/pony/pkg/order"
 	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/snapshot"
+	"github.com/revrost/pony/pkg/watchlist"
 )
 
 // Message types for the Bubble Tea update loop
@@ -13,18 +21,102 @@ type accountsLoadedMsg struct {
 	accounts []*account.Account
 }
 
+type performanceLoadedMsg struct {
+	summary *snapshot.Summary
+}
+
 type ordersLoadedMsg struct {
-	orders []*order.Order
+	page *history.Page
+}
+
+type orderStatsLoadedMsg struct {
+	byDay    []history.Stats
+	bySymbol []history.Stats
 }
 
 type positionsLoadedMsg struct {
 	positions []*position.Position
 }
 
-type eventMsg struct {
-	event broker.Event
+type executionsLoadedMsg struct {
+	orderID    string
+	executions []*order.Execution
+}
+
+type watchlistsLoadedMsg struct {
+	watchlists []*watchlist.Watchlist
+}
+
+type watchlistUpdatedMsg struct {
+	watchlist *watchlist.Watchlist
+}
+
+type auditLoadedMsg struct {
+	entries []*audit.Entry
+}
+
+type orderSubmittedMsg struct {
+	order *order.Order
+}
+
+type orderCanceledMsg struct {
+	symbol string
+}
+
+type positionClosedMsg struct {
+	symbol string
 }
 
+// logsUpdatedMsg means new entries reached the log ring
+type logsUpdatedMsg struct{}
+
 type errMsg struct {
 	err error
 }
+
+// ReconciledMsg is sent by cmd/pony after each background reconciliation
+// with the broker, so the views can reload what was written.
+type ReconciledMsg struct {
+	Err error
+}
+
+// SnapshotMsg is sent by cmd/pony after each background account snapshot,
+// so the dashboard can reload its P&L.
+type SnapshotMsg struct {
+	Err error
+}
+
+// EventMsg is sent by cmd/pony for every broker event, once it has been
+// recorded and applied to the database.
+type EventMsg struct {
+	Event broker.Event
+}
+
+// StreamStateMsg is sent by cmd/pony whenever the broker event stream
+// connects, goes live or breaks. Err is why it went down.
+type StreamStateMsg struct {
+	State events.State
+	Err   error
+}
+
+// ChangedMsg is sent by cmd/pony with the orders, positions and accounts
+// that changed in the database, whichever process wrote them. Err is set
+// when the change feed could not be started.
+type ChangedMsg struct {
+	Changes []changes.Change
+	Err     error
+}
+
+// ScheduledMsg is sent by cmd/pony with the runs the scheduler placed or
+// skipped in the background. Err is set when a pass failed.
+type ScheduledMsg struct {
+	Runs []*schedule.Run
+	Err  error
+}
+
+// AlertMsg is sent by cmd/pony with the alerts fired in the background. Err
+// is set when checking the rules or delivering an alert failed.
+type AlertMsg struct {
+	Alerts []*alert.Alert
+	Err    error
+}
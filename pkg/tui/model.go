@@ -1,11 +1,27 @@
 package tui
 
 import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/revrost/pony/pkg/account"
+	"github.com/revrost/pony/pkg/alert"
+	"github.com/revrost/pony/pkg/audit"
 	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/changes"
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/history"
+	"github.com/revrost/pony/pkg/logging"
 	"github.com/revrost/pony/pkg/order"
 	"github.com/revrost/pony/pkg/position"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/snapshot"
+	"github.com/revrost/pony/pkg/strategy"
 )
 
 type View int
@@ -15,12 +31,71 @@ const (
 	ViewOrders
 	ViewPositions
 	ViewPlaceOrder
+	ViewWatchlists
+	ViewOrderDetail
+	ViewAudit
+	ViewOrderStats
+	ViewStrategies
+	ViewSchedules
+	ViewScheduleRuns
+	ViewNewSchedule
+	ViewAlerts
+	ViewNewAlert
 )
 
-// Store is the interface for database operations (will be implemented by sqlc's Querier)
+// Store is the subset of the sqlc generated Querier that the TUI uses, and
+// transactions to group its writes in.
 type Store interface {
-	// sqlc will generate these methods automatically
-	// For now we just need the interface
+	ListAccounts(ctx context.Context) ([]db.Account, error)
+	SearchOrders(ctx context.Context, arg db.SearchOrdersParams) ([]db.Order, error)
+	OrderStatsByDay(ctx context.Context, arg db.OrderStatsByDayParams) ([]db.OrderStatsByDayRow, error)
+	OrderStatsBySymbol(ctx context.Context, arg db.OrderStatsBySymbolParams) ([]db.OrderStatsBySymbolRow, error)
+	ListPositions(ctx context.Context, accountID string) ([]db.Position, error)
+	ListExecutionsByOrder(ctx context.Context, orderID string) ([]db.Execution, error)
+
+	ListWatchlists(ctx context.Context, accountID string) ([]db.Watchlist, error)
+	DeleteWatchlist(ctx context.Context, id string) error
+
+	ListAuditEntries(ctx context.Context, arg db.ListAuditEntriesParams) ([]db.AuditLog, error)
+	ListAccountSnapshots(ctx context.Context, arg db.ListAccountSnapshotsParams) ([]db.AccountSnapshot, error)
+
+	GetOrder(ctx context.Context, id string) (db.Order, error)
+	ListScheduledOrders(ctx context.Context) ([]db.ScheduledOrder, error)
+	CreateScheduledOrder(ctx context.Context, arg db.CreateScheduledOrderParams) (db.ScheduledOrder, error)
+	SetScheduledOrderEnabled(ctx context.Context, arg db.SetScheduledOrderEnabledParams) (db.ScheduledOrder, error)
+	DeleteScheduledOrder(ctx context.Context, id int64) (int64, error)
+	ListScheduledOrderRuns(ctx context.Context, arg db.ListScheduledOrderRunsParams) ([]db.ListScheduledOrderRunsRow, error)
+
+	ListAlerts(ctx context.Context) ([]db.Alert, error)
+	CreateAlert(ctx context.Context, arg db.CreateAlertParams) (db.Alert, error)
+	SetAlertEnabled(ctx context.Context, arg db.SetAlertEnabledParams) (db.Alert, error)
+	DeleteAlert(ctx context.Context, id int64) (int64, error)
+
+	// InTx runs fn with queries bound to a single transaction, as
+	// *store.DB does
+	InTx(ctx context.Context, fn func(q db.Querier) error) error
+}
+
+// statusFilter is one of the status sets 'f' cycles through in the Orders view
+type statusFilter struct {
+	label    string
+	statuses []order.OrderStatus
+}
+
+var statusFilters = []statusFilter{
+	{label: "all"},
+	{label: "open", statuses: order.OpenStatuses},
+	{label: "filled", statuses: []order.OrderStatus{order.OrderStatusFilled}},
+	{label: "canceled", statuses: []order.OrderStatus{order.OrderStatusCanceled}},
+	{label: "expired", statuses: []order.OrderStatus{order.OrderStatusExpired}},
+	{label: "replaced", statuses: []order.OrderStatus{order.OrderStatusReplaced}},
+	{label: "rejected", statuses: []order.OrderStatus{order.OrderStatusRejected}},
+}
+
+// confirmation is a destructive action waiting for the user to press y
+type confirmation struct {
+	prompt string
+	cmd    tea.Cmd
 }
 
 type Model struct {
@@ -30,41 +105,93 @@ type Model struct {
 
 	// Services
 	brokerClient broker.Client
-	store        Store // sqlc generated Querier will implement this
+	store        Store
+	logs         *logging.Ring
+	strategies   Strategies
 
 	// Data
-	accounts  []*account.Account
-	orders    []*order.Order
-	positions []*position.Position
+	accounts     []*account.Account
+	orders       []*order.Order
+	positions    []*position.Position
+	auditEntries []*audit.Entry
+	statsByDay   []history.Stats
+	statsBySym   []history.Stats
+	performance  *snapshot.Summary
+
+	strategyStatus []strategy.Status
+	schedules      []*schedule.ScheduledOrder
+	scheduleRuns   []*schedule.Run
+	alertRules     []*alert.Rule
 
 	// State
 	selectedAccount *account.Account
-	err             error
-	loading         bool
+	orderCursor     int
+	orderSymbol     string
+	orderStatus     int
+	editingSymbol   bool
+	// orderPages holds the cursor each visited page started from, so
+	// paging back is as cheap as paging forward. The last one is the
+	// current page.
+	orderPages     []history.Cursor
+	ordersHasMore  bool
+	ordersNext     history.Cursor
+	positionCursor int
+	strategyCursor int
+	scheduleCursor int
+	runCursor      int
+	alertCursor    int
+	orderDetail    *order.Order
+	// detailBack is the view esc returns to from the order detail
+	detailBack  View
+	executions  []*order.Execution
+	confirm     *confirmation
+	toast       *toast
+	status      string
+	err         error
+	snapshotErr error
+	streamState events.State
+	streamErr   error
+	loading     bool
+	showLogs    bool
+	logLevel    slog.Level
 
 	// Sub-models
 	placeOrderForm PlaceOrderForm
+	scheduleForm   ScheduleForm
+	alertForm      AlertForm
+	watchlistPanel WatchlistPanel
 }
 
 func NewModel(
 	brokerClient broker.Client,
 	store Store,
+	logs *logging.Ring,
+	strategies Strategies,
 ) Model {
 	return Model{
 		currentView:  ViewDashboard,
 		brokerClient: brokerClient,
 		store:        store,
+		logs:         logs,
+		strategies:   strategies,
+		logLevel:     slog.LevelInfo,
 		accounts:     []*account.Account{},
 		orders:       []*order.Order{},
 		positions:    []*position.Position{},
+
+		watchlistPanel: NewWatchlistPanel(brokerClient, store, ""),
 	}
 }
 
 func (m Model) Init() tea.Cmd {
-	return tea.Batch(
-		loadAccounts(m.store),
-		listenForEvents(m.brokerClient),
-	)
+	cmds := []tea.Cmd{loadAccounts(m.store)}
+	if m.logs != nil {
+		cmds = append(cmds, waitForLogs(m.logs))
+	}
+	if m.strategies != nil {
+		cmds = append(cmds, waitForStrategies(m.strategies))
+	}
+	return tea.Batch(cmds...)
 }
 
 func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -81,22 +208,183 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.accounts = msg.accounts
 		if len(m.accounts) > 0 {
 			m.selectedAccount = m.accounts[0]
-			return m, loadOrders(m.store, m.selectedAccount.ID)
+			return m, tea.Batch(m.loadOrderPage(), loadPerformance(m.store, m.selectedAccount.ID))
 		}
 		return m, nil
 
+	case performanceLoadedMsg:
+		m.performance = msg.summary
+		return m, nil
+
 	case ordersLoadedMsg:
-		m.orders = msg.orders
+		m.orders = msg.page.Orders
+		m.ordersHasMore = msg.page.HasMore
+		m.ordersNext = msg.page.Next
+		if m.orderCursor >= len(m.orders) {
+			m.orderCursor = max(len(m.orders)-1, 0)
+		}
+		return m, nil
+
+	case executionsLoadedMsg:
+		if m.orderDetail != nil && m.orderDetail.ID == msg.orderID {
+			m.executions = msg.executions
+		}
 		return m, nil
 
 	case positionsLoadedMsg:
 		m.positions = msg.positions
+		if m.positionCursor >= len(m.positions) {
+			m.positionCursor = max(len(m.positions)-1, 0)
+		}
+		return m, nil
+
+	case orderStatsLoadedMsg:
+		m.statsByDay = msg.byDay
+		m.statsBySym = msg.bySymbol
+		return m, nil
+
+	case auditLoadedMsg:
+		m.auditEntries = msg.entries
+		return m, nil
+
+	case orderSubmittedMsg:
+		slog.Info("order submitted", "order_id", msg.order.AlpacaOrderID, "symbol", msg.order.Symbol,
+			"side", msg.order.Side, "status", msg.order.Status)
+		m.status = fmt.Sprintf("Submitted %s %s %s", msg.order.Side, msg.order.Symbol, msg.order.Status)
+		m.currentView = ViewOrders
+		return m, m.reloadOrders()
+
+	case orderCanceledMsg:
+		m.status = fmt.Sprintf("Cancel requested for %s", msg.symbol)
+		return m, m.reloadOrders()
+
+	case positionClosedMsg:
+		m.status = fmt.Sprintf("Closing %s", msg.symbol)
+		return m, m.reloadOrders()
+
+	case watchlistsLoadedMsg:
+		m.watchlistPanel = m.watchlistPanel.SetWatchlists(msg.watchlists)
+		return m, nil
+
+	case watchlistUpdatedMsg:
+		m.watchlistPanel = m.watchlistPanel.SetWatchlist(msg.watchlist)
+		return m, nil
+
+	case ReconciledMsg:
+		if msg.Err != nil {
+			slog.Error("reconcile failed", "error", msg.Err)
+			m.err = msg.Err
+			return m, nil
+		}
+		cmds := []tea.Cmd{loadAccounts(m.store)}
+		if m.currentView == ViewPositions && m.selectedAccount != nil {
+			cmds = append(cmds, loadPositions(m.store, m.selectedAccount.ID))
+		}
+		return m, tea.Batch(cmds...)
+
+	case ChangedMsg:
+		if msg.Err != nil {
+			slog.Error("change feed failed", "error", msg.Err)
+			m.err = msg.Err
+			return m, nil
+		}
+		return m, m.refresh(msg.Changes)
+
+	case SnapshotMsg:
+		// A failed snapshot only affects the P&L figures, so it is shown on
+		// the dashboard instead of taking over the screen
+		m.snapshotErr = msg.Err
+		if msg.Err != nil {
+			slog.Warn("account snapshot failed", "error", msg.Err)
+		}
+		if msg.Err != nil || m.selectedAccount == nil {
+			return m, nil
+		}
+		return m, loadPerformance(m.store, m.selectedAccount.ID)
+
+	case EventMsg:
+		meta := msg.Event.Metadata()
+		slog.Debug("broker event", "type", msg.Event.Type(), "event_id", meta.ID, "account_id", meta.AccountID)
+		return m.handleEvent(msg.Event)
+
+	case StreamStateMsg:
+		if msg.Err != nil {
+			slog.Warn("event stream down", "error", msg.Err)
+		} else {
+			slog.Info("event stream " + string(msg.State))
+		}
+		m.streamState = msg.State
+		m.streamErr = msg.Err
+		return m, nil
+
+	case schedulesLoadedMsg:
+		m.schedules = msg.schedules
+		if m.scheduleCursor >= len(m.schedules) {
+			m.scheduleCursor = max(len(m.schedules)-1, 0)
+		}
+		if m.currentView == ViewScheduleRuns && len(m.schedules) == 0 {
+			m.currentView = ViewSchedules
+		}
+		return m, nil
+
+	case scheduleRunsLoadedMsg:
+		if m.currentView == ViewScheduleRuns && len(m.schedules) > 0 &&
+			m.schedules[m.scheduleCursor].ID == msg.scheduleID {
+			m.scheduleRuns = msg.runs
+			if m.runCursor >= len(m.scheduleRuns) {
+				m.runCursor = max(len(m.scheduleRuns)-1, 0)
+			}
+		}
+		return m, nil
+
+	case scheduleSavedMsg:
+		m.status = msg.status
+		m.currentView = ViewSchedules
+		return m, loadSchedules(m.store)
+
+	case runOrderLoadedMsg:
+		m.orderDetail = msg.order
+		m.executions = nil
+		m.detailBack = ViewScheduleRuns
+		m.currentView = ViewOrderDetail
+		return m, loadExecutions(m.store, m.orderDetail.ID)
+
+	case ScheduledMsg:
+		return m.handleScheduled(msg)
+
+	case alertsLoadedMsg:
+		m.alertRules = msg.rules
+		if m.alertCursor >= len(m.alertRules) {
+			m.alertCursor = max(len(m.alertRules)-1, 0)
+		}
+		return m, nil
+
+	case alertSavedMsg:
+		m.status = msg.status
+		m.currentView = ViewAlerts
+		return m, loadAlerts(m.store)
+
+	case AlertMsg:
+		return m.handleAlert(msg)
+
+	case toastExpiredMsg:
+		if m.toast != nil && m.toast.seq == msg.seq {
+			m.toast = nil
+		}
 		return m, nil
 
-	case eventMsg:
-		return m.handleEvent(msg.event)
+	case logsUpdatedMsg:
+		return m, waitForLogs(m.logs)
+
+	case strategiesUpdatedMsg:
+		m.strategyStatus = m.strategies.Status()
+		if m.strategyCursor >= len(m.strategyStatus) {
+			m.strategyCursor = max(len(m.strategyStatus)-1, 0)
+		}
+		return m, waitForStrategies(m.strategies)
 
 	case errMsg:
+		slog.Error("command failed", "error", msg.err)
 		m.err = msg.err
 		m.loading = false
 		return m, nil
@@ -110,39 +398,110 @@ func (m Model) View() string {
 		return "Loading..."
 	}
 
-	if m.err != nil {
-		return renderError(m.err)
-	}
-
+	var view string
 	switch m.currentView {
 	case ViewDashboard:
-		return renderDashboard(m)
+		view = renderDashboard(m)
 	case ViewOrders:
-		return renderOrders(m)
+		view = renderOrders(m)
 	case ViewPositions:
-		return renderPositions(m)
+		view = renderPositions(m)
 	case ViewPlaceOrder:
-		return renderPlaceOrder(m)
+		view = renderPlaceOrder(m)
+	case ViewWatchlists:
+		view = renderWatchlists(m)
+	case ViewOrderDetail:
+		view = renderOrderDetail(m)
+	case ViewAudit:
+		view = renderAudit(m)
+	case ViewOrderStats:
+		view = renderOrderStats(m)
+	case ViewStrategies:
+		view = renderStrategies(m)
+	case ViewSchedules:
+		view = renderSchedules(m)
+	case ViewScheduleRuns:
+		view = renderScheduleRuns(m)
+	case ViewNewSchedule:
+		view = renderNewSchedule(m)
+	case ViewAlerts:
+		view = renderAlerts(m)
+	case ViewNewAlert:
+		view = renderNewAlert(m)
 	default:
-		return "Unknown view"
+		view = "Unknown view"
+	}
+
+	// The error stays above the view until the next key, and is in the log
+	if m.err != nil {
+		view = renderError(m.err) + "\n\n" + view
+	}
+	if m.toast != nil {
+		view = renderToast(m) + "\n\n" + view
+	}
+	if m.streamState != "" {
+		view += "\n" + renderStreamState(m)
 	}
+	if m.showLogs {
+		view += "\n" + renderLogs(m)
+	}
+	return view
 }
 
 func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.err = nil
+
+	// Text entry in the watchlist view takes every key, including the global ones
+	if m.currentView == ViewWatchlists && m.watchlistPanel.Editing() {
+		updatedPanel, cmd := m.watchlistPanel.Update(msg)
+		m.watchlistPanel = updatedPanel
+		return m, cmd
+	}
+
+	if m.currentView == ViewOrders && m.editingSymbol {
+		return m.handleSymbolFilterKey(msg)
+	}
+
+	if m.confirm != nil {
+		return m.handleConfirmKey(msg)
+	}
+
+	if m.currentView == ViewPlaceOrder {
+		return m.handlePlaceOrderKey(msg)
+	}
+
+	if m.currentView == ViewNewSchedule {
+		return m.handleNewScheduleKey(msg)
+	}
+
+	if m.currentView == ViewNewAlert {
+		return m.handleNewAlertKey(msg)
+	}
+
 	switch msg.String() {
 	case "ctrl+c", "q":
 		return m, tea.Quit
 
+	case "L":
+		m.showLogs = !m.showLogs
+		return m, nil
+
+	case "V":
+		if m.showLogs {
+			m.logLevel = nextLogLevel(m.logLevel)
+		}
+		return m, nil
+
 	case "1":
 		m.currentView = ViewDashboard
+		if m.selectedAccount != nil {
+			return m, loadPerformance(m.store, m.selectedAccount.ID)
+		}
 		return m, nil
 
 	case "2":
 		m.currentView = ViewOrders
-		if m.selectedAccount != nil {
-			return m, loadOrders(m.store, m.selectedAccount.ID)
-		}
-		return m, nil
+		return m, m.loadOrderPage()
 
 	case "3":
 		m.currentView = ViewPositions
@@ -151,6 +510,36 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case "4":
+		m.currentView = ViewWatchlists
+		if m.selectedAccount != nil {
+			m.watchlistPanel.accountID = m.selectedAccount.ID
+			return m, loadWatchlists(m.brokerClient, m.store, m.selectedAccount.ID)
+		}
+		return m, nil
+
+	case "5":
+		m.currentView = ViewAudit
+		if m.selectedAccount != nil {
+			return m, loadAudit(m.store, m.selectedAccount.ID)
+		}
+		return m, nil
+
+	case "6":
+		m.currentView = ViewStrategies
+		if m.strategies != nil {
+			m.strategyStatus = m.strategies.Status()
+		}
+		return m, nil
+
+	case "7":
+		m.currentView = ViewSchedules
+		return m, loadSchedules(m.store)
+
+	case "8":
+		m.currentView = ViewAlerts
+		return m, loadAlerts(m.store)
+
 	case "n":
 		if m.currentView == ViewOrders {
 			m.currentView = ViewPlaceOrder
@@ -159,22 +548,287 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case "esc":
-		if m.currentView == ViewPlaceOrder {
+		switch m.currentView {
+		case ViewOrderDetail:
+			m.currentView = m.detailBack
+		case ViewOrderStats:
 			m.currentView = ViewOrders
+		case ViewScheduleRuns:
+			m.currentView = ViewSchedules
 		}
 		return m, nil
 	}
 
+	if m.currentView == ViewOrders {
+		return m.handleOrdersKey(msg)
+	}
+
+	if m.currentView == ViewPositions {
+		return m.handlePositionsKey(msg)
+	}
+
+	if m.currentView == ViewStrategies {
+		return m.handleStrategiesKey(msg)
+	}
+
+	if m.currentView == ViewSchedules {
+		return m.handleSchedulesKey(msg)
+	}
+
+	if m.currentView == ViewScheduleRuns {
+		return m.handleScheduleRunsKey(msg)
+	}
+
+	if m.currentView == ViewAlerts {
+		return m.handleAlertsKey(msg)
+	}
+
 	// Handle sub-model key presses
-	if m.currentView == ViewPlaceOrder {
-		updatedForm, cmd := m.placeOrderForm.Update(msg)
-		m.placeOrderForm = updatedForm
+
+	if m.currentView == ViewWatchlists {
+		updatedPanel, cmd := m.watchlistPanel.Update(msg)
+		m.watchlistPanel = updatedPanel
 		return m, cmd
 	}
 
 	return m, nil
 }
 
+func (m Model) handleOrdersKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "down", "j":
+		if m.orderCursor < len(m.orders)-1 {
+			m.orderCursor++
+		}
+		return m, nil
+
+	case "up", "k":
+		if m.orderCursor > 0 {
+			m.orderCursor--
+		}
+		return m, nil
+
+	case "enter":
+		if len(m.orders) == 0 {
+			return m, nil
+		}
+		m.orderDetail = m.orders[m.orderCursor]
+		m.executions = nil
+		m.detailBack = ViewOrders
+		m.currentView = ViewOrderDetail
+		return m, loadExecutions(m.store, m.orderDetail.ID)
+
+	case "right", "l", "pgdown":
+		if !m.ordersHasMore {
+			return m, nil
+		}
+		m.orderPages = append(m.orderPages, m.ordersNext)
+		m.orderCursor = 0
+		return m, m.loadOrderPage()
+
+	case "left", "h", "pgup":
+		if len(m.orderPages) == 0 {
+			return m, nil
+		}
+		m.orderPages = m.orderPages[:len(m.orderPages)-1]
+		m.orderCursor = 0
+		return m, m.loadOrderPage()
+
+	case "/":
+		m.editingSymbol = true
+		return m, nil
+
+	case "f":
+		m.orderStatus = (m.orderStatus + 1) % len(statusFilters)
+		m.orderPages = nil
+		m.orderCursor = 0
+		return m, m.loadOrderPage()
+
+	case "s":
+		if m.selectedAccount == nil {
+			return m, nil
+		}
+		m.currentView = ViewOrderStats
+		return m, loadOrderStats(m.store, m.selectedAccount.ID)
+
+	case "x":
+		if len(m.orders) == 0 || !m.orders[m.orderCursor].IsOpen() {
+			return m, nil
+		}
+		o := m.orders[m.orderCursor]
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Cancel %s %s order for %s?", o.Side, o.OrderType, o.Symbol),
+			cmd:    cancelOrder(m.brokerClient, o.AlpacaOrderID, o.Symbol),
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleSymbolFilterKey edits the symbol filter; enter applies it and esc
+// leaves it unchanged
+func (m Model) handleSymbolFilterKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.editingSymbol = false
+		return m, nil
+
+	case "enter":
+		m.editingSymbol = false
+		m.orderPages = nil
+		m.orderCursor = 0
+		return m, m.loadOrderPage()
+	}
+
+	m.orderSymbol = editText(m.orderSymbol, strings.ToUpper(msg.String()))
+	return m, nil
+}
+
+func (m Model) handlePositionsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "down", "j":
+		if m.positionCursor < len(m.positions)-1 {
+			m.positionCursor++
+		}
+		return m, nil
+
+	case "up", "k":
+		if m.positionCursor > 0 {
+			m.positionCursor--
+		}
+		return m, nil
+
+	case "x":
+		if len(m.positions) == 0 || m.selectedAccount == nil {
+			return m, nil
+		}
+		p := m.positions[m.positionCursor]
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Close %s position in %s at market?", format.Qty(p.Qty), p.Symbol),
+			cmd:    closePosition(m.brokerClient, m.selectedAccount.ID, p.Symbol),
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) handlePlaceOrderKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = ViewOrders
+		return m, nil
+
+	case "enter":
+		if m.selectedAccount == nil {
+			return m, nil
+		}
+		req, err := m.placeOrderForm.Request()
+		if err != nil {
+			m.status = "Invalid order: " + err.Error()
+			return m, nil
+		}
+		req.AccountID = m.selectedAccount.ID
+		m.status = ""
+		return m, submitOrder(m.brokerClient, req)
+	}
+
+	updatedForm, cmd := m.placeOrderForm.Update(msg)
+	m.placeOrderForm = updatedForm
+	return m, cmd
+}
+
+// handleConfirmKey answers the pending confirmation; any key but y declines
+func (m Model) handleConfirmKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	confirm := m.confirm
+	m.confirm = nil
+
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if msg.String() != "y" {
+		return m, nil
+	}
+	return m, confirm.cmd
+}
+
+// loadOrderPage loads the current page of the Orders view with its filters
+func (m Model) loadOrderPage() tea.Cmd {
+	if m.selectedAccount == nil {
+		return nil
+	}
+
+	filter := history.Filter{
+		AccountID: m.selectedAccount.ID,
+		Symbol:    m.orderSymbol,
+		Statuses:  statusFilters[m.orderStatus].statuses,
+	}
+	var after history.Cursor
+	if len(m.orderPages) > 0 {
+		after = m.orderPages[len(m.orderPages)-1]
+	}
+	return loadOrders(m.store, filter, after)
+}
+
+func (m Model) reloadOrders() tea.Cmd {
+	if m.selectedAccount == nil {
+		return nil
+	}
+	cmds := []tea.Cmd{m.loadOrderPage()}
+	if m.currentView == ViewPositions {
+		cmds = append(cmds, loadPositions(m.store, m.selectedAccount.ID))
+	}
+	return tea.Batch(cmds...)
+}
+
+// refresh reloads what the changes touched for the selected account, in
+// the view being shown
+func (m Model) refresh(changed []changes.Change) tea.Cmd {
+	if m.selectedAccount == nil {
+		return loadAccounts(m.store)
+	}
+
+	var accounts, orders, positions bool
+	for _, c := range changed {
+		if c.AccountID != "" && c.AccountID != m.selectedAccount.ID {
+			continue
+		}
+		switch c.Table {
+		case changes.TableAccounts:
+			accounts = true
+		case changes.TableOrders:
+			orders = true
+		case changes.TablePositions:
+			positions = true
+		default:
+			accounts, orders, positions = true, true, true
+		}
+	}
+
+	var cmds []tea.Cmd
+	switch {
+	case accounts:
+		// Loading the accounts reloads the orders page as well
+		cmds = append(cmds, loadAccounts(m.store))
+	case orders:
+		cmds = append(cmds, m.loadOrderPage())
+	}
+	if orders && m.currentView == ViewOrderDetail && m.orderDetail != nil {
+		cmds = append(cmds, loadExecutions(m.store, m.orderDetail.ID))
+	}
+	if positions && m.currentView == ViewPositions {
+		cmds = append(cmds, loadPositions(m.store, m.selectedAccount.ID))
+	}
+	return tea.Batch(cmds...)
+}
+
 func (m Model) handleEvent(event broker.Event) (tea.Model, tea.Cmd) {
 	switch e := event.(type) {
 	case broker.TradeUpdateEvent:
@@ -185,6 +839,17 @@ func (m Model) handleEvent(event broker.Event) (tea.Model, tea.Cmd) {
 				break
 			}
 		}
+
+		// Refresh the fill breakdown if the order being inspected just filled
+		if m.currentView == ViewOrderDetail && m.orderDetail != nil &&
+			m.orderDetail.AlpacaOrderID == e.Order.AlpacaOrderID {
+			id := m.orderDetail.ID
+			m.orderDetail = e.Order
+			m.orderDetail.ID = id
+			if e.IsFill() {
+				return m, loadExecutions(m.store, id)
+			}
+		}
 		return m, nil
 
 	case broker.AccountUpdateEvent:
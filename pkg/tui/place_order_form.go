@@ -1,9 +1,30 @@
 package tui
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbletea"
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/order"
+)
+
+var (
+	orderSides = []string{string(order.OrderSideBuy), string(order.OrderSideSell)}
+	orderTypes = []string{
+		string(order.OrderTypeMarket),
+		string(order.OrderTypeLimit),
+		string(order.OrderTypeStop),
+		string(order.OrderTypeStopLimit),
+	}
+	timesInForce = []string{
+		string(order.TimeInForceDay),
+		string(order.TimeInForceGTC),
+		string(order.TimeInForceIOC),
+		string(order.TimeInForceFOK),
+	}
 )
 
 type PlaceOrderForm struct {
@@ -26,6 +47,8 @@ func NewPlaceOrderForm() PlaceOrderForm {
 	}
 }
 
+// Update handles navigation and input. Enter is handled by the Model, which
+// submits Request().
 func (f PlaceOrderForm) Update(msg tea.KeyMsg) (PlaceOrderForm, tea.Cmd) {
 	switch msg.String() {
 	case "tab", "down":
@@ -42,10 +65,6 @@ func (f PlaceOrderForm) Update(msg tea.KeyMsg) (PlaceOrderForm, tea.Cmd) {
 		}
 		return f, nil
 
-	case "enter":
-		// TODO: Submit order
-		return f, nil
-
 	default:
 		// Handle text input for focused field
 		return f.handleInput(msg.String()), nil
@@ -56,21 +75,98 @@ func (f PlaceOrderForm) handleInput(input string) PlaceOrderForm {
 	// Simplified input handling - in production you'd want proper text input
 	switch f.focusIndex {
 	case 0:
-		if input == "backspace" && len(f.symbol) > 0 {
-			f.symbol = f.symbol[:len(f.symbol)-1]
-		} else if len(input) == 1 {
-			f.symbol += input
-		}
+		f.symbol = editText(f.symbol, strings.ToUpper(input))
+	case 1:
+		f.side = cycle(orderSides, f.side, input)
 	case 2:
-		if input == "backspace" && len(f.qty) > 0 {
-			f.qty = f.qty[:len(f.qty)-1]
-		} else if len(input) == 1 {
-			f.qty += input
-		}
+		f.qty = editNumber(f.qty, input)
+	case 3:
+		f.orderType = cycle(orderTypes, f.orderType, input)
+	case 4:
+		f.limitPrice = editNumber(f.limitPrice, input)
+	case 5:
+		f.stopPrice = editNumber(f.stopPrice, input)
+	case 6:
+		f.timeInForce = cycle(timesInForce, f.timeInForce, input)
 	}
 	return f
 }
 
+func editText(value, input string) string {
+	if input == "backspace" && len(value) > 0 {
+		return value[:len(value)-1]
+	}
+	if len(input) == 1 {
+		return value + input
+	}
+	return value
+}
+
+func editNumber(value, input string) string {
+	if len(input) == 1 && !strings.ContainsAny(input, "0123456789.") {
+		return value
+	}
+	return editText(value, input)
+}
+
+// cycle moves through a fixed set of options with space, left and right
+func cycle(options []string, value, input string) string {
+	step := 0
+	switch input {
+	case " ", "right", "l":
+		step = 1
+	case "left", "h":
+		step = -1
+	default:
+		return value
+	}
+
+	for i, option := range options {
+		if option == value {
+			return options[(i+step+len(options))%len(options)]
+		}
+	}
+	return options[0]
+}
+
+// Request validates the form and builds the order to submit. AccountID is
+// left for the caller to fill in.
+func (f PlaceOrderForm) Request() (*order.CreateOrderRequest, error) {
+	if f.symbol == "" {
+		return nil, errors.New("symbol is required")
+	}
+
+	qty, err := decimal.NewFromString(f.qty)
+	if err != nil || !qty.IsPositive() {
+		return nil, errors.New("quantity must be a positive number")
+	}
+
+	req := &order.CreateOrderRequest{
+		Symbol:      f.symbol,
+		Side:        order.OrderSide(f.side),
+		OrderType:   order.OrderType(f.orderType),
+		Qty:         &qty,
+		TimeInForce: order.TimeInForce(f.timeInForce),
+	}
+
+	if req.OrderType == order.OrderTypeLimit || req.OrderType == order.OrderTypeStopLimit {
+		price, err := decimal.NewFromString(f.limitPrice)
+		if err != nil || !price.IsPositive() {
+			return nil, errors.New("limit price must be a positive number")
+		}
+		req.LimitPrice = &price
+	}
+	if req.OrderType == order.OrderTypeStop || req.OrderType == order.OrderTypeStopLimit {
+		price, err := decimal.NewFromString(f.stopPrice)
+		if err != nil || !price.IsPositive() {
+			return nil, errors.New("stop price must be a positive number")
+		}
+		req.StopPrice = &price
+	}
+
+	return req, nil
+}
+
 func (f PlaceOrderForm) View() string {
 	cursor := func(active bool) string {
 		if active {
@@ -81,12 +177,12 @@ func (f PlaceOrderForm) View() string {
 
 	return fmt.Sprintf(`
 %s Symbol:       %s
-%s Side:         %s  (TODO: toggle)
+%s Side:         %s  (space to toggle)
 %s Quantity:     %s
-%s Type:         %s  (TODO: select)
+%s Type:         %s  (space to change)
 %s Limit Price:  %s
 %s Stop Price:   %s
-%s Time in Force: %s  (TODO: select)
+%s Time in Force: %s  (space to change)
 
 Press [Enter] to submit
 `,
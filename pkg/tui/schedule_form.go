@@ -0,0 +1,155 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/shopspring/decimal"
+
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/schedule"
+)
+
+// scheduleFormFields is how many fields ScheduleForm has
+const scheduleFormFields = 10
+
+// ScheduleForm is the form the Schedules view creates a schedule with
+type ScheduleForm struct {
+	cron        string
+	timeZone    string
+	symbol      string
+	side        string
+	qty         string
+	notional    string
+	orderType   string
+	limitPrice  string
+	stopPrice   string
+	timeInForce string
+	focusIndex  int
+}
+
+func NewScheduleForm() ScheduleForm {
+	return ScheduleForm{
+		timeZone:    schedule.DefaultTimeZone,
+		side:        string(order.OrderSideBuy),
+		orderType:   string(order.OrderTypeMarket),
+		timeInForce: string(order.TimeInForceDay),
+	}
+}
+
+// Update handles navigation and input. Enter is handled by the Model, which
+// saves Schedule().
+func (f ScheduleForm) Update(msg tea.KeyMsg) (ScheduleForm, tea.Cmd) {
+	switch msg.String() {
+	case "tab", "down":
+		f.focusIndex = (f.focusIndex + 1) % scheduleFormFields
+		return f, nil
+
+	case "shift+tab", "up":
+		f.focusIndex = (f.focusIndex - 1 + scheduleFormFields) % scheduleFormFields
+		return f, nil
+
+	default:
+		return f.handleInput(msg.String()), nil
+	}
+}
+
+func (f ScheduleForm) handleInput(input string) ScheduleForm {
+	switch f.focusIndex {
+	case 0:
+		f.cron = editText(f.cron, input)
+	case 1:
+		f.timeZone = editText(f.timeZone, input)
+	case 2:
+		f.symbol = editText(f.symbol, strings.ToUpper(input))
+	case 3:
+		f.side = cycle(orderSides, f.side, input)
+	case 4:
+		f.qty = editNumber(f.qty, input)
+	case 5:
+		f.notional = editNumber(f.notional, input)
+	case 6:
+		f.orderType = cycle(orderTypes, f.orderType, input)
+	case 7:
+		f.limitPrice = editNumber(f.limitPrice, input)
+	case 8:
+		f.stopPrice = editNumber(f.stopPrice, input)
+	case 9:
+		f.timeInForce = cycle(timesInForce, f.timeInForce, input)
+	}
+	return f
+}
+
+// Schedule validates the form and builds the schedule to save, with its
+// first run after now. Prices the order type does not use are ignored.
+func (f ScheduleForm) Schedule(accountID string, now time.Time) (*schedule.ScheduledOrder, error) {
+	req := order.CreateOrderRequest{
+		AccountID:   accountID,
+		Symbol:      f.symbol,
+		Side:        order.OrderSide(f.side),
+		OrderType:   order.OrderType(f.orderType),
+		TimeInForce: order.TimeInForce(f.timeInForce),
+	}
+	usesLimit := req.OrderType == order.OrderTypeLimit || req.OrderType == order.OrderTypeStopLimit
+	usesStop := req.OrderType == order.OrderTypeStop || req.OrderType == order.OrderTypeStopLimit
+
+	for _, field := range []struct {
+		name  string
+		value string
+		used  bool
+		dest  **decimal.Decimal
+	}{
+		{"quantity", f.qty, true, &req.Qty},
+		{"notional amount", f.notional, true, &req.Notional},
+		{"limit price", f.limitPrice, usesLimit, &req.LimitPrice},
+		{"stop price", f.stopPrice, usesStop, &req.StopPrice},
+	} {
+		if field.value == "" || !field.used {
+			continue
+		}
+		d, err := decimal.NewFromString(field.value)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", field.name)
+		}
+		*field.dest = &d
+	}
+
+	return schedule.New(req, f.cron, f.timeZone, now)
+}
+
+func (f ScheduleForm) View() string {
+	cursor := func(active bool) string {
+		if active {
+			return ">"
+		}
+		return " "
+	}
+
+	return fmt.Sprintf(`
+%s Schedule:      %s  (cron: minute hour day month weekday, such as 0 10 * * mon)
+%s Time Zone:     %s
+%s Symbol:        %s
+%s Side:          %s  (space to toggle)
+%s Quantity:      %s
+%s Notional $:    %s  (instead of a quantity, for market day orders)
+%s Type:          %s  (space to change)
+%s Limit Price:   %s
+%s Stop Price:    %s
+%s Time in Force: %s  (space to change)
+
+Press [Enter] to save
+`,
+		cursor(f.focusIndex == 0), f.cron,
+		cursor(f.focusIndex == 1), f.timeZone,
+		cursor(f.focusIndex == 2), f.symbol,
+		cursor(f.focusIndex == 3), f.side,
+		cursor(f.focusIndex == 4), f.qty,
+		cursor(f.focusIndex == 5), f.notional,
+		cursor(f.focusIndex == 6), f.orderType,
+		cursor(f.focusIndex == 7), f.limitPrice,
+		cursor(f.focusIndex == 8), f.stopPrice,
+		cursor(f.focusIndex == 9), f.timeInForce,
+	)
+}
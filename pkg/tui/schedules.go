@@ -0,0 +1,385 @@
+package tui
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/revrost/pony/pkg/db"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/revrost/pony/pkg/schedule"
+	"github.com/revrost/pony/pkg/tracing"
+)
+
+// scheduleRunsShown is how many of a schedule's latest runs the run history
+// shows
+const scheduleRunsShown = 50
+
+type schedulesLoadedMsg struct {
+	schedules []*schedule.ScheduledOrder
+}
+
+type scheduleRunsLoadedMsg struct {
+	scheduleID int64
+	runs       []*schedule.Run
+}
+
+// scheduleSavedMsg means a schedule was created, changed or deleted
+type scheduleSavedMsg struct {
+	status string
+}
+
+type runOrderLoadedMsg struct {
+	order *order.Order
+}
+
+func loadSchedules(store Store) tea.Cmd {
+	return traced("loadSchedules", nil, func(ctx context.Context) tea.Msg {
+		rows, err := store.ListScheduledOrders(ctx)
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return schedulesLoadedMsg{schedules: db.ToScheduledOrders(rows)}
+	})
+}
+
+func loadScheduleRuns(store Store, scheduleID int64) tea.Cmd {
+	return traced("loadScheduleRuns", scheduleAttrs(scheduleID), func(ctx context.Context) tea.Msg {
+		rows, err := store.ListScheduledOrderRuns(ctx, db.ListScheduledOrderRunsParams{
+			ScheduledOrderID: scheduleID,
+			RowLimit:         scheduleRunsShown,
+		})
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return scheduleRunsLoadedMsg{scheduleID: scheduleID, runs: db.ToScheduledOrderRuns(rows)}
+	})
+}
+
+func createSchedule(store Store, s *schedule.ScheduledOrder) tea.Cmd {
+	return traced("createSchedule", accountAttrs(s.Request.AccountID), func(ctx context.Context) tea.Msg {
+		row, err := store.CreateScheduledOrder(ctx, db.NewCreateScheduledOrderParams(s))
+		if err != nil {
+			return errMsg{err: err}
+		}
+		return scheduleSavedMsg{status: fmt.Sprintf("Saved schedule %d; first run %s",
+			row.ID, row.NextRunAt.Time.Local().Format("2006-01-02 15:04"))}
+	})
+}
+
+// setScheduleEnabled enables or disables a schedule. Runs due while it was
+// disabled are not made up.
+func setScheduleEnabled(store Store, s *schedule.ScheduledOrder, enabled bool) tea.Cmd {
+	return traced("setScheduleEnabled", scheduleAttrs(s.ID), func(ctx context.Context) tea.Msg {
+		arg := db.SetScheduledOrderEnabledParams{ID: s.ID, Enabled: enabled}
+		status := fmt.Sprintf("Disabled schedule %d", s.ID)
+		if enabled {
+			next, err := s.Next(time.Now())
+			if err != nil {
+				return errMsg{err: err}
+			}
+			arg.NextRunAt = sql.NullTime{Time: next.UTC(), Valid: true}
+			status = fmt.Sprintf("Enabled schedule %d; next run %s", s.ID, next.Local().Format("2006-01-02 15:04"))
+		}
+		if _, err := store.SetScheduledOrderEnabled(ctx, arg); err != nil {
+			return errMsg{err: err}
+		}
+		return scheduleSavedMsg{status: status}
+	})
+}
+
+func deleteSchedule(store Store, id int64) tea.Cmd {
+	return traced("deleteSchedule", scheduleAttrs(id), func(ctx context.Context) tea.Msg {
+		if _, err := store.DeleteScheduledOrder(ctx, id); err != nil {
+			return errMsg{err: err}
+		}
+		return scheduleSavedMsg{status: fmt.Sprintf("Deleted schedule %d", id)}
+	})
+}
+
+func loadRunOrder(store Store, orderID string) tea.Cmd {
+	return traced("loadRunOrder", []attribute.KeyValue{tracing.OrderIDKey.String(orderID)}, func(ctx context.Context) tea.Msg {
+		row, err := store.GetOrder(ctx, orderID)
+		if err != nil {
+			return errMsg{err: fmt.Errorf("order %s is not recorded yet: %w", orderID, err)}
+		}
+		return runOrderLoadedMsg{order: db.ToOrder(row)}
+	})
+}
+
+func scheduleAttrs(id int64) []attribute.KeyValue {
+	return []attribute.KeyValue{tracing.ScheduleIDKey.Int64(id)}
+}
+
+func (m Model) handleSchedulesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "n":
+		if m.selectedAccount == nil {
+			return m, nil
+		}
+		m.currentView = ViewNewSchedule
+		m.scheduleForm = NewScheduleForm()
+		m.status = ""
+		return m, nil
+	}
+
+	if len(m.schedules) == 0 {
+		return m, nil
+	}
+	selected := m.schedules[m.scheduleCursor]
+
+	switch msg.String() {
+	case "down", "j":
+		if m.scheduleCursor < len(m.schedules)-1 {
+			m.scheduleCursor++
+		}
+		return m, nil
+
+	case "up", "k":
+		if m.scheduleCursor > 0 {
+			m.scheduleCursor--
+		}
+		return m, nil
+
+	case "enter":
+		m.currentView = ViewScheduleRuns
+		m.scheduleRuns = nil
+		m.runCursor = 0
+		return m, loadScheduleRuns(m.store, selected.ID)
+
+	case "e":
+		return m, setScheduleEnabled(m.store, selected, !selected.Enabled)
+
+	case "d":
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Delete schedule %d (%s) and its run history?", selected.ID, describeSchedule(selected)),
+			cmd:    deleteSchedule(m.store, selected.ID),
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func (m Model) handleScheduleRunsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "down", "j":
+		if m.runCursor < len(m.scheduleRuns)-1 {
+			m.runCursor++
+		}
+		return m, nil
+
+	case "up", "k":
+		if m.runCursor > 0 {
+			m.runCursor--
+		}
+		return m, nil
+
+	case "enter":
+		if len(m.scheduleRuns) == 0 || m.scheduleRuns[m.runCursor].OrderID == "" {
+			return m, nil
+		}
+		return m, loadRunOrder(m.store, m.scheduleRuns[m.runCursor].OrderID)
+	}
+
+	return m, nil
+}
+
+func (m Model) handleNewScheduleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		m.currentView = ViewSchedules
+		return m, nil
+
+	case "enter":
+		if m.selectedAccount == nil {
+			return m, nil
+		}
+		s, err := m.scheduleForm.Schedule(m.selectedAccount.ID, time.Now())
+		if err != nil {
+			m.status = "Invalid schedule: " + err.Error()
+			return m, nil
+		}
+		m.status = ""
+		return m, createSchedule(m.store, s)
+	}
+
+	updatedForm, cmd := m.scheduleForm.Update(msg)
+	m.scheduleForm = updatedForm
+	return m, cmd
+}
+
+// handleScheduled reports the runs the scheduler placed in the background
+func (m Model) handleScheduled(msg ScheduledMsg) (tea.Model, tea.Cmd) {
+	if msg.Err != nil {
+		slog.Error("scheduler failed", "error", msg.Err)
+		m.err = msg.Err
+	}
+	for _, run := range msg.Runs {
+		slog.Info("scheduled order run", "schedule_id", run.ScheduleID, "result", run.Result,
+			"order_id", run.OrderID, "message", run.Message)
+		m.status = fmt.Sprintf("Schedule %d: %s", run.ScheduleID, run.Result)
+		if run.Message != "" {
+			m.status += " (" + run.Message + ")"
+		}
+	}
+
+	cmds := []tea.Cmd{m.reloadOrders()}
+	switch m.currentView {
+	case ViewSchedules:
+		cmds = append(cmds, loadSchedules(m.store))
+	case ViewScheduleRuns:
+		cmds = append(cmds, loadScheduleRuns(m.store, m.schedules[m.scheduleCursor].ID))
+	}
+	return m, tea.Batch(cmds...)
+}
+
+func renderSchedules(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Schedules"))
+	b.WriteString("\n\n")
+
+	if len(m.schedules) == 0 {
+		b.WriteString(infoStyle.Render("No schedules; press 'n' to add one"))
+		b.WriteString("\n\n")
+	} else {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("  %-4s %-18s %-28s %-18s %-10s %s",
+			"ID", "Schedule", "Order", "Next Run", "Last", "Enabled")))
+		b.WriteString("\n")
+
+		for i, s := range m.schedules {
+			cursor := " "
+			if i == m.scheduleCursor {
+				cursor = ">"
+			}
+
+			next := "-"
+			if s.NextRunAt != nil {
+				next = s.NextRunAt.Local().Format("2006-01-02 15:04")
+			}
+			enabled := successStyle.Render("yes")
+			if !s.Enabled {
+				enabled = infoStyle.Render("no")
+			}
+
+			b.WriteString(fmt.Sprintf("%s %-4d %-18s %-28s %-18s %s %s\n",
+				cursor,
+				s.ID,
+				s.Schedule,
+				describeSchedule(s),
+				next,
+				resultStyle(s.LastResult).Render(fmt.Sprintf("%-10s", orDash(string(s.LastResult)))),
+				enabled,
+			))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(renderPrompt(m, "[n] New  [e] Enable/disable  [d] Delete  [enter] Run history"))
+	b.WriteString(renderNavigation())
+
+	return b.String()
+}
+
+func renderScheduleRuns(m Model) string {
+	var b strings.Builder
+
+	s := m.schedules[m.scheduleCursor]
+	b.WriteString(titleStyle.Render(fmt.Sprintf("Runs of schedule %d: %s, %s %s", s.ID, describeSchedule(s), s.Schedule, s.TimeZone)))
+	b.WriteString("\n\n")
+
+	if len(m.scheduleRuns) == 0 {
+		b.WriteString(infoStyle.Render("No runs yet"))
+		b.WriteString("\n\n")
+	} else {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("  %-18s %-8s %-38s %-17s %s",
+			"Scheduled For", "Result", "Order", "Status", "Message")))
+		b.WriteString("\n")
+
+		for i, r := range m.scheduleRuns {
+			cursor := " "
+			if i == m.runCursor {
+				cursor = ">"
+			}
+			b.WriteString(fmt.Sprintf("%s %-18s %s %-38s %-17s %s\n",
+				cursor,
+				r.ScheduledFor.Local().Format("2006-01-02 15:04"),
+				resultStyle(r.Result).Render(fmt.Sprintf("%-8s", r.Result)),
+				orDash(r.OrderID),
+				orDash(string(r.OrderStatus)),
+				r.Message,
+			))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(renderPrompt(m, "[enter] Open order  [esc] Back"))
+
+	return b.String()
+}
+
+func renderNewSchedule(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("New Schedule"))
+	b.WriteString("\n\n")
+
+	b.WriteString(m.scheduleForm.View())
+	b.WriteString("\n")
+	b.WriteString(infoStyle.Render("Runs on days the market is closed are skipped"))
+	b.WriteString("\n\n")
+
+	b.WriteString(renderPrompt(m, "Press 'esc' to cancel"))
+
+	return b.String()
+}
+
+// describeSchedule is the order a schedule places, such as buy $100.00 VTI
+// or sell 10 AAPL limit 190.00
+func describeSchedule(s *schedule.ScheduledOrder) string {
+	req := s.Request
+	amount := format.QtyOrDash(req.Qty)
+	if req.Notional != nil {
+		amount = format.Money(*req.Notional)
+	}
+	text := fmt.Sprintf("%s %s %s", req.Side, amount, req.Symbol)
+	if req.LimitPrice != nil {
+		text += " limit " + format.Price(*req.LimitPrice)
+	}
+	if req.StopPrice != nil {
+		text += " stop " + format.Price(*req.StopPrice)
+	}
+	return text
+}
+
+func resultStyle(result schedule.Result) lipgloss.Style {
+	switch result {
+	case schedule.ResultPlaced:
+		return successStyle
+	case schedule.ResultFailed:
+		return errorStyle
+	case schedule.ResultSkipped, schedule.ResultPending:
+		return warnStyle
+	default:
+		return infoStyle
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -0,0 +1,180 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/strategy"
+	"github.com/revrost/pony/pkg/tracing"
+)
+
+// Strategies controls the strategies the Strategies view shows.
+// *strategy.Engine implements it.
+type Strategies interface {
+	Status() []strategy.Status
+	Updated() <-chan struct{}
+	Start(ctx context.Context, name string) error
+	Pause(name string) error
+	Stop(ctx context.Context, name string) error
+}
+
+// strategiesUpdatedMsg means a strategy's state or P&L changed
+type strategiesUpdatedMsg struct{}
+
+// waitForStrategies waits for a strategy to change, so the view is redrawn
+func waitForStrategies(strategies Strategies) tea.Cmd {
+	return func() tea.Msg {
+		<-strategies.Updated()
+		return strategiesUpdatedMsg{}
+	}
+}
+
+func startStrategy(strategies Strategies, name string) tea.Cmd {
+	return traced("startStrategy", strategyAttrs(name), func(ctx context.Context) tea.Msg {
+		if err := strategies.Start(ctx, name); err != nil {
+			return errMsg{err: err}
+		}
+		// The view is redrawn once the engine reports the change
+		return nil
+	})
+}
+
+func pauseStrategy(strategies Strategies, name string) tea.Cmd {
+	return traced("pauseStrategy", strategyAttrs(name), func(ctx context.Context) tea.Msg {
+		if err := strategies.Pause(name); err != nil {
+			return errMsg{err: err}
+		}
+		return nil
+	})
+}
+
+func stopStrategy(strategies Strategies, name string) tea.Cmd {
+	return traced("stopStrategy", strategyAttrs(name), func(ctx context.Context) tea.Msg {
+		if err := strategies.Stop(ctx, name); err != nil {
+			return errMsg{err: err}
+		}
+		return nil
+	})
+}
+
+func strategyAttrs(name string) []attribute.KeyValue {
+	return []attribute.KeyValue{tracing.StrategyKey.String(name)}
+}
+
+func (m Model) handleStrategiesKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if len(m.strategyStatus) == 0 {
+		return m, nil
+	}
+	selected := m.strategyStatus[m.strategyCursor]
+
+	switch msg.String() {
+	case "down", "j":
+		if m.strategyCursor < len(m.strategyStatus)-1 {
+			m.strategyCursor++
+		}
+		return m, nil
+
+	case "up", "k":
+		if m.strategyCursor > 0 {
+			m.strategyCursor--
+		}
+		return m, nil
+
+	case "s":
+		return m, startStrategy(m.strategies, selected.Name)
+
+	case "p":
+		return m, pauseStrategy(m.strategies, selected.Name)
+
+	case "x":
+		if selected.State == strategy.StateStopped {
+			return m, nil
+		}
+		m.confirm = &confirmation{
+			prompt: fmt.Sprintf("Stop %s and cancel its open orders?", selected.Name),
+			cmd:    stopStrategy(m.strategies, selected.Name),
+		}
+		return m, nil
+	}
+
+	return m, nil
+}
+
+func renderStrategies(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Strategies"))
+	b.WriteString("\n\n")
+
+	if m.strategies == nil || len(m.strategyStatus) == 0 {
+		b.WriteString(infoStyle.Render("No strategies; set STRATEGIES, such as sma_cross:AAPL"))
+		b.WriteString("\n")
+		b.WriteString(renderNavigation())
+		return b.String()
+	}
+
+	b.WriteString(headerStyle.Render(fmt.Sprintf("  %-22s %-9s %-22s %-14s %-14s",
+		"Name", "State", "Position", "Realized", "Unrealized")))
+	b.WriteString("\n")
+
+	for i, s := range m.strategyStatus {
+		cursor := " "
+		if i == m.strategyCursor {
+			cursor = ">"
+		}
+
+		stateStyle := infoStyle
+		switch s.State {
+		case strategy.StateRunning:
+			stateStyle = successStyle
+		case strategy.StatePaused:
+			stateStyle = warnStyle
+		}
+
+		b.WriteString(fmt.Sprintf("%s %-22s %s %-22s %s %s\n",
+			cursor,
+			s.Name,
+			stateStyle.Render(fmt.Sprintf("%-9s", s.State)),
+			renderHoldings(s.Holdings),
+			plStyle(s.RealizedPL.IsNegative()).Render(fmt.Sprintf("%-14s", format.SignedMoney(s.RealizedPL))),
+			plStyle(s.UnrealizedPL.IsNegative()).Render(fmt.Sprintf("%-14s", format.SignedMoney(s.UnrealizedPL))),
+		))
+		if s.Err != nil {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("    stopped: %v", s.Err)))
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+
+	b.WriteString(renderPrompt(m, "[s] Start/resume  [p] Pause  [x] Stop"))
+	b.WriteString(renderNavigation())
+
+	return b.String()
+}
+
+// renderHoldings shows the open positions, such as 10 AAPL @ 187.20
+func renderHoldings(holdings []strategy.Holding) string {
+	var open []string
+	for _, h := range holdings {
+		if !h.Qty.IsZero() {
+			open = append(open, fmt.Sprintf("%s %s @ %s", format.Qty(h.Qty), h.Symbol, format.Price(h.AvgPrice)))
+		}
+	}
+	if len(open) == 0 {
+		return "flat"
+	}
+	return strings.Join(open, ", ")
+}
+
+func plStyle(negative bool) lipgloss.Style {
+	if negative {
+		return errorStyle
+	}
+	return successStyle
+}
@@ -5,6 +5,11 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/revrost/pony/pkg/events"
+	"github.com/revrost/pony/pkg/format"
+	"github.com/revrost/pony/pkg/history"
+	"github.com/revrost/pony/pkg/order"
+	"github.com/shopspring/decimal"
 )
 
 var (
@@ -43,10 +48,11 @@ func renderDashboard(m Model) string {
 		b.WriteString("\n")
 		b.WriteString(fmt.Sprintf("ID: %s\n", m.selectedAccount.AlpacaAccountID))
 		b.WriteString(fmt.Sprintf("Status: %s\n", m.selectedAccount.Status))
-		b.WriteString(fmt.Sprintf("Cash: $%.2f\n", m.selectedAccount.Cash))
-		b.WriteString(fmt.Sprintf("Portfolio Value: $%.2f\n", m.selectedAccount.PortfolioValue))
-		b.WriteString(fmt.Sprintf("Buying Power: $%.2f\n", m.selectedAccount.BuyingPower))
+		b.WriteString(fmt.Sprintf("Cash: %s\n", format.Money(m.selectedAccount.Cash)))
+		b.WriteString(fmt.Sprintf("Portfolio Value: %s\n", format.Money(m.selectedAccount.PortfolioValue)))
+		b.WriteString(fmt.Sprintf("Buying Power: %s\n", format.Money(m.selectedAccount.BuyingPower)))
 		b.WriteString("\n")
+		b.WriteString(renderPerformance(m))
 	} else {
 		b.WriteString(infoStyle.Render("No account selected"))
 		b.WriteString("\n\n")
@@ -57,26 +63,70 @@ func renderDashboard(m Model) string {
 	return b.String()
 }
 
+// renderPerformance shows the P&L summed from the daily account snapshots
+func renderPerformance(m Model) string {
+	var b strings.Builder
+
+	p := m.performance
+	if p == nil {
+		b.WriteString(headerStyle.Render("Performance"))
+		b.WriteString("\n")
+		b.WriteString(infoStyle.Render("No snapshots yet; one is taken each trading day"))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("Performance (as of %s)", p.AsOf.Format("2006-01-02"))))
+		b.WriteString("\n")
+		b.WriteString(fmt.Sprintf("Equity: %s\n", format.Money(p.Equity)))
+		b.WriteString(fmt.Sprintf("Day P&L: %s\n", format.SignedMoney(p.Day)))
+		b.WriteString(fmt.Sprintf("MTD P&L: %s\n", format.SignedMoney(p.MTD)))
+		b.WriteString(fmt.Sprintf("YTD P&L: %s\n", format.SignedMoney(p.YTD)))
+	}
+	if m.snapshotErr != nil {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Snapshot failed: %v", m.snapshotErr)))
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
 func renderOrders(m Model) string {
 	var b strings.Builder
 
 	b.WriteString(titleStyle.Render("Orders"))
 	b.WriteString("\n\n")
 
+	symbol := m.orderSymbol
+	if m.editingSymbol {
+		symbol += "_"
+	} else if symbol == "" {
+		symbol = "all"
+	}
+	b.WriteString(infoStyle.Render(fmt.Sprintf("Symbol: %s  Status: %s  Page: %d",
+		symbol, statusFilters[m.orderStatus].label, len(m.orderPages)+1)))
+	b.WriteString("\n\n")
+
 	if len(m.orders) == 0 {
 		b.WriteString(infoStyle.Render("No orders found"))
 		b.WriteString("\n\n")
 	} else {
-		b.WriteString(headerStyle.Render(fmt.Sprintf("%-15s %-10s %-6s %-10s %-12s %-15s",
+		b.WriteString(headerStyle.Render(fmt.Sprintf("  %-15s %-10s %-12s %-10s %-12s %-20s",
 			"Symbol", "Side", "Qty", "Type", "Status", "Filled")))
 		b.WriteString("\n")
 
-		for _, order := range m.orders {
-			filledQty := fmt.Sprintf("%.2f/%.2f", order.FilledQty, order.Qty)
-			b.WriteString(fmt.Sprintf("%-15s %-10s %-6.2f %-10s %-12s %-15s\n",
+		for i, order := range m.orders {
+			cursor := " "
+			if i == m.orderCursor {
+				cursor = ">"
+			}
+
+			qty := format.QtyOrDash(order.Qty)
+			filledQty := fmt.Sprintf("%s/%s", format.Qty(order.FilledQty), qty)
+			b.WriteString(fmt.Sprintf("%s %-15s %-10s %-12s %-10s %-12s %-20s\n",
+				cursor,
 				order.Symbol,
 				order.Side,
-				order.Qty,
+				qty,
 				order.OrderType,
 				order.Status,
 				filledQty,
@@ -85,13 +135,112 @@ func renderOrders(m Model) string {
 		b.WriteString("\n")
 	}
 
-	b.WriteString(infoStyle.Render("Press 'n' to place new order"))
-	b.WriteString("\n")
+	help := "Press 'n' to place new order, 'enter' for fills, 'x' to cancel\n" +
+		"'/' filter symbol, 'f' filter status, 'h'/'l' previous/next page, 's' stats"
+	if m.editingSymbol {
+		help = "Type a symbol, 'enter' to apply, 'esc' to cancel"
+	}
+	b.WriteString(renderPrompt(m, help))
 	b.WriteString(renderNavigation())
 
 	return b.String()
 }
 
+func renderOrderDetail(m Model) string {
+	var b strings.Builder
+
+	o := m.orderDetail
+	b.WriteString(titleStyle.Render("Order Detail"))
+	b.WriteString("\n\n")
+
+	b.WriteString(fmt.Sprintf("ID: %s\n", o.AlpacaOrderID))
+	b.WriteString(fmt.Sprintf("Symbol: %s\n", o.Symbol))
+	b.WriteString(fmt.Sprintf("Side: %s  Type: %s  TIF: %s\n", o.Side, o.OrderType, o.TimeInForce))
+	b.WriteString(fmt.Sprintf("Status: %s\n", o.Status))
+	b.WriteString(fmt.Sprintf("Filled: %s/%s\n", format.Qty(o.FilledQty), format.QtyOrDash(o.Qty)))
+	if o.LimitPrice != nil {
+		b.WriteString(fmt.Sprintf("Limit Price: %s\n", format.Price(*o.LimitPrice)))
+	}
+	if o.StopPrice != nil {
+		b.WriteString(fmt.Sprintf("Stop Price: %s\n", format.Price(*o.StopPrice)))
+	}
+	if o.FilledAvgPrice != nil {
+		b.WriteString(fmt.Sprintf("Avg Fill Price: %s\n", format.Price(*o.FilledAvgPrice)))
+	}
+	b.WriteString("\n")
+
+	b.WriteString(headerStyle.Render("Fills"))
+	b.WriteString("\n")
+
+	if len(m.executions) == 0 {
+		b.WriteString(infoStyle.Render("No fills recorded"))
+		b.WriteString("\n")
+	} else {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("%-4s %-20s %-14s %-12s %-14s",
+			"#", "Time", "Qty", "Price", "Cum Qty")))
+		b.WriteString("\n")
+
+		cumQty := decimal.Zero
+		for i, e := range m.executions {
+			cumQty = cumQty.Add(e.Qty)
+			b.WriteString(fmt.Sprintf("%-4d %-20s %-14s %-12s %-14s\n",
+				i+1,
+				e.ExecutedAt.Local().Format("2006-01-02 15:04:05"),
+				format.Qty(e.Qty),
+				format.Price(e.Price),
+				format.Qty(cumQty),
+			))
+		}
+
+		if vwap, ok := order.VWAP(m.executions); ok {
+			b.WriteString("\n")
+			b.WriteString(fmt.Sprintf("VWAP: %s over %d fills\n", format.Price(vwap), len(m.executions)))
+		}
+	}
+
+	b.WriteString("\n")
+	b.WriteString(infoStyle.Render("Press 'esc' to go back"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func renderOrderStats(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Order Stats (last 30 days)"))
+	b.WriteString("\n\n")
+
+	b.WriteString(headerStyle.Render("By Symbol"))
+	b.WriteString("\n")
+	b.WriteString(renderStatsTable("Symbol", m.statsBySym))
+	b.WriteString("\n")
+
+	b.WriteString(headerStyle.Render("By Day (UTC)"))
+	b.WriteString("\n")
+	b.WriteString(renderStatsTable("Day", m.statsByDay))
+	b.WriteString("\n")
+
+	b.WriteString(infoStyle.Render("Press 'esc' to go back"))
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+func renderStatsTable(key string, stats []history.Stats) string {
+	if len(stats) == 0 {
+		return infoStyle.Render("No orders") + "\n"
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render(fmt.Sprintf("%-12s %-8s %-8s %s", key, "Orders", "Filled", "Notional")))
+	b.WriteString("\n")
+	for _, s := range stats {
+		b.WriteString(fmt.Sprintf("%-12s %-8d %-8d %s\n", s.Key, s.Orders, s.Filled, format.Money(s.Notional)))
+	}
+	return b.String()
+}
+
 func renderPositions(m Model) string {
 	var b strings.Builder
 
@@ -102,28 +251,35 @@ func renderPositions(m Model) string {
 		b.WriteString(infoStyle.Render("No positions found"))
 		b.WriteString("\n\n")
 	} else {
-		b.WriteString(headerStyle.Render(fmt.Sprintf("%-10s %-10s %-12s %-12s %-12s %-12s",
+		b.WriteString(headerStyle.Render(fmt.Sprintf("  %-10s %-14s %-14s %-14s %-14s %-12s",
 			"Symbol", "Qty", "Entry", "Current", "Value", "P/L")))
 		b.WriteString("\n")
 
-		for _, pos := range m.positions {
+		for i, pos := range m.positions {
+			cursor := " "
+			if i == m.positionCursor {
+				cursor = ">"
+			}
+
 			plStyle := successStyle
-			if pos.UnrealizedPL < 0 {
+			if pos.UnrealizedPL.IsNegative() {
 				plStyle = errorStyle
 			}
 
-			b.WriteString(fmt.Sprintf("%-10s %-10.2f $%-11.2f $%-11.2f $%-11.2f %s\n",
+			b.WriteString(fmt.Sprintf("%s %-10s %-14s %-14s %-14s %-14s %s\n",
+				cursor,
 				pos.Symbol,
-				pos.Qty,
-				pos.AvgEntryPrice,
-				pos.CurrentPrice,
-				pos.MarketValue,
-				plStyle.Render(fmt.Sprintf("$%.2f (%.2f%%)", pos.UnrealizedPL, pos.UnrealizedPLPC)),
+				format.Qty(pos.Qty),
+				format.Price(pos.AvgEntryPrice),
+				format.Price(pos.CurrentPrice),
+				format.Money(pos.MarketValue),
+				plStyle.Render(fmt.Sprintf("%s (%s)", format.SignedMoney(pos.UnrealizedPL), format.Percent(pos.UnrealizedPLPC))),
 			))
 		}
 		b.WriteString("\n")
 	}
 
+	b.WriteString(renderPrompt(m, "Press 'x' to close the selected position"))
 	b.WriteString(renderNavigation())
 
 	return b.String()
@@ -138,12 +294,92 @@ func renderPlaceOrder(m Model) string {
 	b.WriteString(m.placeOrderForm.View())
 	b.WriteString("\n\n")
 
-	b.WriteString(infoStyle.Render("Press 'esc' to cancel"))
+	b.WriteString(renderPrompt(m, "Press 'esc' to cancel"))
+
+	return b.String()
+}
+
+func renderAudit(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Audit Log"))
+	b.WriteString("\n\n")
+
+	if len(m.auditEntries) == 0 {
+		b.WriteString(infoStyle.Render("No audit entries found"))
+		b.WriteString("\n\n")
+	} else {
+		b.WriteString(headerStyle.Render(fmt.Sprintf("%-20s %-20s %-16s %s",
+			"Time", "Operator", "Action", "Result")))
+		b.WriteString("\n")
+
+		for _, e := range m.auditEntries {
+			result := successStyle.Render("ok")
+			if e.Failed() {
+				result = errorStyle.Render(e.Error)
+			}
+			b.WriteString(fmt.Sprintf("%-20s %-20s %-16s %s\n",
+				e.CreatedAt.Local().Format("2006-01-02 15:04:05"),
+				e.Operator+"@"+e.Host,
+				e.Action,
+				result,
+			))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString(infoStyle.Render("Run 'pony audit --full' for requests and responses"))
 	b.WriteString("\n")
+	b.WriteString(renderNavigation())
 
 	return b.String()
 }
 
+// renderPrompt shows a pending confirmation, the last action's status or the
+// view's help text, in that order of priority.
+func renderPrompt(m Model, help string) string {
+	switch {
+	case m.confirm != nil:
+		return headerStyle.Render(m.confirm.prompt+" [y/n]") + "\n"
+	case m.status != "":
+		return headerStyle.Render(m.status) + "\n" + infoStyle.Render(help) + "\n"
+	default:
+		return infoStyle.Render(help) + "\n"
+	}
+}
+
+func renderWatchlists(m Model) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Watchlists"))
+	b.WriteString("\n\n")
+
+	b.WriteString(m.watchlistPanel.View())
+	b.WriteString("\n")
+
+	b.WriteString(infoStyle.Render("[tab] Switch list  [a] Add  [d] Remove  [J/K] Move  [c] New list  [r] Refresh"))
+	b.WriteString("\n")
+	b.WriteString(renderNavigation())
+
+	return b.String()
+}
+
+// renderStreamState shows how the broker event stream is doing
+func renderStreamState(m Model) string {
+	text := fmt.Sprintf("Events: %s", m.streamState)
+	switch m.streamState {
+	case events.StateLive:
+		return successStyle.Render(text)
+	case events.StateDown:
+		if m.streamErr != nil {
+			text += fmt.Sprintf(", retrying (%v)", m.streamErr)
+		}
+		return errorStyle.Render(text)
+	default:
+		return infoStyle.Render(text)
+	}
+}
+
 func renderNavigation() string {
-	return infoStyle.Render("\n[1] Dashboard  [2] Orders  [3] Positions  [q] Quit")
+	return infoStyle.Render("\n[1] Dashboard  [2] Orders  [3] Positions  [4] Watchlists  [5] Audit  [6] Strategies  [7] Schedules  [8] Alerts  [L] Log  [q] Quit")
 }
@@ -0,0 +1,279 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/watchlist"
+)
+
+type watchlistInputMode int
+
+const (
+	watchlistInputNone watchlistInputMode = iota
+	watchlistInputSymbol
+	watchlistInputName
+)
+
+// WatchlistPanel is the sub-model behind the Watchlists view. Every change is
+// sent to the broker and the panel is refreshed from the broker's response,
+// so what is shown is always what Alpaca has. Responses are written through
+// to the local watchlist tables.
+type WatchlistPanel struct {
+	brokerClient broker.Client
+	store        Store
+	accountID    string
+
+	watchlists []*watchlist.Watchlist
+	active     int
+	cursor     int
+
+	inputMode watchlistInputMode
+	input     string
+}
+
+func NewWatchlistPanel(brokerClient broker.Client, store Store, accountID string) WatchlistPanel {
+	return WatchlistPanel{
+		brokerClient: brokerClient,
+		store:        store,
+		accountID:    accountID,
+		watchlists:   []*watchlist.Watchlist{},
+	}
+}
+
+// Editing reports whether the panel is capturing text input, in which case
+// global key bindings must not be applied.
+func (p WatchlistPanel) Editing() bool {
+	return p.inputMode != watchlistInputNone
+}
+
+func (p WatchlistPanel) current() *watchlist.Watchlist {
+	if p.active < 0 || p.active >= len(p.watchlists) {
+		return nil
+	}
+	return p.watchlists[p.active]
+}
+
+// SetWatchlists replaces all watchlists, keeping the active list if it still exists.
+func (p WatchlistPanel) SetWatchlists(watchlists []*watchlist.Watchlist) WatchlistPanel {
+	activeID := ""
+	if w := p.current(); w != nil {
+		activeID = w.ID
+	}
+
+	p.watchlists = watchlists
+	p.active = 0
+	for i, w := range watchlists {
+		if w.ID == activeID {
+			p.active = i
+			break
+		}
+	}
+	return p.clampCursor()
+}
+
+// SetWatchlist replaces a single watchlist, appending it if it is new.
+func (p WatchlistPanel) SetWatchlist(updated *watchlist.Watchlist) WatchlistPanel {
+	for i, w := range p.watchlists {
+		if w.ID == updated.ID {
+			p.watchlists[i] = updated
+			return p.clampCursor()
+		}
+	}
+
+	p.watchlists = append(p.watchlists, updated)
+	p.active = len(p.watchlists) - 1
+	p.cursor = 0
+	return p
+}
+
+func (p WatchlistPanel) clampCursor() WatchlistPanel {
+	w := p.current()
+	if w == nil || len(w.Symbols) == 0 {
+		p.cursor = 0
+		return p
+	}
+	if p.cursor >= len(w.Symbols) {
+		p.cursor = len(w.Symbols) - 1
+	}
+	if p.cursor < 0 {
+		p.cursor = 0
+	}
+	return p
+}
+
+func (p WatchlistPanel) Update(msg tea.KeyMsg) (WatchlistPanel, tea.Cmd) {
+	if p.Editing() {
+		return p.handleInput(msg)
+	}
+
+	w := p.current()
+
+	switch msg.String() {
+	case "tab", "right", "l":
+		if len(p.watchlists) > 0 {
+			p.active = (p.active + 1) % len(p.watchlists)
+			p.cursor = 0
+		}
+		return p, nil
+
+	case "shift+tab", "left", "h":
+		if len(p.watchlists) > 0 {
+			p.active = (p.active - 1 + len(p.watchlists)) % len(p.watchlists)
+			p.cursor = 0
+		}
+		return p, nil
+
+	case "down", "j":
+		p.cursor++
+		return p.clampCursor(), nil
+
+	case "up", "k":
+		p.cursor--
+		return p.clampCursor(), nil
+
+	case "shift+down", "J":
+		return p.move(1)
+
+	case "shift+up", "K":
+		return p.move(-1)
+
+	case "a":
+		if w != nil {
+			p.inputMode = watchlistInputSymbol
+			p.input = ""
+		}
+		return p, nil
+
+	case "c":
+		p.inputMode = watchlistInputName
+		p.input = ""
+		return p, nil
+
+	case "d", "x":
+		if w == nil || len(w.Symbols) == 0 {
+			return p, nil
+		}
+		return p, removeWatchlistSymbol(p.brokerClient, p.store, w.ID, w.Symbols[p.cursor])
+
+	case "r":
+		return p, loadWatchlists(p.brokerClient, p.store, p.accountID)
+	}
+
+	return p, nil
+}
+
+// move swaps the selected symbol with its neighbour and pushes the new order
+// to the broker. The local list is reordered immediately so the cursor can
+// follow the symbol without waiting for the round trip.
+func (p WatchlistPanel) move(delta int) (WatchlistPanel, tea.Cmd) {
+	w := p.current()
+	if w == nil {
+		return p, nil
+	}
+
+	target := p.cursor + delta
+	if target < 0 || target >= len(w.Symbols) {
+		return p, nil
+	}
+
+	symbols := make([]string, len(w.Symbols))
+	copy(symbols, w.Symbols)
+	symbols[p.cursor], symbols[target] = symbols[target], symbols[p.cursor]
+
+	reordered := *w
+	reordered.Symbols = symbols
+	p.watchlists[p.active] = &reordered
+	p.cursor = target
+
+	return p, updateWatchlist(p.brokerClient, p.store, w.ID, &watchlist.UpdateWatchlistRequest{
+		Name:    w.Name,
+		Symbols: symbols,
+	})
+}
+
+func (p WatchlistPanel) handleInput(msg tea.KeyMsg) (WatchlistPanel, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		p.inputMode = watchlistInputNone
+		p.input = ""
+		return p, nil
+
+	case "backspace":
+		if len(p.input) > 0 {
+			p.input = p.input[:len(p.input)-1]
+		}
+		return p, nil
+
+	case "enter":
+		value := strings.TrimSpace(p.input)
+		mode := p.inputMode
+		p.inputMode = watchlistInputNone
+		p.input = ""
+		if value == "" {
+			return p, nil
+		}
+
+		if mode == watchlistInputName {
+			return p, createWatchlist(p.brokerClient, p.store, &watchlist.CreateWatchlistRequest{
+				AccountID: p.accountID,
+				Name:      value,
+			})
+		}
+
+		w := p.current()
+		if w == nil {
+			return p, nil
+		}
+		return p, addWatchlistSymbol(p.brokerClient, p.store, w.ID, strings.ToUpper(value))
+	}
+
+	if msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace {
+		p.input += string(msg.Runes)
+	}
+	return p, nil
+}
+
+func (p WatchlistPanel) View() string {
+	var b strings.Builder
+
+	if len(p.watchlists) == 0 {
+		b.WriteString(infoStyle.Render("No watchlists found"))
+		b.WriteString("\n")
+	} else {
+		tabs := make([]string, 0, len(p.watchlists))
+		for i, w := range p.watchlists {
+			if i == p.active {
+				tabs = append(tabs, headerStyle.Render(fmt.Sprintf("[%s]", w.Name)))
+			} else {
+				tabs = append(tabs, infoStyle.Render(fmt.Sprintf(" %s ", w.Name)))
+			}
+		}
+		b.WriteString(strings.Join(tabs, " "))
+		b.WriteString("\n\n")
+
+		w := p.current()
+		if len(w.Symbols) == 0 {
+			b.WriteString(infoStyle.Render("No symbols in this watchlist"))
+			b.WriteString("\n")
+		}
+		for i, symbol := range w.Symbols {
+			cursor := " "
+			if i == p.cursor {
+				cursor = ">"
+			}
+			b.WriteString(fmt.Sprintf("%s %2d. %s\n", cursor, i+1, symbol))
+		}
+	}
+
+	switch p.inputMode {
+	case watchlistInputSymbol:
+		b.WriteString(fmt.Sprintf("\nAdd symbol: %s_\n", p.input))
+	case watchlistInputName:
+		b.WriteString(fmt.Sprintf("\nNew watchlist name: %s_\n", p.input))
+	}
+
+	return b.String()
+}
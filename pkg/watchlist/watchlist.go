@@ -0,0 +1,25 @@
+package watchlist
+
+import "time"
+
+type Watchlist struct {
+	ID        string
+	AccountID string
+	Name      string
+	Symbols   []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type CreateWatchlistRequest struct {
+	AccountID string
+	Name      string
+	Symbols   []string
+}
+
+// UpdateWatchlistRequest replaces the name and the full, ordered symbol list
+// of a watchlist. Reordering is done by sending the symbols in the new order.
+type UpdateWatchlistRequest struct {
+	Name    string
+	Symbols []string
+}
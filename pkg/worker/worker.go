@@ -0,0 +1,240 @@
+package worker
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/revrost/pony/pkg/broker"
+	"github.com/revrost/pony/pkg/store"
+)
+
+// lockKey identifies the Postgres advisory lock held by the active worker.
+// It differs from the migration lock so a worker never blocks migrations.
+const lockKey int64 = 0x706f6e7977 // "ponyw"
+
+// DefaultRetryInterval is how long a standby worker waits before trying
+// the lock again, and how long the active one waits before reconnecting
+const DefaultRetryInterval = 5 * time.Second
+
+// DefaultHealthAddr is where pony-worker serves its health endpoint
+const DefaultHealthAddr = ":8090"
+
+// EventLog is where the worker records events. *events.Store implements it.
+type EventLog interface {
+	Record(ctx context.Context, event broker.Event) error
+	ApplyPending(ctx context.Context) (int, error)
+}
+
+// Status is what the health endpoint reports
+type Status struct {
+	// Leader is set while this worker holds the lock and is the one
+	// consuming events; the others wait on standby
+	Leader         bool       `json:"leader"`
+	Streaming      bool       `json:"streaming"`
+	EventsRecorded int64      `json:"events_recorded"`
+	LastEventAt    *time.Time `json:"last_event_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// Healthy reports whether the worker is either on standby or streaming
+func (s Status) Healthy() bool {
+	return !s.Leader || s.Streaming
+}
+
+// Worker consumes broker events into the event log, which applies them to
+// the orders, accounts and positions projections. Any number of workers may
+// run against one database; a Postgres advisory lock makes sure only one of
+// them consumes at a time, and a standby takes over when it goes away.
+type Worker struct {
+	brokerClient broker.Client
+	db           *store.DB
+	eventLog     EventLog
+
+	RetryInterval time.Duration
+
+	mu     sync.Mutex
+	status Status
+}
+
+var _ http.Handler = (*Worker)(nil)
+
+func New(brokerClient broker.Client, conn *store.DB, eventLog EventLog) *Worker {
+	return &Worker{
+		brokerClient:  brokerClient,
+		db:            conn,
+		eventLog:      eventLog,
+		RetryInterval: DefaultRetryInterval,
+	}
+}
+
+// Run waits until this worker holds the lock and then consumes events until
+// ctx is done. If the connection holding the lock is lost it stops
+// consuming, as another worker may take over, and waits for the lock again.
+func (w *Worker) Run(ctx context.Context) error {
+	if w.db.Dialect != store.Postgres {
+		return errors.New("the event worker needs Postgres; SQLite has no advisory locks")
+	}
+
+	for {
+		lock, err := w.acquire(ctx)
+		if err != nil {
+			return nil // ctx is done
+		}
+		w.lead(ctx, lock)
+		release(lock)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// Status returns what the worker is doing right now
+func (w *Worker) Status() Status {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.status
+}
+
+// ServeHTTP reports the worker's Status as JSON, with 503 Service
+// Unavailable while it is the leader but not streaming
+func (w *Worker) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	status := w.Status()
+
+	rw.Header().Set("Content-Type", "application/json")
+	if !status.Healthy() {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(rw).Encode(status)
+}
+
+// acquire blocks until it holds the lock, on a connection of its own since
+// session-level advisory locks belong to a connection. It only fails once
+// ctx is done.
+func (w *Worker) acquire(ctx context.Context) (*sql.Conn, error) {
+	for {
+		conn, err := w.db.Conn(ctx)
+		if err == nil {
+			var locked bool
+			err = conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, lockKey).Scan(&locked)
+			if err == nil && locked {
+				return conn, nil
+			}
+			conn.Close()
+		}
+		if err != nil && ctx.Err() == nil {
+			w.setError(fmt.Errorf("failed to acquire worker lock: %w", err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(w.RetryInterval):
+		}
+	}
+}
+
+// release unlocks before the connection goes back to the pool, where the
+// lock would otherwise stay held
+func release(lock *sql.Conn) {
+	lock.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, lockKey)
+	lock.Close()
+}
+
+// lead consumes events, reconnecting after failures, until ctx is done or
+// the lock's connection is lost
+func (w *Worker) lead(ctx context.Context, lock *sql.Conn) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	w.update(func(s *Status) { s.Leader = true })
+	defer w.update(func(s *Status) { s.Leader = false })
+
+	go func() {
+		ticker := time.NewTicker(w.RetryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			if err := lock.PingContext(ctx); err != nil && ctx.Err() == nil {
+				w.setError(fmt.Errorf("lost worker lock: %w", err))
+				cancel()
+				return
+			}
+		}
+	}()
+
+	for {
+		err := w.consume(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		w.setError(err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(w.RetryInterval):
+		}
+	}
+}
+
+// consume applies whatever a previous worker logged but did not apply, then
+// records streamed events until the stream fails or ctx is done
+func (w *Worker) consume(ctx context.Context) error {
+	if _, err := w.eventLog.ApplyPending(ctx); err != nil {
+		return fmt.Errorf("failed to apply pending events: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	eventCh, errCh := w.brokerClient.StreamEvents(ctx, "")
+	w.update(func(s *Status) { s.Streaming = true })
+	defer w.update(func(s *Status) { s.Streaming = false })
+
+	for {
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				return errors.New("event stream closed")
+			}
+			if err := w.eventLog.Record(ctx, event); err != nil {
+				return fmt.Errorf("failed to record event: %w", err)
+			}
+			now := time.Now()
+			w.update(func(s *Status) {
+				s.EventsRecorded++
+				s.LastEventAt = &now
+			})
+		case err, ok := <-errCh:
+			if !ok {
+				// Closed along with eventCh
+				errCh = nil
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (w *Worker) setError(err error) {
+	w.update(func(s *Status) { s.LastError = err.Error() })
+}
+
+func (w *Worker) update(fn func(s *Status)) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	fn(&w.status)
+}